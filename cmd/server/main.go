@@ -12,7 +12,9 @@ import (
 	"tabimoney/internal/config"
 	"tabimoney/internal/database"
 	"tabimoney/internal/handlers"
+	"tabimoney/internal/i18n"
 	appmw "tabimoney/internal/middleware"
+	"tabimoney/internal/models"
 	"tabimoney/internal/services"
 
 	"github.com/labstack/echo/v4"
@@ -47,8 +49,18 @@ func main() {
 	}
 	defer database.CloseRedis()
 
+	// Load notification message catalog
+	if err := i18n.Load(cfg.I18n.Dir, cfg.I18n.DefaultLanguage); err != nil {
+		log.Fatal("Failed to load message catalog:", err)
+	}
+
 	// Initialize services
 	authService := services.NewAuthService(cfg)
+	if err := authService.SyncAdminRoles(); err != nil {
+		log.Fatal("Failed to sync admin roles:", err)
+	}
+	householdService := services.NewHouseholdService(cfg)
+	scheduledService := services.NewScheduledNotificationService(cfg)
 	// Initialize optional services later
 	txHandler := handlers.NewTransactionHandler(cfg)
 	categoryHandler := handlers.NewCategoryHandler()
@@ -57,6 +69,7 @@ func main() {
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
+	householdHandler := handlers.NewHouseholdHandler(householdService)
 
 	// Setup Echo server
 	e := echo.New()
@@ -108,7 +121,12 @@ func main() {
 	auth := api.Group("/auth")
 	auth.POST("/register", authHandler.Register)
 	auth.POST("/login", authHandler.Login)
+	auth.POST("/login/2fa", authHandler.LoginTwoFactor)
 	auth.POST("/refresh", authHandler.RefreshToken)
+	auth.POST("/forgot-password", authHandler.ForgotPassword)
+	auth.POST("/reset-password", authHandler.ResetPassword)
+	auth.POST("/verify-email", authHandler.VerifyEmail)
+	auth.POST("/resend-verification", authHandler.ResendVerification, appmw.AuthMiddleware(authService))
 	auth.POST("/logout", authHandler.Logout, appmw.AuthMiddleware(authService))
 	auth.POST("/change-password", authHandler.ChangePassword, appmw.AuthMiddleware(authService))
 	auth.GET("/profile", authHandler.GetProfile, appmw.AuthMiddleware(authService))
@@ -117,34 +135,151 @@ func main() {
 	auth.PUT("/income", authHandler.SetMonthlyIncome, appmw.AuthMiddleware(authService))
 	auth.PUT("/large-transaction-threshold", authHandler.SetLargeTransactionThreshold, appmw.AuthMiddleware(authService))
 
+	// OIDC social login routes
+	auth.GET("/oidc/providers", authHandler.ListOIDCProviders)
+	auth.GET("/oidc/identities", authHandler.ListIdentities, appmw.AuthMiddleware(authService))
+	auth.DELETE("/oidc/identities/:id", authHandler.UnlinkIdentity, appmw.AuthMiddleware(authService))
+	auth.POST("/oidc/link", authHandler.LinkOIDCIdentity, appmw.AuthMiddleware(authService))
+	auth.GET("/oidc/:provider/authorize", authHandler.StartOIDCLogin)
+	auth.POST("/oidc/:provider/callback", authHandler.OIDCCallback)
+
+	// Personal access token (API key) routes
+	auth.GET("/api-keys", authHandler.ListAPIKeys, appmw.AuthMiddleware(authService))
+	auth.POST("/api-keys", authHandler.CreateAPIKey, appmw.AuthMiddleware(authService))
+	auth.DELETE("/api-keys/:id", authHandler.RevokeAPIKey, appmw.AuthMiddleware(authService))
+
+	// Session (device) management routes
+	auth.GET("/sessions", authHandler.ListSessions, appmw.AuthMiddleware(authService))
+	auth.DELETE("/sessions/:id", authHandler.RevokeSession, appmw.AuthMiddleware(authService))
+	auth.POST("/sessions/revoke-others", authHandler.RevokeOtherSessions, appmw.AuthMiddleware(authService))
+	auth.POST("/stream-ticket", authHandler.IssueStreamTicket, appmw.AuthMiddleware(authService))
+
+	// Two-factor authentication routes
+	auth.GET("/2fa", authHandler.GetTwoFactorStatus, appmw.AuthMiddleware(authService))
+	auth.POST("/2fa/setup", authHandler.SetupTwoFactor, appmw.AuthMiddleware(authService))
+	auth.POST("/2fa/enable", authHandler.EnableTwoFactor, appmw.AuthMiddleware(authService))
+	auth.POST("/2fa/disable", authHandler.DisableTwoFactor, appmw.AuthMiddleware(authService))
+	auth.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes, appmw.AuthMiddleware(authService))
+
+	// Account data export and deletion routes
+	auth.GET("/account/exports", authHandler.ListDataExports, appmw.AuthMiddleware(authService))
+	auth.POST("/account/exports", authHandler.RequestDataExport, appmw.AuthMiddleware(authService))
+	auth.GET("/account/exports/:id/download", authHandler.DownloadDataExport, appmw.AuthMiddleware(authService))
+	auth.GET("/account/deletion", authHandler.GetAccountDeletion, appmw.AuthMiddleware(authService))
+	auth.POST("/account/deletion", authHandler.ScheduleAccountDeletion, appmw.AuthMiddleware(authService))
+	auth.DELETE("/account/deletion", authHandler.CancelAccountDeletion, appmw.AuthMiddleware(authService))
+
 	// Telegram integration routes
-	auth.POST("/telegram/generate-link-code", authHandler.GenerateTelegramLinkCode, appmw.AuthMiddleware(authService))
+	auth.POST("/telegram/generate-link-code", authHandler.GenerateTelegramLinkCode, appmw.AuthMiddleware(authService), appmw.RequireVerifiedEmail(authService))
 	auth.GET("/telegram/status", authHandler.GetTelegramStatus, appmw.AuthMiddleware(authService))
 	auth.POST("/telegram/disconnect", authHandler.DisconnectTelegram, appmw.AuthMiddleware(authService))
 	auth.POST("/telegram/link", authHandler.LinkTelegramAccount)
 
+	// Telegram bot webhook (authenticated by the secret token header)
+	telegramWebhookHandler := handlers.NewTelegramWebhookHandler(cfg)
+	api.POST("/telegram/webhook", telegramWebhookHandler.Webhook)
+
 	// Transactions routes
-	tx := api.Group("/transactions", appmw.AuthMiddleware(authService))
+	tx := api.Group("/transactions", appmw.AuthMiddleware(authService, appmw.ScopeRule{Read: models.ScopeTransactionsRead, Write: models.ScopeTransactionsWrite}), appmw.LedgerMiddleware(householdService))
 	tx.GET("", txHandler.List)
 	tx.POST("", txHandler.Create)
 	tx.PUT("/:id", txHandler.Update)
 	tx.DELETE("/:id", txHandler.Delete)
 
 	// Categories
-	cat := api.Group("/categories", appmw.AuthMiddleware(authService))
+	cat := api.Group("/categories", appmw.AuthMiddleware(authService), appmw.LedgerMiddleware(householdService))
 	cat.GET("", categoryHandler.List)
 	cat.POST("", categoryHandler.Create)
 	cat.PUT("/:id", categoryHandler.Update)
 	cat.DELETE("/:id", categoryHandler.Delete)
 
+	// Household (shared ledger) routes. Ledger-scoped groups below select a
+	// household with the X-Household-ID header.
+	households := api.Group("/households", appmw.AuthMiddleware(authService))
+	households.GET("", householdHandler.ListHouseholds)
+	households.POST("", householdHandler.CreateHousehold)
+	households.POST("/invitations/accept", householdHandler.AcceptInvitation)
+	households.GET("/:id", householdHandler.GetHousehold)
+	households.PUT("/:id", householdHandler.UpdateHousehold)
+	households.DELETE("/:id", householdHandler.DeleteHousehold)
+	households.GET("/:id/invitations", householdHandler.ListInvitations)
+	households.POST("/:id/invitations", householdHandler.InviteMember)
+	households.DELETE("/:id/invitations/:invitationId", householdHandler.RevokeInvitation)
+	households.PUT("/:id/members/:userId", householdHandler.UpdateMemberRole)
+	households.DELETE("/:id/members/:userId", householdHandler.RemoveMember)
+
+	// Split expense routes
+	splitHandler := handlers.NewSplitHandler(cfg)
+	splits := api.Group("/splits", appmw.AuthMiddleware(authService))
+	splits.GET("", splitHandler.ListSplits)
+	splits.POST("", splitHandler.CreateSplit)
+	splits.GET("/balances", splitHandler.GetBalances)
+	splits.POST("/settle", splitHandler.SettleUp)
+	splits.GET("/counterparties", splitHandler.ListCounterparties)
+	splits.POST("/counterparties", splitHandler.CreateCounterparty)
+	splits.POST("/counterparties/accept", splitHandler.AcceptCounterpartyInvite)
+	splits.PUT("/counterparties/:id", splitHandler.UpdateCounterparty)
+	splits.DELETE("/counterparties/:id", splitHandler.DeleteCounterparty)
+	splits.GET("/counterparties/:id/entries", splitHandler.GetEntries)
+	splits.POST("/counterparties/:id/invite", splitHandler.InviteCounterparty)
+	splits.POST("/counterparties/:id/remind", splitHandler.RemindCounterparty)
+	splits.GET("/:id", splitHandler.GetSplit)
+	splits.DELETE("/:id", splitHandler.DeleteSplit)
+
 	// Goals routes
 	goalHandler := handlers.NewGoalHandler(cfg)
-	goals := api.Group("/goals", appmw.AuthMiddleware(authService))
+	goals := api.Group("/goals", appmw.AuthMiddleware(authService), appmw.LedgerMiddleware(householdService))
 	goals.GET("", goalHandler.GetGoals)
 	goals.POST("", goalHandler.CreateGoal)
 	goals.PUT("/:id", goalHandler.UpdateGoal)
 	goals.DELETE("/:id", goalHandler.DeleteGoal)
 	goals.POST("/:id/contribute", goalHandler.AddContribution)
+	goals.GET("/:id/contributions", goalHandler.ListContributions)
+	goals.PUT("/:id/contributions/:contributionId", goalHandler.UpdateContribution)
+	goals.DELETE("/:id/contributions/:contributionId", goalHandler.DeleteContribution)
+	goals.GET("/:id/plan", goalHandler.GetSavingsPlan)
+	goals.PUT("/:id/plan", goalHandler.SaveSavingsPlan)
+	goals.DELETE("/:id/plan", goalHandler.DeleteSavingsPlan)
+	goals.GET("/:id/plan/runs", goalHandler.ListSavingsPlanRuns)
+
+	// Debts routes
+	debtHandler := handlers.NewDebtHandler(cfg)
+	debts := api.Group("/debts", appmw.AuthMiddleware(authService), appmw.LedgerMiddleware(householdService))
+	debts.GET("", debtHandler.ListDebts)
+	debts.POST("", debtHandler.CreateDebt)
+	debts.GET("/payoff", debtHandler.ComparePayoffStrategies)
+	debts.GET("/:id", debtHandler.GetDebt)
+	debts.PUT("/:id", debtHandler.UpdateDebt)
+	debts.DELETE("/:id", debtHandler.DeleteDebt)
+	debts.GET("/:id/schedule", debtHandler.GetAmortizationSchedule)
+	debts.GET("/:id/payments", debtHandler.ListPayments)
+	debts.POST("/:id/payments", debtHandler.AddPayment)
+	debts.DELETE("/:id/payments/:paymentId", debtHandler.DeletePayment)
+
+	// Bills and subscriptions routes
+	billHandler := handlers.NewBillHandler(cfg)
+	bills := api.Group("/bills", appmw.AuthMiddleware(authService), appmw.LedgerMiddleware(householdService))
+	bills.GET("", billHandler.ListBills)
+	bills.POST("", billHandler.CreateBill)
+	bills.GET("/detect", billHandler.DetectSubscriptions)
+	bills.POST("/detect/confirm", billHandler.ConfirmSubscription)
+	bills.POST("/detect/dismiss", billHandler.DismissSubscription)
+	bills.PUT("/:id", billHandler.UpdateBill)
+	bills.DELETE("/:id", billHandler.DeleteBill)
+	bills.POST("/:id/paid", billHandler.MarkBillPaid)
+
+	// Investments routes
+	investmentHandler := handlers.NewInvestmentHandler(cfg)
+	investments := api.Group("/investments", appmw.AuthMiddleware(authService), appmw.LedgerMiddleware(householdService))
+	investments.GET("", investmentHandler.ListHoldings)
+	investments.POST("", investmentHandler.CreateHolding)
+	investments.GET("/portfolio", investmentHandler.GetPortfolio)
+	investments.GET("/:id", investmentHandler.GetHolding)
+	investments.PUT("/:id", investmentHandler.UpdateHolding)
+	investments.DELETE("/:id", investmentHandler.DeleteHolding)
+	investments.GET("/:id/trades", investmentHandler.ListTrades)
+	investments.POST("/:id/trades", investmentHandler.AddTrade)
+	investments.DELETE("/:id/trades/:tradeId", investmentHandler.DeleteTrade)
 
 	// Budgets routes
 	budgetHandler := handlers.NewBudgetHandler(cfg)
@@ -152,7 +287,14 @@ func main() {
 	notificationHandler := handlers.NewNotificationHandler()
 	notifications := api.Group("/notifications", appmw.AuthMiddleware(authService))
 	notifications.GET("", notificationHandler.List)
+	notifications.GET("/unread-count", notificationHandler.UnreadCount)
+	notifications.POST("/read-all", notificationHandler.MarkAllRead)
+	notifications.POST("/archive", notificationHandler.Archive)
+	notifications.POST("/unarchive", notificationHandler.Unarchive)
+	notifications.POST("/bulk-delete", notificationHandler.BulkDelete)
 	notifications.POST("/:id/read", notificationHandler.MarkRead)
+	notifications.DELETE("/:id", notificationHandler.Delete)
+	api.GET("/notifications/stream", notificationHandler.Stream, appmw.StreamAuthMiddleware(authService))
 
 	// Notification preferences routes
 	notificationPrefsHandler := handlers.NewNotificationPreferencesHandler(cfg)
@@ -162,13 +304,23 @@ func main() {
 	notificationPrefs.GET("/summary", notificationPrefsHandler.GetSummary)
 	notificationPrefs.POST("/reset", notificationPrefsHandler.ResetToDefaults)
 	notificationPrefs.GET("/channels", notificationPrefsHandler.GetEnabledChannels)
-	notificationPrefs.POST("/test", notificationPrefsHandler.TestNotification)
-	budgets := api.Group("/budgets", appmw.AuthMiddleware(authService))
+	notificationPrefs.POST("/test", notificationPrefsHandler.TestNotification, appmw.RequireVerifiedEmail(authService))
+	budgets := api.Group("/budgets", appmw.AuthMiddleware(authService, appmw.ScopeRule{Read: models.ScopeBudgetsRead}), appmw.LedgerMiddleware(householdService))
 	budgets.GET("", budgetHandler.GetBudgets)
 	budgets.POST("", budgetHandler.CreateBudget)
 	budgets.PUT("/:id", budgetHandler.UpdateBudget)
 	budgets.DELETE("/:id", budgetHandler.DeleteBudget)
+	budgets.GET("/:id/history", budgetHandler.GetBudgetHistory)
 	budgets.GET("/insights", budgetHandler.GetBudgetInsights)
+	// Simulating changes nothing, so API keys only need read access despite the POST
+	api.POST("/budgets/simulate", budgetHandler.SimulateBudgets,
+		appmw.AuthMiddleware(authService, appmw.ScopeRule{Read: models.ScopeBudgetsRead, Write: models.ScopeBudgetsRead}),
+		appmw.LedgerMiddleware(householdService))
+	budgets.GET("/envelopes", budgetHandler.GetEnvelopeMonth)
+	budgets.GET("/envelopes/allocations", budgetHandler.ListEnvelopeAllocations)
+	budgets.POST("/envelopes/assign", budgetHandler.AssignToEnvelope)
+	budgets.POST("/envelopes/move", budgetHandler.MoveBetweenEnvelopes)
+	budgets.POST("/envelopes/cover", budgetHandler.CoverOverspending)
 	budgets.GET("/auto/suggestions", budgetHandler.GetAutoBudgetSuggestions)
 	budgets.POST("/auto/create", budgetHandler.CreateBudgetsFromSuggestions)
 
@@ -178,13 +330,36 @@ func main() {
 
 	// Analytics routes
 	analyticsHandler := handlers.NewAnalyticsHandler(cfg)
-	analytics := api.Group("/analytics", appmw.AuthMiddleware(authService))
+	analytics := api.Group("/analytics", appmw.AuthMiddleware(authService, appmw.ScopeRule{Read: models.ScopeAnalyticsRead}), appmw.LedgerMiddleware(householdService))
 	analytics.GET("/dashboard", analyticsHandler.GetDashboardAnalytics)
 	analytics.GET("/category-spending", analyticsHandler.GetCategorySpending)
+	analytics.GET("/category-spending/tree", analyticsHandler.GetCategorySpendingTree)
+	analytics.GET("/net-worth", analyticsHandler.GetNetWorth)
 	analytics.GET("/spending-patterns", analyticsHandler.GetSpendingPatterns)
 	analytics.GET("/anomalies", analyticsHandler.GetAnomalies)
 	analytics.GET("/predictions", analyticsHandler.GetPredictions)
 
+	// Admin routes
+	adminHandler := handlers.NewAdminHandler(cfg, scheduledService)
+	admin := api.Group("/admin", appmw.AuthMiddleware(authService), appmw.RequireAdmin(authService))
+	admin.GET("/categories", adminHandler.ListCategories)
+	admin.POST("/categories", adminHandler.CreateCategory)
+	admin.POST("/categories/reorder", adminHandler.ReorderCategories)
+	admin.PUT("/categories/:id", adminHandler.UpdateCategory)
+	admin.DELETE("/categories/:id", adminHandler.DeleteCategory)
+	admin.GET("/users", adminHandler.ListUsers)
+	admin.GET("/users/:id", adminHandler.GetUser)
+	admin.POST("/users/:id/lock", adminHandler.LockUser)
+	admin.POST("/users/:id/unlock", adminHandler.UnlockUser)
+	admin.PUT("/users/:id/role", adminHandler.SetUserRole)
+	admin.GET("/audit-logs", adminHandler.ListAuditLogs)
+	admin.GET("/stats/scheduler", adminHandler.SchedulerStats)
+	admin.GET("/stats/notifications", adminHandler.NotificationStats)
+	admin.POST("/jobs/:name/run", adminHandler.RunJob)
+	admin.GET("/prices", adminHandler.ListAssetPrices)
+	admin.PUT("/prices", adminHandler.SetAssetPrices)
+	admin.POST("/prices/import", adminHandler.ImportAssetPrices)
+
 	// Start server
 	server := &http.Server{
 		Addr:         cfg.GetServerAddr(),
@@ -204,7 +379,6 @@ func main() {
 
 	// Start scheduled notification service
 	ctx := context.Background()
-	scheduledService := services.NewScheduledNotificationService(cfg)
 	go scheduledService.StartScheduler(ctx)
 	logrus.Info("Scheduled notification service started")
 
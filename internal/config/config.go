@@ -14,11 +14,21 @@ type Config struct {
 	Database DatabaseConfig
 	Redis    RedisConfig
 	JWT      JWTConfig
+	Auth     AuthConfig
 	Server   ServerConfig
 	Email    EmailConfig
 	Upload   UploadConfig
 	RateLimit RateLimitConfig
 	Logging  LoggingConfig
+	I18n     I18nConfig
+	Notification NotificationConfig
+	Telegram TelegramConfig
+	OIDC     OIDCConfig
+	Household HouseholdConfig
+	Account   AccountConfig
+	Admin     AdminConfig
+	Budget    BudgetConfig
+	Investment InvestmentConfig
 	Environment string
 }
 
@@ -43,10 +53,53 @@ type JWTConfig struct {
 	RefreshExpireHours int
 }
 
+type AuthConfig struct {
+	ResetTokenExpireMinutes      int
+	VerificationTokenExpireHours int
+	EmailRequestsPerHour         int  // reset/verification emails per address
+	RequireVerifiedEmail         bool // block sensitive actions for unverified accounts
+	TOTPIssuer                   string
+	TOTPEncryptionKey            string // encrypts TOTP secrets at rest; defaults to JWT secret
+	MFATokenExpireMinutes        int    // lifetime of the pending-MFA token issued by login
+	LoginFailureWindowMinutes    int    // failed logins are counted over this window
+	LoginCaptchaAfter            int    // failures per account or IP before a CAPTCHA is required; 0 disables
+	LoginLockoutAfter            int    // failures per account before a temporary lockout; 0 disables
+	LoginLockoutMinutes          int
+	LoginIPMaxFailures           int    // failures per IP before it is blocked for the window; 0 disables
+	CaptchaSecret                string // hCaptcha/reCAPTCHA secret; without it the CAPTCHA flag is advisory
+	CaptchaVerifyURL             string
+	NewDeviceAlerts              bool   // notify users of sign-ins from a device they have not used before
+}
+
+type HouseholdConfig struct {
+	InvitationExpireHours int
+	MaxMembers            int
+}
+
+type AdminConfig struct {
+	Emails []string // accounts with these emails are given the admin role
+}
+
+type BudgetConfig struct {
+	SuggestionMonths     int // full months of history budget suggestions look at
+	SuggestionPercentile int // percentile of monthly spending suggested per category; 50 is the median
+}
+
+type InvestmentConfig struct {
+	PricesFile string // CSV of symbol,price[,date] loaded daily; empty disables
+}
+
+type AccountConfig struct {
+	ExportDir         string // where data export archives are written
+	ExportExpireHours int    // export archives are deleted after this long
+	DeletionGraceDays int    // delay before a requested account deletion runs; 0 deletes immediately
+}
+
 type ServerConfig struct {
 	Port        string
 	Host        string
 	CORSOrigins []string
+	FrontendURL string // base URL for links in emails
 }
 
 type EmailConfig struct {
@@ -72,6 +125,37 @@ type LoggingConfig struct {
 	Format string
 }
 
+type NotificationConfig struct {
+	RetentionDays    int // read notifications older than this are purged
+	IOUReminderDays  int // remind linked counterparties of balances idle this long; 0 disables
+	DebtReminderDays int // remind of debt payments due within this many days; 0 disables
+	BillReminderDays int // default for new bills: remind this many days before the due date
+}
+
+type TelegramConfig struct {
+	WebhookSecret string // must match secret_token passed to setWebhook
+}
+
+type OIDCConfig struct {
+	Providers       []OIDCProviderConfig
+	RedirectURL     string // "{provider}" is replaced with the provider name
+	StateTTLMinutes int    // lifetime of the state/PKCE verifier and pending link tokens
+}
+
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+type I18nConfig struct {
+	Dir             string // optional override for the embedded locale files
+	DefaultLanguage string
+}
+
 func Load() (*Config, error) {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
@@ -97,10 +181,28 @@ func Load() (*Config, error) {
 			ExpireHours:       getEnvAsInt("JWT_EXPIRE_HOURS", 24),
 			RefreshExpireHours: getEnvAsInt("JWT_REFRESH_EXPIRE_HOURS", 168),
 		},
+		Auth: AuthConfig{
+			ResetTokenExpireMinutes:      getEnvAsInt("RESET_TOKEN_EXPIRE_MINUTES", 60),
+			VerificationTokenExpireHours: getEnvAsInt("VERIFICATION_TOKEN_EXPIRE_HOURS", 48),
+			EmailRequestsPerHour:         getEnvAsInt("ACCOUNT_EMAIL_REQUESTS_PER_HOUR", 3),
+			RequireVerifiedEmail:         getEnvAsBool("REQUIRE_VERIFIED_EMAIL", false),
+			TOTPIssuer:                   getEnv("TOTP_ISSUER", "TabiMoney"),
+			TOTPEncryptionKey:            getEnv("TOTP_ENCRYPTION_KEY", ""),
+			MFATokenExpireMinutes:        getEnvAsInt("MFA_TOKEN_EXPIRE_MINUTES", 5),
+			LoginFailureWindowMinutes:    getEnvAsInt("LOGIN_FAILURE_WINDOW_MINUTES", 15),
+			LoginCaptchaAfter:            getEnvAsInt("LOGIN_CAPTCHA_AFTER", 3),
+			LoginLockoutAfter:            getEnvAsInt("LOGIN_LOCKOUT_AFTER", 10),
+			LoginLockoutMinutes:          getEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15),
+			LoginIPMaxFailures:           getEnvAsInt("LOGIN_IP_MAX_FAILURES", 50),
+			CaptchaSecret:                getEnv("CAPTCHA_SECRET", ""),
+			CaptchaVerifyURL:             getEnv("CAPTCHA_VERIFY_URL", "https://hcaptcha.com/siteverify"),
+			NewDeviceAlerts:              getEnvAsBool("LOGIN_NEW_DEVICE_ALERTS", true),
+		},
 		Server: ServerConfig{
 			Port:        getEnv("SERVER_PORT", "8080"),
 			Host:        getEnv("SERVER_HOST", "localhost"),
 			CORSOrigins: strings.Split(getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:8080"), ","),
+			FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
 		},
 		Email: EmailConfig{
 			SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
@@ -121,6 +223,43 @@ func Load() (*Config, error) {
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
+		I18n: I18nConfig{
+			Dir:             getEnv("I18N_DIR", ""),
+			DefaultLanguage: getEnv("DEFAULT_LANGUAGE", "vi"),
+		},
+		Notification: NotificationConfig{
+			RetentionDays:    getEnvAsInt("NOTIFICATION_RETENTION_DAYS", 90),
+			IOUReminderDays:  getEnvAsInt("IOU_REMINDER_DAYS", 7),
+			DebtReminderDays: getEnvAsInt("DEBT_REMINDER_DAYS", 3),
+			BillReminderDays: getEnvAsInt("BILL_REMINDER_DAYS", 3),
+		},
+		Telegram: TelegramConfig{
+			WebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+		},
+		OIDC: OIDCConfig{
+			Providers:       loadOIDCProviders(),
+			RedirectURL:     getEnv("OIDC_REDIRECT_URL", getEnv("FRONTEND_URL", "http://localhost:3000")+"/auth/oidc/{provider}/callback"),
+			StateTTLMinutes: getEnvAsInt("OIDC_STATE_TTL_MINUTES", 10),
+		},
+		Household: HouseholdConfig{
+			InvitationExpireHours: getEnvAsInt("HOUSEHOLD_INVITATION_EXPIRE_HOURS", 72),
+			MaxMembers:            getEnvAsInt("HOUSEHOLD_MAX_MEMBERS", 10),
+		},
+		Account: AccountConfig{
+			ExportDir:         getEnv("ACCOUNT_EXPORT_DIR", "./data/exports"),
+			ExportExpireHours: getEnvAsInt("ACCOUNT_EXPORT_EXPIRE_HOURS", 48),
+			DeletionGraceDays: getEnvAsInt("ACCOUNT_DELETION_GRACE_DAYS", 14),
+		},
+		Admin: AdminConfig{
+			Emails: splitList(getEnv("ADMIN_EMAILS", "")),
+		},
+		Budget: BudgetConfig{
+			SuggestionMonths:     getEnvAsInt("BUDGET_SUGGESTION_MONTHS", 6),
+			SuggestionPercentile: getEnvAsInt("BUDGET_SUGGESTION_PERCENTILE", 50),
+		},
+		Investment: InvestmentConfig{
+			PricesFile: getEnv("INVESTMENT_PRICES_FILE", ""),
+		},
 		Environment: getEnv("ENV", "development"),
 	}
 
@@ -134,6 +273,49 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// splitList splits a comma-separated value, dropping blanks and lowercasing entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadOIDCProviders reads OIDC_PROVIDERS (e.g. "google,mock") and the
+// OIDC_<NAME>_* variables of each provider. Providers without a client ID are skipped.
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		defaultIssuer := ""
+		if name == "google" {
+			defaultIssuer = "https://accounts.google.com"
+		}
+		provider := OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", strings.ToUpper(name[:1])+name[1:]),
+			IssuerURL:    strings.TrimRight(getEnv(prefix+"ISSUER_URL", defaultIssuer), "/"),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		}
+		if provider.ClientID == "" || provider.IssuerURL == "" {
+			logrus.Warnf("OIDC provider %q is missing a client ID or issuer URL, skipping", name)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -152,6 +334,15 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsFloat64(key string, defaultValue float64) float64 {
 	if value := os.Getenv(key); value != "" {
 		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
@@ -169,6 +360,42 @@ func (c *Config) GetJWTRefreshExpiration() time.Duration {
 	return time.Duration(c.JWT.RefreshExpireHours) * time.Hour
 }
 
+func (c *Config) GetResetTokenExpiration() time.Duration {
+	return time.Duration(c.Auth.ResetTokenExpireMinutes) * time.Minute
+}
+
+func (c *Config) GetVerificationTokenExpiration() time.Duration {
+	return time.Duration(c.Auth.VerificationTokenExpireHours) * time.Hour
+}
+
+func (c *Config) GetMFATokenExpiration() time.Duration {
+	return time.Duration(c.Auth.MFATokenExpireMinutes) * time.Minute
+}
+
+func (c *Config) GetLoginFailureWindow() time.Duration {
+	return time.Duration(c.Auth.LoginFailureWindowMinutes) * time.Minute
+}
+
+func (c *Config) GetLoginLockoutDuration() time.Duration {
+	return time.Duration(c.Auth.LoginLockoutMinutes) * time.Minute
+}
+
+func (c *Config) GetOIDCStateTTL() time.Duration {
+	return time.Duration(c.OIDC.StateTTLMinutes) * time.Minute
+}
+
+func (c *Config) GetHouseholdInvitationExpiration() time.Duration {
+	return time.Duration(c.Household.InvitationExpireHours) * time.Hour
+}
+
+func (c *Config) GetExportExpiration() time.Duration {
+	return time.Duration(c.Account.ExportExpireHours) * time.Hour
+}
+
+func (c *Config) GetAccountDeletionGracePeriod() time.Duration {
+	return time.Duration(c.Account.DeletionGraceDays) * 24 * time.Hour
+}
+
 func (c *Config) GetDatabaseDSN() string {
 	return c.Database.User + ":" + c.Database.Password + "@tcp(" + c.Database.Host + ":" + strconv.Itoa(c.Database.Port) + ")/" + c.Database.Name + "?charset=utf8mb4&parseTime=True&loc=Local"
 }
@@ -54,10 +54,34 @@ func AutoMigrate() error {
 		&models.User{},
 		&models.UserProfile{},
 		&models.UserSession{},
+		&models.UserRecoveryCode{},
+		&models.UserIdentity{},
+		&models.APIKey{},
+		&models.DataExport{},
+		&models.AuditLog{},
+		&models.Household{},
+		&models.HouseholdMember{},
+		&models.HouseholdInvitation{},
+		&models.Counterparty{},
+		&models.SplitExpense{},
+		&models.SplitShare{},
+		&models.IOUEntry{},
 		&models.Category{},
 		&models.Transaction{},
 		&models.FinancialGoal{},
+		&models.GoalContribution{},
+		&models.GoalSavingsPlan{},
+		&models.GoalSavingsPlanRun{},
+		&models.Debt{},
+		&models.DebtPayment{},
+		&models.Bill{},
+		&models.DismissedSubscription{},
+		&models.InvestmentHolding{},
+		&models.InvestmentTrade{},
+		&models.AssetPrice{},
 		&models.Budget{},
+		&models.BudgetPeriod{},
+		&models.EnvelopeAllocation{},
 		&models.AIAnalysis{},
 		&models.Notification{},
 		&models.TelegramAccount{},
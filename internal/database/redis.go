@@ -67,6 +67,11 @@ func GetCache(ctx context.Context, key string) (string, error) {
 	return RedisClient.Get(ctx, key).Result()
 }
 
+// TakeCache reads and deletes a key atomically, for single-use values
+func TakeCache(ctx context.Context, key string) (string, error) {
+	return RedisClient.GetDel(ctx, key).Result()
+}
+
 func DeleteCache(ctx context.Context, key string) error {
 	return RedisClient.Del(ctx, key).Err()
 }
@@ -76,6 +81,15 @@ func ExistsCache(ctx context.Context, key string) (bool, error) {
 	return result > 0, err
 }
 
+// CacheTTL returns the time left on a key; zero when it is missing or never expires
+func CacheTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := RedisClient.TTL(ctx, key).Result()
+	if err != nil || ttl < 0 {
+		return 0, err
+	}
+	return ttl, nil
+}
+
 // Session management
 func SetSession(ctx context.Context, sessionID string, userID uint64, expiration time.Duration) error {
 	key := fmt.Sprintf("session:%s", sessionID)
@@ -129,6 +143,15 @@ func GetRateLimit(ctx context.Context, key string) (int64, error) {
 	return RedisClient.Get(ctx, key).Int64()
 }
 
+// GetCounter reads a counter maintained by IncrementRateLimit; missing keys count as zero
+func GetCounter(ctx context.Context, key string) (int64, error) {
+	n, err := RedisClient.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
 // Dashboard cache
 func SetDashboardCache(ctx context.Context, userID uint64, period string, data interface{}, expiration time.Duration) error {
 	key := fmt.Sprintf("dashboard:%d:%s", userID, period)
@@ -145,6 +168,11 @@ func DeleteDashboardCache(ctx context.Context, userID uint64) error {
 	return deleteByPattern(ctx, pattern)
 }
 
+func DeleteHouseholdDashboardCache(ctx context.Context, householdID uint64) error {
+	pattern := fmt.Sprintf("dashboard:household:%d:*", householdID)
+	return deleteByPattern(ctx, pattern)
+}
+
 // AI analysis cache
 func SetAIAnalysisCache(ctx context.Context, userID uint64, analysisType string, data interface{}, expiration time.Duration) error {
 	key := fmt.Sprintf("ai_analysis:%d:%s", userID, analysisType)
@@ -177,6 +205,106 @@ func GetNotificationQueueLength(ctx context.Context, userID uint64) (int64, erro
 	return RedisClient.LLen(ctx, key).Result()
 }
 
+// Notification unread count cache
+func SetUnreadCountCache(ctx context.Context, userID uint64, count int64, expiration time.Duration) error {
+	key := fmt.Sprintf("notification_unread:%d", userID)
+	return SetCache(ctx, key, count, expiration)
+}
+
+func GetUnreadCountCache(ctx context.Context, userID uint64) (int64, error) {
+	key := fmt.Sprintf("notification_unread:%d", userID)
+	return RedisClient.Get(ctx, key).Int64()
+}
+
+func DeleteUnreadCountCache(ctx context.Context, userID uint64) error {
+	key := fmt.Sprintf("notification_unread:%d", userID)
+	return DeleteCache(ctx, key)
+}
+
+// DeleteUserCache removes every per-user key: cached dashboards, AI analyses
+// and predictions, the notification queue and the unread counter
+func DeleteUserCache(ctx context.Context, userID uint64) error {
+	for _, pattern := range []string{
+		fmt.Sprintf("dashboard:%d:*", userID),
+		fmt.Sprintf("ai_analysis:%d:*", userID),
+		fmt.Sprintf("expense_prediction:%d:*", userID),
+	} {
+		if err := deleteByPattern(ctx, pattern); err != nil {
+			return err
+		}
+	}
+	return RedisClient.Del(ctx,
+		fmt.Sprintf("notifications:%d", userID),
+		fmt.Sprintf("notification_unread:%d", userID),
+	).Err()
+}
+
+// Revoked session families. Entries live as long as an access token could, so
+// token validation can reject revoked sessions without a database lookup.
+func SetRevokedSession(ctx context.Context, familyID string, expiration time.Duration) error {
+	key := fmt.Sprintf("revoked_session:%s", familyID)
+	return SetCache(ctx, key, 1, expiration)
+}
+
+func IsSessionRevoked(ctx context.Context, familyID string) (bool, error) {
+	key := fmt.Sprintf("revoked_session:%s", familyID)
+	return ExistsCache(ctx, key)
+}
+
+// Daily delivery counters, kept long enough to chart the last month
+const dailyCounterTTL = 35 * 24 * time.Hour
+
+func dailyCounterKey(name string, day time.Time) string {
+	return fmt.Sprintf("stats:%s:%s", name, day.Format("2006-01-02"))
+}
+
+func IncrementDailyCounter(ctx context.Context, name string) error {
+	if RedisClient == nil {
+		return fmt.Errorf("Redis not initialized")
+	}
+	key := dailyCounterKey(name, time.Now())
+	pipe := RedisClient.TxPipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, dailyCounterTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func GetDailyCounter(ctx context.Context, name string, day time.Time) (int64, error) {
+	n, err := RedisClient.Get(ctx, dailyCounterKey(name, day)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+// Scheduler task status (outcome of the last run of each job)
+func SetSchedulerStatus(ctx context.Context, task string, status interface{}) error {
+	key := fmt.Sprintf("scheduler:task:%s", task)
+	return SetCache(ctx, key, status, 0)
+}
+
+func GetSchedulerStatus(ctx context.Context, task string) (string, error) {
+	key := fmt.Sprintf("scheduler:task:%s", task)
+	return GetCache(ctx, key)
+}
+
+// User event pub/sub (fan-out of real-time events to every API replica)
+func userEventsChannel(userID uint64) string {
+	return fmt.Sprintf("events:user:%d", userID)
+}
+
+func PublishUserEvent(ctx context.Context, userID uint64, payload interface{}) error {
+	if RedisClient == nil {
+		return fmt.Errorf("Redis not initialized")
+	}
+	return RedisClient.Publish(ctx, userEventsChannel(userID), payload).Err()
+}
+
+func SubscribeUserEvents(ctx context.Context, userID uint64) *redis.PubSub {
+	return RedisClient.Subscribe(ctx, userEventsChannel(userID))
+}
+
 // Health check for Redis connection
 func RedisHealthCheck() error {
 	if RedisClient == nil {
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// maxPriceFileSize bounds uploaded price files
+const maxPriceFileSize = 5 << 20
+
+type AdminHandler struct {
+	adminService *services.AdminService
+	scheduler    *services.ScheduledNotificationService
+	validator    *validator.Validate
+}
+
+func NewAdminHandler(cfg *config.Config, scheduler *services.ScheduledNotificationService) *AdminHandler {
+	return &AdminHandler{
+		adminService: services.NewAdminService(cfg),
+		scheduler:    scheduler,
+		validator:    validator.New(),
+	}
+}
+
+// ListCategories lists all system categories, including inactive ones
+func (h *AdminHandler) ListCategories(c echo.Context) error {
+	categories, err := h.adminService.ListSystemCategories()
+	if err != nil {
+		return adminError(c, "Failed to list categories", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": categories,
+	})
+}
+
+// CreateCategory adds a system category
+func (h *AdminHandler) CreateCategory(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+
+	var req models.AdminCategoryRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	category, err := h.adminService.CreateSystemCategory(adminID, c.RealIP(), &req)
+	if err != nil {
+		return adminError(c, "Failed to create category", err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": category,
+	})
+}
+
+// UpdateCategory edits a system category and its translations
+func (h *AdminHandler) UpdateCategory(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+	categoryID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.AdminCategoryRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	category, err := h.adminService.UpdateSystemCategory(adminID, c.RealIP(), categoryID, &req)
+	if err != nil {
+		return adminError(c, "Failed to update category", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": category,
+	})
+}
+
+// DeleteCategory removes an unused system category
+func (h *AdminHandler) DeleteCategory(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+	categoryID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.adminService.DeleteSystemCategory(adminID, c.RealIP(), categoryID); err != nil {
+		return adminError(c, "Failed to delete category", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Category deleted successfully",
+	})
+}
+
+// ReorderCategories sets the display order of system categories
+func (h *AdminHandler) ReorderCategories(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+
+	var req models.AdminCategoryReorderRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.adminService.ReorderSystemCategories(adminID, c.RealIP(), &req); err != nil {
+		return adminError(c, "Failed to reorder categories", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Categories reordered successfully",
+	})
+}
+
+// ListUsers searches users
+func (h *AdminHandler) ListUsers(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page <= 0 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	query := &models.AdminUserQuery{
+		Search: c.QueryParam("search"),
+		Role:   c.QueryParam("role"),
+		Page:   page,
+		Limit:  limit,
+	}
+	if v := c.QueryParam("locked"); v != "" {
+		locked := v == "true"
+		query.Locked = &locked
+	}
+
+	users, total, err := h.adminService.ListUsers(query)
+	if err != nil {
+		return adminError(c, "Failed to list users", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  users,
+		"total": total,
+		"page":  query.Page,
+		"limit": query.Limit,
+	})
+}
+
+// GetUser retrieves a user
+func (h *AdminHandler) GetUser(c echo.Context) error {
+	userID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.adminService.GetUser(userID)
+	if err != nil {
+		return adminError(c, "Failed to get user", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": user,
+	})
+}
+
+// LockUser locks an account and revokes its sessions
+func (h *AdminHandler) LockUser(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+	userID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.AdminLockRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.adminService.LockUser(adminID, c.RealIP(), userID, req.Reason)
+	if err != nil {
+		return adminError(c, "Failed to lock user", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": user,
+	})
+}
+
+// UnlockUser unlocks an account
+func (h *AdminHandler) UnlockUser(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+	userID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.adminService.UnlockUser(adminID, c.RealIP(), userID)
+	if err != nil {
+		return adminError(c, "Failed to unlock user", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": user,
+	})
+}
+
+// SetUserRole grants or revokes the admin role
+func (h *AdminHandler) SetUserRole(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+	userID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.AdminRoleRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.adminService.SetUserRole(adminID, c.RealIP(), userID, req.Role)
+	if err != nil {
+		return adminError(c, "Failed to update role", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": user,
+	})
+}
+
+// ListAuditLogs lists admin actions, newest first
+func (h *AdminHandler) ListAuditLogs(c echo.Context) error {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page <= 0 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	query := &models.AuditLogQuery{
+		Action: c.QueryParam("action"),
+		Page:   page,
+		Limit:  limit,
+	}
+	if v := c.QueryParam("actor_id"); v != "" {
+		actorID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid actor ID",
+				Message: err.Error(),
+			})
+		}
+		query.ActorID = &actorID
+	}
+
+	logs, total, err := h.adminService.ListAuditLogs(query)
+	if err != nil {
+		return adminError(c, "Failed to list audit logs", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data":  logs,
+		"total": total,
+		"page":  query.Page,
+		"limit": query.Limit,
+	})
+}
+
+// SchedulerStats reports the last run of every background job
+func (h *AdminHandler) SchedulerStats(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": h.scheduler.TaskStatuses(),
+	})
+}
+
+// NotificationStats reports notification volume and delivery outcomes
+func (h *AdminHandler) NotificationStats(c echo.Context) error {
+	stats, err := h.adminService.NotificationStats()
+	if err != nil {
+		return adminError(c, "Failed to get notification stats", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": stats,
+	})
+}
+
+// RunJob starts a background job and returns without waiting for it
+func (h *AdminHandler) RunJob(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+	name := c.Param("name")
+
+	if !h.scheduler.HasTask(name) {
+		return adminError(c, "Failed to run job", services.ErrUnknownJob)
+	}
+	if err := h.adminService.RecordJobRun(adminID, c.RealIP(), name); err != nil {
+		return adminError(c, "Failed to run job", err)
+	}
+
+	go func() {
+		if err := h.scheduler.RunTask(name); err != nil {
+			log.Printf("Admin-triggered job %s failed: %v", name, err)
+		}
+	}()
+
+	return c.JSON(http.StatusAccepted, SuccessResponse{
+		Message: "Job started",
+	})
+}
+
+// ListAssetPrices lists the stored asset prices
+func (h *AdminHandler) ListAssetPrices(c echo.Context) error {
+	prices, err := h.adminService.ListAssetPrices()
+	if err != nil {
+		return adminError(c, "Failed to list prices", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": prices,
+	})
+}
+
+// SetAssetPrices sets the latest price of one or more symbols
+func (h *AdminHandler) SetAssetPrices(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+
+	var req models.AssetPriceRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	n, err := h.adminService.SetAssetPrices(adminID, c.RealIP(), &req)
+	if err != nil {
+		return adminError(c, "Failed to set prices", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"updated": n,
+	})
+}
+
+// ImportAssetPrices sets prices from a CSV sent as the "file" form field or
+// as the request body
+func (h *AdminHandler) ImportAssetPrices(c echo.Context) error {
+	adminID := c.Get("user_id").(uint64)
+
+	var body io.Reader = http.MaxBytesReader(c.Response(), c.Request().Body, maxPriceFileSize)
+	if file, err := c.FormFile("file"); err == nil {
+		if file.Size > maxPriceFileSize {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid file",
+				Message: "price file is too large",
+			})
+		}
+		src, err := file.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid file",
+				Message: err.Error(),
+			})
+		}
+		defer src.Close()
+		body = src
+	}
+
+	n, err := h.adminService.ImportAssetPrices(adminID, c.RealIP(), body)
+	if err != nil {
+		return adminError(c, "Failed to import prices", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"updated": n,
+	})
+}
+
+// bind decodes and validates a request body
+func (h *AdminHandler) bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return h.validator.Struct(req)
+}
+
+// adminError maps admin service errors to HTTP status codes
+func adminError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound), errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrUnknownJob):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrCategoryInUse):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrAdminSelfAction), errors.Is(err, services.ErrParentCategoryNotFound),
+		errors.Is(err, services.ErrCategoryCycle), errors.Is(err, services.ErrCategoryTooDeep),
+		errors.Is(err, services.ErrInvalidPriceFile):
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
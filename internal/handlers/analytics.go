@@ -27,6 +27,7 @@ func NewAnalyticsHandler(cfg *config.Config) *AnalyticsHandler {
 // GetDashboardAnalytics retrieves monthly financial summary with AI predictions
 func (h *AnalyticsHandler) GetDashboardAnalytics(c echo.Context) error {
 	userID := c.Get("user_id").(uint64)
+	ledger := analyticsLedger(c)
 
 	// Parse year and month from query params
 	year := time.Now().Year()
@@ -44,7 +45,7 @@ func (h *AnalyticsHandler) GetDashboardAnalytics(c echo.Context) error {
 	}
 
 	// Get basic analytics
-	analytics, err := h.transactionService.GetMonthlySummary(userID, year, month)
+	analytics, err := h.transactionService.GetMonthlySummary(ledger, year, month)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to get analytics",
@@ -62,18 +63,22 @@ func (h *AnalyticsHandler) GetDashboardAnalytics(c echo.Context) error {
 		EndDate:   endDate,
 	}
 
-	predictions, err := h.aiService.PredictExpenses(predictionReq)
-	if err != nil {
-		// If AI service fails, continue without predictions
-		// Set default empty predictions
-		predictions = &models.ExpensePredictionResponse{
-			UserID:            userID,
-			PredictedAmount:   0,
-			ConfidenceScore:   0,
-			CategoryBreakdown: []models.CategoryPrediction{},
-			Trends:            []models.ExpenseTrend{},
-			Recommendations:   []string{"AI Service đang khởi tạo..."},
-			GeneratedAt:       time.Now(),
+	// Household dashboards have no predictions (null in the response)
+	var predictions *models.ExpensePredictionResponse
+	if !ledger.IsHousehold() {
+		predictions, err = h.aiService.PredictExpenses(predictionReq)
+		if err != nil {
+			// If AI service fails, continue without predictions
+			// Set default empty predictions
+			predictions = &models.ExpensePredictionResponse{
+				UserID:            userID,
+				PredictedAmount:   0,
+				ConfidenceScore:   0,
+				CategoryBreakdown: []models.CategoryPrediction{},
+				Trends:            []models.ExpenseTrend{},
+				Recommendations:   []string{"AI Service đang khởi tạo..."},
+				GeneratedAt:       time.Now(),
+			}
 		}
 	}
 
@@ -99,7 +104,7 @@ func (h *AnalyticsHandler) GetDashboardAnalytics(c echo.Context) error {
 
 // GetCategorySpending retrieves spending breakdown by category
 func (h *AnalyticsHandler) GetCategorySpending(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := analyticsLedger(c)
 
 	// Parse date range
 	startDate := time.Now().AddDate(0, -1, 0) // Default: last month
@@ -116,7 +121,7 @@ func (h *AnalyticsHandler) GetCategorySpending(c echo.Context) error {
 		}
 	}
 
-	spending, err := h.transactionService.GetCategorySpending(userID, startDate, endDate)
+	spending, err := h.transactionService.GetCategorySpending(ledger, startDate, endDate)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to get category spending",
@@ -127,6 +132,50 @@ func (h *AnalyticsHandler) GetCategorySpending(c echo.Context) error {
 	return c.JSON(http.StatusOK, spending)
 }
 
+// GetCategorySpendingTree retrieves spending as a category tree with totals rolled up at every level
+func (h *AnalyticsHandler) GetCategorySpendingTree(c echo.Context) error {
+	ledger := analyticsLedger(c)
+
+	startDate := time.Now().AddDate(0, -1, 0) // Default: last month
+	endDate := time.Now()
+
+	if s := c.QueryParam("start_date"); s != "" {
+		if parsed, err := time.Parse("2006-01-02", s); err == nil {
+			startDate = parsed
+		}
+	}
+	if e := c.QueryParam("end_date"); e != "" {
+		if parsed, err := time.Parse("2006-01-02", e); err == nil {
+			endDate = parsed
+		}
+	}
+
+	tree, err := h.transactionService.GetCategorySpendingTree(ledger, startDate, endDate)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to get category spending",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, tree)
+}
+
+// GetNetWorth returns the ledger's cash and investments less its debts
+func (h *AnalyticsHandler) GetNetWorth(c echo.Context) error {
+	netWorth, err := h.transactionService.GetNetWorth(analyticsLedger(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to get net worth",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": netWorth,
+	})
+}
+
 // GetSpendingPatterns analyzes spending patterns using AI
 func (h *AnalyticsHandler) GetSpendingPatterns(c echo.Context) error {
 	userID := c.Get("user_id").(uint64)
@@ -254,3 +303,15 @@ func (h *AnalyticsHandler) GetPredictions(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, predictions)
 }
+
+// analyticsLedger returns the request's ledger, narrowed to one household
+// member when a member_id query parameter is given
+func analyticsLedger(c echo.Context) services.Ledger {
+	ledger := ledgerFrom(c)
+	if m := c.QueryParam("member_id"); m != "" {
+		if memberID, err := strconv.ParseUint(m, 10, 64); err == nil {
+			ledger = ledger.ForMember(memberID)
+		}
+	}
+	return ledger
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+    "errors"
+    "math"
     "net/http"
+    "strconv"
 
     "tabimoney/internal/models"
     "tabimoney/internal/services"
@@ -50,7 +53,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		})
 	}
 
-	response, err := h.authService.Register(&req)
+	response, err := h.authService.Register(&req, clientInfo(c))
 	if err != nil {
 		return c.JSON(http.StatusConflict, ErrorResponse{
 			Error:   "Registration failed",
@@ -69,9 +72,13 @@ func (h *AuthHandler) Register(c echo.Context) error {
 // @Produce json
 // @Param request body models.UserLoginRequest true "Login credentials"
 // @Success 200 {object} models.AuthResponse
+// @Success 200 {object} models.MFAChallengeResponse "When two-factor authentication is enabled"
 // @Failure 400 {object} ErrorResponse
-// @Failure 401 {object} ErrorResponse
+// @Failure 401 {object} models.LoginFailureResponse "Invalid credentials or CAPTCHA required"
+// @Failure 403 {object} ErrorResponse "Account locked"
+// @Failure 429 {object} models.LoginFailureResponse "Too many failed attempts"
 // @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "Sign-in protection unavailable"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c echo.Context) error {
 	var req models.UserLoginRequest
@@ -89,12 +96,14 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		})
 	}
 
-	response, err := h.authService.Login(&req)
+	response, challenge, err := h.authService.Login(&req, clientInfo(c))
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Error:   "Login failed",
-			Message: err.Error(),
-		})
+		return loginError(c, err)
+	}
+
+	// Two-factor accounts must finish with POST /auth/login/2fa
+	if challenge != nil {
+		return c.JSON(http.StatusOK, challenge)
 	}
 
 	return c.JSON(http.StatusOK, response)
@@ -131,7 +140,7 @@ func (h *AuthHandler) RefreshToken(c echo.Context) error {
 		})
 	}
 
-	response, err := h.authService.RefreshToken(req.RefreshToken)
+	response, err := h.authService.RefreshToken(req.RefreshToken, clientInfo(c))
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error:   "Token refresh failed",
@@ -391,3 +400,43 @@ func (h *AuthHandler) SetLargeTransactionThreshold(c echo.Context) error {
     }
     return c.JSON(http.StatusOK, SuccessResponse{Message: message})
 }
+
+// loginError writes a rejected sign-in, with CAPTCHA and retry hints when the
+// attempt was throttled
+func loginError(c echo.Context, err error) error {
+	if errors.Is(err, services.ErrLoginUnavailable) || errors.Is(err, services.ErrTwoFactorUnavailable) {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+	}
+	if errors.Is(err, services.ErrAccountLocked) {
+		return c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+	}
+
+	var loginErr *services.LoginError
+	if !errors.As(err, &loginErr) {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+	}
+
+	status := http.StatusUnauthorized
+	if errors.Is(err, services.ErrTooManyLoginAttempts) || errors.Is(err, services.ErrAccountTemporarilyLocked) {
+		status = http.StatusTooManyRequests
+	}
+	retryAfter := int(math.Ceil(loginErr.RetryAfter.Seconds()))
+	if retryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	return c.JSON(status, models.LoginFailureResponse{
+		Error:             "Login failed",
+		Message:           err.Error(),
+		CaptchaRequired:   loginErr.CaptchaRequired,
+		RetryAfterSeconds: retryAfter,
+	})
+}
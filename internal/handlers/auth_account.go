@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestDataExport godoc
+// @Summary Request a data export
+// @Description Start a background job that bundles all account data into a zip of JSON and CSV files. The user is emailed when it is ready.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} models.DataExport
+// @Failure 409 {object} ErrorResponse
+// @Router /auth/account/exports [post]
+func (h *AuthHandler) RequestDataExport(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	export, err := h.authService.RequestDataExport(userID)
+	if err != nil {
+		return accountError(c, "Failed to start data export", err)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"data": export,
+	})
+}
+
+// ListDataExports godoc
+// @Summary List data exports
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.DataExport
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/account/exports [get]
+func (h *AuthHandler) ListDataExports(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	exports, err := h.authService.ListDataExports(userID)
+	if err != nil {
+		return accountError(c, "Failed to list data exports", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": exports,
+	})
+}
+
+// DownloadDataExport godoc
+// @Summary Download a data export
+// @Tags auth
+// @Produce application/zip
+// @Security BearerAuth
+// @Param id path int true "Export ID"
+// @Success 200 {file} file
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /auth/account/exports/{id}/download [get]
+func (h *AuthHandler) DownloadDataExport(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	exportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid export ID",
+			Message: err.Error(),
+		})
+	}
+
+	export, err := h.authService.GetDataExportFile(userID, exportID)
+	if err != nil {
+		return accountError(c, "Failed to download data export", err)
+	}
+
+	name := fmt.Sprintf("tabimoney-export-%s.zip", export.CreatedAt.Format("2006-01-02"))
+	return c.Attachment(export.FilePath, name)
+}
+
+// GetAccountDeletion godoc
+// @Summary Get account deletion status
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.AccountDeletionResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/account/deletion [get]
+func (h *AuthHandler) GetAccountDeletion(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	status, err := h.authService.GetAccountDeletion(userID)
+	if err != nil {
+		return accountError(c, "Failed to get account deletion status", err)
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// ScheduleAccountDeletion godoc
+// @Summary Delete account
+// @Description Confirm with the account password. The account and all of its data are permanently deleted after the configured grace period, or at once when there is none.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.AccountDeletionRequest true "Account password"
+// @Success 200 {object} models.AccountDeletionResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/account/deletion [post]
+func (h *AuthHandler) ScheduleAccountDeletion(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.AccountDeletionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	status, err := h.authService.ScheduleAccountDeletion(userID, req.Password)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to delete account",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// CancelAccountDeletion godoc
+// @Summary Cancel account deletion
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /auth/account/deletion [delete]
+func (h *AuthHandler) CancelAccountDeletion(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	if err := h.authService.CancelAccountDeletion(userID); err != nil {
+		return accountError(c, "Failed to cancel account deletion", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Account deletion cancelled",
+	})
+}
+
+func accountError(c echo.Context, title string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrExportNotFound), errors.Is(err, services.ErrDeletionNotScheduled):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrExportInProgress), errors.Is(err, services.ErrExportNotReady):
+		status = http.StatusConflict
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   title,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.APIKeyResponse
+// @Router /auth/api-keys [get]
+func (h *AuthHandler) ListAPIKeys(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	keys, err := h.authService.ListAPIKeys(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to list API keys",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": keys,
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create a personal access token for scripts. The key is only returned once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.APIKeyCreateRequest true "Key name, scopes and optional expiry"
+// @Success 201 {object} models.APIKeyCreateResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/api-keys [post]
+func (h *AuthHandler) CreateAPIKey(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.APIKeyCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	key, err := h.authService.CreateAPIKey(userID, &req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to create API key",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /auth/api-keys/{id} [delete]
+func (h *AuthHandler) RevokeAPIKey(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid API key ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.authService.RevokeAPIKey(userID, keyID); err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Failed to revoke API key",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "API key revoked",
+	})
+}
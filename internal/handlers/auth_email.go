@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link. Always succeeds for unknown emails.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.PasswordResetRequest true "Account email"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	var req models.PasswordResetRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.authService.ForgotPassword(&req); err != nil {
+		return accountEmailError(c, "Password reset failed", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "If an account exists for this email, a reset link has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using a reset token. Signs out every session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.PasswordResetConfirmRequest true "Reset token and new password"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var req models.PasswordResetConfirmRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.authService.ResetPassword(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Password reset failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Password reset successfully",
+	})
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm the account email using the token from the verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	var req models.VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.authService.VerifyEmail(req.Token)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Email verification failed",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new email verification link to the current user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	if err := h.authService.SendVerificationEmail(userID); err != nil {
+		return accountEmailError(c, "Failed to send verification email", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Verification email sent",
+	})
+}
+
+func accountEmailError(c echo.Context, title string, err error) error {
+	status := http.StatusBadRequest
+	if errors.Is(err, services.ErrRateLimited) {
+		status = http.StatusTooManyRequests
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   title,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListOIDCProviders godoc
+// @Summary List social login providers
+// @Tags auth
+// @Produce json
+// @Success 200 {array} models.OIDCProviderResponse
+// @Router /auth/oidc/providers [get]
+func (h *AuthHandler) ListOIDCProviders(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": h.authService.OIDCProviders(),
+	})
+}
+
+// StartOIDCLogin godoc
+// @Summary Start social login
+// @Description Returns the provider authorization URL (authorization code flow with PKCE)
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} models.OIDCAuthorizeResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/authorize [get]
+func (h *AuthHandler) StartOIDCLogin(c echo.Context) error {
+	response, err := h.authService.StartOIDCLogin(c.Param("provider"))
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, services.ErrOIDCProviderNotFound) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, ErrorResponse{
+			Error:   "Failed to start login",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// OIDCCallback godoc
+// @Summary Complete social login
+// @Description Exchange the code and state the provider redirected back with.
+// @Description Returns tokens, an MFA challenge, or a link_required response.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param request body models.OIDCCallbackRequest true "Code and state"
+// @Success 200 {object} models.AuthResponse
+// @Success 200 {object} models.MFAChallengeResponse
+// @Success 200 {object} models.OIDCLinkRequiredResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /auth/oidc/{provider}/callback [post]
+func (h *AuthHandler) OIDCCallback(c echo.Context) error {
+	var req models.OIDCCallbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	result, err := h.authService.CompleteOIDCLogin(c.Param("provider"), &req, clientInfo(c))
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, services.ErrOIDCProviderNotFound) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, ErrorResponse{
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+	}
+
+	switch {
+	case result.MFAChallenge != nil:
+		return c.JSON(http.StatusOK, result.MFAChallenge)
+	case result.LinkRequired != nil:
+		return c.JSON(http.StatusOK, result.LinkRequired)
+	}
+	return c.JSON(http.StatusOK, result.Auth)
+}
+
+// LinkOIDCIdentity godoc
+// @Summary Link an external account
+// @Description Confirm a link_required response after signing in with the password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.OIDCLinkRequest true "Link token"
+// @Success 201 {object} models.UserIdentity
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /auth/oidc/link [post]
+func (h *AuthHandler) LinkOIDCIdentity(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.OIDCLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	identity, err := h.authService.LinkOIDCIdentity(userID, req.LinkToken)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrOIDCIdentityInUse) {
+			status = http.StatusConflict
+		}
+		return c.JSON(status, ErrorResponse{
+			Error:   "Failed to link account",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, identity)
+}
+
+// ListIdentities godoc
+// @Summary List linked external accounts
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.UserIdentity
+// @Router /auth/oidc/identities [get]
+func (h *AuthHandler) ListIdentities(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	identities, err := h.authService.ListIdentities(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to list linked accounts",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": identities,
+	})
+}
+
+// UnlinkIdentity godoc
+// @Summary Unlink an external account
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Identity ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /auth/oidc/identities/{id} [delete]
+func (h *AuthHandler) UnlinkIdentity(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	identityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid identity ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.authService.UnlinkIdentity(userID, identityID); err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Failed to unlink account",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Account unlinked",
+	})
+}
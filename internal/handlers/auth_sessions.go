@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListSessions godoc
+// @Summary List active sessions
+// @Description List the devices currently signed in to the account
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.SessionResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	token := c.Get("token").(string)
+
+	sessions, err := h.authService.ListSessions(userID, token)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to list sessions",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": sessions,
+	})
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Sign out a single device
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Session ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid session ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.authService.RevokeSession(userID, sessionID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrSessionNotFound) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, ErrorResponse{
+			Error:   "Failed to revoke session",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Session revoked",
+	})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revoke all other sessions
+// @Description Sign out every device except the current one
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/sessions/revoke-others [post]
+func (h *AuthHandler) RevokeOtherSessions(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	token := c.Get("token").(string)
+
+	revoked, err := h.authService.RevokeOtherSessions(userID, token)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Failed to revoke sessions",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Other sessions revoked",
+		"revoked": revoked,
+	})
+}
+
+// IssueStreamTicket godoc
+// @Summary Issue a notification stream ticket
+// @Description Issue a single-use ticket, valid for 30 seconds, for opening the notification stream with ?ticket=
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.StreamTicketResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /auth/stream-ticket [post]
+func (h *AuthHandler) IssueStreamTicket(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	ticket, err := h.authService.IssueStreamTicket(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to issue stream ticket",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, ticket)
+}
+
+// clientInfo describes the device making the request, stored on new sessions
+func clientInfo(c echo.Context) models.ClientInfo {
+	return models.ClientInfo{
+		UserAgent: c.Request().UserAgent(),
+		IPAddress: c.RealIP(),
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type BillHandler struct {
+	billService *services.BillService
+	validator   *validator.Validate
+}
+
+func NewBillHandler(cfg *config.Config) *BillHandler {
+	return &BillHandler{
+		billService: services.NewBillService(cfg),
+		validator:   validator.New(),
+	}
+}
+
+// ListBills lists the ledger's bills and subscriptions
+func (h *BillHandler) ListBills(c echo.Context) error {
+	bills, err := h.billService.ListBills(ledgerFrom(c))
+	if err != nil {
+		return billError(c, "Failed to get bills", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": bills,
+	})
+}
+
+// CreateBill adds a bill or subscription
+func (h *BillHandler) CreateBill(c echo.Context) error {
+	var req models.BillRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	bill, err := h.billService.CreateBill(ledgerFrom(c), &req)
+	if err != nil {
+		return billError(c, "Failed to create bill", err)
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": bill,
+	})
+}
+
+// UpdateBill updates a bill
+func (h *BillHandler) UpdateBill(c echo.Context) error {
+	billID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid bill ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.BillRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	bill, err := h.billService.UpdateBill(ledgerFrom(c), billID, &req)
+	if err != nil {
+		return billError(c, "Failed to update bill", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": bill,
+	})
+}
+
+// DeleteBill deletes a bill
+func (h *BillHandler) DeleteBill(c echo.Context) error {
+	billID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid bill ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.billService.DeleteBill(ledgerFrom(c), billID); err != nil {
+		return billError(c, "Failed to delete bill", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Bill deleted successfully",
+	})
+}
+
+// MarkBillPaid marks the bill's current due date paid
+func (h *BillHandler) MarkBillPaid(c echo.Context) error {
+	billID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid bill ID",
+			Message: err.Error(),
+		})
+	}
+
+	bill, err := h.billService.MarkBillPaid(ledgerFrom(c), billID)
+	if err != nil {
+		return billError(c, "Failed to mark bill paid", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": bill,
+	})
+}
+
+// DetectSubscriptions lists periodic charges that look like subscriptions
+// and are not tracked yet
+func (h *BillHandler) DetectSubscriptions(c echo.Context) error {
+	detected, err := h.billService.DetectSubscriptions(ledgerFrom(c))
+	if err != nil {
+		return billError(c, "Failed to detect subscriptions", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": detected,
+	})
+}
+
+// ConfirmSubscription starts tracking a detected subscription as a bill
+func (h *BillHandler) ConfirmSubscription(c echo.Context) error {
+	var req models.BillConfirmRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	bill, err := h.billService.ConfirmSubscription(ledgerFrom(c), &req)
+	if err != nil {
+		return billError(c, "Failed to confirm subscription", err)
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": bill,
+	})
+}
+
+// DismissSubscription stops offering a detected subscription
+func (h *BillHandler) DismissSubscription(c echo.Context) error {
+	var req models.BillDismissRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.billService.DismissSubscription(ledgerFrom(c), &req); err != nil {
+		return billError(c, "Failed to dismiss subscription", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Subscription dismissed",
+	})
+}
+
+func (h *BillHandler) bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return h.validator.Struct(req)
+}
+
+// billError maps bill service errors to HTTP status codes
+func billError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrBillNotFound), errors.Is(err, services.ErrDetectedSubscriptionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrBillCategoryNotFound):
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
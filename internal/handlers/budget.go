@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,24 +9,27 @@ import (
 	"tabimoney/internal/models"
 	"tabimoney/internal/services"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
 
 type BudgetHandler struct {
 	budgetService *services.BudgetService
+	validator     *validator.Validate
 }
 
 func NewBudgetHandler(cfg *config.Config) *BudgetHandler {
 	return &BudgetHandler{
 		budgetService: services.NewBudgetService(cfg),
+		validator:     validator.New(),
 	}
 }
 
 // GetBudgets retrieves user's budgets
 func (h *BudgetHandler) GetBudgets(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 
-	budgets, err := h.budgetService.GetBudgets(userID)
+	budgets, err := h.budgetService.GetBudgets(ledger)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to get budgets",
@@ -40,7 +44,7 @@ func (h *BudgetHandler) GetBudgets(c echo.Context) error {
 
 // CreateBudget creates a new budget
 func (h *BudgetHandler) CreateBudget(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 
 	var req models.BudgetCreateRequest
 	if err := c.Bind(&req); err != nil {
@@ -50,12 +54,9 @@ func (h *BudgetHandler) CreateBudget(c echo.Context) error {
 		})
 	}
 
-	budget, err := h.budgetService.CreateBudget(userID, &req)
+	budget, err := h.budgetService.CreateBudget(ledger, &req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to create budget",
-			Message: err.Error(),
-		})
+		return budgetError(c, "Failed to create budget", err)
 	}
 
 	return c.JSON(http.StatusCreated, map[string]interface{}{
@@ -65,7 +66,7 @@ func (h *BudgetHandler) CreateBudget(c echo.Context) error {
 
 // UpdateBudget updates an existing budget
 func (h *BudgetHandler) UpdateBudget(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 	budgetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -82,12 +83,9 @@ func (h *BudgetHandler) UpdateBudget(c echo.Context) error {
 		})
 	}
 
-	budget, err := h.budgetService.UpdateBudget(userID, budgetID, &req)
+	budget, err := h.budgetService.UpdateBudget(ledger, budgetID, &req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update budget",
-			Message: err.Error(),
-		})
+		return budgetError(c, "Failed to update budget", err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -97,7 +95,7 @@ func (h *BudgetHandler) UpdateBudget(c echo.Context) error {
 
 // DeleteBudget deletes a budget
 func (h *BudgetHandler) DeleteBudget(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 	budgetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -106,7 +104,7 @@ func (h *BudgetHandler) DeleteBudget(c echo.Context) error {
 		})
 	}
 
-	if err := h.budgetService.DeleteBudget(userID, budgetID); err != nil {
+	if err := h.budgetService.DeleteBudget(ledger, budgetID); err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to delete budget",
 			Message: err.Error(),
@@ -118,11 +116,39 @@ func (h *BudgetHandler) DeleteBudget(c echo.Context) error {
 	})
 }
 
+// GetBudgetHistory lists the closed periods of an auto-renewing budget
+func (h *BudgetHandler) GetBudgetHistory(c echo.Context) error {
+	ledger := ledgerFrom(c)
+	budgetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid budget ID",
+			Message: "Budget ID must be a valid number",
+		})
+	}
+
+	periods, err := h.budgetService.GetBudgetHistory(ledger, budgetID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrBudgetNotFound) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, ErrorResponse{
+			Error:   "Failed to get budget history",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": periods,
+	})
+}
+
 // GetBudgetInsights returns safe-to-spend and pacing info
 func (h *BudgetHandler) GetBudgetInsights(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
 
-    insights, err := h.budgetService.GetBudgetInsights(userID)
+    insights, err := h.budgetService.GetBudgetInsights(ledger)
     if err != nil {
         return c.JSON(http.StatusInternalServerError, ErrorResponse{
             Error:   "Failed to get budget insights",
@@ -136,8 +162,11 @@ func (h *BudgetHandler) GetBudgetInsights(c echo.Context) error {
 
 // GetAutoBudgetSuggestions suggests budgets for current period
 func (h *BudgetHandler) GetAutoBudgetSuggestions(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
-    resp, err := h.budgetService.SuggestBudgets(userID)
+    ledger := ledgerFrom(c)
+    // Optional overrides of the configured history window and percentile
+    months, _ := strconv.Atoi(c.QueryParam("months"))
+    percentile, _ := strconv.Atoi(c.QueryParam("percentile"))
+    resp, err := h.budgetService.SuggestBudgets(ledger, months, percentile)
     if err != nil {
         return c.JSON(http.StatusInternalServerError, ErrorResponse{
             Error:   "Failed to suggest budgets",
@@ -151,7 +180,7 @@ func (h *BudgetHandler) GetAutoBudgetSuggestions(c echo.Context) error {
 
 // CreateBudgetsFromSuggestions bulk creates budgets from suggestions
 func (h *BudgetHandler) CreateBudgetsFromSuggestions(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
     var req models.AutoBudgetCreateRequest
     if err := c.Bind(&req); err != nil {
         return c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -162,7 +191,7 @@ func (h *BudgetHandler) CreateBudgetsFromSuggestions(c echo.Context) error {
     if req.AlertThreshold == 0 {
         req.AlertThreshold = 80
     }
-    created, err := h.budgetService.CreateBudgetsFromSuggestions(userID, &req)
+    created, err := h.budgetService.CreateBudgetsFromSuggestions(ledger, &req)
     if err != nil {
         return c.JSON(http.StatusInternalServerError, ErrorResponse{
             Error:   "Failed to create budgets",
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "strconv"
 
@@ -14,11 +15,11 @@ type CategoryHandler struct{}
 
 func NewCategoryHandler() *CategoryHandler { return &CategoryHandler{} }
 
-// List categories: returns system categories and user's categories
+// List categories: returns system categories and the ledger's categories
 func (h *CategoryHandler) List(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
     var categories []models.Category
-    if err := services.DB().Where("is_system = ? OR user_id = ?", true, userID).Order("sort_order ASC, name ASC").Find(&categories).Error; err != nil {
+    if err := services.DB().Scopes(ledger.CategoryScope()).Order("sort_order ASC, name ASC").Find(&categories).Error; err != nil {
         return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to load categories", Message: err.Error()})
     }
 
@@ -29,6 +30,7 @@ func (h *CategoryHandler) List(c echo.Context) error {
         responses = append(responses, models.CategoryResponse{
             ID: cModel.ID,
             UserID: cModel.UserID,
+            HouseholdID: cModel.HouseholdID,
             Name: cModel.Name,
             NameEn: cModel.NameEn,
             Description: cModel.Description,
@@ -43,9 +45,9 @@ func (h *CategoryHandler) List(c echo.Context) error {
     return c.JSON(http.StatusOK, responses)
 }
 
-// Create category: user-defined only
+// Create category: user-defined only, owned by the ledger
 func (h *CategoryHandler) Create(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
     var req struct {
         Name        string  `json:"name"`
         Description string  `json:"description"`
@@ -58,8 +60,12 @@ func (h *CategoryHandler) Create(c echo.Context) error {
     if req.Name == "" {
         return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Validation failed", Message: "name is required"})
     }
+    if err := services.ValidateCategoryParent(services.DB(), ledger.CategoryScope(), 0, req.ParentID); err != nil {
+        return categoryParentError(c, err)
+    }
     cat := &models.Category{
-        UserID:     &userID,
+        UserID:     &ledger.UserID,
+        HouseholdID: ledger.HouseholdID,
         Name:       req.Name,
         Description:req.Description,
         NameEn:req.NameEn,
@@ -71,7 +77,7 @@ func (h *CategoryHandler) Create(c echo.Context) error {
         return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Create failed", Message: err.Error()})
     }
     resp := models.CategoryResponse{
-        ID: cat.ID, UserID: cat.UserID, Name: cat.Name, NameEn: cat.NameEn, Description: cat.Description,
+        ID: cat.ID, UserID: cat.UserID, HouseholdID: cat.HouseholdID, Name: cat.Name, NameEn: cat.NameEn, Description: cat.Description,
         ParentID: cat.ParentID, IsSystem: cat.IsSystem, IsActive: cat.IsActive,
         SortOrder: cat.SortOrder, CreatedAt: cat.CreatedAt, UpdatedAt: cat.UpdatedAt,
     }
@@ -80,7 +86,7 @@ func (h *CategoryHandler) Create(c echo.Context) error {
 
 // Update category
 func (h *CategoryHandler) Update(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
     idParam := c.Param("id")
     id, err := strconv.ParseUint(idParam, 10, 64)
     if err != nil {
@@ -101,10 +107,14 @@ func (h *CategoryHandler) Update(c echo.Context) error {
     }
 
     var cat models.Category
-    if err := services.DB().Where("id = ? AND user_id = ? AND is_system = ?", id, userID, false).First(&cat).Error; err != nil {
+    if err := services.DB().Scopes(ledger.Scope("")).Where("id = ? AND is_system = ?", id, false).First(&cat).Error; err != nil {
         return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found", Message: "Category not found or cannot be updated"})
     }
 
+    if err := services.ValidateCategoryParent(services.DB(), ledger.CategoryScope(), cat.ID, req.ParentID); err != nil {
+        return categoryParentError(c, err)
+    }
+
     cat.Name = req.Name
     cat.Description = req.Description
     cat.ParentID = req.ParentID
@@ -115,7 +125,7 @@ func (h *CategoryHandler) Update(c echo.Context) error {
     }
 
     resp := models.CategoryResponse{
-        ID: cat.ID, UserID: cat.UserID, Name: cat.Name, NameEn: cat.NameEn, Description: cat.Description,
+        ID: cat.ID, UserID: cat.UserID, HouseholdID: cat.HouseholdID, Name: cat.Name, NameEn: cat.NameEn, Description: cat.Description,
         ParentID: cat.ParentID, IsSystem: cat.IsSystem, IsActive: cat.IsActive,
         SortOrder: cat.SortOrder, CreatedAt: cat.CreatedAt, UpdatedAt: cat.UpdatedAt,
     }
@@ -124,7 +134,7 @@ func (h *CategoryHandler) Update(c echo.Context) error {
 
 // Delete category
 func (h *CategoryHandler) Delete(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
     idParam := c.Param("id")
     id, err := strconv.ParseUint(idParam, 10, 64)
     if err != nil {
@@ -132,7 +142,7 @@ func (h *CategoryHandler) Delete(c echo.Context) error {
     }
 
     var cat models.Category
-    if err := services.DB().Where("id = ? AND user_id = ? AND is_system = ?", id, userID, false).First(&cat).Error; err != nil {
+    if err := services.DB().Scopes(ledger.Scope("")).Where("id = ? AND is_system = ?", id, false).First(&cat).Error; err != nil {
         return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found", Message: "Category not found or cannot be deleted"})
     }
 
@@ -144,3 +154,14 @@ func (h *CategoryHandler) Delete(c echo.Context) error {
 }
 
 
+
+// categoryParentError maps a rejected parent category to a response
+func categoryParentError(c echo.Context, err error) error {
+    switch {
+    case errors.Is(err, services.ErrParentCategoryNotFound),
+        errors.Is(err, services.ErrCategoryCycle),
+        errors.Is(err, services.ErrCategoryTooDeep):
+        return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid parent category", Message: err.Error()})
+    }
+    return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to validate parent category", Message: err.Error()})
+}
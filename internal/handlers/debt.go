@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type DebtHandler struct {
+	debtService *services.DebtService
+	validator   *validator.Validate
+}
+
+func NewDebtHandler(cfg *config.Config) *DebtHandler {
+	return &DebtHandler{
+		debtService: services.NewDebtService(cfg),
+		validator:   validator.New(),
+	}
+}
+
+// ListDebts lists the ledger's loans and credit card debts
+func (h *DebtHandler) ListDebts(c echo.Context) error {
+	debts, err := h.debtService.ListDebts(ledgerFrom(c))
+	if err != nil {
+		return debtError(c, "Failed to get debts", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": debts,
+	})
+}
+
+// GetDebt returns one debt
+func (h *DebtHandler) GetDebt(c echo.Context) error {
+	debtID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid debt ID",
+			Message: err.Error(),
+		})
+	}
+
+	debt, err := h.debtService.GetDebt(ledgerFrom(c), debtID)
+	if err != nil {
+		return debtError(c, "Failed to get debt", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": debt,
+	})
+}
+
+// CreateDebt adds a loan or credit card debt
+func (h *DebtHandler) CreateDebt(c echo.Context) error {
+	var req models.DebtRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	debt, err := h.debtService.CreateDebt(ledgerFrom(c), &req)
+	if err != nil {
+		return debtError(c, "Failed to create debt", err)
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": debt,
+	})
+}
+
+// UpdateDebt updates a debt's terms or balance
+func (h *DebtHandler) UpdateDebt(c echo.Context) error {
+	debtID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid debt ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.DebtRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	debt, err := h.debtService.UpdateDebt(ledgerFrom(c), debtID, &req)
+	if err != nil {
+		return debtError(c, "Failed to update debt", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": debt,
+	})
+}
+
+// DeleteDebt deletes a debt and its payments
+func (h *DebtHandler) DeleteDebt(c echo.Context) error {
+	debtID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid debt ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.debtService.DeleteDebt(ledgerFrom(c), debtID); err != nil {
+		return debtError(c, "Failed to delete debt", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Debt deleted successfully",
+	})
+}
+
+// ListPayments lists a debt's payments
+func (h *DebtHandler) ListPayments(c echo.Context) error {
+	debtID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid debt ID",
+			Message: err.Error(),
+		})
+	}
+
+	payments, err := h.debtService.ListPayments(ledgerFrom(c), debtID)
+	if err != nil {
+		return debtError(c, "Failed to get debt payments", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": payments,
+	})
+}
+
+// AddPayment records a repayment
+func (h *DebtHandler) AddPayment(c echo.Context) error {
+	debtID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid debt ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.DebtPaymentRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	debt, err := h.debtService.AddPayment(ledgerFrom(c), debtID, &req)
+	if err != nil {
+		return debtError(c, "Failed to add debt payment", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": debt,
+	})
+}
+
+// DeletePayment removes a manual repayment
+func (h *DebtHandler) DeletePayment(c echo.Context) error {
+	debtID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid debt ID",
+			Message: err.Error(),
+		})
+	}
+	paymentID, err := uintParam(c, "paymentId")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid payment ID",
+			Message: err.Error(),
+		})
+	}
+
+	debt, err := h.debtService.DeletePayment(ledgerFrom(c), debtID, paymentID)
+	if err != nil {
+		return debtError(c, "Failed to delete debt payment", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": debt,
+	})
+}
+
+// GetAmortizationSchedule lays out a debt's payments; ?payment= overrides
+// the minimum monthly payment
+func (h *DebtHandler) GetAmortizationSchedule(c echo.Context) error {
+	debtID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid debt ID",
+			Message: err.Error(),
+		})
+	}
+	payment, err := amountQuery(c, "payment")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid payment",
+			Message: err.Error(),
+		})
+	}
+
+	schedule, err := h.debtService.GetAmortizationSchedule(ledgerFrom(c), debtID, payment)
+	if err != nil {
+		return debtError(c, "Failed to get amortization schedule", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": schedule,
+	})
+}
+
+// ComparePayoffStrategies compares avalanche and snowball payoff plans;
+// ?budget= sets the total monthly payment, the sum of minimums by default
+func (h *DebtHandler) ComparePayoffStrategies(c echo.Context) error {
+	budget, err := amountQuery(c, "budget")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid budget",
+			Message: err.Error(),
+		})
+	}
+
+	comparison, err := h.debtService.ComparePayoffStrategies(ledgerFrom(c), budget)
+	if err != nil {
+		return debtError(c, "Failed to compare payoff strategies", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": comparison,
+	})
+}
+
+func (h *DebtHandler) bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return h.validator.Struct(req)
+}
+
+// amountQuery parses an optional non-negative amount query parameter
+func amountQuery(c echo.Context, name string) (float64, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(raw, 64)
+	if err != nil || amount < 0 {
+		return 0, errors.New(name + " must be a non-negative number")
+	}
+	return amount, nil
+}
+
+// debtError maps debt service errors to HTTP status codes
+func debtError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrDebtNotFound), errors.Is(err, services.ErrDebtPaymentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrDebtPaidOff), errors.Is(err, services.ErrDebtCategoryNotFound),
+		errors.Is(err, services.ErrAutomaticDebtPayment), errors.Is(err, services.ErrDebtPaymentTooLow),
+		errors.Is(err, services.ErrPayoffBudgetTooLow), errors.Is(err, services.ErrNoDebtsToPay),
+		errors.Is(err, services.ErrInvalidDebtPaymentDate), errors.Is(err, services.ErrLinkedTransactionNotFound),
+		errors.Is(err, services.ErrDebtPaymentTooHigh):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrDebtCategoryLinked), errors.Is(err, services.ErrTransactionAlreadyLinked):
+		status = http.StatusConflict
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
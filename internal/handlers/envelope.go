@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetEnvelopeMonth returns the envelope assignment screen for ?month=YYYY-MM
+func (h *BudgetHandler) GetEnvelopeMonth(c echo.Context) error {
+	ledger := ledgerFrom(c)
+
+	state, err := h.budgetService.GetEnvelopeMonth(ledger, c.QueryParam("month"))
+	if err != nil {
+		return budgetError(c, "Failed to get envelopes", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": state,
+	})
+}
+
+// ListEnvelopeAllocations lists the envelope allocation ledger for ?month=YYYY-MM
+func (h *BudgetHandler) ListEnvelopeAllocations(c echo.Context) error {
+	ledger := ledgerFrom(c)
+
+	allocations, err := h.budgetService.ListEnvelopeAllocations(ledger, c.QueryParam("month"))
+	if err != nil {
+		return budgetError(c, "Failed to list envelope allocations", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": allocations,
+	})
+}
+
+// AssignToEnvelope assigns money from "to be assigned" to an envelope
+func (h *BudgetHandler) AssignToEnvelope(c echo.Context) error {
+	ledger := ledgerFrom(c)
+
+	var req models.EnvelopeAssignRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	state, err := h.budgetService.AssignToEnvelope(ledger, &req)
+	if err != nil {
+		return budgetError(c, "Failed to assign money", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": state,
+	})
+}
+
+// MoveBetweenEnvelopes moves money from one envelope to another
+func (h *BudgetHandler) MoveBetweenEnvelopes(c echo.Context) error {
+	ledger := ledgerFrom(c)
+
+	var req models.EnvelopeMoveRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	state, err := h.budgetService.MoveBetweenEnvelopes(ledger, &req)
+	if err != nil {
+		return budgetError(c, "Failed to move money", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": state,
+	})
+}
+
+// CoverOverspending covers an overspent envelope
+func (h *BudgetHandler) CoverOverspending(c echo.Context) error {
+	ledger := ledgerFrom(c)
+
+	var req models.EnvelopeCoverRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	state, err := h.budgetService.CoverOverspending(ledger, &req)
+	if err != nil {
+		return budgetError(c, "Failed to cover overspending", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": state,
+	})
+}
+
+// SimulateBudgets runs a what-if simulation without saving anything
+func (h *BudgetHandler) SimulateBudgets(c echo.Context) error {
+	ledger := ledgerFrom(c)
+
+	var req models.BudgetSimulationRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	result, err := h.budgetService.SimulateBudgets(ledger, &req)
+	if err != nil {
+		return budgetError(c, "Failed to simulate budgets", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": result,
+	})
+}
+
+// bind decodes and validates a request body
+func (h *BudgetHandler) bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return h.validator.Struct(req)
+}
+
+// budgetError maps budget and envelope service errors to HTTP status codes
+func budgetError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrBudgetNotFound), errors.Is(err, services.ErrEnvelopeNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrEnvelopeCategoryRequired), errors.Is(err, services.ErrInvalidEnvelopeMonth),
+		errors.Is(err, services.ErrNotEnoughToAssign), errors.Is(err, services.ErrEnvelopeInsufficientFunds),
+		errors.Is(err, services.ErrCoverOverspentFirst), errors.Is(err, services.ErrEnvelopeNotOverspent),
+		errors.Is(err, services.ErrCoverExceedsOverspend), errors.Is(err, services.ErrSimulationCategoryNotFound),
+		errors.Is(err, services.ErrSimulationGoalNotFound):
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,24 +9,27 @@ import (
 	"tabimoney/internal/models"
 	"tabimoney/internal/services"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
 
 type GoalHandler struct {
 	goalService *services.GoalService
+	validator   *validator.Validate
 }
 
 func NewGoalHandler(cfg *config.Config) *GoalHandler {
 	return &GoalHandler{
 		goalService: services.NewGoalService(cfg),
+		validator:   validator.New(),
 	}
 }
 
 // GetGoals retrieves user's financial goals
 func (h *GoalHandler) GetGoals(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 
-	goals, err := h.goalService.GetGoals(userID)
+	goals, err := h.goalService.GetGoals(ledger)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to get goals",
@@ -40,7 +44,7 @@ func (h *GoalHandler) GetGoals(c echo.Context) error {
 
 // CreateGoal creates a new financial goal
 func (h *GoalHandler) CreateGoal(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 
 	var req models.FinancialGoalCreateRequest
 	if err := c.Bind(&req); err != nil {
@@ -50,12 +54,9 @@ func (h *GoalHandler) CreateGoal(c echo.Context) error {
 		})
 	}
 
-	goal, err := h.goalService.CreateGoal(userID, &req)
+	goal, err := h.goalService.CreateGoal(ledger, &req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to create goal",
-			Message: err.Error(),
-		})
+		return goalError(c, "Failed to create goal", err)
 	}
 
 	return c.JSON(http.StatusCreated, map[string]interface{}{
@@ -65,7 +66,7 @@ func (h *GoalHandler) CreateGoal(c echo.Context) error {
 
 // UpdateGoal updates an existing goal
 func (h *GoalHandler) UpdateGoal(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -82,12 +83,9 @@ func (h *GoalHandler) UpdateGoal(c echo.Context) error {
 		})
 	}
 
-	goal, err := h.goalService.UpdateGoal(userID, goalID, &req)
+	goal, err := h.goalService.UpdateGoal(ledger, goalID, &req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update goal",
-			Message: err.Error(),
-		})
+		return goalError(c, "Failed to update goal", err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -97,7 +95,7 @@ func (h *GoalHandler) UpdateGoal(c echo.Context) error {
 
 // DeleteGoal deletes a goal
 func (h *GoalHandler) DeleteGoal(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -106,11 +104,8 @@ func (h *GoalHandler) DeleteGoal(c echo.Context) error {
 		})
 	}
 
-	if err := h.goalService.DeleteGoal(userID, goalID); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to delete goal",
-			Message: err.Error(),
-		})
+	if err := h.goalService.DeleteGoal(ledger, goalID); err != nil {
+		return goalError(c, "Failed to delete goal", err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -118,9 +113,9 @@ func (h *GoalHandler) DeleteGoal(c echo.Context) error {
 	})
 }
 
-// AddContribution adds money to a goal
+// AddContribution records a contribution to a goal
 func (h *GoalHandler) AddContribution(c echo.Context) error {
-	userID := c.Get("user_id").(uint64)
+	ledger := ledgerFrom(c)
 	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -129,26 +124,221 @@ func (h *GoalHandler) AddContribution(c echo.Context) error {
 		})
 	}
 
-	var req struct {
-		Amount float64 `json:"amount"`
-		Note   string  `json:"note"`
+	var req models.GoalContributionRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
 	}
-	if err := c.Bind(&req); err != nil {
+
+	goal, err := h.goalService.AddContribution(ledger, goalID, &req)
+	if err != nil {
+		return goalError(c, "Failed to add contribution", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": goal,
+	})
+}
+
+// ListContributions lists a goal's contributions
+func (h *GoalHandler) ListContributions(c echo.Context) error {
+	ledger := ledgerFrom(c)
+	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid goal ID",
+			Message: "Goal ID must be a valid number",
+		})
+	}
+
+	contributions, err := h.goalService.ListContributions(ledger, goalID)
+	if err != nil {
+		return goalError(c, "Failed to get contributions", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": contributions,
+	})
+}
+
+// UpdateContribution edits a manual contribution
+func (h *GoalHandler) UpdateContribution(c echo.Context) error {
+	ledger := ledgerFrom(c)
+	goalID, contributionID, err := contributionParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.GoalContributionRequest
+	if err := h.bind(c, &req); err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "Invalid request",
 			Message: err.Error(),
 		})
 	}
 
-	goal, err := h.goalService.AddContribution(userID, goalID, req.Amount, req.Note)
+	goal, err := h.goalService.UpdateContribution(ledger, goalID, contributionID, &req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to add contribution",
+		return goalError(c, "Failed to update contribution", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": goal,
+	})
+}
+
+// DeleteContribution removes a manual contribution
+func (h *GoalHandler) DeleteContribution(c echo.Context) error {
+	ledger := ledgerFrom(c)
+	goalID, contributionID, err := contributionParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
 			Message: err.Error(),
 		})
 	}
 
+	goal, err := h.goalService.DeleteContribution(ledger, goalID, contributionID)
+	if err != nil {
+		return goalError(c, "Failed to delete contribution", err)
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"data": goal,
 	})
 }
+
+// GetSavingsPlan returns a goal's savings plan and where it takes the goal
+func (h *GoalHandler) GetSavingsPlan(c echo.Context) error {
+	ledger := ledgerFrom(c)
+	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid goal ID",
+			Message: "Goal ID must be a valid number",
+		})
+	}
+
+	plan, err := h.goalService.GetSavingsPlan(ledger, goalID)
+	if err != nil {
+		return goalError(c, "Failed to get savings plan", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": plan,
+	})
+}
+
+// SaveSavingsPlan creates or replaces a goal's savings plan
+func (h *GoalHandler) SaveSavingsPlan(c echo.Context) error {
+	ledger := ledgerFrom(c)
+	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid goal ID",
+			Message: "Goal ID must be a valid number",
+		})
+	}
+
+	var req models.GoalSavingsPlanRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	plan, err := h.goalService.SaveSavingsPlan(ledger, goalID, &req)
+	if err != nil {
+		return goalError(c, "Failed to save savings plan", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": plan,
+	})
+}
+
+// DeleteSavingsPlan removes a goal's savings plan
+func (h *GoalHandler) DeleteSavingsPlan(c echo.Context) error {
+	ledger := ledgerFrom(c)
+	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid goal ID",
+			Message: "Goal ID must be a valid number",
+		})
+	}
+
+	if err := h.goalService.DeleteSavingsPlan(ledger, goalID); err != nil {
+		return goalError(c, "Failed to delete savings plan", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Savings plan deleted successfully",
+	})
+}
+
+// ListSavingsPlanRuns lists the completed, skipped and failed runs of a goal's savings plan
+func (h *GoalHandler) ListSavingsPlanRuns(c echo.Context) error {
+	ledger := ledgerFrom(c)
+	goalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid goal ID",
+			Message: "Goal ID must be a valid number",
+		})
+	}
+
+	runs, err := h.goalService.ListSavingsPlanRuns(ledger, goalID)
+	if err != nil {
+		return goalError(c, "Failed to get savings plan runs", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": runs,
+	})
+}
+
+func contributionParams(c echo.Context) (uint64, uint64, error) {
+	goalID, err := uintParam(c, "id")
+	if err != nil {
+		return 0, 0, err
+	}
+	contributionID, err := uintParam(c, "contributionId")
+	if err != nil {
+		return 0, 0, err
+	}
+	return goalID, contributionID, nil
+}
+
+func (h *GoalHandler) bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return h.validator.Struct(req)
+}
+
+// goalError maps goal service errors to HTTP status codes
+func goalError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrGoalNotFound), errors.Is(err, services.ErrGoalContributionNotFound),
+		errors.Is(err, services.ErrSavingsPlanNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrGoalAchieved), errors.Is(err, services.ErrInvalidContribution),
+		errors.Is(err, services.ErrAutomaticContribution), errors.Is(err, services.ErrLinkedTransactionNotFound),
+		errors.Is(err, services.ErrGoalCategoryNotFound), errors.Is(err, services.ErrSavingsPlanCategoryNotFound):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrTransactionAlreadyLinked), errors.Is(err, services.ErrGoalCategoryLinked):
+		status = http.StatusConflict
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
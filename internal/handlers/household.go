@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type HouseholdHandler struct {
+	householdService *services.HouseholdService
+	validator        *validator.Validate
+}
+
+func NewHouseholdHandler(householdService *services.HouseholdService) *HouseholdHandler {
+	return &HouseholdHandler{
+		householdService: householdService,
+		validator:        validator.New(),
+	}
+}
+
+// ledgerFrom returns the ledger chosen by LedgerMiddleware, falling back to
+// the caller's personal ledger
+func ledgerFrom(c echo.Context) services.Ledger {
+	if ledger, ok := c.Get("ledger").(services.Ledger); ok {
+		return ledger
+	}
+	return services.PersonalLedger(c.Get("user_id").(uint64))
+}
+
+// ListHouseholds lists the households the user belongs to
+func (h *HouseholdHandler) ListHouseholds(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	households, err := h.householdService.ListHouseholds(userID)
+	if err != nil {
+		return householdError(c, "Failed to list households", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": households,
+	})
+}
+
+// CreateHousehold creates a household owned by the user
+func (h *HouseholdHandler) CreateHousehold(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.HouseholdCreateRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	household, err := h.householdService.CreateHousehold(userID, &req)
+	if err != nil {
+		return householdError(c, "Failed to create household", err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": household,
+	})
+}
+
+// GetHousehold retrieves a household and its members
+func (h *HouseholdHandler) GetHousehold(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	householdID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	household, err := h.householdService.GetHousehold(userID, householdID)
+	if err != nil {
+		return householdError(c, "Failed to get household", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": household,
+	})
+}
+
+// UpdateHousehold renames a household
+func (h *HouseholdHandler) UpdateHousehold(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	householdID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.HouseholdUpdateRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	household, err := h.householdService.UpdateHousehold(userID, householdID, &req)
+	if err != nil {
+		return householdError(c, "Failed to update household", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": household,
+	})
+}
+
+// DeleteHousehold deletes a household and its ledger
+func (h *HouseholdHandler) DeleteHousehold(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	householdID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.householdService.DeleteHousehold(userID, householdID); err != nil {
+		return householdError(c, "Failed to delete household", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Household deleted successfully",
+	})
+}
+
+// InviteMember invites someone to the household by email
+func (h *HouseholdHandler) InviteMember(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	householdID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.HouseholdInviteRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	invitation, err := h.householdService.InviteMember(userID, householdID, &req)
+	if err != nil {
+		return householdError(c, "Failed to invite member", err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": invitation,
+	})
+}
+
+// ListInvitations lists the household's pending invitations
+func (h *HouseholdHandler) ListInvitations(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	householdID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	invitations, err := h.householdService.ListInvitations(userID, householdID)
+	if err != nil {
+		return householdError(c, "Failed to list invitations", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": invitations,
+	})
+}
+
+// RevokeInvitation cancels a pending invitation
+func (h *HouseholdHandler) RevokeInvitation(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	householdID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+	invitationID, err := uintParam(c, "invitationId")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.householdService.RevokeInvitation(userID, householdID, invitationID); err != nil {
+		return householdError(c, "Failed to revoke invitation", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Invitation revoked",
+	})
+}
+
+// AcceptInvitation joins the household an invitation was sent for
+func (h *HouseholdHandler) AcceptInvitation(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.HouseholdAcceptRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	household, err := h.householdService.AcceptInvitation(userID, req.Token)
+	if err != nil {
+		return householdError(c, "Failed to accept invitation", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": household,
+	})
+}
+
+// UpdateMemberRole changes a member's role or transfers ownership
+func (h *HouseholdHandler) UpdateMemberRole(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	householdID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+	memberID, err := uintParam(c, "userId")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.HouseholdMemberRoleRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	household, err := h.householdService.UpdateMemberRole(userID, householdID, memberID, &req)
+	if err != nil {
+		return householdError(c, "Failed to update member role", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": household,
+	})
+}
+
+// RemoveMember removes a member, or lets a member leave the household
+func (h *HouseholdHandler) RemoveMember(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	householdID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+	memberID, err := uintParam(c, "userId")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.householdService.RemoveMember(userID, householdID, memberID); err != nil {
+		return householdError(c, "Failed to remove member", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Member removed",
+	})
+}
+
+// bind decodes and validates a request body
+func (h *HouseholdHandler) bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return h.validator.Struct(req)
+}
+
+func uintParam(c echo.Context, name string) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a valid number", name)
+	}
+	return id, nil
+}
+
+// householdError maps household service errors to HTTP status codes
+func householdError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrHouseholdNotFound), errors.Is(err, services.ErrHouseholdMemberNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrHouseholdForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrHouseholdFull), errors.Is(err, services.ErrAlreadyMember):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrInvitationInvalid), errors.Is(err, services.ErrOwnerCannotLeave):
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
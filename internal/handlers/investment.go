@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type InvestmentHandler struct {
+	investmentService *services.InvestmentService
+	validator         *validator.Validate
+}
+
+func NewInvestmentHandler(cfg *config.Config) *InvestmentHandler {
+	return &InvestmentHandler{
+		investmentService: services.NewInvestmentService(cfg),
+		validator:         validator.New(),
+	}
+}
+
+// ListHoldings lists the ledger's holdings; ?goal_id= narrows them to one goal
+func (h *InvestmentHandler) ListHoldings(c echo.Context) error {
+	var goalID *uint64
+	if raw := c.QueryParam("goal_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "Invalid goal ID",
+				Message: err.Error(),
+			})
+		}
+		goalID = &id
+	}
+
+	holdings, err := h.investmentService.ListHoldings(ledgerFrom(c), goalID)
+	if err != nil {
+		return investmentError(c, "Failed to get holdings", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": holdings,
+	})
+}
+
+// GetHolding returns one holding
+func (h *InvestmentHandler) GetHolding(c echo.Context) error {
+	holdingID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid holding ID",
+			Message: err.Error(),
+		})
+	}
+
+	holding, err := h.investmentService.GetHolding(ledgerFrom(c), holdingID)
+	if err != nil {
+		return investmentError(c, "Failed to get holding", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": holding,
+	})
+}
+
+// CreateHolding adds a holding
+func (h *InvestmentHandler) CreateHolding(c echo.Context) error {
+	var req models.InvestmentHoldingRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	holding, err := h.investmentService.CreateHolding(ledgerFrom(c), &req)
+	if err != nil {
+		return investmentError(c, "Failed to create holding", err)
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": holding,
+	})
+}
+
+// UpdateHolding updates a holding
+func (h *InvestmentHandler) UpdateHolding(c echo.Context) error {
+	holdingID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid holding ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.InvestmentHoldingRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	holding, err := h.investmentService.UpdateHolding(ledgerFrom(c), holdingID, &req)
+	if err != nil {
+		return investmentError(c, "Failed to update holding", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": holding,
+	})
+}
+
+// DeleteHolding deletes a holding and its trades
+func (h *InvestmentHandler) DeleteHolding(c echo.Context) error {
+	holdingID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid holding ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.investmentService.DeleteHolding(ledgerFrom(c), holdingID); err != nil {
+		return investmentError(c, "Failed to delete holding", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Holding deleted successfully",
+	})
+}
+
+// ListTrades lists a holding's trades
+func (h *InvestmentHandler) ListTrades(c echo.Context) error {
+	holdingID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid holding ID",
+			Message: err.Error(),
+		})
+	}
+
+	trades, err := h.investmentService.ListTrades(ledgerFrom(c), holdingID)
+	if err != nil {
+		return investmentError(c, "Failed to get trades", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": trades,
+	})
+}
+
+// AddTrade records a buy, sale or dividend
+func (h *InvestmentHandler) AddTrade(c echo.Context) error {
+	holdingID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid holding ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.InvestmentTradeRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	holding, err := h.investmentService.AddTrade(ledgerFrom(c), holdingID, &req)
+	if err != nil {
+		return investmentError(c, "Failed to add trade", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": holding,
+	})
+}
+
+// DeleteTrade removes a trade
+func (h *InvestmentHandler) DeleteTrade(c echo.Context) error {
+	holdingID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid holding ID",
+			Message: err.Error(),
+		})
+	}
+	tradeID, err := uintParam(c, "tradeId")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid trade ID",
+			Message: err.Error(),
+		})
+	}
+
+	holding, err := h.investmentService.DeleteTrade(ledgerFrom(c), holdingID, tradeID)
+	if err != nil {
+		return investmentError(c, "Failed to delete trade", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": holding,
+	})
+}
+
+// GetPortfolio returns the portfolio's value, profit and loss, and allocation
+func (h *InvestmentHandler) GetPortfolio(c echo.Context) error {
+	portfolio, err := h.investmentService.GetPortfolio(ledgerFrom(c))
+	if err != nil {
+		return investmentError(c, "Failed to get portfolio", err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": portfolio,
+	})
+}
+
+func (h *InvestmentHandler) bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return h.validator.Struct(req)
+}
+
+// investmentError maps investment service errors to HTTP status codes
+func investmentError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrHoldingNotFound), errors.Is(err, services.ErrTradeNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTrade), errors.Is(err, services.ErrInsufficientQuantity),
+		errors.Is(err, services.ErrInvestmentGoalNotFound):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrHoldingExists):
+		status = http.StatusConflict
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
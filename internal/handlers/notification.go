@@ -3,26 +3,74 @@ package handlers
 import (
     "net/http"
     "strconv"
+    "time"
+
+    "tabimoney/internal/models"
     "tabimoney/internal/services"
+
+    "github.com/go-playground/validator/v10"
     "github.com/labstack/echo/v4"
 )
 
 type NotificationHandler struct {
-    svc *services.NotificationService
+    svc       *services.NotificationService
+    validator *validator.Validate
 }
 
 func NewNotificationHandler() *NotificationHandler {
-    return &NotificationHandler{svc: services.NewNotificationService()}
+    return &NotificationHandler{svc: services.NewNotificationService(), validator: validator.New()}
 }
 
 func (h *NotificationHandler) List(c echo.Context) error {
     userID := c.Get("user_id").(uint64)
-    unread := c.QueryParam("unread") == "true"
-    items, err := h.svc.List(userID, unread)
+
+    page, _ := strconv.Atoi(c.QueryParam("page"))
+    if page <= 0 { page = 1 }
+    limit, _ := strconv.Atoi(c.QueryParam("limit"))
+    if limit <= 0 { limit = 20 }
+
+    var startDate *time.Time
+    if v := c.QueryParam("start_date"); v != "" {
+        if t, err := time.Parse("2006-01-02", v); err == nil { startDate = &t }
+    }
+    var endDate *time.Time
+    if v := c.QueryParam("end_date"); v != "" {
+        if t, err := time.Parse("2006-01-02", v); err == nil {
+            t = t.Add(24*time.Hour - time.Nanosecond)
+            endDate = &t
+        }
+    }
+
+    req := &models.NotificationQueryRequest{
+        Page: page,
+        Limit: limit,
+        OnlyUnread: c.QueryParam("unread") == "true",
+        Archived: c.QueryParam("archived") == "true",
+        NotificationType: c.QueryParam("type"),
+        Priority: c.QueryParam("priority"),
+        StartDate: startDate,
+        EndDate: endDate,
+    }
+
+    items, total, err := h.svc.List(userID, req)
     if err != nil {
         return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list notifications", Message: err.Error()})
     }
-    return c.JSON(http.StatusOK, map[string]interface{}{"data": items})
+    return c.JSON(http.StatusOK, map[string]interface{}{
+        "data": items,
+        "total": total,
+        "page": req.Page,
+        "limit": req.Limit,
+    })
+}
+
+func (h *NotificationHandler) UnreadCount(c echo.Context) error {
+    userID := c.Get("user_id").(uint64)
+    count, err := h.svc.UnreadCount(userID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to count notifications", Message: err.Error()})
+    }
+    return c.JSON(http.StatusOK, map[string]interface{}{"data": map[string]int64{"unread": count}})
 }
 
 func (h *NotificationHandler) MarkRead(c echo.Context) error {
@@ -37,4 +85,72 @@ func (h *NotificationHandler) MarkRead(c echo.Context) error {
     return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 }
 
+func (h *NotificationHandler) MarkAllRead(c echo.Context) error {
+    userID := c.Get("user_id").(uint64)
+    updated, err := h.svc.MarkAllRead(userID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to mark all read", Message: err.Error()})
+    }
+    return c.JSON(http.StatusOK, map[string]interface{}{"data": map[string]int64{"updated": updated}})
+}
 
+func (h *NotificationHandler) Delete(c echo.Context) error {
+    userID := c.Get("user_id").(uint64)
+    id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID", Message: "id must be a number"})
+    }
+    deleted, err := h.svc.Delete(userID, []uint64{id})
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Delete failed", Message: err.Error()})
+    }
+    if deleted == 0 {
+        return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found", Message: "notification not found"})
+    }
+    return c.JSON(http.StatusOK, SuccessResponse{Message: "Deleted"})
+}
+
+func (h *NotificationHandler) BulkDelete(c echo.Context) error {
+    userID := c.Get("user_id").(uint64)
+    req, err := h.bindBulk(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+    }
+    deleted, err := h.svc.Delete(userID, req.IDs)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Delete failed", Message: err.Error()})
+    }
+    return c.JSON(http.StatusOK, map[string]interface{}{"data": map[string]int64{"deleted": deleted}})
+}
+
+func (h *NotificationHandler) Archive(c echo.Context) error {
+    return h.setArchived(c, true)
+}
+
+func (h *NotificationHandler) Unarchive(c echo.Context) error {
+    return h.setArchived(c, false)
+}
+
+func (h *NotificationHandler) setArchived(c echo.Context, archived bool) error {
+    userID := c.Get("user_id").(uint64)
+    req, err := h.bindBulk(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+    }
+    updated, err := h.svc.Archive(userID, req.IDs, archived)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Archive failed", Message: err.Error()})
+    }
+    return c.JSON(http.StatusOK, map[string]interface{}{"data": map[string]int64{"updated": updated}})
+}
+
+func (h *NotificationHandler) bindBulk(c echo.Context) (*models.NotificationBulkRequest, error) {
+    var req models.NotificationBulkRequest
+    if err := c.Bind(&req); err != nil {
+        return nil, err
+    }
+    if err := h.validator.Struct(req); err != nil {
+        return nil, err
+    }
+    return &req, nil
+}
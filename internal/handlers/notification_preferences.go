@@ -130,8 +130,7 @@ func (h *NotificationPreferencesHandler) TestNotification(c echo.Context) error
 		UserID:           userID,
 		NotificationType: "info",
 		Priority:         "low",
-		Title:            "🔔 Thông báo Test",
-		Message:          "Đây là thông báo test để kiểm tra cài đặt thông báo của bạn có hoạt động đúng không.",
+		Kind:             "test",
 		Metadata: map[string]interface{}{
 			"test": true,
 		},
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"tabimoney/internal/database"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	streamHeartbeatInterval = 25 * time.Second
+	// streamPongWait closes a WebSocket whose client missed two pings
+	streamPongWait = 2*streamHeartbeatInterval + 10*time.Second
+)
+
+// Stream pushes real-time notification and dashboard events to the client.
+// Server-Sent Events is the default transport; WebSocket is used when the
+// request is a WebSocket upgrade or transport=ws is given.
+func (h *NotificationHandler) Stream(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	if c.QueryParam("transport") == "ws" || strings.EqualFold(c.Request().Header.Get("Upgrade"), "websocket") {
+		return h.streamWebSocket(c, userID)
+	}
+	return h.streamSSE(c, userID)
+}
+
+func (h *NotificationHandler) streamSSE(c echo.Context, userID uint64) error {
+	ctx := c.Request().Context()
+
+	// Long-lived response: lift the server-wide write timeout for this connection
+	rc := http.NewResponseController(c.Response().Writer)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	sub := database.SubscribeUserEvents(ctx, userID)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Stream unavailable", Message: err.Error()})
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprint(res, "event: ready\ndata: {}\n\n")
+	res.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	events := sub.Channel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case msg, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", eventType(msg.Payload), msg.Payload); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+func (h *NotificationHandler) streamWebSocket(c echo.Context, userID uint64) error {
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		ctx, cancel := context.WithCancel(c.Request().Context())
+		defer cancel()
+
+		sub := database.SubscribeUserEvents(ctx, userID)
+		defer sub.Close()
+
+		// Reader loop only detects client disconnects, including a client that
+		// stopped answering pings; the stream is server-push
+		go func() {
+			defer cancel()
+			var discard string
+			for {
+				if err := websocket.Message.Receive(ws, &discard); err != nil {
+					return
+				}
+			}
+		}()
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+		events := sub.Channel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				ws.PayloadType = websocket.PingFrame
+				_, err := ws.Write(nil)
+				ws.PayloadType = websocket.TextFrame
+				if err != nil {
+					return
+				}
+			case msg, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := websocket.Message.Send(ws, msg.Payload); err != nil {
+					return
+				}
+			}
+		}
+	}).ServeHTTP(&keepaliveResponse{Response: c.Response(), timeout: streamPongWait}, c.Request())
+	return nil
+}
+
+// keepaliveResponse hands the WebSocket handler a hijacked connection without
+// the server-wide read and write timeouts. Writes get no deadline; reads time
+// out once the client has been silent for the keepalive timeout, which every
+// pong pushes back.
+type keepaliveResponse struct {
+	*echo.Response
+	timeout time.Duration
+}
+
+func (r *keepaliveResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := r.Response.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	kc := &keepaliveConn{Conn: conn, timeout: r.timeout}
+	kc.SetReadDeadline(time.Now().Add(kc.timeout))
+
+	// Bytes the server already buffered are read before the connection
+	pending, _ := rw.Reader.Peek(rw.Reader.Buffered())
+	reader := io.MultiReader(bytes.NewReader(append([]byte(nil), pending...)), kc)
+	return kc, bufio.NewReadWriter(bufio.NewReader(reader), rw.Writer), nil
+}
+
+// keepaliveConn extends the read deadline whenever the client sends anything.
+// Pong frames are swallowed by the websocket package, so this is the only
+// place they can be seen.
+type keepaliveConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *keepaliveConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	return n, err
+}
+
+// eventType extracts the "type" field of a published UserEvent for the SSE event name
+func eventType(payload string) string {
+	const marker = `"type":"`
+	i := strings.Index(payload, marker)
+	if i < 0 {
+		return "message"
+	}
+	rest := payload[i+len(marker):]
+	if j := strings.IndexByte(rest, '"'); j > 0 {
+		return rest[:j]
+	}
+	return "message"
+}
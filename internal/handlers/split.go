@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type SplitHandler struct {
+	splitService *services.SplitService
+	validator    *validator.Validate
+}
+
+func NewSplitHandler(cfg *config.Config) *SplitHandler {
+	return &SplitHandler{
+		splitService: services.NewSplitService(cfg),
+		validator:    validator.New(),
+	}
+}
+
+// ListSplits lists the user's split expenses
+func (h *SplitHandler) ListSplits(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	splits, err := h.splitService.ListSplits(userID)
+	if err != nil {
+		return splitError(c, "Failed to list splits", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": splits,
+	})
+}
+
+// CreateSplit splits an expense with counterparties
+func (h *SplitHandler) CreateSplit(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.SplitExpenseCreateRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	split, err := h.splitService.CreateSplit(userID, &req)
+	if err != nil {
+		return splitError(c, "Failed to create split", err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": split,
+	})
+}
+
+// GetSplit retrieves a split expense and its shares
+func (h *SplitHandler) GetSplit(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	splitID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	split, err := h.splitService.GetSplit(userID, splitID)
+	if err != nil {
+		return splitError(c, "Failed to get split", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": split,
+	})
+}
+
+// DeleteSplit deletes a split expense and the balances it created
+func (h *SplitHandler) DeleteSplit(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	splitID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.splitService.DeleteSplit(userID, splitID); err != nil {
+		return splitError(c, "Failed to delete split", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Split deleted successfully",
+	})
+}
+
+// ListCounterparties lists the people the user splits expenses with
+func (h *SplitHandler) ListCounterparties(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	counterparties, err := h.splitService.ListCounterparties(userID)
+	if err != nil {
+		return splitError(c, "Failed to list counterparties", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": counterparties,
+	})
+}
+
+// CreateCounterparty adds a counterparty
+func (h *SplitHandler) CreateCounterparty(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.CounterpartyCreateRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	counterparty, err := h.splitService.CreateCounterparty(userID, &req)
+	if err != nil {
+		return splitError(c, "Failed to create counterparty", err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": counterparty,
+	})
+}
+
+// InviteCounterparty sends a counterparty a new invitation to link their account
+func (h *SplitHandler) InviteCounterparty(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	counterpartyID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	counterparty, err := h.splitService.InviteCounterparty(userID, counterpartyID)
+	if err != nil {
+		return splitError(c, "Failed to invite counterparty", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": counterparty,
+	})
+}
+
+// AcceptCounterpartyInvite links the caller with the user who invited them
+func (h *SplitHandler) AcceptCounterpartyInvite(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.CounterpartyAcceptRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	counterparty, err := h.splitService.AcceptCounterpartyInvite(userID, req.Token)
+	if err != nil {
+		return splitError(c, "Failed to accept invitation", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": counterparty,
+	})
+}
+
+// UpdateCounterparty renames a counterparty
+func (h *SplitHandler) UpdateCounterparty(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	counterpartyID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	var req models.CounterpartyUpdateRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	counterparty, err := h.splitService.UpdateCounterparty(userID, counterpartyID, &req)
+	if err != nil {
+		return splitError(c, "Failed to update counterparty", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": counterparty,
+	})
+}
+
+// DeleteCounterparty removes a settled counterparty
+func (h *SplitHandler) DeleteCounterparty(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	counterpartyID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.splitService.DeleteCounterparty(userID, counterpartyID); err != nil {
+		return splitError(c, "Failed to delete counterparty", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Counterparty deleted successfully",
+	})
+}
+
+// GetEntries lists the IOU history with a counterparty
+func (h *SplitHandler) GetEntries(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	counterpartyID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	entries, err := h.splitService.GetEntries(userID, counterpartyID)
+	if err != nil {
+		return splitError(c, "Failed to get entries", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": entries,
+	})
+}
+
+// RemindCounterparty sends a payment reminder to a registered counterparty
+func (h *SplitHandler) RemindCounterparty(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+	counterpartyID, err := uintParam(c, "id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid ID",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.splitService.RemindCounterparty(userID, counterpartyID); err != nil {
+		return splitError(c, "Failed to send reminder", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Reminder sent",
+	})
+}
+
+// GetBalances returns the running balance with each counterparty
+func (h *SplitHandler) GetBalances(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	balances, err := h.splitService.GetBalances(userID)
+	if err != nil {
+		return splitError(c, "Failed to get balances", err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"data": balances,
+	})
+}
+
+// SettleUp records a payment to or from a counterparty
+func (h *SplitHandler) SettleUp(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.SettleUpRequest
+	if err := h.bind(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	}
+
+	entry, err := h.splitService.SettleUp(userID, &req)
+	if err != nil {
+		return splitError(c, "Failed to settle up", err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"data": entry,
+	})
+}
+
+// bind decodes and validates a request body
+func (h *SplitHandler) bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	return h.validator.Struct(req)
+}
+
+// splitError maps split service errors to HTTP status codes
+func splitError(c echo.Context, message string, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrCounterpartyNotFound), errors.Is(err, services.ErrSplitNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrCounterpartyExists), errors.Is(err, services.ErrCounterpartyHasBalance),
+		errors.Is(err, services.ErrCounterpartyLinked):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrReminderTooSoon):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, services.ErrSplitInvalid), errors.Is(err, services.ErrCounterpartyNotLinked), errors.Is(err, services.ErrNothingOwed),
+		errors.Is(err, services.ErrCounterpartyNoEmail), errors.Is(err, services.ErrCounterpartyInvalidInvite):
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
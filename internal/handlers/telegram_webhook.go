@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// telegramSecretHeader carries the secret_token configured with setWebhook
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+type TelegramWebhookHandler struct {
+	bot    *services.TelegramBotService
+	secret string
+}
+
+func NewTelegramWebhookHandler(cfg *config.Config) *TelegramWebhookHandler {
+	return &TelegramWebhookHandler{
+		bot:    services.NewTelegramBotService(cfg),
+		secret: cfg.Telegram.WebhookSecret,
+	}
+}
+
+// Webhook receives updates from the Telegram Bot API
+func (h *TelegramWebhookHandler) Webhook(c echo.Context) error {
+	if h.secret == "" {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Webhook disabled", Message: "TELEGRAM_WEBHOOK_SECRET is not configured"})
+	}
+	token := c.Request().Header.Get(telegramSecretHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) != 1 {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized", Message: "invalid secret token"})
+	}
+
+	var update services.TelegramUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid update", Message: err.Error()})
+	}
+
+	// Telegram retries non-2xx responses, so failures are logged rather than returned
+	if err := h.bot.HandleUpdate(&update); err != nil {
+		log.Printf("Failed to handle telegram update %d: %v", update.UpdateID, err)
+	}
+	return c.NoContent(http.StatusOK)
+}
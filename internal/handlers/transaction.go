@@ -21,7 +21,7 @@ func NewTransactionHandler(cfg *config.Config) *TransactionHandler {
 }
 
 func (h *TransactionHandler) List(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
 
     page, _ := strconv.Atoi(c.QueryParam("page"))
     if page <= 0 { page = 1 }
@@ -71,7 +71,7 @@ func (h *TransactionHandler) List(c echo.Context) error {
         SortOrder: c.QueryParam("sort_order"),
     }
 
-    items, total, err := h.svc.GetTransactions(userID, req)
+    items, total, err := h.svc.GetTransactions(ledger, req)
     if err != nil {
         return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Failed to list transactions", Message: err.Error()})
     }
@@ -85,34 +85,34 @@ func (h *TransactionHandler) List(c echo.Context) error {
 }
 
 func (h *TransactionHandler) Create(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
     var req models.TransactionCreateRequest
     if err := c.Bind(&req); err != nil {
         return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
     }
-    tx, err := h.svc.CreateTransaction(userID, &req)
+    tx, err := h.svc.CreateTransaction(ledger, &req)
     if err != nil { return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Create failed", Message: err.Error()}) }
     return c.JSON(http.StatusCreated, tx)
 }
 
 func (h *TransactionHandler) Update(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
     idParam := c.Param("id")
     id, err := strconv.ParseUint(idParam, 10, 64)
     if err != nil { return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID", Message: "id must be uint"}) }
     var req models.TransactionUpdateRequest
     if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()}) }
-    tx, err := h.svc.UpdateTransaction(userID, uint64(id), &req)
+    tx, err := h.svc.UpdateTransaction(ledger, uint64(id), &req)
     if err != nil { return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Update failed", Message: err.Error()}) }
     return c.JSON(http.StatusOK, tx)
 }
 
 func (h *TransactionHandler) Delete(c echo.Context) error {
-    userID := c.Get("user_id").(uint64)
+    ledger := ledgerFrom(c)
     idParam := c.Param("id")
     id, err := strconv.ParseUint(idParam, 10, 64)
     if err != nil { return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID", Message: "id must be uint"}) }
-    if err := h.svc.DeleteTransaction(userID, uint64(id)); err != nil {
+    if err := h.svc.DeleteTransaction(ledger, uint64(id)); err != nil {
         return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Delete failed", Message: err.Error()})
     }
     return c.JSON(http.StatusOK, SuccessResponse{Message: "Deleted"})
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"tabimoney/internal/models"
+	"tabimoney/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LoginTwoFactor godoc
+// @Summary Complete two-factor login
+// @Description Exchange the pending-MFA token from login and a TOTP or recovery code for tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "MFA token and code"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /auth/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(c echo.Context) error {
+	var req models.TwoFactorLoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	response, err := h.authService.CompleteTwoFactorLogin(&req, clientInfo(c))
+	if err != nil {
+		return loginError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetTwoFactorStatus godoc
+// @Summary Get two-factor status
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TwoFactorStatusResponse
+// @Router /auth/2fa [get]
+func (h *AuthHandler) GetTwoFactorStatus(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	status, err := h.authService.GetTwoFactorStatus(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to get two-factor status",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// SetupTwoFactor godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret and otpauth:// provisioning URI for a QR code
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.TwoFactorSetupResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /auth/2fa/setup [post]
+func (h *AuthHandler) SetupTwoFactor(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	setup, err := h.authService.SetupTwoFactor(userID)
+	if err != nil {
+		return twoFactorError(c, "Two-factor setup failed", err)
+	}
+
+	return c.JSON(http.StatusOK, setup)
+}
+
+// EnableTwoFactor godoc
+// @Summary Confirm two-factor enrollment
+// @Description Verify the first TOTP code and return one-time recovery codes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.TwoFactorRecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse "Too many wrong codes"
+// @Failure 503 {object} ErrorResponse
+// @Router /auth/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactor(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	codes, err := h.authService.EnableTwoFactor(userID, req.Code)
+	if err != nil {
+		return twoFactorError(c, "Failed to enable two-factor authentication", err)
+	}
+
+	return c.JSON(http.StatusOK, models.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorPasswordRequest true "Account password"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/2fa/disable [post]
+func (h *AuthHandler) DisableTwoFactor(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.TwoFactorPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.authService.DisableTwoFactor(userID, req.Password); err != nil {
+		return twoFactorError(c, "Failed to disable two-factor authentication", err)
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes; previous codes stop working
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.TwoFactorPasswordRequest true "Account password"
+// @Success 200 {object} models.TwoFactorRecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /auth/2fa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID := c.Get("user_id").(uint64)
+
+	var req models.TwoFactorPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+		})
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(userID, req.Password)
+	if err != nil {
+		return twoFactorError(c, "Failed to regenerate recovery codes", err)
+	}
+
+	return c.JSON(http.StatusOK, models.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func twoFactorError(c echo.Context, title string, err error) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrAccountTemporarilyLocked):
+		status = http.StatusTooManyRequests
+		var loginErr *services.LoginError
+		if errors.As(err, &loginErr) && loginErr.RetryAfter > 0 {
+			c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(loginErr.RetryAfter.Seconds()))))
+		}
+	case errors.Is(err, services.ErrTwoFactorUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, ErrorResponse{
+		Error:   title,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,432 @@
+// Package i18n holds the message catalog used to render notification titles,
+// messages, email and Telegram content in the user's language.
+//
+// Catalog files live in locales/<lang>.json and map a message key (for example
+// "budget_threshold.title") to either a text/template string or, for plural
+// aware entries, an object of CLDR plural forms ("one", "other") where "{n}" is
+// replaced with the formatted count. Templates can use the locale aware helpers
+// number, percent, money, date, datetime and plural.
+package i18n
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+//go:embed locales/*.json templates/*.html
+var embeddedFS embed.FS
+
+const fallbackLanguage = "vi"
+
+type entry struct {
+	tmpl   *template.Template
+	plural map[string]string
+}
+
+type catalog struct {
+	defaultLang string
+	messages    map[string]map[string]*entry
+	files       fs.FS
+}
+
+var (
+	mu      sync.RWMutex
+	current *catalog
+	once    sync.Once
+)
+
+// Load reads catalog files from dir, or from the embedded defaults when dir is
+// empty, and makes them the active catalog.
+func Load(dir, defaultLang string) error {
+	var files fs.FS = embeddedFS
+	if dir != "" {
+		files = os.DirFS(dir)
+	}
+	if defaultLang == "" {
+		defaultLang = fallbackLanguage
+	}
+
+	c, err := loadCatalog(files, defaultLang)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	current = c
+	mu.Unlock()
+
+	log.Printf("i18n catalog loaded: languages=%s default=%s", strings.Join(c.languages(), ","), defaultLang)
+	return nil
+}
+
+func active() *catalog {
+	once.Do(func() {
+		mu.RLock()
+		loaded := current != nil
+		mu.RUnlock()
+		if loaded {
+			return
+		}
+		if err := Load("", fallbackLanguage); err != nil {
+			log.Printf("failed to load embedded i18n catalog: %v", err)
+		}
+	})
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+func loadCatalog(files fs.FS, defaultLang string) (*catalog, error) {
+	paths, err := fs.Glob(files, "locales/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locale files: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no locale files found")
+	}
+
+	c := &catalog{
+		defaultLang: defaultLang,
+		messages:    make(map[string]map[string]*entry),
+		files:       files,
+	}
+
+	for _, p := range paths {
+		lang := strings.TrimSuffix(filepath.Base(p), ".json")
+		data, err := fs.ReadFile(files, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+		}
+
+		msgs := make(map[string]*entry, len(raw))
+		for key, value := range raw {
+			e, err := parseEntry(lang, key, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", p, err)
+			}
+			msgs[key] = e
+		}
+		c.messages[lang] = msgs
+	}
+
+	if _, ok := c.messages[defaultLang]; !ok {
+		return nil, fmt.Errorf("default language %q has no locale file", defaultLang)
+	}
+
+	return c, nil
+}
+
+func parseEntry(lang, key string, value json.RawMessage) (*entry, error) {
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		t, err := template.New(lang + ":" + key).Funcs(placeholderFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for %q: %w", key, err)
+		}
+		return &entry{tmpl: t}, nil
+	}
+
+	var forms map[string]string
+	if err := json.Unmarshal(value, &forms); err != nil {
+		return nil, fmt.Errorf("entry %q must be a string or plural object", key)
+	}
+	if _, ok := forms["other"]; !ok {
+		return nil, fmt.Errorf("plural entry %q is missing the \"other\" form", key)
+	}
+	return &entry{plural: forms}, nil
+}
+
+func (c *catalog) languages() []string {
+	langs := make([]string, 0, len(c.messages))
+	for l := range c.messages {
+		langs = append(langs, l)
+	}
+	return langs
+}
+
+func (c *catalog) lookup(lang, key string) (*entry, string) {
+	if msgs, ok := c.messages[lang]; ok {
+		if e, ok := msgs[key]; ok {
+			return e, lang
+		}
+	}
+	if e, ok := c.messages[c.defaultLang][key]; ok {
+		return e, c.defaultLang
+	}
+	return nil, lang
+}
+
+// placeholderFuncs lets templates parse; real implementations are bound per
+// Localizer at render time.
+var placeholderFuncs = template.FuncMap{
+	"number":   func(v interface{}, decimals int) string { return "" },
+	"percent":  func(v interface{}) string { return "" },
+	"money":    func(v interface{}) string { return "" },
+	"date":     func(v interface{}) string { return "" },
+	"datetime": func(v interface{}) string { return "" },
+	"plural":   func(key string, n interface{}) string { return "" },
+	"t":        func(key string) string { return "" },
+}
+
+// Localizer renders catalog messages and formats values for one language and
+// currency.
+type Localizer struct {
+	Lang     string
+	Currency string
+}
+
+// For returns a Localizer for the given language and currency, falling back to
+// the catalog default language when lang is unknown.
+func For(lang, currency string) *Localizer {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	c := active()
+	if c != nil {
+		if _, ok := c.messages[lang]; !ok {
+			lang = c.defaultLang
+		}
+	} else if lang == "" {
+		lang = fallbackLanguage
+	}
+	if currency == "" {
+		currency = "VND"
+	}
+	return &Localizer{Lang: lang, Currency: strings.ToUpper(currency)}
+}
+
+// Has reports whether key exists in the catalog.
+func (l *Localizer) Has(key string) bool {
+	c := active()
+	if c == nil {
+		return false
+	}
+	e, _ := c.lookup(l.Lang, key)
+	return e != nil
+}
+
+// T renders the message for key with data. Missing keys render as the key
+// itself so gaps in a locale file are visible rather than silent.
+func (l *Localizer) T(key string, data interface{}) string {
+	c := active()
+	if c == nil {
+		return key
+	}
+	e, _ := c.lookup(l.Lang, key)
+	if e == nil {
+		return key
+	}
+	if e.plural != nil {
+		return l.pluralForm(e, data)
+	}
+
+	t, err := e.tmpl.Clone()
+	if err != nil {
+		log.Printf("i18n: failed to clone template %s: %v", key, err)
+		return key
+	}
+	var buf bytes.Buffer
+	if err := t.Funcs(l.funcs()).Execute(&buf, data); err != nil {
+		log.Printf("i18n: failed to render %s (%s): %v", key, l.Lang, err)
+		return key
+	}
+	return buf.String()
+}
+
+// Plural renders a plural entry for count n.
+func (l *Localizer) Plural(key string, n interface{}) string {
+	c := active()
+	if c == nil {
+		return key
+	}
+	e, _ := c.lookup(l.Lang, key)
+	if e == nil || e.plural == nil {
+		return key
+	}
+	return l.pluralForm(e, n)
+}
+
+func (l *Localizer) pluralForm(e *entry, n interface{}) string {
+	count := toFloat(n)
+	form, ok := e.plural[pluralCategory(l.Lang, count)]
+	if !ok {
+		form = e.plural["other"]
+	}
+	return strings.ReplaceAll(form, "{n}", l.Number(count, 0))
+}
+
+// pluralCategory returns the CLDR plural category for the integer part of n.
+// Vietnamese has a single form; English distinguishes one/other.
+func pluralCategory(lang string, n float64) string {
+	switch lang {
+	case "en":
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	default:
+		return "other"
+	}
+}
+
+func (l *Localizer) funcs() template.FuncMap {
+	return template.FuncMap{
+		"number":   func(v interface{}, decimals int) string { return l.Number(toFloat(v), decimals) },
+		"percent":  func(v interface{}) string { return l.Percent(toFloat(v)) },
+		"money":    func(v interface{}) string { return l.Money(toFloat(v)) },
+		"date":     func(v interface{}) string { return l.Date(toTime(v)) },
+		"datetime": func(v interface{}) string { return l.DateTime(toTime(v)) },
+		"plural":   func(key string, n interface{}) string { return l.Plural(key, n) },
+		"t":        func(key string) string { return l.T(key, nil) },
+	}
+}
+
+// separators returns the thousands and decimal separators for the locale.
+func (l *Localizer) separators() (string, string) {
+	switch l.Lang {
+	case "vi":
+		return ".", ","
+	default:
+		return ",", "."
+	}
+}
+
+// Number formats v with the locale's separators and the given decimals.
+func (l *Localizer) Number(v float64, decimals int) string {
+	thousands, decimal := l.separators()
+
+	neg := v < 0
+	v = math.Abs(v)
+	s := fmt.Sprintf("%.*f", decimals, v)
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	var b strings.Builder
+	if neg {
+		b.WriteByte('-')
+	}
+	n := len(intPart)
+	pre := n % 3
+	if pre == 0 {
+		pre = 3
+	}
+	b.WriteString(intPart[:pre])
+	for i := pre; i < n; i += 3 {
+		b.WriteString(thousands)
+		b.WriteString(intPart[i : i+3])
+	}
+	if fracPart != "" {
+		b.WriteString(decimal)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+// Percent formats v (already in 0-100 scale) with one decimal.
+func (l *Localizer) Percent(v float64) string {
+	return l.Number(v, 1) + "%"
+}
+
+// Money formats an amount in the localizer's currency.
+func (l *Localizer) Money(v float64) string {
+	decimals := 2
+	if l.Currency == "VND" {
+		decimals = 0
+	}
+	amount := l.Number(v, decimals)
+	if l.Lang == "vi" {
+		return amount + " " + l.Currency
+	}
+	return l.Currency + " " + amount
+}
+
+// Date formats t as a short date.
+func (l *Localizer) Date(t time.Time) string {
+	if l.Lang == "en" {
+		return t.Format("Jan 2, 2006")
+	}
+	return t.Format("02/01/2006")
+}
+
+// Time formats t as hours and minutes.
+func (l *Localizer) Time(t time.Time) string {
+	return t.Format("15:04")
+}
+
+// DateTime formats t as a short date and time.
+func (l *Localizer) DateTime(t time.Time) string {
+	return l.Date(t) + " " + l.Time(t)
+}
+
+// Template returns a raw template file (for example templates/email.html) from
+// the active catalog source.
+func Template(name string) (string, error) {
+	c := active()
+	if c == nil {
+		return "", fmt.Errorf("i18n catalog not loaded")
+	}
+	data, err := fs.ReadFile(c.files, "templates/"+name)
+	if err != nil {
+		// Allow overriding only locale files while keeping embedded templates
+		data, err = fs.ReadFile(embeddedFS, "templates/"+name)
+		if err != nil {
+			return "", fmt.Errorf("template %s not found: %w", name, err)
+		}
+	}
+	return string(data), nil
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	default:
+		return 0
+	}
+}
+
+func toTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case *time.Time:
+		if t != nil {
+			return *t
+		}
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
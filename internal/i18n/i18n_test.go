@@ -0,0 +1,173 @@
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+// useCatalog makes a catalog built from the given locale files active for the
+// duration of the test
+func useCatalog(t *testing.T, locales map[string]string) {
+	t.Helper()
+	files := fstest.MapFS{}
+	for lang, data := range locales {
+		files["locales/"+lang+".json"] = &fstest.MapFile{Data: []byte(data)}
+	}
+	c, err := loadCatalog(files, "vi")
+	if err != nil {
+		t.Fatalf("loadCatalog: %v", err)
+	}
+
+	active() // run the embedded load first so it cannot replace the test catalog
+	mu.Lock()
+	previous := current
+	current = c
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		current = previous
+		mu.Unlock()
+	})
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		lang     string
+		v        float64
+		decimals int
+		want     string
+	}{
+		{"vi", 0, 0, "0"},
+		{"vi", 999, 0, "999"},
+		{"vi", 1000, 0, "1.000"},
+		{"vi", 1234567, 0, "1.234.567"},
+		{"vi", 1234.5, 2, "1.234,50"},
+		{"vi", -45000, 0, "-45.000"},
+		{"en", 1234567, 0, "1,234,567"},
+		{"en", 1234.5, 2, "1,234.50"},
+		{"en", 100000, 0, "100,000"},
+		{"en", -0.5, 1, "-0.5"},
+		{"en", 999.96, 1, "1,000.0"},
+	}
+	for _, tt := range tests {
+		l := &Localizer{Lang: tt.lang}
+		if got := l.Number(tt.v, tt.decimals); got != tt.want {
+			t.Errorf("Number(%v, %d) in %s = %q, want %q", tt.v, tt.decimals, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyAndPercent(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"vi VND", (&Localizer{Lang: "vi", Currency: "VND"}).Money(1500000), "1.500.000 VND"},
+		{"en VND", (&Localizer{Lang: "en", Currency: "VND"}).Money(1500000), "VND 1,500,000"},
+		{"en USD", (&Localizer{Lang: "en", Currency: "USD"}).Money(12.5), "USD 12.50"},
+		{"vi percent", (&Localizer{Lang: "vi"}).Percent(85.26), "85,3%"},
+		{"en percent", (&Localizer{Lang: "en"}).Percent(100), "100.0%"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+	useCatalog(t, map[string]string{
+		"vi": `{"unit.days": {"other": "{n} ngày"}}`,
+		"en": `{"unit.days": {"one": "{n} day", "other": "{n} days"}, "unit.items": {"other": "{n} items"}}`,
+	})
+	tests := []struct {
+		lang string
+		key  string
+		n    interface{}
+		want string
+	}{
+		{"en", "unit.days", 1, "1 day"},
+		{"en", "unit.days", 0, "0 days"},
+		{"en", "unit.days", 2, "2 days"},
+		{"en", "unit.days", int64(1500), "1,500 days"},
+		{"en", "unit.items", 1, "1 items"},
+		{"vi", "unit.days", 1, "1 ngày"},
+		{"vi", "unit.days", 1500, "1.500 ngày"},
+		{"vi", "unit.missing", 3, "unit.missing"},
+	}
+	for _, tt := range tests {
+		l := For(tt.lang, "")
+		if got := l.Plural(tt.key, tt.n); got != tt.want {
+			t.Errorf("Plural(%q, %v) in %s = %q, want %q", tt.key, tt.n, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	useCatalog(t, map[string]string{
+		"vi": `{
+			"greeting": "Xin chào {{.name}}",
+			"only_vi": "Chỉ có tiếng Việt",
+			"spent": "Đã chi {{money .amount}} trong {{plural \"unit.days\" .days}}",
+			"unit.days": {"other": "{n} ngày"}
+		}`,
+		"en": `{
+			"greeting": "Hello {{.name}}",
+			"spent": "Spent {{money .amount}} over {{plural \"unit.days\" .days}}",
+			"unit.days": {"one": "{n} day", "other": "{n} days"}
+		}`,
+	})
+	data := map[string]interface{}{"name": "Lan", "amount": 250000, "days": 1}
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		want string
+	}{
+		{"english", "en", "greeting", "Hello Lan"},
+		{"vietnamese", "vi", "greeting", "Xin chào Lan"},
+		{"missing in english falls back to the default language", "en", "only_vi", "Chỉ có tiếng Việt"},
+		{"missing everywhere renders the key", "en", "nowhere", "nowhere"},
+		{"unknown language uses the default", "fr", "greeting", "Xin chào Lan"},
+		{"template helpers", "en", "spent", "Spent VND 250,000 over 1 day"},
+		{"template helpers in vietnamese", "vi", "spent", "Đã chi 250.000 VND trong 1 ngày"},
+		{"plural entry through T", "en", "unit.days", "1 day"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := For(tt.lang, "")
+			var arg interface{} = data
+			if tt.key == "unit.days" {
+				arg = 1
+			}
+			if got := l.T(tt.key, arg); got != tt.want {
+				t.Errorf("T(%q) in %s = %q, want %q", tt.key, tt.lang, got, tt.want)
+			}
+		})
+	}
+
+	if l := For("en", ""); !l.Has("only_vi") || l.Has("nowhere") {
+		t.Errorf("Has() does not follow the default language fallback")
+	}
+}
+
+func TestLoadCatalogErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"no locale files", fstest.MapFS{}},
+		{"no default language", fstest.MapFS{"locales/en.json": {Data: []byte(`{}`)}}},
+		{"invalid json", fstest.MapFS{"locales/vi.json": {Data: []byte(`{`)}}},
+		{"invalid template", fstest.MapFS{"locales/vi.json": {Data: []byte(`{"a": "{{.x"}`)}}},
+		{"plural without other", fstest.MapFS{"locales/vi.json": {Data: []byte(`{"a": {"one": "x"}}`)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadCatalog(tt.files, "vi"); err == nil {
+				t.Error("loadCatalog() succeeded, want an error")
+			}
+		})
+	}
+}
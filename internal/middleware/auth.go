@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"tabimoney/internal/services"
@@ -8,8 +11,17 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-// AuthMiddleware validates JWT token and sets user context
-func AuthMiddleware(authService *services.AuthService) echo.MiddlewareFunc {
+// ScopeRule declares the API key scopes a route group requires. Read is needed
+// for GET/HEAD requests and Write for everything else; an empty scope means API
+// keys cannot be used for that kind of request.
+type ScopeRule struct {
+	Read  string
+	Write string
+}
+
+// AuthMiddleware validates JWT token and sets user context. API keys are only
+// accepted when a ScopeRule is given, so routes opt in to key access explicitly.
+func AuthMiddleware(authService *services.AuthService, rules ...ScopeRule) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Get Authorization header
@@ -30,6 +42,10 @@ func AuthMiddleware(authService *services.AuthService) echo.MiddlewareFunc {
 			// Extract token
 			token := strings.TrimPrefix(authHeader, "Bearer ")
 
+			if services.IsAPIKey(token) {
+				return authenticateAPIKey(c, next, authService, token, rules)
+			}
+
 			// Validate token
 			userID, err := authService.ValidateToken(token)
 			if err != nil {
@@ -47,6 +63,46 @@ func AuthMiddleware(authService *services.AuthService) echo.MiddlewareFunc {
 	}
 }
 
+func authenticateAPIKey(c echo.Context, next echo.HandlerFunc, authService *services.AuthService, key string, rules []ScopeRule) error {
+	required := requiredScope(c.Request().Method, rules)
+	if required == "" {
+		return c.JSON(403, map[string]string{
+			"error": "API keys cannot be used for this endpoint",
+		})
+	}
+
+	userID, scopes, err := authService.ValidateAPIKey(key)
+	if err != nil {
+		return c.JSON(401, map[string]string{
+			"error": "Invalid or expired API key",
+		})
+	}
+
+	for _, scope := range scopes {
+		if scope == required {
+			c.Set("user_id", userID)
+			c.Set("api_key_scopes", scopes)
+			return next(c)
+		}
+	}
+	return c.JSON(403, map[string]string{
+		"error": "API key is missing the " + required + " scope",
+	})
+}
+
+func requiredScope(method string, rules []ScopeRule) string {
+	for _, rule := range rules {
+		if method == http.MethodGet || method == http.MethodHead {
+			if rule.Read != "" {
+				return rule.Read
+			}
+		} else if rule.Write != "" {
+			return rule.Write
+		}
+	}
+	return ""
+}
+
 // OptionalAuthMiddleware validates JWT token if present but doesn't require it
 func OptionalAuthMiddleware(authService *services.AuthService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -65,3 +121,141 @@ func OptionalAuthMiddleware(authService *services.AuthService) echo.MiddlewareFu
 		}
 	}
 }
+
+// StreamAuthMiddleware authenticates long-lived stream connections. Browsers
+// cannot set headers on EventSource/WebSocket, so a single-use ticket from
+// POST /auth/stream-ticket is accepted as the ticket query parameter in place
+// of the Authorization header. Access tokens are never read from the query
+// string, where request logs would record them.
+func StreamAuthMiddleware(authService *services.AuthService) echo.MiddlewareFunc {
+	auth := AuthMiddleware(authService)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		authed := auth(next)
+		return func(c echo.Context) error {
+			ticket := c.QueryParam("ticket")
+			if ticket == "" || c.Request().Header.Get("Authorization") != "" {
+				return authed(c)
+			}
+
+			userID, err := authService.RedeemStreamTicket(ticket)
+			if err != nil {
+				return c.JSON(401, map[string]string{
+					"error": "Invalid or expired stream ticket",
+				})
+			}
+			c.Set("user_id", userID)
+			return next(c)
+		}
+	}
+}
+
+// RequireVerifiedEmail blocks unverified accounts when REQUIRE_VERIFIED_EMAIL
+// is enabled. It must run after AuthMiddleware.
+func RequireVerifiedEmail(authService *services.AuthService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !authService.RequiresVerifiedEmail() {
+				return next(c)
+			}
+
+			userID, ok := c.Get("user_id").(uint64)
+			if !ok {
+				return c.JSON(401, map[string]string{
+					"error": "Authentication required",
+				})
+			}
+
+			verified, err := authService.IsEmailVerified(userID)
+			if err != nil || !verified {
+				return c.JSON(403, map[string]string{
+					"error": "Email address must be verified",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// LedgerMiddleware selects the ledger a request works on. Requests are scoped
+// to the caller's personal ledger unless an X-Household-ID header (or
+// household_id query parameter) names a household they belong to. Household
+// viewers may only read. It must run after AuthMiddleware.
+func LedgerMiddleware(householdService *services.HouseholdService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, ok := c.Get("user_id").(uint64)
+			if !ok {
+				return c.JSON(401, map[string]string{
+					"error": "Authentication required",
+				})
+			}
+
+			raw := c.Request().Header.Get("X-Household-ID")
+			if raw == "" {
+				raw = c.QueryParam("household_id")
+			}
+			if raw == "" {
+				c.Set("ledger", services.PersonalLedger(userID))
+				return next(c)
+			}
+
+			householdID, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return c.JSON(400, map[string]string{
+					"error": "Invalid household ID",
+				})
+			}
+			ledger, err := householdService.ResolveLedger(userID, householdID)
+			if err != nil {
+				if errors.Is(err, services.ErrHouseholdNotFound) {
+					return c.JSON(404, map[string]string{
+						"error": "Household not found",
+					})
+				}
+				return c.JSON(500, map[string]string{
+					"error": "Failed to load household",
+				})
+			}
+
+			method := c.Request().Method
+			if method != http.MethodGet && method != http.MethodHead && !ledger.CanWrite() {
+				return c.JSON(403, map[string]string{
+					"error": services.ErrLedgerReadOnly.Error(),
+				})
+			}
+
+			c.Set("ledger", ledger)
+			return next(c)
+		}
+	}
+}
+
+// RequireAdmin rejects users without the admin role. It must run after
+// AuthMiddleware.
+func RequireAdmin(authService *services.AuthService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, ok := c.Get("user_id").(uint64)
+			if !ok {
+				return c.JSON(401, map[string]string{
+					"error": "Authentication required",
+				})
+			}
+
+			isAdmin, err := authService.IsAdmin(userID)
+			if err != nil {
+				return c.JSON(500, map[string]string{
+					"error": "Failed to check permissions",
+				})
+			}
+			if !isAdmin {
+				return c.JSON(403, map[string]string{
+					"error": "Admin access required",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"tabimoney/internal/models"
+)
+
+func TestRequiredScope(t *testing.T) {
+	transactions := ScopeRule{Read: models.ScopeTransactionsRead, Write: models.ScopeTransactionsWrite}
+	analytics := ScopeRule{Read: models.ScopeAnalyticsRead}
+	simulate := ScopeRule{Read: models.ScopeBudgetsRead, Write: models.ScopeBudgetsRead}
+
+	tests := []struct {
+		name   string
+		method string
+		rules  []ScopeRule
+		want   string
+	}{
+		{"read", http.MethodGet, []ScopeRule{transactions}, models.ScopeTransactionsRead},
+		{"head reads", http.MethodHead, []ScopeRule{transactions}, models.ScopeTransactionsRead},
+		{"post writes", http.MethodPost, []ScopeRule{transactions}, models.ScopeTransactionsWrite},
+		{"delete writes", http.MethodDelete, []ScopeRule{transactions}, models.ScopeTransactionsWrite},
+		{"read-only rule allows reads", http.MethodGet, []ScopeRule{analytics}, models.ScopeAnalyticsRead},
+		{"read-only rule rejects writes", http.MethodPut, []ScopeRule{analytics}, ""},
+		{"no rules", http.MethodGet, nil, ""},
+		{"first matching rule wins", http.MethodPost, []ScopeRule{analytics, transactions}, models.ScopeTransactionsWrite},
+		{"budget simulation is a read", http.MethodPost, []ScopeRule{simulate}, models.ScopeBudgetsRead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requiredScope(tt.method, tt.rules); got != tt.want {
+				t.Errorf("requiredScope(%s) = %q, want %q", tt.method, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// AuditLog records an action taken through the admin API
+type AuditLog struct {
+	ID         uint64    `json:"id" gorm:"primaryKey"`
+	ActorID    uint64    `json:"actor_id" gorm:"not null;index"`
+	Action     string    `json:"action" gorm:"size:64;not null;index"`
+	TargetType string    `json:"target_type" gorm:"size:32"`
+	TargetID   *uint64   `json:"target_id"`
+	Metadata   string    `json:"metadata" gorm:"type:json"`
+	IPAddress  string    `json:"ip_address" gorm:"size:45"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+
+	// Relations
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+// AdminCategoryRequest creates or updates a system category
+type AdminCategoryRequest struct {
+	Name        string  `json:"name" validate:"required,max=100"`
+	NameEn      string  `json:"name_en" validate:"max=100"`
+	Description string  `json:"description" validate:"max=500"`
+	ParentID    *uint64 `json:"parent_id"`
+	IsActive    *bool   `json:"is_active"`
+	SortOrder   *int    `json:"sort_order"`
+}
+
+// AdminCategoryReorderRequest sets the display order of system categories to
+// the order of the given IDs
+type AdminCategoryReorderRequest struct {
+	CategoryIDs []uint64 `json:"category_ids" validate:"required,min=1"`
+}
+
+// AdminUserQuery filters the admin user list
+type AdminUserQuery struct {
+	Search string `json:"search"` // matches email, username or name
+	Role   string `json:"role"`
+	Locked *bool  `json:"locked"`
+	Page   int    `json:"page"`
+	Limit  int    `json:"limit"`
+}
+
+// AdminUserResponse is a user as seen by an admin
+type AdminUserResponse struct {
+	UserResponse
+	LockedAt         *time.Time `json:"locked_at"`
+	LockReason       string     `json:"lock_reason,omitempty"`
+	TransactionCount int64      `json:"transaction_count"`
+}
+
+// AdminLockRequest locks a user account
+type AdminLockRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// AdminRoleRequest changes a user's role
+type AdminRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+// AuditLogQuery filters the audit log
+type AuditLogQuery struct {
+	ActorID *uint64 `json:"actor_id"`
+	Action  string  `json:"action"`
+	Page    int     `json:"page"`
+	Limit   int     `json:"limit"`
+}
+
+// SchedulerTaskStatus is the outcome of the last run of a scheduled job
+type SchedulerTaskStatus struct {
+	Name       string     `json:"name"`
+	Scheduled  bool       `json:"scheduled"` // false for jobs that only run when triggered
+	LastRunAt  *time.Time `json:"last_run_at"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// NotificationDeliveryStats counts notifications per channel and outcome for one day
+type NotificationDeliveryStats struct {
+	Date     string           `json:"date"`
+	Channels map[string]int64 `json:"channels"` // e.g. "email:sent", "telegram:failed"
+}
+
+// NotificationStatsResponse summarizes stored notifications and recent deliveries
+type NotificationStatsResponse struct {
+	Total      int64                       `json:"total"`
+	Unread     int64                       `json:"unread"`
+	ByType     map[string]int64            `json:"by_type"`
+	ByPriority map[string]int64            `json:"by_priority"`
+	Deliveries []NotificationDeliveryStats `json:"deliveries"`
+}
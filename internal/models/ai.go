@@ -34,20 +34,39 @@ type AIFeedback struct {
 
 type Notification struct {
 	ID               uint64     `json:"id" gorm:"primaryKey"`
-	UserID           uint64     `json:"user_id" gorm:"not null"`
+	UserID           uint64     `json:"user_id" gorm:"not null;index:idx_notifications_inbox,priority:1"`
 	Title            string     `json:"title" gorm:"not null"`
 	Message          string     `json:"message" gorm:"not null"`
 	NotificationType string     `json:"notification_type" gorm:"type:enum('info','warning','success','error','reminder');not null"`
 	Priority         string     `json:"priority" gorm:"type:enum('low','medium','high','urgent');default:'medium'"`
-	IsRead           bool       `json:"is_read" gorm:"default:false"`
+	IsRead           bool       `json:"is_read" gorm:"default:false;index:idx_notifications_inbox,priority:2"`
 	ReadAt           *time.Time `json:"read_at"`
+	IsArchived       bool       `json:"is_archived" gorm:"default:false;index:idx_notifications_inbox,priority:3"`
+	ArchivedAt       *time.Time `json:"archived_at"`
 	Metadata         string     `json:"metadata" gorm:"type:json"`
-	CreatedAt        time.Time  `json:"created_at"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"index:idx_notifications_inbox,priority:4"`
 
 	// Relations
 	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// NotificationQueryRequest represents filters and pagination for the notification inbox
+type NotificationQueryRequest struct {
+	Page             int        `json:"page"`
+	Limit            int        `json:"limit"`
+	OnlyUnread       bool       `json:"only_unread"`
+	Archived         bool       `json:"archived"`
+	NotificationType string     `json:"notification_type"`
+	Priority         string     `json:"priority"`
+	StartDate        *time.Time `json:"start_date"`
+	EndDate          *time.Time `json:"end_date"`
+}
+
+// NotificationBulkRequest represents a bulk action on notifications
+type NotificationBulkRequest struct {
+	IDs []uint64 `json:"ids" validate:"required,min=1,max=500"`
+}
+
 // AI Analysis Request/Response Models
 
 type ExpensePredictionRequest struct {
@@ -227,6 +246,21 @@ type CategoryAnalytics struct {
 	AverageAmount    float64 `json:"average_amount"`
 }
 
+// CategorySpendingNode is a category in the spending tree. Amount and
+// TransactionCount cover the category itself; the Total fields roll up its
+// subcategories as well.
+type CategorySpendingNode struct {
+	CategoryID            uint64                 `json:"category_id"`
+	CategoryName          string                 `json:"category_name"`
+	ParentID              *uint64                `json:"parent_id"`
+	Amount                float64                `json:"amount"`
+	TransactionCount      int                    `json:"transaction_count"`
+	TotalAmount           float64                `json:"total_amount"`
+	TotalTransactionCount int                    `json:"total_transaction_count"`
+	Percentage            float64                `json:"percentage"` // share of all spending in the range, by TotalAmount
+	Children              []CategorySpendingNode `json:"children,omitempty"`
+}
+
 type MonthlyTrend struct {
 	Month            string  `json:"month"`
 	Income           float64 `json:"income"`
@@ -0,0 +1,118 @@
+package models
+
+import "time"
+
+// Bill kinds
+const (
+	BillKindBill         = "bill"
+	BillKindSubscription = "subscription"
+)
+
+// Bill cycles
+const (
+	BillCycleWeekly    = "weekly"
+	BillCycleMonthly   = "monthly"
+	BillCycleQuarterly = "quarterly"
+	BillCycleYearly    = "yearly"
+)
+
+// Bill sources
+const (
+	BillSourceManual   = "manual"
+	BillSourceDetected = "detected" // confirmed from subscription detection
+)
+
+// Bill is a recurring bill or subscription. Expense transactions whose
+// description matches MatchDescription (and CategoryID, when set) count as
+// paying it: they move NextDueDate on and record the amount charged.
+type Bill struct {
+	ID                uint64     `json:"id" gorm:"primaryKey"`
+	UserID            uint64     `json:"user_id" gorm:"not null"`
+	HouseholdID       *uint64    `json:"household_id" gorm:"index"`
+	Name              string     `json:"name" gorm:"size:200;not null"`
+	Kind              string     `json:"kind" gorm:"type:enum('bill','subscription');default:'bill'"`
+	Payee             string     `json:"payee" gorm:"size:200"`
+	Amount            float64    `json:"amount" gorm:"type:decimal(15,2);not null"` // expected amount
+	Cycle             string     `json:"cycle" gorm:"type:enum('weekly','monthly','quarterly','yearly');not null"`
+	AnchorDate        time.Time  `json:"anchor_date" gorm:"type:date;not null"` // due dates repeat from here
+	NextDueDate       time.Time  `json:"next_due_date" gorm:"type:date;not null;index"`
+	CategoryID        *uint64    `json:"category_id"`
+	MatchDescription  string     `json:"match_description" gorm:"size:255;index"` // normalized transaction description
+	ReminderDays      int        `json:"reminder_days" gorm:"default:3"`
+	IsActive          bool       `json:"is_active" gorm:"default:true"`
+	Source            string     `json:"source" gorm:"type:enum('manual','detected');default:'manual'"`
+	LastPaidAt        *time.Time `json:"last_paid_at"`
+	LastChargedAmount *float64   `json:"last_charged_amount"` // from the latest matching transaction
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	DaysUntilDue  int          `json:"days_until_due" gorm:"-"`
+	IsOverdue     bool         `json:"is_overdue" gorm:"-"`
+	PriceIncrease *PriceChange `json:"price_increase,omitempty" gorm:"-"`
+}
+
+// DismissedSubscription hides a detected subscription from future detection
+type DismissedSubscription struct {
+	ID          uint64    `json:"id" gorm:"primaryKey"`
+	UserID      uint64    `json:"user_id" gorm:"not null;index"`
+	HouseholdID *uint64   `json:"household_id" gorm:"index"`
+	Key         string    `json:"key" gorm:"size:300;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PriceChange is a charge that went up from the amount before it
+type PriceChange struct {
+	PreviousAmount float64   `json:"previous_amount"`
+	CurrentAmount  float64   `json:"current_amount"`
+	Percent        float64   `json:"percent"`
+	ChangedAt      time.Time `json:"changed_at"`
+}
+
+// DetectedSubscription is a run of periodic, similar charges that looks like
+// a subscription and is not tracked as a bill yet
+type DetectedSubscription struct {
+	Key              string       `json:"key"` // pass back to confirm or dismiss
+	Description      string       `json:"description"`
+	MatchDescription string       `json:"match_description"`
+	CategoryID       uint64       `json:"category_id"`
+	CategoryName     string       `json:"category_name"`
+	Cycle            string       `json:"cycle"`
+	Amount           float64      `json:"amount"` // latest charge
+	AverageAmount    float64      `json:"average_amount"`
+	Occurrences      int          `json:"occurrences"`
+	FirstChargedAt   time.Time    `json:"first_charged_at"`
+	LastChargedAt    time.Time    `json:"last_charged_at"`
+	NextDueDate      time.Time    `json:"next_due_date"`
+	FromRecurring    bool         `json:"from_recurring"` // taken from a transaction marked recurring
+	PriceIncrease    *PriceChange `json:"price_increase,omitempty"`
+	TransactionIDs   []uint64     `json:"transaction_ids"`
+}
+
+// BillRequest creates or updates a bill. MatchDescription is normalized
+// before it is stored; ReminderDays defaults to the configured number of days.
+type BillRequest struct {
+	Name             string    `json:"name" validate:"required,max=200"`
+	Kind             string    `json:"kind" validate:"omitempty,oneof=bill subscription"`
+	Payee            string    `json:"payee" validate:"max=200"`
+	Amount           float64   `json:"amount" validate:"gt=0"`
+	Cycle            string    `json:"cycle" validate:"required,oneof=weekly monthly quarterly yearly"`
+	NextDueDate      time.Time `json:"next_due_date" validate:"required"`
+	CategoryID       *uint64   `json:"category_id"`
+	MatchDescription string    `json:"match_description" validate:"max=255"`
+	ReminderDays     *int      `json:"reminder_days" validate:"omitempty,min=0,max=30"`
+	IsActive         *bool     `json:"is_active"`
+}
+
+// BillConfirmRequest turns a detected subscription into a bill. Name defaults
+// to the charges' description.
+type BillConfirmRequest struct {
+	Key          string `json:"key" validate:"required"`
+	Name         string `json:"name" validate:"max=200"`
+	Kind         string `json:"kind" validate:"omitempty,oneof=bill subscription"`
+	ReminderDays *int   `json:"reminder_days" validate:"omitempty,min=0,max=30"`
+}
+
+// BillDismissRequest hides a detected subscription
+type BillDismissRequest struct {
+	Key string `json:"key" validate:"required,max=300"`
+}
@@ -0,0 +1,189 @@
+package models
+
+import "time"
+
+// Budget rollover modes: what an auto-renewing budget carries into its next period
+const (
+	BudgetRolloverNone    = "none"
+	BudgetRolloverUnspent = "unspent" // only money left over
+	BudgetRolloverFull    = "full"    // left over money, or the overspend as a deduction
+)
+
+// BudgetPeriod is a closed period of an auto-renewing budget, kept as history
+type BudgetPeriod struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	BudgetID       uint64    `json:"budget_id" gorm:"not null;index"`
+	StartDate      time.Time `json:"start_date" gorm:"not null"`
+	EndDate        time.Time `json:"end_date" gorm:"not null"`
+	Amount         float64   `json:"amount" gorm:"not null"`           // base amount of the period
+	RolloverAmount float64   `json:"rollover_amount" gorm:"default:0"` // carried in from the period before
+	SpentAmount    float64   `json:"spent_amount" gorm:"not null"`
+	CarriedOver    float64   `json:"carried_over" gorm:"default:0"` // passed on to the next period
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// BudgetAdherence summarizes closed budget periods that started in one month
+type BudgetAdherence struct {
+	Month         string  `json:"month"` // YYYY-MM
+	TotalLimit    float64 `json:"total_limit"`
+	TotalSpent    float64 `json:"total_spent"`
+	Budgets       int     `json:"budgets"`
+	WithinLimit   int     `json:"within_limit"`
+	AdherenceRate float64 `json:"adherence_rate"` // share of budgets kept within their limit, in percent
+}
+
+// Budget modes
+const (
+	BudgetModeLimit    = "limit"    // a spending limit for each period
+	BudgetModeEnvelope = "envelope" // spends only money assigned to it from income
+)
+
+// Envelope allocation kinds
+const (
+	EnvelopeAllocationAssign = "assign" // from or back to "to be assigned"
+	EnvelopeAllocationMove   = "move"   // between two envelopes
+	EnvelopeAllocationCover  = "cover"  // covering an overspent envelope
+)
+
+// EnvelopeAllocation is one entry in the envelope allocation ledger. Money
+// moved between envelopes is recorded as a pair of entries, one negative and
+// one positive; entries without a counterpart come from or go back to
+// "to be assigned".
+type EnvelopeAllocation struct {
+	ID            uint64    `json:"id" gorm:"primaryKey"`
+	UserID        uint64    `json:"user_id" gorm:"not null"` // who made the allocation
+	HouseholdID   *uint64   `json:"household_id" gorm:"index"`
+	BudgetID      uint64    `json:"budget_id" gorm:"not null;index"`
+	Month         string    `json:"month" gorm:"type:char(7);not null;index"` // YYYY-MM
+	Amount        float64   `json:"amount" gorm:"not null"`
+	Kind          string    `json:"kind" gorm:"type:enum('assign','move','cover');not null"`
+	CounterpartID *uint64   `json:"counterpart_id"` // the other envelope of a move or cover
+	Note          string    `json:"note" gorm:"size:255"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// EnvelopeSummary is one envelope on the monthly assignment screen
+type EnvelopeSummary struct {
+	BudgetID   uint64  `json:"budget_id"`
+	Name       string  `json:"name"`
+	CategoryID *uint64 `json:"category_id"`
+	Target     float64 `json:"target"`    // the budget amount, what the envelope should get each month
+	Assigned   float64 `json:"assigned"`  // assigned this month
+	Activity   float64 `json:"activity"`  // spent this month
+	Available  float64 `json:"available"` // balance carried from earlier months plus assigned minus activity
+	Overspent  bool    `json:"overspent"`
+}
+
+// EnvelopeMonthResponse is the monthly assignment screen
+type EnvelopeMonthResponse struct {
+	Month        string            `json:"month"`
+	Income       float64           `json:"income"`         // received this month
+	ToBeAssigned float64           `json:"to_be_assigned"` // income not yet given to an envelope
+	Assigned     float64           `json:"assigned"`
+	Activity     float64           `json:"activity"`
+	Available    float64           `json:"available"`
+	Overspent    float64           `json:"overspent"` // total still to be covered
+	Envelopes    []EnvelopeSummary `json:"envelopes"`
+}
+
+// EnvelopeAssignRequest assigns money to an envelope; a negative amount
+// returns it to "to be assigned"
+type EnvelopeAssignRequest struct {
+	Month    string  `json:"month" validate:"omitempty,len=7"`
+	BudgetID uint64  `json:"budget_id" validate:"required"`
+	Amount   float64 `json:"amount" validate:"required"`
+	Note     string  `json:"note" validate:"max=255"`
+}
+
+// EnvelopeMoveRequest moves money between two envelopes
+type EnvelopeMoveRequest struct {
+	Month        string  `json:"month" validate:"omitempty,len=7"`
+	FromBudgetID uint64  `json:"from_budget_id" validate:"required"`
+	ToBudgetID   uint64  `json:"to_budget_id" validate:"required,nefield=FromBudgetID"`
+	Amount       float64 `json:"amount" validate:"required,gt=0"`
+	Note         string  `json:"note" validate:"max=255"`
+}
+
+// EnvelopeCoverRequest covers an overspent envelope from another envelope, or
+// from "to be assigned" when from_budget_id is omitted. Amount defaults to the
+// whole overspend.
+type EnvelopeCoverRequest struct {
+	Month        string  `json:"month" validate:"omitempty,len=7"`
+	BudgetID     uint64  `json:"budget_id" validate:"required"`
+	FromBudgetID *uint64 `json:"from_budget_id"`
+	Amount       float64 `json:"amount" validate:"gte=0"`
+	Note         string  `json:"note" validate:"max=255"`
+}
+
+// BudgetSimulationRequest describes hypothetical changes for the what-if
+// simulator. Nothing in it is saved.
+type BudgetSimulationRequest struct {
+	CategoryChanges   []SimulatedCategoryChange   `json:"category_changes" validate:"dive"`
+	ExtraExpenses     []SimulatedCashflow         `json:"extra_expenses" validate:"dive"`
+	ExtraIncome       []SimulatedCashflow         `json:"extra_income" validate:"dive"`
+	GoalContributions []SimulatedGoalContribution `json:"goal_contributions" validate:"dive"`
+}
+
+// SimulatedCategoryChange scales spending in a category and its subcategories,
+// e.g. -20 to cut it by a fifth
+type SimulatedCategoryChange struct {
+	CategoryID    uint64  `json:"category_id" validate:"required"`
+	PercentChange float64 `json:"percent_change" validate:"gte=-100"`
+}
+
+// SimulatedCashflow is a new monthly expense or income, such as a rent increase
+type SimulatedCashflow struct {
+	Name          string  `json:"name" validate:"max=200"`
+	CategoryID    *uint64 `json:"category_id"` // counts towards budgets on this category
+	MonthlyAmount float64 `json:"monthly_amount" validate:"gt=0"`
+}
+
+// SimulatedGoalContribution adds a monthly contribution to a goal
+type SimulatedGoalContribution struct {
+	GoalID        uint64  `json:"goal_id" validate:"required"`
+	MonthlyAmount float64 `json:"monthly_amount" validate:"gt=0"`
+}
+
+// SimulatedBudget is a budget's pacing today with its projected month-end
+// spending before and after the simulated changes
+type SimulatedBudget struct {
+	BudgetPace
+	BaselineProjectedSpent float64 `json:"baseline_projected_spent"`
+	BaselineProjectedPct   float64 `json:"baseline_projected_pct"`
+	ProjectedSpent         float64 `json:"projected_spent"`
+	ProjectedPct           float64 `json:"projected_pct"`
+	WillExceed             bool    `json:"will_exceed"`
+}
+
+// SimulatedCashflowSummary is average monthly income and spending
+type SimulatedCashflowSummary struct {
+	MonthlyIncome     float64 `json:"monthly_income"`
+	MonthlyExpense    float64 `json:"monthly_expense"`
+	MonthlySavings    float64 `json:"monthly_savings"`
+	SavingsRate       float64 `json:"savings_rate"` // percent of income
+	GoalContributions float64 `json:"goal_contributions"`
+	SurplusAfterGoals float64 `json:"surplus_after_goals"`
+}
+
+// SimulatedGoal is a goal's projected completion before and after the changes
+type SimulatedGoal struct {
+	GoalID                  uint64     `json:"goal_id"`
+	Title                   string     `json:"title"`
+	TargetAmount            float64    `json:"target_amount"`
+	CurrentAmount           float64    `json:"current_amount"`
+	TargetDate              *time.Time `json:"target_date"`
+	BaselineContribution    float64    `json:"baseline_contribution"`
+	MonthlyContribution     float64    `json:"monthly_contribution"`
+	BaselineCompletionDate  *time.Time `json:"baseline_completion_date"`
+	ProjectedCompletionDate *time.Time `json:"projected_completion_date"`
+	MeetsTargetDate         *bool      `json:"meets_target_date"` // nil when the goal has no target date
+}
+
+// BudgetSimulationResponse is the result of a what-if simulation
+type BudgetSimulationResponse struct {
+	Budgets     []SimulatedBudget        `json:"budgets"`
+	Baseline    SimulatedCashflowSummary `json:"baseline"`
+	Simulated   SimulatedCashflowSummary `json:"simulated"`
+	Goals       []SimulatedGoal          `json:"goals"`
+	GeneratedAt time.Time                `json:"generated_at"`
+}
@@ -0,0 +1,131 @@
+package models
+
+import "time"
+
+// Debt kinds
+const (
+	DebtKindLoan       = "loan"
+	DebtKindCreditCard = "credit_card"
+)
+
+// Debt payoff strategies
+const (
+	DebtStrategyAvalanche = "avalanche" // highest interest rate first
+	DebtStrategySnowball  = "snowball"  // smallest balance first
+)
+
+// Debt is a loan or credit card balance being paid down. Expense and transfer
+// transactions in CategoryID count as repayments automatically.
+type Debt struct {
+	ID             uint64     `json:"id" gorm:"primaryKey"`
+	UserID         uint64     `json:"user_id" gorm:"not null"`
+	HouseholdID    *uint64    `json:"household_id" gorm:"index"`
+	Name           string     `json:"name" gorm:"size:200;not null"`
+	Kind           string     `json:"kind" gorm:"type:enum('loan','credit_card');not null"`
+	Lender         string     `json:"lender" gorm:"size:200"`
+	Principal      float64    `json:"principal" gorm:"type:decimal(15,2);not null"` // amount originally borrowed
+	Balance        float64    `json:"balance" gorm:"type:decimal(15,2);not null"`   // still owed
+	APR            float64    `json:"apr" gorm:"type:decimal(6,3);default:0"`       // annual interest rate in percent
+	MinimumPayment float64    `json:"minimum_payment" gorm:"type:decimal(15,2);not null"`
+	DueDay         int        `json:"due_day" gorm:"not null"` // day of the month; short months use their last day
+	StartDate      time.Time  `json:"start_date" gorm:"type:date;not null"`
+	CategoryID     *uint64    `json:"category_id"` // repayment category
+	PaidOffAt      *time.Time `json:"paid_off_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	NextDueDate *time.Time `json:"next_due_date" gorm:"-"` // nil once paid off
+}
+
+// DebtPayment is a repayment of a debt. Payments made automatically from a
+// transaction in the debt's category follow that transaction when it is
+// edited or deleted.
+type DebtPayment struct {
+	ID            uint64    `json:"id" gorm:"primaryKey"`
+	DebtID        uint64    `json:"debt_id" gorm:"not null;index"`
+	UserID        uint64    `json:"user_id" gorm:"not null"` // who paid
+	HouseholdID   *uint64   `json:"household_id" gorm:"index"`
+	Amount        float64   `json:"amount" gorm:"type:decimal(15,2);not null"`
+	PaidAt        time.Time `json:"paid_at" gorm:"not null"`
+	Note          string    `json:"note" gorm:"size:500"`
+	TransactionID *uint64   `json:"transaction_id" gorm:"uniqueIndex"`
+	IsAutomatic   bool      `json:"is_automatic" gorm:"default:false"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// DebtRequest creates or updates a debt. Balance defaults to the principal
+// for a new debt and is left unchanged on update when omitted.
+type DebtRequest struct {
+	Name           string    `json:"name" validate:"required,max=200"`
+	Kind           string    `json:"kind" validate:"required,oneof=loan credit_card"`
+	Lender         string    `json:"lender" validate:"max=200"`
+	Principal      float64   `json:"principal" validate:"gt=0"`
+	Balance        *float64  `json:"balance" validate:"omitempty,gte=0"`
+	APR            float64   `json:"apr" validate:"gte=0,lte=100"`
+	MinimumPayment float64   `json:"minimum_payment" validate:"gt=0"`
+	DueDay         int       `json:"due_day" validate:"min=1,max=31"`
+	StartDate      time.Time `json:"start_date" validate:"required"`
+	CategoryID     *uint64   `json:"category_id"`
+}
+
+// DebtPaymentRequest records a repayment. Date defaults to today.
+type DebtPaymentRequest struct {
+	Amount        float64    `json:"amount" validate:"gt=0"`
+	Date          *time.Time `json:"date"`
+	Note          string     `json:"note" validate:"max=500"`
+	TransactionID *uint64    `json:"transaction_id"`
+}
+
+// AmortizationRow is one monthly payment of an amortization schedule
+type AmortizationRow struct {
+	Month     int       `json:"month"`
+	DueDate   time.Time `json:"due_date"`
+	Payment   float64   `json:"payment"`
+	Interest  float64   `json:"interest"`
+	Principal float64   `json:"principal"`
+	Balance   float64   `json:"balance"` // left after the payment
+}
+
+// AmortizationSchedule pays a debt off with a fixed monthly payment
+type AmortizationSchedule struct {
+	DebtID         uint64            `json:"debt_id"`
+	Balance        float64           `json:"balance"`
+	APR            float64           `json:"apr"`
+	MonthlyPayment float64           `json:"monthly_payment"`
+	Months         int               `json:"months"`
+	TotalInterest  float64           `json:"total_interest"`
+	TotalPaid      float64           `json:"total_paid"`
+	PayoffDate     time.Time         `json:"payoff_date"`
+	Rows           []AmortizationRow `json:"rows"`
+}
+
+// DebtPayoffOrder is when one debt is cleared under a payoff strategy
+type DebtPayoffOrder struct {
+	DebtID        uint64    `json:"debt_id"`
+	Name          string    `json:"name"`
+	Months        int       `json:"months"`
+	PayoffDate    time.Time `json:"payoff_date"`
+	TotalInterest float64   `json:"total_interest"`
+}
+
+// DebtPayoffPlan pays all debts off with one monthly budget: every debt gets
+// its minimum and the rest goes to the debt the strategy picks
+type DebtPayoffPlan struct {
+	Strategy      string            `json:"strategy"`
+	Months        int               `json:"months"`
+	TotalInterest float64           `json:"total_interest"`
+	TotalPaid     float64           `json:"total_paid"`
+	PayoffDate    time.Time         `json:"payoff_date"`
+	Order         []DebtPayoffOrder `json:"order"`
+}
+
+// DebtPayoffComparison compares the avalanche and snowball strategies
+type DebtPayoffComparison struct {
+	MonthlyBudget  float64        `json:"monthly_budget"`
+	MinimumTotal   float64        `json:"minimum_total"` // sum of minimum payments
+	TotalBalance   float64        `json:"total_balance"`
+	Avalanche      DebtPayoffPlan `json:"avalanche"`
+	Snowball       DebtPayoffPlan `json:"snowball"`
+	InterestSaved  float64        `json:"interest_saved"` // by avalanche over snowball
+	Recommendation string         `json:"recommendation"` // avalanche or snowball
+}
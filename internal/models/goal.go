@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// GoalContribution is money put towards a goal. Contributions made
+// automatically from a transaction in the goal's linked category follow that
+// transaction when it is edited or deleted.
+type GoalContribution struct {
+	ID            uint64    `json:"id" gorm:"primaryKey"`
+	GoalID        uint64    `json:"goal_id" gorm:"not null;index"`
+	UserID        uint64    `json:"user_id" gorm:"not null"` // who contributed
+	HouseholdID   *uint64   `json:"household_id" gorm:"index"`
+	Amount        float64   `json:"amount" gorm:"not null"`
+	ContributedAt time.Time `json:"contributed_at" gorm:"not null"`
+	Note          string    `json:"note" gorm:"size:500"`
+	TransactionID *uint64   `json:"transaction_id" gorm:"uniqueIndex"`
+	IsAutomatic   bool      `json:"is_automatic" gorm:"default:false"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// GoalContributionRequest adds or edits a contribution. Date defaults to today.
+type GoalContributionRequest struct {
+	Amount        float64    `json:"amount" validate:"gt=0"`
+	Date          *time.Time `json:"date"`
+	Note          string     `json:"note" validate:"max=500"`
+	TransactionID *uint64    `json:"transaction_id"`
+}
+
+// Savings plan frequencies
+const (
+	SavingsPlanWeekly  = "weekly"
+	SavingsPlanMonthly = "monthly" // on the start date's day of the month, or the month's last day
+	SavingsPlanYearly  = "yearly"
+)
+
+// Savings plan run statuses
+const (
+	SavingsPlanRunCompleted = "completed"
+	SavingsPlanRunSkipped   = "skipped"
+	SavingsPlanRunFailed    = "failed"
+)
+
+// GoalSavingsPlan contributes a fixed amount to a goal on a schedule. With
+// CreateTransaction set each run also records a transfer transaction in
+// CategoryID, so the money shows up leaving the budget.
+type GoalSavingsPlan struct {
+	ID                uint64     `json:"id" gorm:"primaryKey"`
+	GoalID            uint64     `json:"goal_id" gorm:"not null;uniqueIndex"`
+	UserID            uint64     `json:"user_id" gorm:"not null"` // who set it up; runs are recorded as theirs
+	HouseholdID       *uint64    `json:"household_id" gorm:"index"`
+	Amount            float64    `json:"amount" gorm:"not null"`
+	Frequency         string     `json:"frequency" gorm:"type:enum('weekly','monthly','yearly');not null"`
+	StartDate         time.Time  `json:"start_date" gorm:"not null"`
+	NextRunAt         time.Time  `json:"next_run_at" gorm:"not null;index"`
+	LastRunAt         *time.Time `json:"last_run_at"`
+	IsActive          bool       `json:"is_active" gorm:"default:true"`
+	CreateTransaction bool       `json:"create_transaction" gorm:"default:false"`
+	CategoryID        *uint64    `json:"category_id"` // category of the transfer transactions
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	Projection *GoalSavingsPlanProjection `json:"projection,omitempty" gorm:"-"`
+}
+
+// GoalSavingsPlanRun records one scheduled run of a savings plan
+type GoalSavingsPlanRun struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	PlanID         uint64    `json:"plan_id" gorm:"not null;uniqueIndex:idx_plan_run"`
+	GoalID         uint64    `json:"goal_id" gorm:"not null;index"`
+	ScheduledFor   time.Time `json:"scheduled_for" gorm:"not null;uniqueIndex:idx_plan_run"`
+	Status         string    `json:"status" gorm:"type:enum('completed','skipped','failed');not null"`
+	Amount         float64   `json:"amount"`
+	ContributionID *uint64   `json:"contribution_id"`
+	TransactionID  *uint64   `json:"transaction_id"`
+	Reason         string    `json:"reason" gorm:"size:500"` // why the run was skipped or failed
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// GoalSavingsPlanProjection says where the plan takes the goal
+type GoalSavingsPlanProjection struct {
+	RemainingAmount         float64    `json:"remaining_amount"`
+	ProjectedCompletionDate *time.Time `json:"projected_completion_date"` // the run that reaches the target
+	RunsBeforeTargetDate    *int       `json:"runs_before_target_date"`   // nil without a target date
+	MeetsTargetDate         *bool      `json:"meets_target_date"`
+	RequiredAmount          *float64   `json:"required_amount"` // per run, to reach the target by its date
+}
+
+// GoalSavingsPlanRequest creates or replaces a goal's savings plan. StartDate
+// is the first run; IsActive defaults to true.
+type GoalSavingsPlanRequest struct {
+	Amount            float64   `json:"amount" validate:"gt=0"`
+	Frequency         string    `json:"frequency" validate:"required,oneof=weekly monthly yearly"`
+	StartDate         time.Time `json:"start_date" validate:"required"`
+	IsActive          *bool     `json:"is_active"`
+	CreateTransaction bool      `json:"create_transaction"`
+	CategoryID        *uint64   `json:"category_id" validate:"required_if=CreateTransaction true"`
+}
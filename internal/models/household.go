@@ -0,0 +1,121 @@
+package models
+
+import "time"
+
+// Household member roles. Owners manage the household and its members,
+// editors can change ledger data and viewers have read-only access.
+const (
+	HouseholdRoleOwner  = "owner"
+	HouseholdRoleEditor = "editor"
+	HouseholdRoleViewer = "viewer"
+)
+
+// Household is a shared ledger that several users can join. Transactions,
+// budgets, goals and categories with a HouseholdID belong to it rather than
+// to the user who created them.
+type Household struct {
+	ID        uint64    `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:100;not null"`
+	OwnerID   uint64    `json:"owner_id" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Members []HouseholdMember `json:"members,omitempty" gorm:"foreignKey:HouseholdID"`
+}
+
+// HouseholdMember grants a user a role in a household
+type HouseholdMember struct {
+	ID          uint64    `json:"id" gorm:"primaryKey"`
+	HouseholdID uint64    `json:"household_id" gorm:"not null;uniqueIndex:idx_household_member"`
+	UserID      uint64    `json:"user_id" gorm:"not null;uniqueIndex:idx_household_member;index"`
+	Role        string    `json:"role" gorm:"type:enum('owner','editor','viewer');not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// HouseholdInvitation lets someone join a household by email. Only the
+// SHA-256 hash of the invitation token is stored.
+type HouseholdInvitation struct {
+	ID          uint64     `json:"id" gorm:"primaryKey"`
+	HouseholdID uint64     `json:"household_id" gorm:"not null;index"`
+	Email       string     `json:"email" gorm:"size:255;not null"`
+	Role        string     `json:"role" gorm:"type:enum('editor','viewer');not null"`
+	TokenHash   string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	InvitedBy   uint64     `json:"invited_by" gorm:"not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Relations
+	Household *Household `json:"household,omitempty" gorm:"foreignKey:HouseholdID"`
+}
+
+// HouseholdCreateRequest represents the request payload for creating a household
+type HouseholdCreateRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// HouseholdUpdateRequest represents the request payload for renaming a household
+type HouseholdUpdateRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// HouseholdInviteRequest represents the request payload for inviting a member
+type HouseholdInviteRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+// HouseholdAcceptRequest represents the request payload for accepting an invitation
+type HouseholdAcceptRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// HouseholdMemberRoleRequest changes a member's role. Giving another member
+// the owner role transfers ownership and makes the previous owner an editor.
+type HouseholdMemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+// HouseholdMemberResponse represents a household member
+type HouseholdMemberResponse struct {
+	UserID    uint64    `json:"user_id"`
+	Username  string    `json:"username"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// HouseholdResponse represents a household as seen by one of its members
+type HouseholdResponse struct {
+	ID        uint64                    `json:"id"`
+	Name      string                    `json:"name"`
+	OwnerID   uint64                    `json:"owner_id"`
+	Role      string                    `json:"role"` // the caller's role
+	Members   []HouseholdMemberResponse `json:"members"`
+	CreatedAt time.Time                 `json:"created_at"`
+}
+
+// HouseholdInvitationResponse represents a pending invitation
+type HouseholdInvitationResponse struct {
+	ID          uint64    `json:"id"`
+	HouseholdID uint64    `json:"household_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	InvitedBy   uint64    `json:"invited_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// HouseholdInviteResponse includes the plaintext invitation token, which is
+// only shown once so the inviter can share it by other means
+type HouseholdInviteResponse struct {
+	HouseholdInvitationResponse
+	Token string `json:"token"`
+}
@@ -0,0 +1,153 @@
+package models
+
+import "time"
+
+// Investment asset classes
+const (
+	AssetClassStock      = "stock"
+	AssetClassETF        = "etf"
+	AssetClassFund       = "fund"
+	AssetClassBond       = "bond"
+	AssetClassCrypto     = "crypto"
+	AssetClassGold       = "gold"
+	AssetClassRealEstate = "real_estate"
+	AssetClassOther      = "other"
+)
+
+// Investment trade types
+const (
+	InvestmentTradeBuy      = "buy"
+	InvestmentTradeSell     = "sell"
+	InvestmentTradeDividend = "dividend"
+)
+
+// Asset price sources
+const (
+	AssetPriceSourceCSV   = "csv"   // loaded from the configured price file
+	AssetPriceSourceAdmin = "admin" // set through the admin API
+)
+
+// InvestmentHolding is a position in one symbol. Quantity, CostBasis,
+// RealizedPL and Dividends are replayed from its trades using the average
+// cost method: a sale takes out its share of the cost basis and the rest of
+// the proceeds is realized profit.
+type InvestmentHolding struct {
+	ID          uint64    `json:"id" gorm:"primaryKey"`
+	UserID      uint64    `json:"user_id" gorm:"not null"`
+	HouseholdID *uint64   `json:"household_id" gorm:"index"`
+	GoalID      *uint64   `json:"goal_id" gorm:"index"` // investment goal the holding is saved towards
+	Symbol      string    `json:"symbol" gorm:"size:32;not null;index"`
+	Name        string    `json:"name" gorm:"size:200"`
+	AssetClass  string    `json:"asset_class" gorm:"type:enum('stock','etf','fund','bond','crypto','gold','real_estate','other');not null"`
+	Quantity    float64   `json:"quantity" gorm:"type:decimal(24,8);default:0"`
+	CostBasis   float64   `json:"cost_basis" gorm:"type:decimal(15,2);default:0"` // cost of the quantity still held
+	RealizedPL  float64   `json:"realized_pl" gorm:"type:decimal(15,2);default:0"`
+	Dividends   float64   `json:"dividends" gorm:"type:decimal(15,2);default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	AverageCost         float64    `json:"average_cost" gorm:"-"`
+	Price               *float64   `json:"price" gorm:"-"` // nil until a price is loaded for the symbol
+	PriceAsOf           *time.Time `json:"price_as_of" gorm:"-"`
+	MarketValue         float64    `json:"market_value" gorm:"-"` // cost basis while unpriced
+	UnrealizedPL        float64    `json:"unrealized_pl" gorm:"-"`
+	UnrealizedPLPercent float64    `json:"unrealized_pl_percent" gorm:"-"`
+}
+
+// InvestmentTrade is a buy, sell or dividend of a holding. Amount is the cash
+// that changed hands: price times quantity plus fees for a buy, less fees for
+// a sale, and the payout for a dividend.
+type InvestmentTrade struct {
+	ID          uint64    `json:"id" gorm:"primaryKey"`
+	HoldingID   uint64    `json:"holding_id" gorm:"not null;index"`
+	UserID      uint64    `json:"user_id" gorm:"not null"` // who recorded it
+	HouseholdID *uint64   `json:"household_id" gorm:"index"`
+	Type        string    `json:"type" gorm:"type:enum('buy','sell','dividend');not null"`
+	Quantity    float64   `json:"quantity" gorm:"type:decimal(24,8);default:0"`
+	Price       float64   `json:"price" gorm:"type:decimal(20,8);default:0"`
+	Fee         float64   `json:"fee" gorm:"type:decimal(15,2);default:0"`
+	Amount      float64   `json:"amount" gorm:"type:decimal(15,2);not null"`
+	RealizedPL  float64   `json:"realized_pl" gorm:"type:decimal(15,2);default:0"` // sales only
+	TradedAt    time.Time `json:"traded_at" gorm:"type:date;not null"`
+	Note        string    `json:"note" gorm:"size:500"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AssetPrice is the latest known price of a symbol, shared by every ledger
+type AssetPrice struct {
+	ID        uint64    `json:"id" gorm:"primaryKey"`
+	Symbol    string    `json:"symbol" gorm:"size:32;not null;uniqueIndex"`
+	Price     float64   `json:"price" gorm:"type:decimal(20,8);not null"`
+	AsOf      time.Time `json:"as_of" gorm:"type:date;not null"`
+	Source    string    `json:"source" gorm:"type:enum('csv','admin');default:'admin'"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InvestmentHoldingRequest creates or updates a holding. The symbol is stored
+// in upper case.
+type InvestmentHoldingRequest struct {
+	Symbol     string  `json:"symbol" validate:"required,max=32"`
+	Name       string  `json:"name" validate:"max=200"`
+	AssetClass string  `json:"asset_class" validate:"required,oneof=stock etf fund bond crypto gold real_estate other"`
+	GoalID     *uint64 `json:"goal_id"`
+}
+
+// InvestmentTradeRequest records a trade. Buys and sales need a quantity and
+// price; dividends need an amount. Date defaults to today.
+type InvestmentTradeRequest struct {
+	Type     string     `json:"type" validate:"required,oneof=buy sell dividend"`
+	Quantity float64    `json:"quantity" validate:"gte=0"`
+	Price    float64    `json:"price" validate:"gte=0"`
+	Fee      float64    `json:"fee" validate:"gte=0"`
+	Amount   float64    `json:"amount" validate:"gte=0"`
+	Date     *time.Time `json:"date"`
+	Note     string     `json:"note" validate:"max=500"`
+}
+
+// AssetPriceEntry sets one symbol's price. AsOf defaults to today.
+type AssetPriceEntry struct {
+	Symbol string     `json:"symbol" validate:"required,max=32"`
+	Price  float64    `json:"price" validate:"gt=0"`
+	AsOf   *time.Time `json:"as_of"`
+}
+
+// AssetPriceRequest sets the prices of several symbols
+type AssetPriceRequest struct {
+	Prices []AssetPriceEntry `json:"prices" validate:"required,min=1,dive"`
+}
+
+// PortfolioAllocation is the share of the portfolio's market value in one
+// asset class or goal
+type PortfolioAllocation struct {
+	Key         string  `json:"key"` // asset class, or goal title
+	GoalID      *uint64 `json:"goal_id,omitempty"`
+	MarketValue float64 `json:"market_value"`
+	Percent     float64 `json:"percent"`
+}
+
+// PortfolioSummary values a ledger's holdings at the latest prices
+type PortfolioSummary struct {
+	MarketValue         float64               `json:"market_value"`
+	CostBasis           float64               `json:"cost_basis"`
+	UnrealizedPL        float64               `json:"unrealized_pl"`
+	UnrealizedPLPercent float64               `json:"unrealized_pl_percent"`
+	RealizedPL          float64               `json:"realized_pl"`
+	Dividends           float64               `json:"dividends"`
+	TotalReturn         float64               `json:"total_return"` // unrealized + realized + dividends
+	Holdings            []InvestmentHolding   `json:"holdings"`
+	ByAssetClass        []PortfolioAllocation `json:"by_asset_class"`
+	ByGoal              []PortfolioAllocation `json:"by_goal"`
+	UnpricedSymbols     []string              `json:"unpriced_symbols"` // valued at cost
+}
+
+// NetWorth is what a ledger owns less what it owes. Cash is all income less
+// all expenses recorded, less what was spent on buys and plus what sales and
+// dividends paid out; transfers are left out as they move money within the
+// ledger.
+type NetWorth struct {
+	Cash        float64   `json:"cash"`
+	Investments float64   `json:"investments"` // portfolio market value
+	Debts       float64   `json:"debts"`       // outstanding debt balances
+	Total       float64   `json:"total"`
+	AsOf        time.Time `json:"as_of"`
+}
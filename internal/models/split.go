@@ -0,0 +1,155 @@
+package models
+
+import "time"
+
+// Split methods
+const (
+	SplitMethodEqual      = "equal"
+	SplitMethodPercentage = "percentage"
+	SplitMethodExact      = "exact"
+)
+
+// IOU entry types
+const (
+	IOUEntrySplit      = "split"
+	IOUEntrySettlement = "settlement"
+)
+
+// Counterparty is someone the user shares expenses with. A counterparty with
+// an email is invited to link their TabiMoney account; LinkedUserID is only
+// set once they accept, after which IOU entries are mirrored into their books
+// as well. Only the SHA-256 hash of the invitation token is stored.
+type Counterparty struct {
+	ID              uint64     `json:"id" gorm:"primaryKey"`
+	UserID          uint64     `json:"user_id" gorm:"not null;index;uniqueIndex:idx_counterparty_link"`
+	LinkedUserID    *uint64    `json:"linked_user_id" gorm:"uniqueIndex:idx_counterparty_link"`
+	Name            string     `json:"name" gorm:"size:100;not null"`
+	Email           string     `json:"email" gorm:"size:255"`
+	InviteTokenHash string     `json:"-" gorm:"size:64;index"`
+	InviteExpiresAt *time.Time `json:"invite_expires_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	InviteToken string `json:"invite_token,omitempty" gorm:"-"` // only returned when the invitation is sent
+}
+
+// SplitExpense is an expense shared between the user and one or more
+// counterparties. PaidByCounterpartyID is nil when the user paid.
+type SplitExpense struct {
+	ID                   uint64    `json:"id" gorm:"primaryKey"`
+	UserID               uint64    `json:"user_id" gorm:"not null;index"`
+	Description          string    `json:"description" gorm:"size:500;not null"`
+	TotalAmount          float64   `json:"total_amount" gorm:"type:decimal(15,2);not null"`
+	SplitMethod          string    `json:"split_method" gorm:"type:enum('equal','percentage','exact');not null"`
+	PaidByCounterpartyID *uint64   `json:"paid_by_counterparty_id"`
+	TransactionID        *uint64   `json:"transaction_id"`
+	ExpenseDate          time.Time `json:"expense_date" gorm:"type:date;not null"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+
+	// Relations
+	Shares []SplitShare `json:"shares,omitempty" gorm:"foreignKey:SplitExpenseID"`
+}
+
+// SplitShare is one participant's part of a split expense. CounterpartyID is
+// nil for the user's own share.
+type SplitShare struct {
+	ID             uint64   `json:"id" gorm:"primaryKey"`
+	SplitExpenseID uint64   `json:"split_expense_id" gorm:"not null;index"`
+	CounterpartyID *uint64  `json:"counterparty_id"`
+	Percentage     *float64 `json:"percentage" gorm:"type:decimal(5,2)"`
+	Amount         float64  `json:"amount" gorm:"type:decimal(15,2);not null"`
+}
+
+// IOUEntry moves the balance between the user and a counterparty. A positive
+// amount means the counterparty owes the user more; a negative amount means
+// the user owes the counterparty more.
+type IOUEntry struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	UserID         uint64    `json:"user_id" gorm:"not null;index:idx_iou_user_counterparty"`
+	CounterpartyID uint64    `json:"counterparty_id" gorm:"not null;index:idx_iou_user_counterparty"`
+	Amount         float64   `json:"amount" gorm:"type:decimal(15,2);not null"`
+	EntryType      string    `json:"entry_type" gorm:"type:enum('split','settlement');not null"`
+	SplitExpenseID *uint64   `json:"split_expense_id" gorm:"index"`
+	TransactionID  *uint64   `json:"transaction_id"`
+	Note           string    `json:"note" gorm:"size:500"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Relations
+	Counterparty *Counterparty `json:"counterparty,omitempty" gorm:"foreignKey:CounterpartyID"`
+}
+
+// CounterpartyCreateRequest adds a counterparty. When Email is given an
+// invitation to link their account is sent to that address.
+type CounterpartyCreateRequest struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Email string `json:"email" validate:"omitempty,email,max=255"`
+}
+
+// CounterpartyAcceptRequest accepts an invitation to link with the inviter's
+// counterparty
+type CounterpartyAcceptRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// CounterpartyUpdateRequest renames a counterparty
+type CounterpartyUpdateRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// SplitShareRequest is one counterparty's share of a split. Percentage is
+// used by the percentage method and Amount by the exact method; both are
+// ignored for equal splits.
+type SplitShareRequest struct {
+	CounterpartyID uint64  `json:"counterparty_id" validate:"required"`
+	Percentage     float64 `json:"percentage" validate:"gte=0,lte=100"`
+	Amount         float64 `json:"amount" validate:"gte=0"`
+}
+
+// SplitExpenseCreateRequest represents the request payload for splitting an
+// expense. The user's own share is given by MyPercentage or MyAmount for the
+// percentage and exact methods; for equal splits the user takes a share when
+// IncludeSelf is set. When CategoryID is given and the user paid, the full
+// amount is also recorded as an expense transaction.
+type SplitExpenseCreateRequest struct {
+	Description          string              `json:"description" validate:"required,max=500"`
+	TotalAmount          float64             `json:"total_amount" validate:"required,gt=0"`
+	SplitMethod          string              `json:"split_method" validate:"required,oneof=equal percentage exact"`
+	PaidByCounterpartyID *uint64             `json:"paid_by_counterparty_id"`
+	IncludeSelf          bool                `json:"include_self"`
+	MyPercentage         float64             `json:"my_percentage" validate:"gte=0,lte=100"`
+	MyAmount             float64             `json:"my_amount" validate:"gte=0"`
+	Shares               []SplitShareRequest `json:"shares" validate:"required,min=1,dive"`
+	ExpenseDate          string              `json:"expense_date" validate:"required"`
+	CategoryID           *uint64             `json:"category_id"`
+}
+
+// SettleUpRequest records a payment between the user and a counterparty.
+// Direction "received" means the counterparty paid the user, "paid" means the
+// user paid the counterparty. When CategoryID is given a matching income or
+// expense transaction is created, or a transfer when AsTransfer is set.
+type SettleUpRequest struct {
+	CounterpartyID uint64  `json:"counterparty_id" validate:"required"`
+	Amount         float64 `json:"amount" validate:"required,gt=0"`
+	Direction      string  `json:"direction" validate:"required,oneof=received paid"`
+	CategoryID     *uint64 `json:"category_id"`
+	AsTransfer     bool    `json:"as_transfer"`
+	Date           string  `json:"date"`
+	Note           string  `json:"note" validate:"max=500"`
+}
+
+// CounterpartyBalanceResponse is the running balance with one counterparty.
+// A positive balance means the counterparty owes the user.
+type CounterpartyBalanceResponse struct {
+	Counterparty Counterparty `json:"counterparty"`
+	Balance      float64      `json:"balance"`
+	LastActivity *time.Time   `json:"last_activity"`
+}
+
+// BalanceSummaryResponse totals the user's IOU balances
+type BalanceSummaryResponse struct {
+	OwedToYou float64                       `json:"owed_to_you"`
+	YouOwe    float64                       `json:"you_owe"`
+	Net       float64                       `json:"net"`
+	Balances  []CounterpartyBalanceResponse `json:"balances"`
+}
@@ -6,7 +6,8 @@ import (
 
 type Transaction struct {
 	ID                      uint64         `json:"id" gorm:"primaryKey"`
-	UserID                  uint64         `json:"user_id" gorm:"not null"`
+	UserID                  uint64         `json:"user_id" gorm:"not null"` // who added it
+	HouseholdID             *uint64        `json:"household_id" gorm:"index"`
 	CategoryID              uint64         `json:"category_id" gorm:"not null"`
 	Amount                  float64        `json:"amount" gorm:"not null"`
 	Description             string         `json:"description"`
@@ -34,6 +35,7 @@ type Transaction struct {
 type Category struct {
 	ID          uint64         `json:"id" gorm:"primaryKey"`
 	UserID      *uint64        `json:"user_id"`
+	HouseholdID *uint64        `json:"household_id" gorm:"index"`
 	Name        string         `json:"name" gorm:"not null"`
 	NameEn      string         `json:"name_en"`
 	Description string         `json:"description"`
@@ -54,6 +56,7 @@ type Category struct {
 type FinancialGoal struct {
 	ID            uint64     `json:"id" gorm:"primaryKey"`
 	UserID        uint64     `json:"user_id" gorm:"not null"`
+	HouseholdID   *uint64    `json:"household_id" gorm:"index"`
 	Title         string     `json:"title" gorm:"not null"`
 	Description   string     `json:"description"`
 	TargetAmount  float64    `json:"target_amount" gorm:"not null"`
@@ -63,6 +66,7 @@ type FinancialGoal struct {
 	Priority      string     `json:"priority" gorm:"type:enum('low','medium','high','urgent');default:'medium'"`
 	IsAchieved    bool       `json:"is_achieved" gorm:"default:false"`
 	AchievedAt    *time.Time `json:"achieved_at"`
+	LinkedCategoryID *uint64 `json:"linked_category_id"` // transactions in this category contribute automatically
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 	Progress      float64    `json:"progress" gorm:"-"` // Calculated field, not stored in DB
@@ -74,6 +78,7 @@ type FinancialGoal struct {
 type Budget struct {
 	ID              uint64     `json:"id" gorm:"primaryKey"`
 	UserID          uint64     `json:"user_id" gorm:"not null"`
+	HouseholdID     *uint64    `json:"household_id" gorm:"index"`
 	CategoryID      *uint64    `json:"category_id"`
 	Name            string     `json:"name" gorm:"not null"`
 	Amount          float64    `json:"amount" gorm:"not null"`
@@ -82,6 +87,11 @@ type Budget struct {
 	EndDate         time.Time  `json:"end_date" gorm:"not null"`
 	IsActive        bool       `json:"is_active" gorm:"default:true"`
 	AlertThreshold  float64    `json:"alert_threshold" gorm:"default:80.00"`
+	AlertsSnoozedUntil *time.Time `json:"alerts_snoozed_until"`
+	AutoRenew       bool       `json:"auto_renew" gorm:"default:false"` // start the next period when this one ends
+	RolloverMode    string     `json:"rollover_mode" gorm:"type:enum('none','unspent','full');default:'none'"`
+	RolloverAmount  float64    `json:"rollover_amount" gorm:"default:0"` // carried in from the previous period
+	Mode            string     `json:"mode" gorm:"type:enum('limit','envelope');default:'limit'"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 	SpentAmount     float64    `json:"spent_amount" gorm:"-"` // Calculated field
@@ -140,6 +150,7 @@ type TransactionQueryRequest struct {
 type TransactionResponse struct {
 	ID                      uint64                `json:"id"`
 	UserID                  uint64                `json:"user_id"`
+	HouseholdID             *uint64               `json:"household_id"`
 	CategoryID              uint64                `json:"category_id"`
 	Amount                  float64               `json:"amount"`
 	Description             string                `json:"description"`
@@ -164,6 +175,7 @@ type TransactionResponse struct {
 type CategoryResponse struct {
 	ID          uint64    `json:"id"`
 	UserID      *uint64   `json:"user_id"`
+	HouseholdID *uint64   `json:"household_id"`
 	Name        string    `json:"name"`
 	NameEn      string    `json:"name_en"`
 	Description string    `json:"description"`
@@ -183,6 +195,7 @@ type FinancialGoalCreateRequest struct {
 	TargetDate    *time.Time `json:"target_date"`
 	GoalType      string     `json:"goal_type" validate:"required,oneof=savings debt_payment investment purchase other"`
 	Priority      string     `json:"priority" validate:"omitempty,oneof=low medium high urgent"`
+	LinkedCategoryID *uint64 `json:"linked_category_id"`
 }
 
 // FinancialGoalUpdateRequest represents the request payload for updating a financial goal
@@ -194,6 +207,7 @@ type FinancialGoalUpdateRequest struct {
 	TargetDate    *time.Time `json:"target_date"`
 	GoalType      string     `json:"goal_type" validate:"required,oneof=savings debt_payment investment purchase other"`
 	Priority      string     `json:"priority" validate:"omitempty,oneof=low medium high urgent"`
+	LinkedCategoryID *uint64 `json:"linked_category_id"`
 }
 
 // FinancialGoalResponse represents the response payload for financial goal data
@@ -223,6 +237,9 @@ type BudgetCreateRequest struct {
 	StartDate      time.Time `json:"start_date" validate:"required"`
 	EndDate        time.Time `json:"end_date" validate:"required"`
 	AlertThreshold float64   `json:"alert_threshold" validate:"min=0,max=100"`
+	AutoRenew      bool      `json:"auto_renew"`
+	RolloverMode   string    `json:"rollover_mode" validate:"omitempty,oneof=none unspent full"`
+	Mode           string    `json:"mode" validate:"omitempty,oneof=limit envelope"` // fixed once the budget exists
 }
 
 // BudgetUpdateRequest represents the request payload for updating a budget
@@ -235,6 +252,8 @@ type BudgetUpdateRequest struct {
 	EndDate        time.Time `json:"end_date" validate:"required"`
 	IsActive       bool      `json:"is_active"`
 	AlertThreshold float64   `json:"alert_threshold" validate:"min=0,max=100"`
+	AutoRenew      bool      `json:"auto_renew"`
+	RolloverMode   string    `json:"rollover_mode" validate:"omitempty,oneof=none unspent full"`
 }
 
 // BudgetResponse represents the response payload for budget data
@@ -249,6 +268,10 @@ type BudgetResponse struct {
 	EndDate        time.Time             `json:"end_date"`
 	IsActive       bool                  `json:"is_active"`
 	AlertThreshold float64               `json:"alert_threshold"`
+	AutoRenew      bool                  `json:"auto_renew"`
+	RolloverMode   string                `json:"rollover_mode"`
+	RolloverAmount float64               `json:"rollover_amount"`
+	Mode           string                `json:"mode"`
 	CreatedAt      time.Time             `json:"created_at"`
 	UpdatedAt      time.Time             `json:"updated_at"`
 	Category       *CategoryResponse     `json:"category,omitempty"`
@@ -269,6 +292,7 @@ type BudgetInsights struct {
     Budgets                []BudgetPace        `json:"budgets"`
     ProjectedEndUsagePct   float64             `json:"projected_end_usage_pct"`
     RiskBudgetIDs          []uint64            `json:"risk_budget_ids"`
+    Adherence              []BudgetAdherence   `json:"adherence"` // closed periods of auto-renewing budgets, newest month first
 }
 
 // BudgetPace describes per-budget pacing
@@ -287,9 +311,12 @@ type BudgetPace struct {
 
 // AutoBudgetSuggestion for a single category
 type AutoBudgetSuggestion struct {
-    CategoryID   *uint64 `json:"category_id"`
-    Name         string  `json:"name"`
-    SuggestedAmt float64 `json:"suggested_amount"`
+    CategoryID   *uint64   `json:"category_id"`
+    Name         string    `json:"name"`
+    SuggestedAmt float64   `json:"suggested_amount"`
+    Basis        string    `json:"basis,omitempty"`           // percentile, seasonal or 50_30_20
+    History      []float64 `json:"history,omitempty"`         // monthly spending, oldest first
+    Explanation  string    `json:"explanation,omitempty"`
 }
 
 // AutoBudgetSuggestResponse groups suggestions
@@ -301,6 +328,9 @@ type AutoBudgetSuggestResponse struct {
     EndDate          time.Time               `json:"end_date"`
     Suggestions      []AutoBudgetSuggestion  `json:"suggestions"`
     TotalSuggested   float64                 `json:"total_suggested"`
+    GoalReserve      float64                 `json:"goal_reserve"`   // monthly contributions set aside for active goals
+    HistoryMonths    int                     `json:"history_months"` // months of history the suggestions are based on
+    Percentile       int                     `json:"percentile"`
     Notes            []string                `json:"notes"`
 }
 
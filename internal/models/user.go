@@ -6,23 +6,37 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID                  uint64         `json:"id" gorm:"primaryKey"`
-	Email               string         `json:"email" gorm:"size:191;uniqueIndex;not null"`
-	Username            string         `json:"username" gorm:"size:191;uniqueIndex;not null"`
-	PasswordHash        string         `json:"-" gorm:"not null"`
-	FirstName           string         `json:"first_name"`
-	LastName            string         `json:"last_name"`
-	Phone               string         `json:"phone"`
-	AvatarURL           string         `json:"avatar_url"`
-	IsVerified          bool           `json:"is_verified" gorm:"default:false"`
-	VerificationToken   string         `json:"-"`
-	ResetToken          string         `json:"-"`
-	ResetTokenExpiresAt *time.Time     `json:"-"`
-	LastLoginAt         *time.Time     `json:"last_login_at"`
-	CreatedAt           time.Time      `json:"created_at"`
-	UpdatedAt           time.Time      `json:"updated_at"`
-	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                         uint64         `json:"id" gorm:"primaryKey"`
+	Email                      string         `json:"email" gorm:"size:191;uniqueIndex;not null"`
+	Username                   string         `json:"username" gorm:"size:191;uniqueIndex;not null"`
+	PasswordHash               string         `json:"-" gorm:"not null"`
+	FirstName                  string         `json:"first_name"`
+	LastName                   string         `json:"last_name"`
+	Phone                      string         `json:"phone"`
+	AvatarURL                  string         `json:"avatar_url"`
+	IsVerified                 bool           `json:"is_verified" gorm:"default:false"`
+	VerificationToken          string         `json:"-" gorm:"size:64;index"`
+	VerificationTokenExpiresAt *time.Time     `json:"-"`
+	ResetToken                 string         `json:"-" gorm:"size:64;index"`
+	ResetTokenExpiresAt        *time.Time     `json:"-"`
+	TwoFactorEnabled           bool           `json:"two_factor_enabled" gorm:"default:false"`
+	TwoFactorSecret            string         `json:"-" gorm:"size:255"`
+	TwoFactorLastStep          int64          `json:"-" gorm:"default:0"` // last accepted TOTP time step, prevents code replay
+	Role                       string         `json:"role" gorm:"type:enum('user','admin');not null;default:'user'"`
+	LockedAt                   *time.Time     `json:"locked_at"` // set by an admin; locked accounts cannot sign in
+	LockReason                 string         `json:"lock_reason,omitempty" gorm:"size:255"`
+	LastLoginAt                *time.Time     `json:"last_login_at"`
+	DeletionScheduledAt        *time.Time     `json:"deletion_scheduled_at" gorm:"index"` // account is purged after this time
+	CreatedAt                  time.Time      `json:"created_at"`
+	UpdatedAt                  time.Time      `json:"updated_at"`
+	DeletedAt                  gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
 	Profile         *UserProfile     `json:"profile,omitempty" gorm:"foreignKey:UserID"`
@@ -52,22 +66,94 @@ type UserProfile struct {
 	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// UserRecoveryCode is a hashed one-time 2FA recovery code
+type UserRecoveryCode struct {
+	ID        uint64     `json:"id" gorm:"primaryKey"`
+	UserID    uint64     `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// UserSession is one signed-in device. Every refresh rotates both tokens; the
+// family ID stays the same for the lifetime of the session so reuse of an
+// already-rotated refresh token can be traced back and the whole family revoked.
 type UserSession struct {
-	ID               uint64    `json:"id" gorm:"primaryKey"`
-	UserID           uint64    `json:"user_id" gorm:"not null"`
-	TokenHash        string    `json:"-" gorm:"size:191;not null;index"`
-	RefreshTokenHash string    `json:"-" gorm:"size:191;not null"`
-	ExpiresAt        time.Time `json:"expires_at" gorm:"not null"`
-	RefreshExpiresAt time.Time `json:"refresh_expires_at" gorm:"not null"`
-	UserAgent        string    `json:"user_agent"`
-	IPAddress        string    `json:"ip_address"`
-	IsActive         bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt        time.Time `json:"created_at"`
+	ID               uint64     `json:"id" gorm:"primaryKey"`
+	UserID           uint64     `json:"user_id" gorm:"not null;index"`
+	FamilyID         string     `json:"-" gorm:"size:64;index"`
+	TokenHash        string     `json:"-" gorm:"size:191;not null;index"`
+	RefreshTokenHash string     `json:"-" gorm:"size:191;not null"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null"`
+	RefreshExpiresAt time.Time  `json:"refresh_expires_at" gorm:"not null"`
+	UserAgent        string     `json:"user_agent" gorm:"size:255"`
+	IPAddress        string     `json:"ip_address" gorm:"size:45"`
+	IsActive         bool       `json:"is_active" gorm:"default:true"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	RevokedReason    string     `json:"revoked_reason,omitempty" gorm:"size:32"`
+	CreatedAt        time.Time  `json:"created_at"`
 
 	// Relations
 	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// UserIdentity links an external OIDC account (provider + subject) to a user
+type UserIdentity struct {
+	ID          uint64     `json:"id" gorm:"primaryKey"`
+	UserID      uint64     `json:"user_id" gorm:"not null;index"`
+	Provider    string     `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject     string     `json:"-" gorm:"size:191;not null;uniqueIndex:idx_identity_provider_subject"`
+	Email       string     `json:"email" gorm:"size:191"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// API key scopes
+const (
+	ScopeTransactionsRead  = "transactions:read"
+	ScopeTransactionsWrite = "transactions:write"
+	ScopeBudgetsRead       = "budgets:read"
+	ScopeAnalyticsRead     = "analytics:read"
+)
+
+// APIKey is a personal access token for scripts and integrations. Only the
+// SHA-256 hash of the key is stored; Prefix is kept to help users recognise it.
+type APIKey struct {
+	ID         uint64     `json:"id" gorm:"primaryKey"`
+	UserID     uint64     `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null"`
+	KeyHash    string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Scopes     string     `json:"-" gorm:"size:255;not null"` // space-separated
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ClientInfo identifies the device a session was created from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// StreamTicketResponse is a single-use ticket for opening a notification stream
+type StreamTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionResponse represents an active session in the device list
+type SessionResponse struct {
+	ID               uint64     `json:"id"`
+	UserAgent        string     `json:"user_agent"`
+	IPAddress        string     `json:"ip_address"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	RefreshExpiresAt time.Time  `json:"refresh_expires_at"`
+	Current          bool       `json:"current"`
+}
+
 // UserCreateRequest represents the request payload for creating a user
 type UserCreateRequest struct {
 	Email     string `json:"email" validate:"required,email"`
@@ -82,6 +168,16 @@ type UserCreateRequest struct {
 type UserLoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// CaptchaToken is required once a login response has set captcha_required
+	CaptchaToken string `json:"captcha_token"`
+}
+
+// LoginFailureResponse is returned when a sign-in attempt is rejected
+type LoginFailureResponse struct {
+	Error             string `json:"error"`
+	Message           string `json:"message"`
+	CaptchaRequired   bool   `json:"captcha_required"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"`
 }
 
 // UserUpdateRequest represents the request payload for updating user profile
@@ -105,18 +201,21 @@ type UserProfileUpdateRequest struct {
 
 // UserResponse represents the response payload for user data
 type UserResponse struct {
-	ID          uint64               `json:"id"`
-	Email       string               `json:"email"`
-	Username    string               `json:"username"`
-	FirstName   string               `json:"first_name"`
-	LastName    string               `json:"last_name"`
-	Phone       string               `json:"phone"`
-	AvatarURL   string               `json:"avatar_url"`
-	IsVerified  bool                 `json:"is_verified"`
-	LastLoginAt *time.Time           `json:"last_login_at"`
-	CreatedAt   time.Time            `json:"created_at"`
-	UpdatedAt   time.Time            `json:"updated_at"`
-	Profile     *UserProfileResponse `json:"profile,omitempty"`
+	ID                  uint64               `json:"id"`
+	Email               string               `json:"email"`
+	Username            string               `json:"username"`
+	FirstName           string               `json:"first_name"`
+	LastName            string               `json:"last_name"`
+	Phone               string               `json:"phone"`
+	AvatarURL           string               `json:"avatar_url"`
+	IsVerified          bool                 `json:"is_verified"`
+	TwoFactorEnabled    bool                 `json:"two_factor_enabled"`
+	Role                string               `json:"role"`
+	LastLoginAt         *time.Time           `json:"last_login_at"`
+	DeletionScheduledAt *time.Time           `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time            `json:"created_at"`
+	UpdatedAt           time.Time            `json:"updated_at"`
+	Profile             *UserProfileResponse `json:"profile,omitempty"`
 }
 
 // UserProfileResponse represents the response payload for user profile data
@@ -153,6 +252,11 @@ type PasswordResetConfirmRequest struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+// VerifyEmailRequest represents the request payload for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 // ChangePasswordRequest represents the request payload for changing password
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" validate:"required"`
@@ -184,3 +288,139 @@ type TelegramLinkCode struct {
 	// Relations
 	WebUser *User `json:"web_user,omitempty" gorm:"foreignKey:WebUserID"`
 }
+
+// MFAChallengeResponse is returned by login when a second factor is required
+type MFAChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// TwoFactorLoginRequest completes a login with a TOTP or recovery code
+type TwoFactorLoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code, e.g. to confirm enrollment
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// TwoFactorPasswordRequest confirms sensitive 2FA changes with the account password
+type TwoFactorPasswordRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// TwoFactorSetupResponse holds the secret to load into an authenticator app
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI, rendered as a QR code by the client
+}
+
+// TwoFactorRecoveryCodesResponse returns freshly generated recovery codes (shown once)
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorStatusResponse describes the user's 2FA state
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// OIDCProviderResponse describes a configured social login provider
+type OIDCProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OIDCAuthorizeResponse holds the provider URL the browser must be sent to
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// OIDCCallbackRequest carries the parameters the provider redirected back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// OIDCLinkRequiredResponse is returned when an external account matches an
+// existing user but cannot be linked automatically. The user must sign in and
+// confirm the link with the token. When PasswordResetRequired is set the
+// account's password was cleared and must first be reset by email.
+type OIDCLinkRequiredResponse struct {
+	LinkRequired          bool      `json:"link_required"`
+	PasswordResetRequired bool      `json:"password_reset_required"`
+	LinkToken             string    `json:"link_token"`
+	Provider              string    `json:"provider"`
+	Email                 string    `json:"email"`
+	ExpiresAt             time.Time `json:"expires_at"`
+}
+
+// OIDCLinkRequest confirms a pending account link
+type OIDCLinkRequest struct {
+	LinkToken string `json:"link_token" validate:"required"`
+}
+
+// APIKeyCreateRequest represents the request payload for creating an API key
+type APIKeyCreateRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=transactions:read transactions:write budgets:read analytics:read"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse represents an API key without its secret
+type APIKeyResponse struct {
+	ID         uint64     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyCreateResponse includes the plaintext key, which is only shown once
+type APIKeyCreateResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// Data export statuses
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportCompleted  = "completed"
+	DataExportFailed     = "failed"
+	DataExportExpired    = "expired"
+)
+
+// DataExport is a background job that bundles everything stored about a user
+// into a zip archive they can download until ExpiresAt
+type DataExport struct {
+	ID          uint64     `json:"id" gorm:"primaryKey"`
+	UserID      uint64     `json:"user_id" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"type:enum('pending','processing','completed','failed','expired');not null;default:'pending'"`
+	FilePath    string     `json:"-" gorm:"size:500"`
+	FileSize    int64      `json:"file_size"`
+	Error       string     `json:"error,omitempty" gorm:"size:500"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// AccountDeletionRequest schedules the account for deletion
+type AccountDeletionRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountDeletionResponse describes a pending account deletion
+type AccountDeletionResponse struct {
+	Scheduled   bool       `json:"scheduled"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
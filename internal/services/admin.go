@@ -0,0 +1,475 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("system category not found")
+	ErrCategoryInUse    = errors.New("category is still referenced by transactions, budgets or subcategories; deactivate it instead")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrAdminSelfAction  = errors.New("admins cannot lock or demote their own account")
+)
+
+// deliveryStatsDays is how many days of delivery counters the admin stats return
+const deliveryStatsDays = 7
+
+// AdminService backs the /admin API. Every change made through it is written
+// to the audit log.
+type AdminService struct {
+	db     *gorm.DB
+	config *config.Config
+	auth   *AuthService
+}
+
+func NewAdminService(cfg *config.Config) *AdminService {
+	return &AdminService{
+		db:     database.GetDB(),
+		config: cfg,
+		auth:   NewAuthService(cfg),
+	}
+}
+
+// ListSystemCategories returns every system category, active or not
+func (s *AdminService) ListSystemCategories() ([]models.Category, error) {
+	var categories []models.Category
+	if err := s.db.Where("is_system = ?", true).Order("sort_order ASC, name ASC").Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// CreateSystemCategory adds a category available to every user
+func (s *AdminService) CreateSystemCategory(actorID uint64, ip string, req *models.AdminCategoryRequest) (*models.Category, error) {
+	if err := s.checkParent(req.ParentID, 0); err != nil {
+		return nil, err
+	}
+
+	category := &models.Category{
+		Name:        req.Name,
+		NameEn:      req.NameEn,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		IsSystem:    true,
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	} else {
+		var last int
+		s.db.Model(&models.Category{}).Where("is_system = ?", true).Select("COALESCE(MAX(sort_order), 0)").Scan(&last)
+		category.SortOrder = last + 1
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(category).Error; err != nil {
+			return fmt.Errorf("failed to create category: %w", err)
+		}
+		return s.audit(tx, actorID, ip, "category.create", "category", &category.ID, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// UpdateSystemCategory edits a system category, including its translations
+func (s *AdminService) UpdateSystemCategory(actorID uint64, ip string, categoryID uint64, req *models.AdminCategoryRequest) (*models.Category, error) {
+	category, err := s.systemCategory(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkParent(req.ParentID, categoryID); err != nil {
+		return nil, err
+	}
+
+	category.Name = req.Name
+	category.NameEn = req.NameEn
+	category.Description = req.Description
+	category.ParentID = req.ParentID
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			return fmt.Errorf("failed to update category: %w", err)
+		}
+		return s.audit(tx, actorID, ip, "category.update", "category", &category.ID, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteSystemCategory removes a system category nothing refers to
+func (s *AdminService) DeleteSystemCategory(actorID uint64, ip string, categoryID uint64) error {
+	category, err := s.systemCategory(categoryID)
+	if err != nil {
+		return err
+	}
+
+	for _, model := range []interface{}{&models.Transaction{}, &models.Budget{}} {
+		var count int64
+		if err := s.db.Model(model).Where("category_id = ?", categoryID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check category usage: %w", err)
+		}
+		if count > 0 {
+			return ErrCategoryInUse
+		}
+	}
+	var children int64
+	if err := s.db.Model(&models.Category{}).Where("parent_id = ?", categoryID).Count(&children).Error; err != nil {
+		return fmt.Errorf("failed to check category usage: %w", err)
+	}
+	if children > 0 {
+		return ErrCategoryInUse
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(category).Error; err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+		return s.audit(tx, actorID, ip, "category.delete", "category", &categoryID, map[string]interface{}{
+			"name": category.Name,
+		})
+	})
+}
+
+// ReorderSystemCategories sets sort_order to each category's position in the list
+func (s *AdminService) ReorderSystemCategories(actorID uint64, ip string, req *models.AdminCategoryReorderRequest) error {
+	var count int64
+	if err := s.db.Model(&models.Category{}).
+		Where("id IN ? AND is_system = ?", req.CategoryIDs, true).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load categories: %w", err)
+	}
+	if count != int64(len(uniqueIDs(req.CategoryIDs))) {
+		return ErrCategoryNotFound
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range req.CategoryIDs {
+			if err := tx.Model(&models.Category{}).Where("id = ?", id).Update("sort_order", i+1).Error; err != nil {
+				return fmt.Errorf("failed to reorder categories: %w", err)
+			}
+		}
+		return s.audit(tx, actorID, ip, "category.reorder", "category", nil, req)
+	})
+}
+
+// ListUsers searches users with pagination
+func (s *AdminService) ListUsers(query *models.AdminUserQuery) ([]models.AdminUserResponse, int64, error) {
+	q := s.db.Model(&models.User{})
+	if query.Search != "" {
+		like := "%" + query.Search + "%"
+		q = q.Where("email LIKE ? OR username LIKE ? OR first_name LIKE ? OR last_name LIKE ?", like, like, like, like)
+	}
+	if query.Role != "" {
+		q = q.Where("role = ?", query.Role)
+	}
+	if query.Locked != nil {
+		if *query.Locked {
+			q = q.Where("locked_at IS NOT NULL")
+		} else {
+			q = q.Where("locked_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	var users []models.User
+	offset := (query.Page - 1) * query.Limit
+	if err := q.Order("created_at DESC").Offset(offset).Limit(query.Limit).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	responses := make([]models.AdminUserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, s.userToAdminResponse(&users[i]))
+	}
+	return responses, total, nil
+}
+
+// GetUser returns a user with their transaction count
+func (s *AdminService) GetUser(userID uint64) (*models.AdminUserResponse, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	response := s.userToAdminResponse(user)
+	return &response, nil
+}
+
+// LockUser locks an account and signs it out everywhere
+func (s *AdminService) LockUser(actorID uint64, ip string, userID uint64, reason string) (*models.AdminUserResponse, error) {
+	if actorID == userID {
+		return nil, ErrAdminSelfAction
+	}
+	if _, err := s.user(userID); err != nil {
+		return nil, err
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.auth.LockAccount(tx, userID, reason); err != nil {
+			return err
+		}
+		return s.audit(tx, actorID, ip, "user.lock", "user", &userID, map[string]interface{}{"reason": reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(userID)
+}
+
+// UnlockUser unlocks an account
+func (s *AdminService) UnlockUser(actorID uint64, ip string, userID uint64) (*models.AdminUserResponse, error) {
+	if _, err := s.user(userID); err != nil {
+		return nil, err
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.auth.UnlockAccount(tx, userID); err != nil {
+			return err
+		}
+		return s.audit(tx, actorID, ip, "user.unlock", "user", &userID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(userID)
+}
+
+// SetUserRole grants or revokes the admin role
+func (s *AdminService) SetUserRole(actorID uint64, ip string, userID uint64, role string) (*models.AdminUserResponse, error) {
+	if actorID == userID && role != models.RoleAdmin {
+		return nil, ErrAdminSelfAction
+	}
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("role", role).Error; err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		return s.audit(tx, actorID, ip, "user.role", "user", &userID, map[string]interface{}{
+			"from": user.Role,
+			"to":   role,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(userID)
+}
+
+// ListAuditLogs returns audit entries, newest first
+func (s *AdminService) ListAuditLogs(query *models.AuditLogQuery) ([]models.AuditLog, int64, error) {
+	q := s.db.Model(&models.AuditLog{})
+	if query.ActorID != nil {
+		q = q.Where("actor_id = ?", *query.ActorID)
+	}
+	if query.Action != "" {
+		q = q.Where("action = ?", query.Action)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	var logs []models.AuditLog
+	offset := (query.Page - 1) * query.Limit
+	if err := q.Preload("Actor").Order("created_at DESC, id DESC").Offset(offset).Limit(query.Limit).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	return logs, total, nil
+}
+
+// RecordJobRun writes the audit entry for a manually triggered job
+func (s *AdminService) RecordJobRun(actorID uint64, ip, job string) error {
+	return s.audit(s.db, actorID, ip, "job.run", "job", nil, map[string]interface{}{"job": job})
+}
+
+// NotificationStats summarizes stored notifications and the delivery counters
+// of the last few days
+func (s *AdminService) NotificationStats() (*models.NotificationStatsResponse, error) {
+	stats := &models.NotificationStatsResponse{
+		ByType:     map[string]int64{},
+		ByPriority: map[string]int64{},
+	}
+
+	if err := s.db.Model(&models.Notification{}).Count(&stats.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	if err := s.db.Model(&models.Notification{}).Where("is_read = ?", false).Count(&stats.Unread).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	for column, target := range map[string]map[string]int64{
+		"notification_type": stats.ByType,
+		"priority":          stats.ByPriority,
+	} {
+		var rows []struct {
+			Key   string
+			Count int64
+		}
+		if err := s.db.Model(&models.Notification{}).
+			Select(column + " AS `key`, COUNT(*) AS count").
+			Group(column).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to group notifications: %w", err)
+		}
+		for _, row := range rows {
+			target[row.Key] = row.Count
+		}
+	}
+
+	ctx := context.Background()
+	today := time.Now()
+	for i := 0; i < deliveryStatsDays; i++ {
+		day := today.AddDate(0, 0, -i)
+		delivery := models.NotificationDeliveryStats{
+			Date:     day.Format("2006-01-02"),
+			Channels: map[string]int64{},
+		}
+		for _, channel := range deliveryChannels {
+			n, err := database.GetDailyCounter(ctx, "notifications:"+channel, day)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read delivery stats: %w", err)
+			}
+			delivery.Channels[channel] = n
+		}
+		stats.Deliveries = append(stats.Deliveries, delivery)
+	}
+
+	return stats, nil
+}
+
+// ListAssetPrices lists every stored asset price
+func (s *AdminService) ListAssetPrices() ([]models.AssetPrice, error) {
+	return NewInvestmentService(s.config).ListPrices()
+}
+
+// SetAssetPrices sets the latest price of the given symbols
+func (s *AdminService) SetAssetPrices(actorID uint64, ip string, req *models.AssetPriceRequest) (int, error) {
+	return s.savePrices(actorID, ip, "price.set", req.Prices)
+}
+
+// ImportAssetPrices sets prices from an uploaded CSV of symbol,price[,date]
+func (s *AdminService) ImportAssetPrices(actorID uint64, ip string, r io.Reader) (int, error) {
+	entries, err := parsePriceCSV(r)
+	if err != nil {
+		return 0, err
+	}
+	return s.savePrices(actorID, ip, "price.import", entries)
+}
+
+// savePrices stores admin-entered prices together with their audit entry
+func (s *AdminService) savePrices(actorID uint64, ip, action string, entries []models.AssetPriceEntry) (int, error) {
+	var n int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if n, err = savePrices(tx, entries, models.AssetPriceSourceAdmin); err != nil {
+			return err
+		}
+		return s.audit(tx, actorID, ip, action, "asset_price", nil, map[string]interface{}{"count": n})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// audit writes an audit log entry within the given transaction
+func (s *AdminService) audit(tx *gorm.DB, actorID uint64, ip, action, targetType string, targetID *uint64, metadata interface{}) error {
+	metadataJSON := "{}"
+	if metadata != nil {
+		if data, err := json.Marshal(metadata); err == nil {
+			metadataJSON = string(data)
+		}
+	}
+	entry := &models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Metadata:   metadataJSON,
+		IPAddress:  ip,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+func (s *AdminService) systemCategory(categoryID uint64) (*models.Category, error) {
+	var category models.Category
+	if err := s.db.Where("id = ? AND is_system = ?", categoryID, true).First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("failed to load category: %w", err)
+	}
+	return &category, nil
+}
+
+// checkParent ensures a system category's parent is another system category
+// and that the move keeps the tree acyclic and within the depth limit
+func (s *AdminService) checkParent(parentID *uint64, categoryID uint64) error {
+	systemOnly := func(db *gorm.DB) *gorm.DB { return db.Where("is_system = ?", true) }
+	return ValidateCategoryParent(s.db, systemOnly, categoryID, parentID)
+}
+
+func (s *AdminService) user(userID uint64) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	return &user, nil
+}
+
+func (s *AdminService) userToAdminResponse(user *models.User) models.AdminUserResponse {
+	response := models.AdminUserResponse{
+		UserResponse: UserToResponse(user),
+		LockedAt:     user.LockedAt,
+		LockReason:   user.LockReason,
+	}
+	s.db.Model(&models.Transaction{}).Where("user_id = ?", user.ID).Count(&response.TransactionCount)
+	return response
+}
+
+func uniqueIDs(ids []uint64) []uint64 {
+	seen := make(map[uint64]bool, len(ids))
+	unique := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -210,6 +210,9 @@ func (s *AIService) DetectAnomalies(req *models.AnomalyDetectionRequest) (*model
 		dispatcher := NewNotificationDispatcher(s.config)
 		oneWeekAgo := time.Now().AddDate(0, 0, -7)
 		for _, anomaly := range filtered {
+			if s.isDismissedAnomaly(req.UserID, anomaly.TransactionID) {
+				continue // user marked this transaction as not an anomaly
+			}
 			var recent models.Notification
 			// Requires metadata to contain transaction_id; best-effort LIKE query
 			if err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
@@ -227,18 +230,51 @@ func (s *AIService) DetectAnomalies(req *models.AnomalyDetectionRequest) (*model
 }
 
 // Category Suggestion Service
+// MarkNotAnomaly records user feedback that a flagged transaction is expected spending
+func (s *AIService) MarkNotAnomaly(userID, transactionID uint64) error {
+	var tx models.Transaction
+	if err := s.db.Where("id = ? AND user_id = ?", transactionID, userID).First(&tx).Error; err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+	if s.isDismissedAnomaly(userID, transactionID) {
+		return nil
+	}
+
+	feedback := &models.AIFeedback{
+		UserID:             userID,
+		TransactionID:      &transactionID,
+		FeedbackType:       "prediction_inaccurate",
+		OriginalPrediction: s.marshalToJSON(map[string]interface{}{"analysis_type": "anomaly_detection"}),
+		UserCorrection:     s.marshalToJSON(map[string]interface{}{"is_anomaly": false}),
+	}
+	if err := s.db.Create(feedback).Error; err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	return nil
+}
+
+// isDismissedAnomaly reports whether the user already marked the transaction as not an anomaly
+func (s *AIService) isDismissedAnomaly(userID, transactionID uint64) bool {
+	var count int64
+	s.db.Model(&models.AIFeedback{}).
+		Where("user_id = ? AND transaction_id = ? AND feedback_type = ?", userID, transactionID, "prediction_inaccurate").
+		Where("JSON_UNQUOTE(JSON_EXTRACT(original_prediction, '$.analysis_type')) = ?", "anomaly_detection").
+		Count(&count)
+	return count > 0
+}
+
 func (s *AIService) SuggestCategory(req *models.CategorySuggestionRequest) (*models.CategorySuggestionResponse, error) {
 	// Get user's categories
 	var categories []models.Category
-	if err := s.db.Where("user_id = ? OR is_system = ?", req.UserID, true).
+	if err := s.db.Scopes(PersonalLedger(req.UserID).CategoryScope()).
 		Find(&categories).Error; err != nil {
 		return nil, fmt.Errorf("failed to get categories: %w", err)
 	}
 
 	// Get user's transaction history for context
 	var recentTransactions []models.Transaction
-	if err := s.db.Where("user_id = ? AND transaction_date >= ?",
-		req.UserID, time.Now().AddDate(0, -3, 0)).
+	if err := s.db.Scopes(PersonalLedger(req.UserID).Scope("")).
+		Where("transaction_date >= ?", time.Now().AddDate(0, -3, 0)).
 		Order("transaction_date DESC").
 		Limit(50).
 		Find(&recentTransactions).Error; err != nil {
@@ -393,8 +429,8 @@ func (s *AIService) AnalyzeSpendingPattern(req *models.SpendingPatternRequest) (
 
 	// Compute fast local result
 	var transactions []models.Transaction
-	query := s.db.Where("user_id = ? AND transaction_date BETWEEN ? AND ?",
-		req.UserID, req.StartDate, req.EndDate)
+	query := s.db.Scopes(PersonalLedger(req.UserID).Scope("")).
+		Where("transaction_date BETWEEN ? AND ?", req.StartDate, req.EndDate)
 	if err := query.Preload("Category").Find(&transactions).Error; err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -499,8 +535,8 @@ func (s *AIService) AnalyzeGoal(req *models.GoalAnalysisRequest) (*models.GoalAn
 	// Get user's financial data (both income and expense for recent 90 days)
 	var transactions []models.Transaction
 	windowStart := time.Now().AddDate(0, 0, -90)
-	if err := s.db.Where("user_id = ? AND transaction_date >= ?",
-		req.UserID, windowStart).
+	if err := s.db.Scopes(PersonalLedger(req.UserID).Scope("")).
+		Where("transaction_date >= ?", windowStart).
 		Find(&transactions).Error; err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -508,7 +544,7 @@ func (s *AIService) AnalyzeGoal(req *models.GoalAnalysisRequest) (*models.GoalAn
 	// Analyze goal progress
 	progress := s.calculateGoalProgress(goal, transactions)
 	onTrack := s.isGoalOnTrack(goal, progress)
-	projectedDate := s.projectGoalCompletion(goal, progress)
+	projectedDate := s.projectGoalCompletion(goal, progress, estimatedGoalContribution(goal))
 	recommendations := s.generateGoalRecommendations(goal, progress)
 	riskFactors := s.identifyGoalRiskFactors(goal, progress)
 
@@ -597,8 +633,8 @@ func (s *AIService) parseCategorySuggestionResponse(content string, userID uint6
 
 func (s *AIService) getHistoricalExpenseData(userID uint64, startDate, endDate time.Time) ([]models.Transaction, error) {
 	var transactions []models.Transaction
-	err := s.db.Where("user_id = ? AND transaction_type = ? AND transaction_date BETWEEN ? AND ?",
-		userID, "expense", startDate, endDate).
+	err := s.db.Scopes(PersonalLedger(userID).Scope("")).
+		Where("transaction_type = ? AND transaction_date BETWEEN ? AND ?", "expense", startDate, endDate).
 		Preload("Category").
 		Find(&transactions).Error
 	return transactions, err
@@ -936,7 +972,14 @@ func (s *AIService) isGoalOnTrack(goal models.FinancialGoal, progress float64) b
 	return progress >= 0.5
 }
 
-func (s *AIService) projectGoalCompletion(goal models.FinancialGoal, progress float64) *time.Time {
+// estimatedGoalContribution estimates monthly saving capacity for a goal
+// conservatively as 10% of its target per month
+func estimatedGoalContribution(goal models.FinancialGoal) float64 {
+	return goal.TargetAmount * 0.1
+}
+
+// projectGoalCompletion projects when a goal is reached at monthlyContribution per month
+func (s *AIService) projectGoalCompletion(goal models.FinancialGoal, progress float64, monthlyContribution float64) *time.Time {
 	if progress >= 1.0 || goal.TargetAmount <= 0 {
 		t := time.Now()
 		return &t
@@ -946,8 +989,6 @@ func (s *AIService) projectGoalCompletion(goal models.FinancialGoal, progress fl
 		t := time.Now()
 		return &t
 	}
-	// Estimate monthly saving capacity conservatively = 10% target/month
-	monthlyContribution := goal.TargetAmount * 0.1
 	if monthlyContribution <= 0 {
 		return nil
 	}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidPriceFile = errors.New("invalid price file")
+
+// ListPrices lists every known asset price by symbol
+func (s *InvestmentService) ListPrices() ([]models.AssetPrice, error) {
+	prices := []models.AssetPrice{}
+	if err := s.db.Order("symbol ASC").Find(&prices).Error; err != nil {
+		return nil, fmt.Errorf("failed to load prices: %w", err)
+	}
+	return prices, nil
+}
+
+// SetPrices stores the latest price of each symbol, replacing older ones
+func (s *InvestmentService) SetPrices(entries []models.AssetPriceEntry, source string) (int, error) {
+	return savePrices(s.db, entries, source)
+}
+
+// savePrices is SetPrices within the given transaction
+func savePrices(tx *gorm.DB, entries []models.AssetPriceEntry, source string) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	prices := make([]models.AssetPrice, 0, len(entries))
+	index := map[string]int{}
+	for _, entry := range entries {
+		price := models.AssetPrice{
+			Symbol: normalizeSymbol(entry.Symbol),
+			Price:  entry.Price,
+			AsOf:   contributionDate(entry.AsOf),
+			Source: source,
+		}
+		// A symbol listed twice keeps its last price
+		if i, ok := index[price.Symbol]; ok {
+			prices[i] = price
+			continue
+		}
+		index[price.Symbol] = len(prices)
+		prices = append(prices, price)
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "symbol"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "as_of", "source", "updated_at"}),
+	}).Create(&prices).Error; err != nil {
+		return 0, fmt.Errorf("failed to save prices: %w", err)
+	}
+	return len(prices), nil
+}
+
+// ImportPricesCSV stores prices read from CSV lines of symbol,price and an
+// optional YYYY-MM-DD date. A first line whose price is not a number is
+// taken as a header.
+func (s *InvestmentService) ImportPricesCSV(r io.Reader, source string) (int, error) {
+	entries, err := parsePriceCSV(r)
+	if err != nil {
+		return 0, err
+	}
+	return s.SetPrices(entries, source)
+}
+
+// LoadPriceFile imports the configured price file, if any. It is run by the
+// scheduler so that a file refreshed by an external job is picked up daily.
+func (s *InvestmentService) LoadPriceFile() error {
+	path := s.config.Investment.PricesFile
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open price file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = s.ImportPricesCSV(file, models.AssetPriceSourceCSV)
+	return err
+}
+
+func parsePriceCSV(r io.Reader) ([]models.AssetPriceEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var entries []models.AssetPriceEntry
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPriceFile, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%w: line %d: expected symbol,price[,date]", ErrInvalidPriceFile, line)
+		}
+
+		symbol := strings.TrimSpace(record[0])
+		price, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil && first {
+			continue
+		}
+		if err != nil || price <= 0 {
+			return nil, fmt.Errorf("%w: line %d: price must be a positive number", ErrInvalidPriceFile, line)
+		}
+		if symbol == "" || len(symbol) > 32 {
+			return nil, fmt.Errorf("%w: line %d: symbol must be 1-32 characters", ErrInvalidPriceFile, line)
+		}
+
+		entry := models.AssetPriceEntry{Symbol: symbol, Price: price}
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			asOf, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(record[2]), time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: date must be YYYY-MM-DD", ErrInvalidPriceFile, line)
+			}
+			entry.AsOf = &asOf
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"tabimoney/internal/config"
@@ -29,7 +31,7 @@ func NewAuthService(cfg *config.Config) *AuthService {
 }
 
 // Register creates a new user account
-func (s *AuthService) Register(req *models.UserCreateRequest) (*models.AuthResponse, error) {
+func (s *AuthService) Register(req *models.UserCreateRequest, client models.ClientInfo) (*models.AuthResponse, error) {
 	// Check if user already exists
 	var existingUser models.User
 	if err := s.db.Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser).Error; err == nil {
@@ -42,28 +44,47 @@ func (s *AuthService) Register(req *models.UserCreateRequest) (*models.AuthRespo
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	var user *models.User
-	var profile *models.UserProfile
+	user := &models.User{
+		Email:        req.Email,
+		Username:     req.Username,
+		PasswordHash: string(hashedPassword),
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		Phone:        req.Phone,
+		IsVerified:   false,
+	}
+	if err := s.createUser(user, nil); err != nil {
+		return nil, err
+	}
 
-	// Use transaction to ensure atomicity
-	err = s.db.Transaction(func(tx *gorm.DB) error {
-		// Create user
-		user = &models.User{
-			Email:        req.Email,
-			Username:     req.Username,
-			PasswordHash: string(hashedPassword),
-			FirstName:    req.FirstName,
-			LastName:     req.LastName,
-			Phone:        req.Phone,
-			IsVerified:   false,
-		}
+	response, err := s.issueLogin(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verification email is best-effort; the user can request it again
+	if err := s.SendVerificationEmail(user.ID); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	return response, nil
+}
+
+// createUser inserts a user with a default profile. afterCreate, if set, runs
+// in the same transaction.
+func (s *AuthService) createUser(user *models.User, afterCreate func(tx *gorm.DB) error) error {
+	if user.Role == "" && s.isAdminEmail(user.Email) {
+		user.Role = models.RoleAdmin
+	}
 
+	// Use transaction to ensure atomicity
+	return s.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(user).Error; err != nil {
 			return fmt.Errorf("failed to create user: %w", err)
 		}
 
 		// Create user profile
-		profile = &models.UserProfile{
+		profile := &models.UserProfile{
 			UserID:               user.ID,
 			MonthlyIncome:        0,
 			Currency:             "VND",
@@ -77,116 +98,147 @@ func (s *AuthService) Register(req *models.UserCreateRequest) (*models.AuthRespo
 			return fmt.Errorf("failed to create user profile: %w", err)
 		}
 
+		if afterCreate != nil {
+			return afterCreate(tx)
+		}
 		return nil
 	})
+}
 
-	if err != nil {
-		return nil, err
-	}
-
-	// Generate tokens
-	accessToken, refreshToken, expiresAt, err := s.generateTokens(user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+// Login authenticates a user. When two-factor authentication is enabled no
+// tokens are issued; instead an MFA challenge is returned that must be
+// completed with CompleteTwoFactorLogin. Failed attempts are counted per
+// account and IP; rejections carry a *LoginError with retry hints.
+func (s *AuthService) Login(req *models.UserLoginRequest, client models.ClientInfo) (*models.AuthResponse, *models.MFAChallengeResponse, error) {
+	keys := newLoginKeys(req.Email, client.IPAddress)
+	if err := s.checkLoginAllowed(keys, req.CaptchaToken, client.IPAddress); err != nil {
+		return nil, nil, err
 	}
 
-	// Create session
-	if err := s.createSession(user.ID, accessToken, refreshToken, expiresAt); err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
-	}
-
-	// Update last login
-	now := time.Now()
-	user.LastLoginAt = &now
-	s.db.Save(user)
-
-	return &models.AuthResponse{
-		User:         s.userToResponse(user),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    expiresAt,
-	}, nil
-}
-
-// Login authenticates a user
-func (s *AuthService) Login(req *models.UserLoginRequest) (*models.AuthResponse, error) {
 	// Find user
 	var user models.User
 	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invalid credentials")
+			return nil, nil, s.recordLoginFailure(nil, keys)
 		}
-		return nil, fmt.Errorf("failed to find user: %w", err)
+		return nil, nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
 	// Check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, nil, s.recordLoginFailure(&user, keys)
+	}
+	if user.LockedAt != nil {
+		return nil, nil, ErrAccountLocked
+	}
+
+	// With 2FA the failure counters are cleared by CompleteTwoFactorLogin, so
+	// the password alone does not reset them
+	if user.TwoFactorEnabled {
+		challenge, err := s.generateMFAToken(user.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate MFA token: %w", err)
+		}
+		return nil, challenge, nil
+	}
+	s.clearLoginFailures(keys)
+
+	response, err := s.issueLogin(&user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return response, nil, nil
+}
+
+// issueLogin starts a new session family for an authenticated user and returns its tokens
+func (s *AuthService) issueLogin(user *models.User, client models.ClientInfo) (*models.AuthResponse, error) {
+	if user.LockedAt != nil {
+		return nil, ErrAccountLocked
+	}
+
+	familyID, err := newSessionFamilyID()
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate tokens
-	accessToken, refreshToken, expiresAt, err := s.generateTokens(user.ID)
+	accessToken, refreshToken, expiresAt, err := s.generateTokens(user.ID, familyID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
 	// Create session
-	if err := s.createSession(user.ID, accessToken, refreshToken, expiresAt); err != nil {
+	newDevice := s.isNewDevice(user.ID, client)
+	if err := s.createSession(user.ID, familyID, accessToken, refreshToken, expiresAt, client); err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
+	if newDevice {
+		go func(userID uint64) {
+			if err := NewNotificationDispatcher(s.config).TriggerNewDeviceLogin(userID, client); err != nil {
+				log.Printf("Failed to send new device alert to user %d: %v", userID, err)
+			}
+		}(user.ID)
+	}
 
 	// Update last login
 	now := time.Now()
 	user.LastLoginAt = &now
-	s.db.Save(user)
+	s.db.Model(user).Update("last_login_at", now)
 
 	return &models.AuthResponse{
-		User:         s.userToResponse(&user),
+		User:         s.userToResponse(user),
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		ExpiresAt:    expiresAt,
 	}, nil
 }
 
-// RefreshToken generates new access token using refresh token
-func (s *AuthService) RefreshToken(refreshToken string) (*models.AuthResponse, error) {
-	// Parse refresh token
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWT.Secret), nil
-	})
-
-	if err != nil || !token.Valid {
-		return nil, errors.New("invalid refresh token")
+// RefreshToken rotates the token pair of a session. Each refresh token can be
+// used once; presenting an already-rotated token revokes the whole session
+// family, since either the client or an attacker is holding a stolen copy.
+func (s *AuthService) RefreshToken(refreshToken string, client models.ClientInfo) (*models.AuthResponse, error) {
+	familyID, err := s.refreshTokenFamily(refreshToken)
+	if err != nil {
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, errors.New("invalid token claims")
+	var session models.UserSession
+	if err := s.db.Where("family_id = ?", familyID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, fmt.Errorf("failed to load session: %w", err)
 	}
 
-	userID, ok := claims["user_id"].(float64)
-	if !ok {
-		return nil, errors.New("invalid user ID in token")
+	presentedHash := hashToken(refreshToken)
+	if err := checkRefreshSession(&session, presentedHash, time.Now()); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			log.Printf("Refresh token reuse detected for user %d, revoking session %d", session.UserID, session.ID)
+			if err := s.revokeSessions(s.db, "refresh_token_reuse", "id = ?", session.ID); err != nil {
+				log.Printf("Failed to revoke session %d: %v", session.ID, err)
+			}
+		}
+		return nil, err
 	}
 
 	// Find user
 	var user models.User
-	if err := s.db.First(&user, uint64(userID)).Error; err != nil {
+	if err := s.db.First(&user, session.UserID).Error; err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
+	if user.LockedAt != nil {
+		return nil, ErrAccountLocked
+	}
 
 	// Generate new tokens
-	accessToken, newRefreshToken, expiresAt, err := s.generateTokens(user.ID)
+	accessToken, newRefreshToken, expiresAt, err := s.generateTokens(user.ID, familyID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	// Update session
-	if err := s.updateSession(user.ID, accessToken, newRefreshToken, expiresAt); err != nil {
-		return nil, fmt.Errorf("failed to update session: %w", err)
+	// Rotate session; the hash condition makes concurrent use of one refresh token fail
+	if err := s.rotateSession(&session, presentedHash, accessToken, newRefreshToken, expiresAt, client); err != nil {
+		return nil, err
 	}
 
 	return &models.AuthResponse{
@@ -197,23 +249,40 @@ func (s *AuthService) RefreshToken(refreshToken string) (*models.AuthResponse, e
 	}, nil
 }
 
-// Logout invalidates user session
-func (s *AuthService) Logout(userID uint64, tokenHash string) error {
-	// Deactivate session
-	if err := s.db.Model(&models.UserSession{}).
-		Where("user_id = ? AND token_hash = ?", userID, tokenHash).
-		Update("is_active", false).Error; err != nil {
-		return fmt.Errorf("failed to deactivate session: %w", err)
+// refreshTokenFamily returns the session family a refresh token belongs to
+func (s *AuthService) refreshTokenFamily(refreshToken string) (string, error) {
+	claims, err := s.parseToken(refreshToken, "refresh")
+	if err != nil {
+		return "", errors.New("invalid refresh token")
 	}
+	familyID, _ := claims["sid"].(string)
+	if familyID == "" {
+		return "", errors.New("invalid refresh token")
+	}
+	return familyID, nil
+}
 
-	// Delete from Redis cache
-	ctx := context.Background()
-	sessionKey := fmt.Sprintf("session:%s", tokenHash)
-	if err := database.DeleteCache(ctx, sessionKey); err != nil {
-		// Log error but don't fail logout
-		fmt.Printf("Warning: failed to delete session from cache: %v\n", err)
+// checkRefreshSession decides whether a refresh token may be rotated. A token
+// that is not the family's current one has been used before, which
+// ErrRefreshTokenReused reports so the caller revokes the whole family.
+func checkRefreshSession(session *models.UserSession, presentedHash string, now time.Time) error {
+	if !session.IsActive {
+		return ErrSessionRevoked
+	}
+	if subtle.ConstantTimeCompare([]byte(presentedHash), []byte(session.RefreshTokenHash)) != 1 {
+		return ErrRefreshTokenReused
 	}
+	if now.After(session.RefreshExpiresAt) {
+		return errors.New("refresh token expired")
+	}
+	return nil
+}
 
+// Logout revokes the session the access token belongs to
+func (s *AuthService) Logout(userID uint64, accessToken string) error {
+	if err := s.revokeSessions(s.db, "logout", "user_id = ? AND token_hash = ?", userID, hashToken(accessToken)); err != nil {
+		return fmt.Errorf("failed to deactivate session: %w", err)
+	}
 	return nil
 }
 
@@ -258,10 +327,17 @@ func (s *AuthService) ValidateToken(tokenString string) (uint64, error) {
 		return uint64(userID), nil
 	}
 
+	// Revoked sessions are cached in Redis so they are rejected without a query
+	if familyID, _ := claims["sid"].(string); familyID != "" {
+		if revoked, err := database.IsSessionRevoked(context.Background(), familyID); err == nil && revoked {
+			return 0, ErrSessionRevoked
+		}
+	}
+
 	// Default: require active session for normal access tokens
 	var session models.UserSession
 	if err := s.db.Where("user_id = ? AND token_hash = ? AND is_active = ?",
-		uint64(userID), tokenString, true).First(&session).Error; err != nil {
+		uint64(userID), hashToken(tokenString), true).First(&session).Error; err != nil {
 		return 0, errors.New("session not found or inactive")
 	}
 
@@ -298,9 +374,7 @@ func (s *AuthService) ChangePassword(userID uint64, req *models.ChangePasswordRe
 	}
 
 	// Deactivate all sessions
-	if err := s.db.Model(&models.UserSession{}).
-		Where("user_id = ?", userID).
-		Update("is_active", false).Error; err != nil {
+	if err := s.revokeSessions(s.db, "password_changed", "user_id = ?", userID); err != nil {
 		return fmt.Errorf("failed to deactivate sessions: %w", err)
 	}
 
@@ -494,15 +568,26 @@ func generateRandomCode(length int) string {
 
 // Helper methods
 
-func (s *AuthService) generateTokens(userID uint64) (string, string, time.Time, error) {
+func (s *AuthService) generateTokens(userID uint64, familyID string) (string, string, time.Time, error) {
 	now := time.Now()
 	expiresAt := now.Add(s.config.GetJWTExpiration())
 	refreshExpiresAt := now.Add(s.config.GetJWTRefreshExpiration())
 
+	accessID, err := newSessionFamilyID()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	refreshID, err := newSessionFamilyID()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
 	// Access token
 	accessClaims := jwt.MapClaims{
 		"user_id": userID,
 		"type":    "access",
+		"sid":     familyID,
+		"jti":     accessID,
 		"exp":     expiresAt.Unix(),
 		"iat":     now.Unix(),
 	}
@@ -516,6 +601,8 @@ func (s *AuthService) generateTokens(userID uint64) (string, string, time.Time,
 	refreshClaims := jwt.MapClaims{
 		"user_id": userID,
 		"type":    "refresh",
+		"sid":     familyID,
+		"jti":     refreshID,
 		"exp":     refreshExpiresAt.Unix(),
 		"iat":     now.Unix(),
 	}
@@ -528,30 +615,25 @@ func (s *AuthService) generateTokens(userID uint64) (string, string, time.Time,
 	return accessTokenString, refreshTokenString, expiresAt, nil
 }
 
-func (s *AuthService) createSession(userID uint64, accessToken, refreshToken string, expiresAt time.Time) error {
+// createSession stores a new session. Tokens are stored as SHA-256 hashes.
+func (s *AuthService) createSession(userID uint64, familyID, accessToken, refreshToken string, expiresAt time.Time, client models.ClientInfo) error {
+	now := time.Now()
 	session := &models.UserSession{
 		UserID:           userID,
-		TokenHash:        accessToken,
-		RefreshTokenHash: refreshToken,
+		FamilyID:         familyID,
+		TokenHash:        hashToken(accessToken),
+		RefreshTokenHash: hashToken(refreshToken),
 		ExpiresAt:        expiresAt,
-		RefreshExpiresAt: time.Now().Add(s.config.GetJWTRefreshExpiration()),
+		RefreshExpiresAt: now.Add(s.config.GetJWTRefreshExpiration()),
+		UserAgent:        truncateString(client.UserAgent, 255),
+		IPAddress:        truncateString(client.IPAddress, 45),
 		IsActive:         true,
+		LastUsedAt:       &now,
 	}
 
 	return s.db.Create(session).Error
 }
 
-func (s *AuthService) updateSession(userID uint64, accessToken, refreshToken string, expiresAt time.Time) error {
-	return s.db.Model(&models.UserSession{}).
-		Where("user_id = ? AND is_active = ?", userID, true).
-		Updates(map[string]interface{}{
-			"token_hash":         accessToken,
-			"refresh_token_hash": refreshToken,
-			"expires_at":         expiresAt,
-			"refresh_expires_at": time.Now().Add(s.config.GetJWTRefreshExpiration()),
-		}).Error
-}
-
 func (s *AuthService) userToResponse(user *models.User) models.UserResponse {
 	return UserToResponse(user)
 }
@@ -564,16 +646,19 @@ func DB() *gorm.DB {
 // UserToResponse converts a User model to API response (exported helper)
 func UserToResponse(user *models.User) models.UserResponse {
 	return models.UserResponse{
-		ID:          user.ID,
-		Email:       user.Email,
-		Username:    user.Username,
-		FirstName:   user.FirstName,
-		LastName:    user.LastName,
-		Phone:       user.Phone,
-		AvatarURL:   user.AvatarURL,
-		IsVerified:  user.IsVerified,
-		LastLoginAt: user.LastLoginAt,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
+		ID:                  user.ID,
+		Email:               user.Email,
+		Username:            user.Username,
+		FirstName:           user.FirstName,
+		LastName:            user.LastName,
+		Phone:               user.Phone,
+		AvatarURL:           user.AvatarURL,
+		IsVerified:          user.IsVerified,
+		TwoFactorEnabled:    user.TwoFactorEnabled,
+		Role:                user.Role,
+		LastLoginAt:         user.LastLoginAt,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
 	}
 }
@@ -0,0 +1,692 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportInProgress     = errors.New("a data export is already in progress")
+	ErrExportNotFound       = errors.New("data export not found")
+	ErrExportNotReady       = errors.New("data export is not ready for download")
+	ErrDeletionNotScheduled = errors.New("account deletion is not scheduled")
+)
+
+// staleExportAge is how long an export may stay pending or processing before
+// it is assumed lost (e.g. the server restarted) and marked failed
+const staleExportAge = time.Hour
+
+// RequestDataExport queues a background job that bundles the user's data into
+// a zip archive. Only one export can run at a time.
+func (s *AuthService) RequestDataExport(userID uint64) (*models.DataExport, error) {
+	var running int64
+	if err := s.db.Model(&models.DataExport{}).
+		Where("user_id = ? AND status IN ?", userID, []string{models.DataExportPending, models.DataExportProcessing}).
+		Count(&running).Error; err != nil {
+		return nil, fmt.Errorf("failed to check exports: %w", err)
+	}
+	if running > 0 {
+		return nil, ErrExportInProgress
+	}
+
+	export := &models.DataExport{UserID: userID, Status: models.DataExportPending}
+	if err := s.db.Create(export).Error; err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	go s.runDataExport(export.ID)
+	return export, nil
+}
+
+// ListDataExports lists the user's exports, newest first
+func (s *AuthService) ListDataExports(userID uint64) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&exports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+	return exports, nil
+}
+
+// GetDataExportFile returns a completed export whose archive can be downloaded
+func (s *AuthService) GetDataExportFile(userID, exportID uint64) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := s.db.Where("id = ? AND user_id = ?", exportID, userID).First(&export).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	if export.Status != models.DataExportCompleted || (export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt)) {
+		return nil, ErrExportNotReady
+	}
+	return &export, nil
+}
+
+// runDataExport builds the archive for an export and emails the user when it is ready
+func (s *AuthService) runDataExport(exportID uint64) {
+	var export models.DataExport
+	if err := s.db.First(&export, exportID).Error; err != nil {
+		log.Printf("Failed to load data export %d: %v", exportID, err)
+		return
+	}
+	s.db.Model(&export).Update("status", models.DataExportProcessing)
+
+	path, size, err := s.writeDataExport(&export)
+	if err != nil {
+		log.Printf("Data export %d for user %d failed: %v", export.ID, export.UserID, err)
+		s.db.Model(&export).Updates(map[string]interface{}{
+			"status": models.DataExportFailed,
+			"error":  truncateString(err.Error(), 500),
+		})
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.config.GetExportExpiration())
+	if err := s.db.Model(&export).Updates(map[string]interface{}{
+		"status":       models.DataExportCompleted,
+		"file_path":    path,
+		"file_size":    size,
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	}).Error; err != nil {
+		log.Printf("Failed to complete data export %d: %v", export.ID, err)
+		os.Remove(path)
+		return
+	}
+
+	PublishUserEvent(export.UserID, "data_export_ready", map[string]interface{}{"export_id": export.ID})
+
+	var user models.User
+	if err := s.db.Preload("Profile").First(&user, export.UserID).Error; err == nil {
+		link := strings.TrimRight(s.config.Server.FrontendURL, "/") + "/settings/account?export=" + strconv.FormatUint(export.ID, 10)
+		s.sendAccountEmail(&user, "data_export", link, expiresAt)
+	}
+}
+
+// writeDataExport writes the user's data to a zip archive of JSON files, with
+// CSV copies of the tabular data
+func (s *AuthService) writeDataExport(export *models.DataExport) (string, int64, error) {
+	if err := os.MkdirAll(s.config.Account.ExportDir, 0o700); err != nil {
+		return "", 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	name, err := randomURLToken(16)
+	if err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(s.config.Account.ExportDir, fmt.Sprintf("export-%d-%s.zip", export.UserID, name))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	zw := zip.NewWriter(file)
+
+	err = s.writeDataExportFiles(zw, export.UserID)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+func (s *AuthService) writeDataExportFiles(zw *zip.Writer, userID uint64) error {
+	var user models.User
+	if err := s.db.Preload("Profile").First(&user, userID).Error; err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	var identities []models.UserIdentity
+	var apiKeys []models.APIKey
+	var sessions []models.UserSession
+	var memberships []models.HouseholdMember
+	s.db.Where("user_id = ?", userID).Find(&identities)
+	s.db.Where("user_id = ?", userID).Find(&apiKeys)
+	s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&sessions)
+	s.db.Where("user_id = ?", userID).Find(&memberships)
+
+	if err := writeZipJSON(zw, "profile.json", map[string]interface{}{
+		"user":        user,
+		"identities":  identities,
+		"api_keys":    apiKeys,
+		"sessions":    sessions,
+		"households":  memberships,
+		"exported_at": time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	var transactions []models.Transaction
+	if err := s.db.Preload("Category").Where("user_id = ?", userID).Order("transaction_date ASC, id ASC").Find(&transactions).Error; err != nil {
+		return fmt.Errorf("failed to load transactions: %w", err)
+	}
+	rows := make([][]string, 0, len(transactions))
+	for _, t := range transactions {
+		category := ""
+		if t.Category != nil {
+			category = t.Category.Name
+		}
+		rows = append(rows, []string{
+			strconv.FormatUint(t.ID, 10), t.TransactionDate.Format("2006-01-02"), t.TransactionType,
+			formatFloat(t.Amount), category, t.Description, t.Location, optionalID(t.HouseholdID),
+		})
+	}
+	if err := writeZipTable(zw, "transactions", transactions,
+		[]string{"id", "date", "type", "amount", "category", "description", "location", "household_id"}, rows); err != nil {
+		return err
+	}
+
+	var categories []models.Category
+	if err := s.db.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+		return fmt.Errorf("failed to load categories: %w", err)
+	}
+	rows = make([][]string, 0, len(categories))
+	for _, c := range categories {
+		rows = append(rows, []string{
+			strconv.FormatUint(c.ID, 10), c.Name, c.NameEn, c.Description, optionalID(c.ParentID),
+			strconv.FormatBool(c.IsActive), optionalID(c.HouseholdID),
+		})
+	}
+	if err := writeZipTable(zw, "categories", categories,
+		[]string{"id", "name", "name_en", "description", "parent_id", "is_active", "household_id"}, rows); err != nil {
+		return err
+	}
+
+	var budgets []models.Budget
+	if err := s.db.Where("user_id = ?", userID).Find(&budgets).Error; err != nil {
+		return fmt.Errorf("failed to load budgets: %w", err)
+	}
+	rows = make([][]string, 0, len(budgets))
+	for _, b := range budgets {
+		rows = append(rows, []string{
+			strconv.FormatUint(b.ID, 10), b.Name, formatFloat(b.Amount), b.Period, optionalID(b.CategoryID),
+			b.StartDate.Format("2006-01-02"), b.EndDate.Format("2006-01-02"), strconv.FormatBool(b.IsActive),
+			formatFloat(b.AlertThreshold), optionalID(b.HouseholdID),
+		})
+	}
+	if err := writeZipTable(zw, "budgets", budgets,
+		[]string{"id", "name", "amount", "period", "category_id", "start_date", "end_date", "is_active", "alert_threshold", "household_id"}, rows); err != nil {
+		return err
+	}
+
+	var goals []models.FinancialGoal
+	if err := s.db.Where("user_id = ?", userID).Find(&goals).Error; err != nil {
+		return fmt.Errorf("failed to load goals: %w", err)
+	}
+	rows = make([][]string, 0, len(goals))
+	for _, g := range goals {
+		targetDate := ""
+		if g.TargetDate != nil {
+			targetDate = g.TargetDate.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
+			strconv.FormatUint(g.ID, 10), g.Title, g.GoalType, formatFloat(g.TargetAmount), formatFloat(g.CurrentAmount),
+			targetDate, g.Priority, strconv.FormatBool(g.IsAchieved), optionalID(g.HouseholdID),
+		})
+	}
+	if err := writeZipTable(zw, "goals", goals,
+		[]string{"id", "title", "goal_type", "target_amount", "current_amount", "target_date", "priority", "is_achieved", "household_id"}, rows); err != nil {
+		return err
+	}
+
+	var contributions []models.GoalContribution
+	if err := s.db.Where("user_id = ?", userID).Order("contributed_at ASC, id ASC").Find(&contributions).Error; err != nil {
+		return fmt.Errorf("failed to load goal contributions: %w", err)
+	}
+	rows = make([][]string, 0, len(contributions))
+	for _, gc := range contributions {
+		rows = append(rows, []string{
+			strconv.FormatUint(gc.ID, 10), strconv.FormatUint(gc.GoalID, 10), gc.ContributedAt.Format("2006-01-02"),
+			formatFloat(gc.Amount), gc.Note, optionalID(gc.TransactionID), strconv.FormatBool(gc.IsAutomatic),
+		})
+	}
+	if err := writeZipTable(zw, "goal_contributions", contributions,
+		[]string{"id", "goal_id", "date", "amount", "note", "transaction_id", "is_automatic"}, rows); err != nil {
+		return err
+	}
+
+	var plans []models.GoalSavingsPlan
+	var planRuns []models.GoalSavingsPlanRun
+	s.db.Where("user_id = ?", userID).Find(&plans)
+	s.db.Where("plan_id IN (?)", s.db.Model(&models.GoalSavingsPlan{}).Select("id").Where("user_id = ?", userID)).
+		Order("scheduled_for ASC").Find(&planRuns)
+	if err := writeZipJSON(zw, "goal_savings_plans.json", map[string]interface{}{
+		"plans": plans,
+		"runs":  planRuns,
+	}); err != nil {
+		return err
+	}
+
+	var debts []models.Debt
+	var debtPayments []models.DebtPayment
+	s.db.Where("user_id = ?", userID).Find(&debts)
+	s.db.Where("user_id = ?", userID).Order("paid_at ASC").Find(&debtPayments)
+	if err := writeZipJSON(zw, "debts.json", map[string]interface{}{
+		"debts":    debts,
+		"payments": debtPayments,
+	}); err != nil {
+		return err
+	}
+
+	var bills []models.Bill
+	var dismissedSubscriptions []models.DismissedSubscription
+	s.db.Where("user_id = ?", userID).Find(&bills)
+	s.db.Where("user_id = ?", userID).Find(&dismissedSubscriptions)
+	if err := writeZipJSON(zw, "bills.json", map[string]interface{}{
+		"bills":                   bills,
+		"dismissed_subscriptions": dismissedSubscriptions,
+	}); err != nil {
+		return err
+	}
+
+	var holdings []models.InvestmentHolding
+	var trades []models.InvestmentTrade
+	s.db.Where("user_id = ?", userID).Find(&holdings)
+	s.db.Where("user_id = ?", userID).Order("traded_at ASC, id ASC").Find(&trades)
+	if err := writeZipJSON(zw, "investments.json", map[string]interface{}{
+		"holdings": holdings,
+		"trades":   trades,
+	}); err != nil {
+		return err
+	}
+
+	var notifications []models.Notification
+	if err := s.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&notifications).Error; err != nil {
+		return fmt.Errorf("failed to load notifications: %w", err)
+	}
+	rows = make([][]string, 0, len(notifications))
+	for _, n := range notifications {
+		rows = append(rows, []string{
+			strconv.FormatUint(n.ID, 10), n.CreatedAt.Format(time.RFC3339), n.NotificationType, n.Priority,
+			n.Title, n.Message, strconv.FormatBool(n.IsRead), strconv.FormatBool(n.IsArchived),
+		})
+	}
+	if err := writeZipTable(zw, "notifications", notifications,
+		[]string{"id", "created_at", "type", "priority", "title", "message", "is_read", "is_archived"}, rows); err != nil {
+		return err
+	}
+
+	var analyses []models.AIAnalysis
+	if err := s.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&analyses).Error; err != nil {
+		return fmt.Errorf("failed to load AI analyses: %w", err)
+	}
+	if err := writeZipJSON(zw, "ai_analyses.json", analyses); err != nil {
+		return err
+	}
+
+	var telegramAccounts []models.TelegramAccount
+	var linkCodes []models.TelegramLinkCode
+	s.db.Where("web_user_id = ?", userID).Find(&telegramAccounts)
+	s.db.Where("web_user_id = ?", userID).Find(&linkCodes)
+	if err := writeZipJSON(zw, "telegram.json", map[string]interface{}{
+		"accounts":   telegramAccounts,
+		"link_codes": linkCodes,
+	}); err != nil {
+		return err
+	}
+
+	var counterparties []models.Counterparty
+	var splits []models.SplitExpense
+	var entries []models.IOUEntry
+	s.db.Where("user_id = ?", userID).Find(&counterparties)
+	s.db.Preload("Shares").Where("user_id = ?", userID).Find(&splits)
+	s.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&entries)
+	return writeZipJSON(zw, "splits.json", map[string]interface{}{
+		"counterparties": counterparties,
+		"split_expenses": splits,
+		"iou_entries":    entries,
+	})
+}
+
+// ScheduleAccountDeletion confirms the password and schedules the account to
+// be deleted after the grace period, or deletes it at once when there is none
+func (s *AuthService) ScheduleAccountDeletion(userID uint64, password string) (*models.AccountDeletionResponse, error) {
+	user, err := s.userWithPassword(userID, password)
+	if err != nil {
+		return nil, err
+	}
+
+	grace := s.config.GetAccountDeletionGracePeriod()
+	if grace <= 0 {
+		if err := s.PurgeAccount(userID); err != nil {
+			return nil, err
+		}
+		return &models.AccountDeletionResponse{Scheduled: false}, nil
+	}
+
+	scheduledAt := time.Now().Add(grace)
+	if err := s.db.Model(user).Update("deletion_scheduled_at", scheduledAt).Error; err != nil {
+		return nil, fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+
+	if err := s.db.Preload("Profile").First(user, userID).Error; err == nil {
+		s.sendAccountEmail(user, "account_deletion", strings.TrimRight(s.config.Server.FrontendURL, "/")+"/settings/account", scheduledAt)
+	}
+
+	return &models.AccountDeletionResponse{Scheduled: true, ScheduledAt: &scheduledAt}, nil
+}
+
+// GetAccountDeletion reports whether the account is scheduled for deletion
+func (s *AuthService) GetAccountDeletion(userID uint64) (*models.AccountDeletionResponse, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	return &models.AccountDeletionResponse{
+		Scheduled:   user.DeletionScheduledAt != nil,
+		ScheduledAt: user.DeletionScheduledAt,
+	}, nil
+}
+
+// CancelAccountDeletion keeps an account that was scheduled for deletion
+func (s *AuthService) CancelAccountDeletion(userID uint64) error {
+	result := s.db.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL", userID).
+		Update("deletion_scheduled_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("failed to cancel deletion: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeletionNotScheduled
+	}
+	return nil
+}
+
+// PurgeDueAccounts deletes every account whose grace period has ended
+func (s *AuthService) PurgeDueAccounts() error {
+	var ids []uint64
+	if err := s.db.Model(&models.User{}).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).
+		Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to find accounts to delete: %w", err)
+	}
+	for _, id := range ids {
+		if err := s.PurgeAccount(id); err != nil {
+			log.Printf("Failed to delete account %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// PurgeAccount permanently deletes a user and everything stored about them.
+// Households the user owns pass to another member, or are deleted when the
+// user is the only member; rows the user added to shared households stay in
+// those households under the owner's name. Other users keep their IOU
+// history with this user as a free-text contact.
+func (s *AuthService) PurgeAccount(userID uint64) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if err := s.releaseHouseholds(userID); err != nil {
+		return err
+	}
+
+	var families []string
+	s.db.Model(&models.UserSession{}).Where("user_id = ? AND is_active = ?", userID, true).Pluck("family_id", &families)
+	var exportFiles []string
+	s.db.Model(&models.DataExport{}).Where("user_id = ? AND file_path <> ''", userID).Pluck("file_path", &exportFiles)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var splitIDs []uint64
+		if err := tx.Model(&models.SplitExpense{}).Where("user_id = ?", userID).Pluck("id", &splitIDs).Error; err != nil {
+			return err
+		}
+		if len(splitIDs) > 0 {
+			if err := tx.Where("split_expense_id IN ?", splitIDs).Delete(&models.SplitShare{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Counterparty{}).Where("linked_user_id = ?", userID).Update("linked_user_id", nil).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.BudgetPeriod{}, &models.EnvelopeAllocation{}} {
+			if err := tx.Where("budget_id IN (?)", tx.Model(&models.Budget{}).Select("id").Where("user_id = ?", userID)).
+				Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		userGoals := tx.Model(&models.FinancialGoal{}).Select("id").Where("user_id = ?", userID)
+		for _, model := range []interface{}{&models.GoalContribution{}, &models.GoalSavingsPlanRun{}, &models.GoalSavingsPlan{}} {
+			if err := tx.Where("goal_id IN (?)", userGoals).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("plan_id IN (?)", tx.Model(&models.GoalSavingsPlan{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&models.GoalSavingsPlanRun{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("debt_id IN (?)", tx.Model(&models.Debt{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&models.DebtPayment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("holding_id IN (?)", tx.Model(&models.InvestmentHolding{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&models.InvestmentTrade{}).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{
+			&models.IOUEntry{},
+			&models.SplitExpense{},
+			&models.Counterparty{},
+			&models.Transaction{},
+			&models.Budget{},
+			&models.GoalContribution{},
+			&models.GoalSavingsPlan{},
+			&models.FinancialGoal{},
+			&models.DebtPayment{},
+			&models.Debt{},
+			&models.Bill{},
+			&models.DismissedSubscription{},
+			&models.InvestmentTrade{},
+			&models.InvestmentHolding{},
+			&models.Category{},
+			&models.Notification{},
+			&models.AIAnalysis{},
+			&models.DataExport{},
+			&models.HouseholdMember{},
+			&models.UserSession{},
+			&models.UserRecoveryCode{},
+			&models.UserIdentity{},
+			&models.APIKey{},
+			&models.UserProfile{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("invited_by = ? OR email = ?", userID, user.Email).Delete(&models.HouseholdInvitation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("web_user_id = ?", userID).Delete(&models.TelegramAccount{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("web_user_id = ?", userID).Delete(&models.TelegramLinkCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.User{}, userID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	for _, path := range exportFiles {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove export file %s: %v", path, err)
+		}
+	}
+
+	ctx := context.Background()
+	if err := database.DeleteUserCache(ctx, userID); err != nil {
+		log.Printf("Failed to clear cache for deleted user %d: %v", userID, err)
+	}
+	for _, familyID := range families {
+		if familyID == "" {
+			continue
+		}
+		if err := database.SetRevokedSession(ctx, familyID, s.config.GetJWTExpiration()); err != nil {
+			log.Printf("Failed to cache revoked session: %v", err)
+		}
+	}
+
+	log.Printf("Deleted account %d", userID)
+	return nil
+}
+
+// releaseHouseholds hands the user's households over before the account is
+// deleted: ownership passes to the longest-standing member, sole-member
+// households are deleted, and shared rows the user added are reassigned to
+// the household owner
+func (s *AuthService) releaseHouseholds(userID uint64) error {
+	var memberships []models.HouseholdMember
+	if err := s.db.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		return fmt.Errorf("failed to load households: %w", err)
+	}
+
+	households := NewHouseholdService(s.config)
+	for _, membership := range memberships {
+		ownerID := uint64(0)
+		if membership.Role == models.HouseholdRoleOwner {
+			var successor models.HouseholdMember
+			err := s.db.Where("household_id = ? AND user_id <> ?", membership.HouseholdID, userID).
+				Order("FIELD(role, 'editor', 'viewer'), created_at ASC").First(&successor).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := households.DeleteHousehold(userID, membership.HouseholdID); err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to find household successor: %w", err)
+			}
+			if _, err := households.UpdateMemberRole(userID, membership.HouseholdID, successor.UserID,
+				&models.HouseholdMemberRoleRequest{Role: models.HouseholdRoleOwner}); err != nil {
+				return err
+			}
+			ownerID = successor.UserID
+		} else {
+			var household models.Household
+			if err := s.db.First(&household, membership.HouseholdID).Error; err != nil {
+				return fmt.Errorf("failed to load household: %w", err)
+			}
+			ownerID = household.OwnerID
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			for _, model := range []interface{}{
+				&models.Transaction{},
+				&models.Budget{},
+				&models.FinancialGoal{},
+				&models.GoalContribution{},
+				&models.GoalSavingsPlan{},
+				&models.Debt{},
+				&models.DebtPayment{},
+				&models.Bill{},
+				&models.DismissedSubscription{},
+				&models.InvestmentHolding{},
+				&models.InvestmentTrade{},
+				&models.Category{},
+			} {
+				if err := tx.Model(model).Where("user_id = ? AND household_id = ?", userID, membership.HouseholdID).
+					Update("user_id", ownerID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reassign household data: %w", err)
+		}
+	}
+	return nil
+}
+
+// CleanupDataExports deletes expired export archives and fails exports that
+// never finished
+func (s *AuthService) CleanupDataExports() error {
+	var expired []models.DataExport
+	if err := s.db.Where("status = ? AND expires_at < ?", models.DataExportCompleted, time.Now()).Find(&expired).Error; err != nil {
+		return err
+	}
+	for _, export := range expired {
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove export file %s: %v", export.FilePath, err)
+			continue
+		}
+		s.db.Model(&export).Updates(map[string]interface{}{"status": models.DataExportExpired, "file_path": ""})
+	}
+
+	return s.db.Model(&models.DataExport{}).
+		Where("status IN ? AND created_at < ?", []string{models.DataExportPending, models.DataExportProcessing}, time.Now().Add(-staleExportAge)).
+		Updates(map[string]interface{}{"status": models.DataExportFailed, "error": "export did not finish"}).Error
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeZipTable writes <name>.json with the full records and <name>.csv with
+// the given columns
+func writeZipTable(zw *zip.Writer, name string, records interface{}, header []string, rows [][]string) error {
+	if err := writeZipJSON(zw, name+".json", records); err != nil {
+		return err
+	}
+	w, err := zw.Create(name + ".csv")
+	if err != nil {
+		return fmt.Errorf("failed to add %s.csv: %w", name, err)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s.csv: %w", name, err)
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func optionalID(id *uint64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(*id, 10)
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrAccountLocked = errors.New("this account has been locked, please contact support")
+
+// isAdminEmail reports whether the email is listed in ADMIN_EMAILS
+func (s *AuthService) isAdminEmail(email string) bool {
+	for _, admin := range s.config.Admin.Emails {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncAdminRoles promotes the users listed in ADMIN_EMAILS. It never demotes,
+// so admins granted through the API keep their role.
+func (s *AuthService) SyncAdminRoles() error {
+	if len(s.config.Admin.Emails) == 0 {
+		return nil
+	}
+	if err := s.db.Model(&models.User{}).
+		Where("email IN ? AND role <> ?", s.config.Admin.Emails, models.RoleAdmin).
+		Update("role", models.RoleAdmin).Error; err != nil {
+		return fmt.Errorf("failed to promote admins: %w", err)
+	}
+	return nil
+}
+
+// IsAdmin reports whether the user has the admin role and is not locked
+func (s *AuthService) IsAdmin(userID uint64) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.User{}).
+		Where("id = ? AND role = ? AND locked_at IS NULL", userID, models.RoleAdmin).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// LockAccount blocks sign-in, token refresh and API key use for a user and
+// revokes every active session, within the given transaction
+func (s *AuthService) LockAccount(tx *gorm.DB, userID uint64, reason string) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"locked_at":   time.Now(),
+		"lock_reason": truncateString(reason, 255),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+	return s.revokeSessions(tx, "account_locked", "user_id = ?", userID)
+}
+
+// UnlockAccount lets a locked user sign in again, lifting any temporary
+// lockout from failed sign-ins as well. The unlock is made within the given
+// transaction.
+func (s *AuthService) UnlockAccount(tx *gorm.DB, userID uint64) error {
+	var user models.User
+	if err := tx.Select("id", "email").First(&user, userID).Error; err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	if err := tx.Model(&user).Updates(map[string]interface{}{
+		"locked_at":   nil,
+		"lock_reason": "",
+	}).Error; err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+	s.clearLoginLockout(user.Email)
+	return nil
+}
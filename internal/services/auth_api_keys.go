@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix marks personal access tokens so they can be told apart from JWTs
+const APIKeyPrefix = "tbm_"
+
+const (
+	maxAPIKeysPerUser = 20
+	// apiKeyTouchInterval throttles last_used_at writes for busy keys
+	apiKeyTouchInterval = time.Minute
+)
+
+var ErrInvalidAPIKey = errors.New("invalid or expired API key")
+
+// CreateAPIKey issues a new API key. The plaintext key is only returned here.
+func (s *AuthService) CreateAPIKey(userID uint64, req *models.APIKeyCreateRequest) (*models.APIKeyCreateResponse, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	var count int64
+	if err := s.db.Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count API keys: %w", err)
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, fmt.Errorf("at most %d API keys are allowed", maxAPIKeysPerUser)
+	}
+
+	secret, err := randomURLToken(32)
+	if err != nil {
+		return nil, err
+	}
+	key := APIKeyPrefix + secret
+
+	apiKey := &models.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    key[:len(APIKeyPrefix)+8],
+		KeyHash:   hashToken(key),
+		Scopes:    strings.Join(uniqueStrings(req.Scopes), " "),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.db.Create(apiKey).Error; err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return &models.APIKeyCreateResponse{
+		APIKeyResponse: apiKeyToResponse(apiKey),
+		Key:            key,
+	}, nil
+}
+
+// ListAPIKeys returns the user's API keys
+func (s *AuthService) ListAPIKeys(userID uint64) ([]models.APIKeyResponse, error) {
+	var keys []models.APIKey
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	response := make([]models.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, apiKeyToResponse(&keys[i]))
+	}
+	return response, nil
+}
+
+// RevokeAPIKey deletes an API key
+func (s *AuthService) RevokeAPIKey(userID, keyID uint64) error {
+	result := s.db.Where("id = ? AND user_id = ?", keyID, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke API key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("API key not found")
+	}
+	return nil
+}
+
+// ValidateAPIKey resolves an API key to its user and scopes and records its use
+func (s *AuthService) ValidateAPIKey(key string) (uint64, []string, error) {
+	var apiKey models.APIKey
+	if err := s.db.Where("key_hash = ?", hashToken(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil, ErrInvalidAPIKey
+		}
+		return 0, nil, fmt.Errorf("failed to load API key: %w", err)
+	}
+
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		return 0, nil, ErrInvalidAPIKey
+	}
+
+	var locked int64
+	if err := s.db.Model(&models.User{}).Where("id = ? AND locked_at IS NOT NULL", apiKey.UserID).Count(&locked).Error; err != nil {
+		return 0, nil, fmt.Errorf("failed to load API key owner: %w", err)
+	}
+	if locked > 0 {
+		return 0, nil, ErrAccountLocked
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		s.db.Model(&apiKey).Update("last_used_at", now)
+	}
+
+	return apiKey.UserID, strings.Fields(apiKey.Scopes), nil
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a JWT
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+func apiKeyToResponse(key *models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     strings.Fields(key.Scopes),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
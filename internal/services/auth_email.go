@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrRateLimited is returned when an account email was requested too often
+var ErrRateLimited = errors.New("too many requests, please try again later")
+
+// accountEmailWindow is the window for AuthConfig.EmailRequestsPerHour
+const accountEmailWindow = time.Hour
+
+// ForgotPassword emails a single-use reset link. It returns nil for unknown
+// emails so the endpoint cannot be used to discover accounts.
+func (s *AuthService) ForgotPassword(req *models.PasswordResetRequest) error {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if err := s.checkAccountEmailLimit("password_reset", email); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := s.db.Preload("Profile").Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	token, hash, err := newAccountToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.config.GetResetTokenExpiration())
+	if err := s.db.Model(&user).Updates(map[string]interface{}{
+		"reset_token":            hash,
+		"reset_token_expires_at": expiresAt,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	s.sendAccountEmail(&user, "reset_password", s.frontendLink("/reset-password", token), expiresAt)
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token, signs out every
+// session and lifts a sign-in lockout
+func (s *AuthService) ResetPassword(req *models.PasswordResetConfirmRequest) error {
+	tokenHash := hashToken(req.Token)
+	var user models.User
+	if err := s.db.Where("reset_token = ? AND reset_token_expires_at > ?", tokenHash, time.Now()).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("invalid or expired reset token")
+		}
+		return fmt.Errorf("failed to validate reset token: %w", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Receiving the reset email also proves ownership of the address. The
+		// token condition lets only one of two concurrent resets use it.
+		result := tx.Model(&models.User{}).Where("id = ? AND reset_token = ?", user.ID, tokenHash).Updates(map[string]interface{}{
+			"password_hash":          string(hashedPassword),
+			"reset_token":            "",
+			"reset_token_expires_at": nil,
+			"is_verified":            true,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update password: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invalid or expired reset token")
+		}
+
+		if err := s.revokeSessions(tx, "password_reset", "user_id = ?", user.ID); err != nil {
+			return fmt.Errorf("failed to deactivate sessions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.clearLoginLockout(user.Email)
+	return nil
+}
+
+// SendVerificationEmail issues a new verification token and emails the link
+func (s *AuthService) SendVerificationEmail(userID uint64) error {
+	var user models.User
+	if err := s.db.Preload("Profile").First(&user, userID).Error; err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.IsVerified {
+		return errors.New("email is already verified")
+	}
+	if err := s.checkAccountEmailLimit("verify_email", user.Email); err != nil {
+		return err
+	}
+
+	token, hash, err := newAccountToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.config.GetVerificationTokenExpiration())
+	if err := s.db.Model(&user).Updates(map[string]interface{}{
+		"verification_token":            hash,
+		"verification_token_expires_at": expiresAt,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	s.sendAccountEmail(&user, "verify_email", s.frontendLink("/verify-email", token), expiresAt)
+	return nil
+}
+
+// VerifyEmail marks the account owning the token as verified
+func (s *AuthService) VerifyEmail(token string) (*models.UserResponse, error) {
+	var user models.User
+	if err := s.db.Where("verification_token = ? AND verification_token_expires_at > ?", hashToken(token), time.Now()).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid or expired verification token")
+		}
+		return nil, fmt.Errorf("failed to validate verification token: %w", err)
+	}
+
+	if err := s.db.Model(&user).Updates(map[string]interface{}{
+		"is_verified":                   true,
+		"verification_token":            "",
+		"verification_token_expires_at": nil,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	response := s.userToResponse(&user)
+	return &response, nil
+}
+
+// IsEmailVerified reports whether the user confirmed their email address
+func (s *AuthService) IsEmailVerified(userID uint64) (bool, error) {
+	var user models.User
+	if err := s.db.Select("id", "is_verified").First(&user, userID).Error; err != nil {
+		return false, fmt.Errorf("user not found: %w", err)
+	}
+	return user.IsVerified, nil
+}
+
+// RequiresVerifiedEmail reports whether unverified accounts are restricted
+func (s *AuthService) RequiresVerifiedEmail() bool {
+	return s.config.Auth.RequireVerifiedEmail
+}
+
+// checkAccountEmailLimit limits how often reset/verification emails go to one address
+func (s *AuthService) checkAccountEmailLimit(kind, email string) error {
+	limit := s.config.Auth.EmailRequestsPerHour
+	if limit <= 0 {
+		return nil
+	}
+
+	key := fmt.Sprintf("account_email:%s:%s", kind, hashToken(strings.ToLower(email)))
+	current, err := database.IncrementRateLimit(context.Background(), key, accountEmailWindow)
+	if err != nil {
+		// If Redis is down, allow the request but log the error
+		log.Printf("Account email rate limit check failed: %v", err)
+		return nil
+	}
+	if current > int64(limit) {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// sendAccountEmail sends a verification or reset email in the background
+func (s *AuthService) sendAccountEmail(user *models.User, kind, link string, expiresAt time.Time) {
+	go func() {
+		if err := NewEmailService().SendAccountEmail(user, kind, link, expiresAt); err != nil {
+			log.Printf("Failed to send %s email to user %d: %v", kind, user.ID, err)
+		}
+	}()
+}
+
+func (s *AuthService) frontendLink(path, token string) string {
+	return strings.TrimRight(s.config.Server.FrontendURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// newAccountToken returns a random URL-safe token and the hash stored in the database
+func newAccountToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
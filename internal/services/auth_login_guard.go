@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+)
+
+var (
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrTooManyLoginAttempts     = errors.New("too many failed sign-in attempts, please try again later")
+	ErrCaptchaRequired          = errors.New("please complete the CAPTCHA to continue")
+	ErrAccountTemporarilyLocked = errors.New("account is temporarily locked after too many failed sign-in attempts")
+	ErrLoginUnavailable         = errors.New("sign-in is temporarily unavailable, please try again later")
+)
+
+const (
+	// loginDelayAfter is how many failures an account gets before each further
+	// attempt has to wait; the wait doubles per failure up to maxLoginDelay
+	loginDelayAfter = 3
+	maxLoginDelay   = time.Minute
+)
+
+var captchaClient = &http.Client{Timeout: 10 * time.Second}
+
+// LoginError is a rejected sign-in together with what the client needs to retry
+type LoginError struct {
+	Err             error
+	CaptchaRequired bool
+	RetryAfter      time.Duration
+}
+
+func (e *LoginError) Error() string { return e.Err.Error() }
+
+func (e *LoginError) Unwrap() error { return e.Err }
+
+// loginKeys are the Redis keys tracking failed sign-ins for one attempt
+type loginKeys struct {
+	account string // failures for the email address
+	ip      string // failures from the client IP
+	delay   string // set while the account must wait before its next attempt
+	lockout string // set while the account is temporarily locked
+}
+
+func newLoginKeys(email, ip string) loginKeys {
+	email = strings.ToLower(strings.TrimSpace(email))
+	return loginKeys{
+		account: "login_failures:account:" + email,
+		ip:      "login_failures:ip:" + ip,
+		delay:   "login_delay:" + email,
+		lockout: "login_lockout:" + email,
+	}
+}
+
+// checkLoginAllowed runs before the password is checked. Counters live in
+// Redis; like checkMFAAllowed it fails closed when Redis is unavailable, since
+// failures could not be counted and the lockout would not apply.
+func (s *AuthService) checkLoginAllowed(keys loginKeys, captchaToken, ip string) error {
+	ctx := context.Background()
+
+	ttl, err := database.CacheTTL(ctx, keys.lockout)
+	if err != nil {
+		log.Printf("Failed to read login lockout: %v", err)
+		return ErrLoginUnavailable
+	}
+	if ttl > 0 {
+		return &LoginError{Err: ErrAccountTemporarilyLocked, RetryAfter: ttl}
+	}
+
+	accountFailures, err := database.GetCounter(ctx, keys.account)
+	if err != nil {
+		log.Printf("Failed to read login failures: %v", err)
+		return ErrLoginUnavailable
+	}
+	ipFailures, err := database.GetCounter(ctx, keys.ip)
+	if err != nil {
+		log.Printf("Failed to read login failures: %v", err)
+		return ErrLoginUnavailable
+	}
+
+	if max := s.config.Auth.LoginIPMaxFailures; max > 0 && ipFailures >= int64(max) {
+		ttl, _ := database.CacheTTL(ctx, keys.ip)
+		return &LoginError{Err: ErrTooManyLoginAttempts, RetryAfter: ttl}
+	}
+
+	captchaRequired := s.captchaRequired(accountFailures, ipFailures)
+	if ttl, err := database.CacheTTL(ctx, keys.delay); err == nil && ttl > 0 {
+		return &LoginError{Err: ErrTooManyLoginAttempts, CaptchaRequired: captchaRequired, RetryAfter: ttl}
+	}
+
+	if captchaRequired && s.config.Auth.CaptchaSecret != "" {
+		if err := s.verifyCaptcha(captchaToken, ip); err != nil {
+			return &LoginError{Err: ErrCaptchaRequired, CaptchaRequired: true}
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed sign-in and returns the error for the
+// client. user is nil when no account has the email; those attempts are
+// counted the same way so responses do not reveal which emails exist.
+func (s *AuthService) recordLoginFailure(user *models.User, keys loginKeys) error {
+	ctx := context.Background()
+	window := s.config.GetLoginFailureWindow()
+
+	accountFailures, err := database.IncrementRateLimit(ctx, keys.account, window)
+	if err != nil {
+		log.Printf("Failed to count login failure: %v", err)
+		return ErrInvalidCredentials
+	}
+	ipFailures, err := database.IncrementRateLimit(ctx, keys.ip, window)
+	if err != nil {
+		log.Printf("Failed to count login failure: %v", err)
+	}
+
+	loginErr := s.loginFailureResponse(accountFailures, ipFailures)
+	switch {
+	case loginErr.Err == ErrAccountTemporarilyLocked:
+		if err := database.SetCache(ctx, keys.lockout, 1, loginErr.RetryAfter); err != nil {
+			log.Printf("Failed to lock account: %v", err)
+		}
+		s.clearLoginFailures(keys)
+		if user != nil {
+			log.Printf("Temporarily locked user %d after %d failed sign-ins", user.ID, accountFailures)
+			link := strings.TrimRight(s.config.Server.FrontendURL, "/") + "/settings/security"
+			s.sendAccountEmail(user, "login_lockout", link, time.Now().Add(loginErr.RetryAfter))
+		}
+	case loginErr.RetryAfter > 0:
+		if err := database.SetCache(ctx, keys.delay, 1, loginErr.RetryAfter); err != nil {
+			log.Printf("Failed to set login delay: %v", err)
+		}
+	}
+	return loginErr
+}
+
+// loginFailureResponse decides what a failed sign-in leads to given the
+// failure counts including it: a lockout once the account reaches the
+// lockout threshold, otherwise invalid credentials with a CAPTCHA flag and,
+// past loginDelayAfter failures, a doubling wait before the next attempt
+func (s *AuthService) loginFailureResponse(accountFailures, ipFailures int64) *LoginError {
+	if after := s.config.Auth.LoginLockoutAfter; after > 0 && accountFailures >= int64(after) {
+		return &LoginError{Err: ErrAccountTemporarilyLocked, RetryAfter: s.config.GetLoginLockoutDuration()}
+	}
+
+	loginErr := &LoginError{
+		Err:             ErrInvalidCredentials,
+		CaptchaRequired: s.captchaRequired(accountFailures, ipFailures),
+	}
+	if accountFailures > loginDelayAfter {
+		delay := time.Second << uint(accountFailures-loginDelayAfter)
+		if delay > maxLoginDelay || delay <= 0 {
+			delay = maxLoginDelay
+		}
+		loginErr.RetryAfter = delay
+	}
+	return loginErr
+}
+
+// clearLoginFailures resets the account's counters after a successful sign-in.
+// The IP counter is left alone so one valid account cannot reset it.
+func (s *AuthService) clearLoginFailures(keys loginKeys) {
+	ctx := context.Background()
+	for _, key := range []string{keys.account, keys.delay} {
+		if err := database.DeleteCache(ctx, key); err != nil {
+			log.Printf("Failed to clear login failures: %v", err)
+		}
+	}
+}
+
+// clearLoginLockout lifts a temporary lockout, e.g. when an admin unlocks the account
+func (s *AuthService) clearLoginLockout(email string) {
+	keys := newLoginKeys(email, "")
+	if err := database.DeleteCache(context.Background(), keys.lockout); err != nil {
+		log.Printf("Failed to clear login lockout: %v", err)
+	}
+	s.clearLoginFailures(keys)
+}
+
+func (s *AuthService) captchaRequired(accountFailures, ipFailures int64) bool {
+	after := int64(s.config.Auth.LoginCaptchaAfter)
+	return after > 0 && (accountFailures >= after || ipFailures >= after)
+}
+
+// verifyCaptcha checks a CAPTCHA response with the configured provider. hCaptcha
+// and reCAPTCHA share the same siteverify request and response shape.
+func (s *AuthService) verifyCaptcha(token, ip string) error {
+	if token == "" {
+		return ErrCaptchaRequired
+	}
+
+	form := url.Values{
+		"secret":   {s.config.Auth.CaptchaSecret},
+		"response": {token},
+	}
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+	resp, err := captchaClient.PostForm(s.config.Auth.CaptchaVerifyURL, form)
+	if err != nil {
+		return fmt.Errorf("failed to verify CAPTCHA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode CAPTCHA response: %w", err)
+	}
+	if !result.Success {
+		return ErrCaptchaRequired
+	}
+	return nil
+}
+
+// isNewDevice reports whether the user has signed in before, but never with
+// this user agent. Checked before the new session is stored.
+func (s *AuthService) isNewDevice(userID uint64, client models.ClientInfo) bool {
+	if !s.config.Auth.NewDeviceAlerts || client.UserAgent == "" {
+		return false
+	}
+
+	var sessions, matching int64
+	if err := s.db.Model(&models.UserSession{}).Where("user_id = ?", userID).Count(&sessions).Error; err != nil || sessions == 0 {
+		return false
+	}
+	if err := s.db.Model(&models.UserSession{}).
+		Where("user_id = ? AND user_agent = ?", userID, truncateString(client.UserAgent, 255)).
+		Count(&matching).Error; err != nil {
+		return false
+	}
+	return matching == 0
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"tabimoney/internal/config"
+)
+
+func TestLoginFailureResponse(t *testing.T) {
+	tests := []struct {
+		name            string
+		captchaAfter    int
+		lockoutAfter    int
+		accountFailures int64
+		ipFailures      int64
+		wantErr         error
+		wantCaptcha     bool
+		wantRetryAfter  time.Duration
+	}{
+		{"first failure", 3, 10, 1, 1, ErrInvalidCredentials, false, 0},
+		{"captcha from account failures", 3, 10, 3, 3, ErrInvalidCredentials, true, 0},
+		{"captcha from IP failures", 3, 10, 1, 3, ErrInvalidCredentials, true, 0},
+		{"captcha disabled", 0, 10, 5, 50, ErrInvalidCredentials, false, 4 * time.Second},
+		{"first delayed failure", 3, 10, 4, 4, ErrInvalidCredentials, true, 2 * time.Second},
+		{"delay doubles", 3, 10, 5, 5, ErrInvalidCredentials, true, 4 * time.Second},
+		{"delay capped", 3, 0, 9, 9, ErrInvalidCredentials, true, maxLoginDelay},
+		{"delay capped on overflow", 3, 0, 100, 100, ErrInvalidCredentials, true, maxLoginDelay},
+		{"one failure before lockout", 3, 9, 8, 8, ErrInvalidCredentials, true, 32 * time.Second},
+		{"lockout at threshold", 3, 10, 10, 10, ErrAccountTemporarilyLocked, false, 15 * time.Minute},
+		{"lockout past threshold", 3, 10, 12, 12, ErrAccountTemporarilyLocked, false, 15 * time.Minute},
+		{"lockout ignores IP failures", 3, 10, 2, 50, ErrInvalidCredentials, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AuthService{config: &config.Config{Auth: config.AuthConfig{
+				LoginCaptchaAfter:   tt.captchaAfter,
+				LoginLockoutAfter:   tt.lockoutAfter,
+				LoginLockoutMinutes: 15,
+			}}}
+			got := s.loginFailureResponse(tt.accountFailures, tt.ipFailures)
+			if got.Err != tt.wantErr || got.CaptchaRequired != tt.wantCaptcha || got.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("loginFailureResponse(%d, %d) = {%v, captcha %v, retry %v}, want {%v, captcha %v, retry %v}",
+					tt.accountFailures, tt.ipFailures, got.Err, got.CaptchaRequired, got.RetryAfter,
+					tt.wantErr, tt.wantCaptcha, tt.wantRetryAfter)
+			}
+		})
+	}
+}
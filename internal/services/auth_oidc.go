@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOIDCStateInvalid    = errors.New("login request expired or is invalid, please try again")
+	ErrOIDCIdentityInUse   = errors.New("this external account is already linked to another user")
+	ErrOIDCEmailUnverified = errors.New("the provider did not return a verified email address")
+)
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// OIDCLoginResult is the outcome of a provider callback. Exactly one field is set.
+type OIDCLoginResult struct {
+	Auth         *models.AuthResponse
+	MFAChallenge *models.MFAChallengeResponse
+	LinkRequired *models.OIDCLinkRequiredResponse
+}
+
+// oidcState is kept in Redis between the authorize redirect and the callback
+type oidcState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// oidcPendingLink is an external identity waiting for the matching user to confirm
+type oidcPendingLink struct {
+	UserID   uint64 `json:"user_id"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+	Email    string `json:"email"`
+}
+
+// OIDCProviders lists the configured social login providers
+func (s *AuthService) OIDCProviders() []models.OIDCProviderResponse {
+	providers := make([]models.OIDCProviderResponse, 0, len(s.config.OIDC.Providers))
+	for _, p := range s.config.OIDC.Providers {
+		providers = append(providers, models.OIDCProviderResponse{Name: p.Name, DisplayName: p.DisplayName})
+	}
+	return providers
+}
+
+// StartOIDCLogin creates the state, nonce and PKCE verifier for a login and
+// returns the provider authorization URL
+func (s *AuthService) StartOIDCLogin(providerName string) (*models.OIDCAuthorizeResponse, error) {
+	provider, err := getOIDCProvider(s.config, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := randomURLToken(24)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomURLToken(24)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomURLToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(state, nonce, verifier, s.oidcRedirectURI(provider.config.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(oidcState{Provider: provider.config.Name, Nonce: nonce, CodeVerifier: verifier})
+	if err != nil {
+		return nil, err
+	}
+	ttl := s.config.GetOIDCStateTTL()
+	if err := database.SetCache(context.Background(), "oidc_state:"+state, string(payload), ttl); err != nil {
+		return nil, fmt.Errorf("failed to store login state: %w", err)
+	}
+
+	return &models.OIDCAuthorizeResponse{
+		AuthorizationURL: authURL,
+		State:            state,
+		ExpiresAt:        time.Now().Add(ttl),
+	}, nil
+}
+
+// CompleteOIDCLogin handles the provider callback. The external identity is
+// matched by provider subject first, then by email; new verified emails get a
+// new account. An existing account is only linked automatically when both the
+// provider and the account have verified the email; otherwise the link must be
+// confirmed with LinkOIDCIdentity, and an unverified account loses its local
+// password so that it has to be reclaimed through a password reset.
+func (s *AuthService) CompleteOIDCLogin(providerName string, req *models.OIDCCallbackRequest, client models.ClientInfo) (*OIDCLoginResult, error) {
+	provider, err := getOIDCProvider(s.config, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := database.TakeCache(context.Background(), "oidc_state:"+req.State)
+	if err != nil {
+		return nil, ErrOIDCStateInvalid
+	}
+	state, err := parseOIDCState(raw, provider.config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := provider.Exchange(req.Code, state.CodeVerifier, s.oidcRedirectURI(provider.config.Name))
+	if err != nil {
+		return nil, err
+	}
+	claims, err := provider.VerifyIDToken(tokens.IDToken, state.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Email == "" {
+		if info, err := provider.UserInfo(tokens.AccessToken); err == nil && info.Subject == claims.Subject {
+			claims.Email, claims.EmailVerified = info.Email, info.EmailVerified
+			if claims.GivenName == "" {
+				claims.GivenName, claims.FamilyName = info.GivenName, info.FamilyName
+			}
+		}
+	}
+
+	// Known identity
+	var identity models.UserIdentity
+	err = s.db.Where("provider = ? AND subject = ?", provider.config.Name, claims.Subject).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := s.db.First(&user, identity.UserID).Error; err != nil {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		now := time.Now()
+		s.db.Model(&identity).Update("last_login_at", now)
+		return s.finishOIDCLogin(&user, client)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load identity: %w", err)
+	}
+
+	if claims.Email == "" {
+		return nil, ErrOIDCEmailUnverified
+	}
+
+	// Existing account with the same email
+	var user models.User
+	err = s.db.Where("email = ?", claims.Email).First(&user).Error
+	if err == nil {
+		if claims.EmailVerified && user.IsVerified {
+			if err := s.db.Create(newUserIdentity(user.ID, provider.config.Name, claims)).Error; err != nil {
+				return nil, fmt.Errorf("failed to link identity: %w", err)
+			}
+			return s.finishOIDCLogin(&user, client)
+		}
+		result, err := s.pendingOIDCLink(&user, provider.config.Name, claims)
+		if err != nil {
+			return nil, err
+		}
+		// Whoever registered an unconfirmed address may not own it. The
+		// provider says the caller does, so the local password is dropped and
+		// the owner takes the account over through the password reset email,
+		// after which signing in with the provider links it automatically.
+		if claims.EmailVerified {
+			if err := s.invalidatePassword(&user, "oidc_unverified_email"); err != nil {
+				return nil, err
+			}
+			result.LinkRequired.PasswordResetRequired = true
+		}
+		return result, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	// New account
+	if !claims.EmailVerified {
+		return nil, ErrOIDCEmailUnverified
+	}
+	newUser, err := s.createOIDCUser(provider.config.Name, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.finishOIDCLogin(newUser, client)
+}
+
+// LinkOIDCIdentity confirms a pending link for the signed-in user
+func (s *AuthService) LinkOIDCIdentity(userID uint64, linkToken string) (*models.UserIdentity, error) {
+	raw, err := database.TakeCache(context.Background(), "oidc_link:"+hashToken(linkToken))
+	if err != nil {
+		return nil, errors.New("link request expired or is invalid")
+	}
+	var pending oidcPendingLink
+	if err := json.Unmarshal([]byte(raw), &pending); err != nil {
+		return nil, errors.New("link request expired or is invalid")
+	}
+	// The link token only works for the account it was issued for
+	if pending.UserID != userID {
+		return nil, errors.New("link request belongs to a different account")
+	}
+
+	var count int64
+	s.db.Model(&models.UserIdentity{}).
+		Where("provider = ? AND subject = ?", pending.Provider, pending.Subject).
+		Count(&count)
+	if count > 0 {
+		return nil, ErrOIDCIdentityInUse
+	}
+
+	identity := &models.UserIdentity{
+		UserID:   userID,
+		Provider: pending.Provider,
+		Subject:  pending.Subject,
+		Email:    pending.Email,
+	}
+	if err := s.db.Create(identity).Error; err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+	return identity, nil
+}
+
+// ListIdentities returns the external accounts linked to the user
+func (s *AuthService) ListIdentities(userID uint64) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	if err := s.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity removes a linked external account. Accounts created through
+// a provider can still sign in after a password reset.
+func (s *AuthService) UnlinkIdentity(userID, identityID uint64) error {
+	result := s.db.Where("id = ? AND user_id = ?", identityID, userID).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unlink identity: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("identity not found")
+	}
+	return nil
+}
+
+// finishOIDCLogin issues tokens, or an MFA challenge for 2FA accounts
+func (s *AuthService) finishOIDCLogin(user *models.User, client models.ClientInfo) (*OIDCLoginResult, error) {
+	if user.LockedAt != nil {
+		return nil, ErrAccountLocked
+	}
+	if user.TwoFactorEnabled {
+		challenge, err := s.generateMFAToken(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate MFA token: %w", err)
+		}
+		return &OIDCLoginResult{MFAChallenge: challenge}, nil
+	}
+
+	response, err := s.issueLogin(user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCLoginResult{Auth: response}, nil
+}
+
+func (s *AuthService) pendingOIDCLink(user *models.User, provider string, claims *OIDCClaims) (*OIDCLoginResult, error) {
+	token, err := randomURLToken(32)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(oidcPendingLink{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ttl := s.config.GetOIDCStateTTL()
+	if err := database.SetCache(context.Background(), "oidc_link:"+hashToken(token), string(payload), ttl); err != nil {
+		return nil, fmt.Errorf("failed to store link request: %w", err)
+	}
+
+	return &OIDCLoginResult{LinkRequired: &models.OIDCLinkRequiredResponse{
+		LinkRequired: true,
+		LinkToken:    token,
+		Provider:     provider,
+		Email:        claims.Email,
+		ExpiresAt:    time.Now().Add(ttl),
+	}}, nil
+}
+
+// invalidatePassword replaces the user's password with a random one and signs
+// out every session
+func (s *AuthService) invalidatePassword(user *models.User, reason string) error {
+	password, err := randomURLToken(32)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password_hash", string(hashedPassword)).Error; err != nil {
+			return fmt.Errorf("failed to reset password: %w", err)
+		}
+		if err := s.revokeSessions(tx, reason, "user_id = ?", user.ID); err != nil {
+			return fmt.Errorf("failed to deactivate sessions: %w", err)
+		}
+		return nil
+	})
+}
+
+// createOIDCUser registers a new account for a verified external identity. The
+// password is random; the user can set one with the password reset flow.
+func (s *AuthService) createOIDCUser(provider string, claims *OIDCClaims) (*models.User, error) {
+	password, err := randomURLToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	username, err := s.availableUsername(claims.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:        claims.Email,
+		Username:     username,
+		PasswordHash: string(hashedPassword),
+		FirstName:    claims.GivenName,
+		LastName:     claims.FamilyName,
+		AvatarURL:    claims.Picture,
+		IsVerified:   true,
+	}
+	err = s.createUser(user, func(tx *gorm.DB) error {
+		return tx.Create(newUserIdentity(user.ID, provider, claims)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Created user %d from %s login", user.ID, provider)
+	return user, nil
+}
+
+// availableUsername derives a unique username from the email local part
+func (s *AuthService) availableUsername(email string) (string, error) {
+	base := usernameUnsafeChars.ReplaceAllString(strings.ToLower(strings.SplitN(email, "@", 2)[0]), "")
+	if len(base) < 3 {
+		base = "user" + base
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := s.db.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", fmt.Errorf("failed to check username: %w", err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		candidate = base + "-" + hex.EncodeToString(suffix)
+	}
+	return "", errors.New("could not find an available username")
+}
+
+// parseOIDCState decodes a stored login state, which is only valid for the
+// provider that issued it
+func parseOIDCState(raw, provider string) (*oidcState, error) {
+	var state oidcState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil || state.Provider != provider {
+		return nil, ErrOIDCStateInvalid
+	}
+	if state.Nonce == "" || state.CodeVerifier == "" {
+		return nil, ErrOIDCStateInvalid
+	}
+	return &state, nil
+}
+
+func (s *AuthService) oidcRedirectURI(provider string) string {
+	return strings.ReplaceAll(s.config.OIDC.RedirectURL, "{provider}", provider)
+}
+
+func newUserIdentity(userID uint64, provider string, claims *OIDCClaims) *models.UserIdentity {
+	now := time.Now()
+	return &models.UserIdentity{
+		UserID:      userID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+}
+
+// randomURLToken returns n random bytes encoded for use in URLs
+func randomURLToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
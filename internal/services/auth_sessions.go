@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used, session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrStreamTicketInvalid = errors.New("stream ticket is invalid or has expired")
+)
+
+// streamTicketTTL is how long a stream ticket can be redeemed
+const streamTicketTTL = 30 * time.Second
+
+// ListSessions returns the user's active sessions, marking the one the
+// current access token belongs to
+func (s *AuthService) ListSessions(userID uint64, currentToken string) ([]models.SessionResponse, error) {
+	var sessions []models.UserSession
+	if err := s.db.Where("user_id = ? AND is_active = ? AND refresh_expires_at > ?", userID, true, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	current := s.sessionFamily(currentToken)
+	response := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, models.SessionResponse{
+			ID:               session.ID,
+			UserAgent:        session.UserAgent,
+			IPAddress:        session.IPAddress,
+			CreatedAt:        session.CreatedAt,
+			LastUsedAt:       session.LastUsedAt,
+			RefreshExpiresAt: session.RefreshExpiresAt,
+			Current:          current != "" && session.FamilyID == current,
+		})
+	}
+	return response, nil
+}
+
+// RevokeSession signs out a single session of the user
+func (s *AuthService) RevokeSession(userID, sessionID uint64) error {
+	var session models.UserSession
+	if err := s.db.Where("id = ? AND user_id = ? AND is_active = ?", sessionID, userID, true).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	return s.revokeSessions(s.db, "revoked_by_user", "id = ?", session.ID)
+}
+
+// RevokeOtherSessions signs out every session except the current one and
+// returns how many were revoked
+func (s *AuthService) RevokeOtherSessions(userID uint64, currentToken string) (int64, error) {
+	current := s.sessionFamily(currentToken)
+	if current == "" {
+		return 0, errors.New("current session could not be determined")
+	}
+
+	var count int64
+	if err := s.db.Model(&models.UserSession{}).
+		Where("user_id = ? AND is_active = ? AND family_id <> ?", userID, true, current).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	if err := s.revokeSessions(s.db, "revoked_by_user", "user_id = ? AND family_id <> ?", userID, current); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// rotateSession swaps in a new token pair. The update is conditional on the
+// refresh token hash that was just verified, so two concurrent refreshes with
+// the same token cannot both succeed.
+func (s *AuthService) rotateSession(session *models.UserSession, presentedHash, accessToken, refreshToken string, expiresAt time.Time, client models.ClientInfo) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"token_hash":         hashToken(accessToken),
+		"refresh_token_hash": hashToken(refreshToken),
+		"expires_at":         expiresAt,
+		"refresh_expires_at": now.Add(s.config.GetJWTRefreshExpiration()),
+		"last_used_at":       now,
+	}
+	if client.UserAgent != "" {
+		updates["user_agent"] = truncateString(client.UserAgent, 255)
+	}
+	if client.IPAddress != "" {
+		updates["ip_address"] = truncateString(client.IPAddress, 45)
+	}
+
+	result := s.db.Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND is_active = ?", session.ID, presentedHash, true).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invalid refresh token")
+	}
+	return nil
+}
+
+// revokeSessions deactivates the matching active sessions and caches their
+// family IDs in Redis so outstanding access tokens are rejected immediately
+func (s *AuthService) revokeSessions(db *gorm.DB, reason string, query string, args ...interface{}) error {
+	var families []string
+	if err := db.Model(&models.UserSession{}).
+		Where("is_active = ?", true).
+		Where(query, args...).
+		Pluck("family_id", &families).Error; err != nil {
+		return fmt.Errorf("failed to find sessions: %w", err)
+	}
+	if len(families) == 0 {
+		return nil
+	}
+
+	if err := db.Model(&models.UserSession{}).
+		Where("is_active = ?", true).
+		Where(query, args...).
+		Updates(map[string]interface{}{
+			"is_active":      false,
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	ctx := context.Background()
+	for _, familyID := range families {
+		if familyID == "" {
+			continue
+		}
+		if err := database.SetRevokedSession(ctx, familyID, s.config.GetJWTExpiration()); err != nil {
+			// The database is authoritative; the cache only short-circuits lookups
+			log.Printf("Failed to cache revoked session: %v", err)
+		}
+	}
+	return nil
+}
+
+// parseToken verifies a JWT signed by this service and checks its type claim
+func (s *AuthService) parseToken(tokenString, tokenType string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.config.JWT.Secret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	if t, _ := claims["type"].(string); t != tokenType {
+		return nil, errors.New("invalid token type")
+	}
+	return claims, nil
+}
+
+// sessionFamily returns the session family of an access token, or "" if unknown
+func (s *AuthService) sessionFamily(accessToken string) string {
+	claims, err := s.parseToken(accessToken, "access")
+	if err != nil {
+		return ""
+	}
+	familyID, _ := claims["sid"].(string)
+	return familyID
+}
+
+func newSessionFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
+
+// IssueStreamTicket creates a single-use ticket that opens one notification
+// stream. Browsers cannot set headers on EventSource or WebSocket requests,
+// so the ticket goes in the query string in place of the access token, which
+// request logs would otherwise record.
+func (s *AuthService) IssueStreamTicket(userID uint64) (*models.StreamTicketResponse, error) {
+	ticket, err := randomURLToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.SetCache(context.Background(), "stream_ticket:"+hashToken(ticket), userID, streamTicketTTL); err != nil {
+		return nil, fmt.Errorf("failed to store stream ticket: %w", err)
+	}
+	return &models.StreamTicketResponse{
+		Ticket:    ticket,
+		ExpiresAt: time.Now().Add(streamTicketTTL),
+	}, nil
+}
+
+// RedeemStreamTicket consumes a stream ticket and returns its user
+func (s *AuthService) RedeemStreamTicket(ticket string) (uint64, error) {
+	raw, err := database.TakeCache(context.Background(), "stream_ticket:"+hashToken(ticket))
+	if err != nil {
+		return 0, ErrStreamTicketInvalid
+	}
+	userID, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, ErrStreamTicketInvalid
+	}
+	return userID, nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/models"
+)
+
+func TestRefreshTokenFamily(t *testing.T) {
+	s := &AuthService{config: &config.Config{
+		JWT: config.JWTConfig{Secret: "test-secret", ExpireHours: 1, RefreshExpireHours: 24},
+	}}
+	accessToken, refreshToken, _, err := s.generateTokens(7, "family-1")
+	if err != nil {
+		t.Fatalf("generateTokens: %v", err)
+	}
+	other := &AuthService{config: &config.Config{
+		JWT: config.JWTConfig{Secret: "other-secret", ExpireHours: 1, RefreshExpireHours: 24},
+	}}
+	_, foreignToken, _, err := other.generateTokens(7, "family-1")
+	if err != nil {
+		t.Fatalf("generateTokens: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr bool
+	}{
+		{"refresh token", refreshToken, "family-1", false},
+		{"access token", accessToken, "", true},
+		{"signed with another secret", foreignToken, "", true},
+		{"garbage", "not-a-token", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.refreshTokenFamily(tt.token)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("refreshTokenFamily() = %q, %v, want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckRefreshSession(t *testing.T) {
+	now := time.Now()
+	current := hashToken("current-refresh-token")
+	rotated := hashToken("refresh-token-before-rotation")
+
+	tests := []struct {
+		name       string
+		active     bool
+		presented  string
+		expiresAt  time.Time
+		wantErr    error
+		wantErrMsg string
+	}{
+		{"current token", true, current, now.Add(time.Hour), nil, ""},
+		{"rotated token used again", true, rotated, now.Add(time.Hour), ErrRefreshTokenReused, ""},
+		{"reuse of an expired family", true, rotated, now.Add(-time.Hour), ErrRefreshTokenReused, ""},
+		{"revoked family", false, current, now.Add(time.Hour), ErrSessionRevoked, ""},
+		{"reuse after revocation", false, rotated, now.Add(time.Hour), ErrSessionRevoked, ""},
+		{"expired", true, current, now.Add(-time.Second), nil, "refresh token expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &models.UserSession{
+				IsActive:         tt.active,
+				RefreshTokenHash: current,
+				RefreshExpiresAt: tt.expiresAt,
+			}
+			err := checkRefreshSession(session, tt.presented, now)
+			switch {
+			case tt.wantErrMsg != "":
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("checkRefreshSession() = %v, want %q", err, tt.wantErrMsg)
+				}
+			case !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil):
+				t.Errorf("checkRefreshSession() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBillNotFound         = errors.New("bill not found")
+	ErrBillCategoryNotFound = errors.New("bill category not found")
+)
+
+// billPaymentWindowDays is how early before its due date a matching charge
+// still pays a bill
+const billPaymentWindowDays = 7
+
+type BillService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+func NewBillService(cfg *config.Config) *BillService {
+	return &BillService{
+		db:     database.GetDB(),
+		config: cfg,
+	}
+}
+
+// ListBills lists the ledger's bills, active ones first by due date
+func (s *BillService) ListBills(ledger Ledger) ([]models.Bill, error) {
+	bills := []models.Bill{}
+	if err := s.db.Scopes(ledger.Scope("")).Order("is_active DESC, next_due_date ASC, id ASC").Find(&bills).Error; err != nil {
+		return nil, fmt.Errorf("failed to load bills: %w", err)
+	}
+	now := time.Now()
+	for i := range bills {
+		decorateBill(&bills[i], now)
+	}
+	return bills, nil
+}
+
+// CreateBill adds a bill or subscription to the ledger
+func (s *BillService) CreateBill(ledger Ledger, req *models.BillRequest) (*models.Bill, error) {
+	if err := s.checkBillCategory(ledger, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	bill := &models.Bill{
+		UserID:       ledger.UserID,
+		HouseholdID:  ledger.HouseholdID,
+		Source:       models.BillSourceManual,
+		ReminderDays: s.config.Notification.BillReminderDays,
+	}
+	applyBillRequest(bill, req)
+
+	if err := s.db.Create(bill).Error; err != nil {
+		return nil, fmt.Errorf("failed to create bill: %w", err)
+	}
+	decorateBill(bill, time.Now())
+	return bill, nil
+}
+
+// UpdateBill updates a bill. A new due date also becomes the date later due
+// dates repeat from.
+func (s *BillService) UpdateBill(ledger Ledger, billID uint64, req *models.BillRequest) (*models.Bill, error) {
+	var bill models.Bill
+	if err := s.findBill(ledger, billID, &bill); err != nil {
+		return nil, err
+	}
+	if err := s.checkBillCategory(ledger, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	applyBillRequest(&bill, req)
+	if err := s.db.Save(&bill).Error; err != nil {
+		return nil, fmt.Errorf("failed to update bill: %w", err)
+	}
+	decorateBill(&bill, time.Now())
+	return &bill, nil
+}
+
+// DeleteBill deletes a bill
+func (s *BillService) DeleteBill(ledger Ledger, billID uint64) error {
+	result := s.db.Scopes(ledger.Scope("")).Where("id = ?", billID).Delete(&models.Bill{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete bill: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrBillNotFound
+	}
+	return nil
+}
+
+// MarkBillPaid records that the bill's current due date was paid and moves
+// it on to the next one
+func (s *BillService) MarkBillPaid(ledger Ledger, billID uint64) (*models.Bill, error) {
+	var bill models.Bill
+	if err := s.findBill(ledger, billID, &bill); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	bill.LastPaidAt = &today
+	bill.NextDueDate = nextBillDueDate(&bill, bill.NextDueDate)
+	if err := s.db.Model(&bill).Updates(map[string]interface{}{
+		"last_paid_at":  bill.LastPaidAt,
+		"next_due_date": bill.NextDueDate,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update bill: %w", err)
+	}
+	decorateBill(&bill, now)
+	return &bill, nil
+}
+
+// MatchTransaction treats a new expense matching an active bill as its
+// payment: the charged amount is recorded and due dates it covers are
+// moved on. Older charges entered later do not move the bill back.
+func (s *BillService) MatchTransaction(transaction *models.Transaction) error {
+	if transaction.TransactionType != "expense" {
+		return nil
+	}
+	match := normalizeBillDescription(transaction.Description)
+	if match == "" {
+		return nil
+	}
+
+	var bills []models.Bill
+	if err := s.db.Scopes(rowLedger(transaction.UserID, transaction.HouseholdID).Scope("")).
+		Where("is_active = ? AND match_description = ? AND (category_id IS NULL OR category_id = ?)", true, match, transaction.CategoryID).
+		Order("id ASC").Limit(1).Find(&bills).Error; err != nil {
+		return fmt.Errorf("failed to load bills: %w", err)
+	}
+	if len(bills) == 0 {
+		return nil
+	}
+	bill := &bills[0]
+	if bill.LastPaidAt != nil && transaction.TransactionDate.Before(*bill.LastPaidAt) {
+		return nil
+	}
+
+	paidAt := transaction.TransactionDate
+	amount := roundAmount(transaction.Amount)
+	bill.LastPaidAt = &paidAt
+	bill.LastChargedAmount = &amount
+	for !paidAt.Before(bill.NextDueDate.AddDate(0, 0, -billPaymentWindowDays)) {
+		bill.NextDueDate = nextBillDueDate(bill, bill.NextDueDate)
+	}
+	if err := s.db.Model(bill).Updates(map[string]interface{}{
+		"last_paid_at":        bill.LastPaidAt,
+		"last_charged_amount": bill.LastChargedAmount,
+		"next_due_date":       bill.NextDueDate,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update bill: %w", err)
+	}
+	return nil
+}
+
+func (s *BillService) findBill(ledger Ledger, billID uint64, bill *models.Bill) error {
+	if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", billID).First(bill).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBillNotFound
+		}
+		return fmt.Errorf("failed to load bill: %w", err)
+	}
+	return nil
+}
+
+func (s *BillService) checkBillCategory(ledger Ledger, categoryID *uint64) error {
+	if categoryID == nil {
+		return nil
+	}
+	var count int64
+	if err := s.db.Model(&models.Category{}).Scopes(ledger.CategoryScope()).Where("id = ?", *categoryID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load category: %w", err)
+	}
+	if count == 0 {
+		return ErrBillCategoryNotFound
+	}
+	return nil
+}
+
+func applyBillRequest(bill *models.Bill, req *models.BillRequest) {
+	y, m, d := req.NextDueDate.Date()
+	due := time.Date(y, m, d, 0, 0, 0, 0, req.NextDueDate.Location())
+
+	bill.Name = req.Name
+	bill.Kind = req.Kind
+	if bill.Kind == "" {
+		bill.Kind = models.BillKindBill
+	}
+	bill.Payee = req.Payee
+	bill.Amount = roundAmount(req.Amount)
+	bill.Cycle = req.Cycle
+	if !due.Equal(bill.NextDueDate) {
+		bill.AnchorDate = due
+		bill.NextDueDate = due
+	}
+	bill.CategoryID = req.CategoryID
+	bill.MatchDescription = normalizeBillDescription(req.MatchDescription)
+	if req.ReminderDays != nil {
+		bill.ReminderDays = *req.ReminderDays
+	}
+	bill.IsActive = req.IsActive == nil || *req.IsActive
+}
+
+// decorateBill fills in how far off the bill is and whether its last charge
+// went above the expected amount
+func decorateBill(bill *models.Bill, now time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, bill.NextDueDate.Location())
+	bill.DaysUntilDue = int(bill.NextDueDate.Sub(today).Hours() / 24)
+	bill.IsOverdue = bill.IsActive && bill.DaysUntilDue < 0
+
+	bill.PriceIncrease = nil
+	if bill.LastChargedAmount != nil && bill.LastPaidAt != nil {
+		bill.PriceIncrease = priceIncrease(bill.Amount, *bill.LastChargedAmount, *bill.LastPaidAt)
+	}
+}
+
+// priceIncrease reports a rise from previous to current of more than 1%
+func priceIncrease(previous, current float64, changedAt time.Time) *models.PriceChange {
+	if previous <= 0 || current <= previous*1.01 {
+		return nil
+	}
+	return &models.PriceChange{
+		PreviousAmount: roundAmount(previous),
+		CurrentAmount:  roundAmount(current),
+		Percent:        roundAmount((current - previous) / previous * 100),
+		ChangedAt:      changedAt,
+	}
+}
+
+// billDueDate returns the n-th due date repeating from anchor
+func billDueDate(anchor time.Time, cycle string, n int) time.Time {
+	if cycle == models.BillCycleQuarterly {
+		return savingsPlanRun(anchor, models.SavingsPlanMonthly, 3*n)
+	}
+	return savingsPlanRun(anchor, cycle, n)
+}
+
+// nextBillDueDate returns the first due date after after
+func nextBillDueDate(bill *models.Bill, after time.Time) time.Time {
+	n := 0
+	for !billDueDate(bill.AnchorDate, bill.Cycle, n).After(after) {
+		n++
+	}
+	return billDueDate(bill.AnchorDate, bill.Cycle, n)
+}
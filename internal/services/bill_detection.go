@@ -0,0 +1,289 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"tabimoney/internal/models"
+)
+
+var ErrDetectedSubscriptionNotFound = errors.New("detected subscription not found; it may already be tracked or dismissed")
+
+// subscriptionHistoryMonths is how far back detection looks for charges
+const subscriptionHistoryMonths = 13
+
+// subscriptionAmountTolerance is how far a charge may be from the median and
+// still count as the same subscription
+const subscriptionAmountTolerance = 0.25
+
+// billCycleDays are the typical gap between charges of each cycle and how far
+// a gap may stray from it
+var billCycleDays = []struct {
+	cycle     string
+	days      float64
+	tolerance float64
+}{
+	{models.BillCycleWeekly, 7, 1},
+	{models.BillCycleMonthly, 30.4, 4},
+	{models.BillCycleQuarterly, 91.3, 7},
+	{models.BillCycleYearly, 365.25, 10},
+}
+
+// DetectSubscriptions looks through the ledger's expenses for charges with the
+// same description and category that repeat on a regular cycle with similar
+// amounts. Charges already tracked by a bill, or dismissed, are left out.
+// A single charge marked recurring counts as well, using its pattern.
+func (s *BillService) DetectSubscriptions(ledger Ledger) ([]models.DetectedSubscription, error) {
+	since := time.Now().AddDate(0, -subscriptionHistoryMonths, 0)
+
+	var transactions []models.Transaction
+	if err := s.db.Scopes(ledger.Scope("")).Preload("Category").
+		Where("transaction_type = ? AND transaction_date >= ?", "expense", since).
+		Order("transaction_date ASC, id ASC").
+		Find(&transactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load transactions: %w", err)
+	}
+
+	var bills []models.Bill
+	if err := s.db.Scopes(ledger.Scope("")).Where("match_description <> ?", "").Find(&bills).Error; err != nil {
+		return nil, fmt.Errorf("failed to load bills: %w", err)
+	}
+	var dismissed []string
+	if err := s.db.Model(&models.DismissedSubscription{}).Scopes(ledger.Scope("")).Pluck("`key`", &dismissed).Error; err != nil {
+		return nil, fmt.Errorf("failed to load dismissed subscriptions: %w", err)
+	}
+	skip := make(map[string]bool, len(dismissed))
+	for _, key := range dismissed {
+		skip[key] = true
+	}
+
+	return findSubscriptions(transactions, bills, skip), nil
+}
+
+// findSubscriptions groups expenses, oldest first, by category and normalized
+// description and keeps the groups that repeat on a cycle, soonest due first.
+// Groups that a bill already tracks, or whose key is in skip, are left out.
+func findSubscriptions(transactions []models.Transaction, bills []models.Bill, skip map[string]bool) []models.DetectedSubscription {
+	groups := map[string][]models.Transaction{}
+	var keys []string
+	for _, t := range transactions {
+		match := normalizeBillDescription(t.Description)
+		if match == "" {
+			continue
+		}
+		key := subscriptionKey(t.CategoryID, match)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], t)
+	}
+
+	detected := []models.DetectedSubscription{}
+	for _, key := range keys {
+		if skip[key] {
+			continue
+		}
+		charges := groups[key]
+		first := charges[0]
+		match := normalizeBillDescription(first.Description)
+		if billTracks(bills, match, first.CategoryID) {
+			continue
+		}
+		if subscription, ok := detectSubscription(key, match, charges); ok {
+			detected = append(detected, subscription)
+		}
+	}
+
+	sort.SliceStable(detected, func(i, j int) bool { return detected[i].NextDueDate.Before(detected[j].NextDueDate) })
+	return detected
+}
+
+// ConfirmSubscription starts tracking a detected subscription as a bill
+func (s *BillService) ConfirmSubscription(ledger Ledger, req *models.BillConfirmRequest) (*models.Bill, error) {
+	detected, err := s.DetectSubscriptions(ledger)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subscription := range detected {
+		if subscription.Key != req.Key {
+			continue
+		}
+
+		categoryID := subscription.CategoryID
+		lastCharged := subscription.LastChargedAt
+		amount := subscription.Amount
+		bill := &models.Bill{
+			UserID:            ledger.UserID,
+			HouseholdID:       ledger.HouseholdID,
+			Name:              truncateString(subscription.Description, 200),
+			Kind:              models.BillKindSubscription,
+			Amount:            subscription.Amount,
+			Cycle:             subscription.Cycle,
+			AnchorDate:        subscription.NextDueDate,
+			NextDueDate:       subscription.NextDueDate,
+			CategoryID:        &categoryID,
+			MatchDescription:  subscription.MatchDescription,
+			ReminderDays:      s.config.Notification.BillReminderDays,
+			IsActive:          true,
+			Source:            models.BillSourceDetected,
+			LastPaidAt:        &lastCharged,
+			LastChargedAmount: &amount,
+		}
+		if req.Name != "" {
+			bill.Name = req.Name
+		}
+		if req.Kind != "" {
+			bill.Kind = req.Kind
+		}
+		if req.ReminderDays != nil {
+			bill.ReminderDays = *req.ReminderDays
+		}
+
+		if err := s.db.Create(bill).Error; err != nil {
+			return nil, fmt.Errorf("failed to create bill: %w", err)
+		}
+		decorateBill(bill, time.Now())
+		return bill, nil
+	}
+	return nil, ErrDetectedSubscriptionNotFound
+}
+
+// DismissSubscription stops offering a detected subscription
+func (s *BillService) DismissSubscription(ledger Ledger, req *models.BillDismissRequest) error {
+	var count int64
+	if err := s.db.Model(&models.DismissedSubscription{}).Scopes(ledger.Scope("")).Where("`key` = ?", req.Key).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check dismissed subscriptions: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	dismissal := &models.DismissedSubscription{
+		UserID:      ledger.UserID,
+		HouseholdID: ledger.HouseholdID,
+		Key:         req.Key,
+	}
+	if err := s.db.Create(dismissal).Error; err != nil {
+		return fmt.Errorf("failed to dismiss subscription: %w", err)
+	}
+	return nil
+}
+
+// detectSubscription decides whether one description's charges, oldest first,
+// repeat on a cycle
+func detectSubscription(key, match string, charges []models.Transaction) (models.DetectedSubscription, bool) {
+	last := charges[len(charges)-1]
+	subscription := models.DetectedSubscription{
+		Key:              key,
+		MatchDescription: match,
+		CategoryID:       last.CategoryID,
+		TransactionIDs:   make([]uint64, 0, len(charges)),
+	}
+	if last.Category != nil {
+		subscription.CategoryName = last.Category.Name
+	}
+
+	// Charges far from the typical amount are one-off purchases at the same payee
+	amounts := make([]float64, len(charges))
+	for i, t := range charges {
+		amounts[i] = t.Amount
+	}
+	median := percentileOf(amounts, 50)
+	var kept []models.Transaction
+	for _, t := range charges {
+		if math.Abs(t.Amount-median) <= median*subscriptionAmountTolerance {
+			kept = append(kept, t)
+		}
+	}
+
+	switch {
+	case len(kept) >= 3 || (len(kept) == 2 && cycleOf(kept) == models.BillCycleYearly):
+		subscription.Cycle = cycleOf(kept)
+	case last.IsRecurring && last.RecurringPattern != "" && last.RecurringPattern != "daily":
+		// Too few charges to tell, but the user marked it recurring
+		kept = []models.Transaction{last}
+		subscription.Cycle = last.RecurringPattern
+		subscription.FromRecurring = true
+	}
+	if subscription.Cycle == "" {
+		return subscription, false
+	}
+
+	total := 0.0
+	for _, t := range kept {
+		total += t.Amount
+		subscription.TransactionIDs = append(subscription.TransactionIDs, t.ID)
+	}
+	latest := kept[len(kept)-1]
+	subscription.Description = strings.TrimSpace(latest.Description)
+	subscription.Occurrences = len(kept)
+	subscription.FirstChargedAt = kept[0].TransactionDate
+	subscription.Amount = roundAmount(latest.Amount)
+	subscription.AverageAmount = roundAmount(total / float64(len(kept)))
+	subscription.LastChargedAt = latest.TransactionDate
+	subscription.NextDueDate = billDueDate(latest.TransactionDate, subscription.Cycle, 1)
+	if len(kept) >= 2 {
+		subscription.PriceIncrease = priceIncrease(kept[len(kept)-2].Amount, latest.Amount, latest.TransactionDate)
+	}
+	return subscription, true
+}
+
+// cycleOf returns the cycle that the gaps between charges keep to, allowing
+// one gap in four to be off (a skipped or late charge), or "" when none fits
+func cycleOf(charges []models.Transaction) string {
+	if len(charges) < 2 {
+		return ""
+	}
+	gaps := make([]float64, 0, len(charges)-1)
+	for i := 1; i < len(charges); i++ {
+		gaps = append(gaps, charges[i].TransactionDate.Sub(charges[i-1].TransactionDate).Hours()/24)
+	}
+	median := percentileOf(gaps, 50)
+
+	for _, c := range billCycleDays {
+		if math.Abs(median-c.days) > c.tolerance {
+			continue
+		}
+		regular := 0
+		for _, gap := range gaps {
+			if math.Abs(gap-c.days) <= c.tolerance {
+				regular++
+			}
+		}
+		if regular*4 >= len(gaps)*3 {
+			return c.cycle
+		}
+	}
+	return ""
+}
+
+// billTracks reports whether a bill already matches these charges
+func billTracks(bills []models.Bill, match string, categoryID uint64) bool {
+	for _, bill := range bills {
+		if bill.MatchDescription == match && (bill.CategoryID == nil || *bill.CategoryID == categoryID) {
+			return true
+		}
+	}
+	return false
+}
+
+func subscriptionKey(categoryID uint64, match string) string {
+	return strconv.FormatUint(categoryID, 10) + ":" + match
+}
+
+// normalizeBillDescription reduces a transaction description to the words
+// that stay the same between charges: lower case, without digits (dates,
+// invoice numbers) or punctuation
+func normalizeBillDescription(description string) string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return truncateString(strings.Join(words, " "), 255)
+}
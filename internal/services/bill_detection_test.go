@@ -0,0 +1,203 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"tabimoney/internal/models"
+)
+
+// billCharge is an expense on the given day of 2026, counted from January 1
+type billCharge struct {
+	categoryID  uint64
+	description string
+	amount      float64
+	day         int
+}
+
+func billCharges(charges ...billCharge) []models.Transaction {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	transactions := make([]models.Transaction, len(charges))
+	for i, c := range charges {
+		transactions[i] = models.Transaction{
+			ID:              uint64(i + 1),
+			CategoryID:      c.categoryID,
+			Description:     c.description,
+			Amount:          c.amount,
+			TransactionDate: start.AddDate(0, 0, c.day),
+			TransactionType: "expense",
+		}
+	}
+	return transactions
+}
+
+func TestCycleOf(t *testing.T) {
+	tests := []struct {
+		name string
+		days []int
+		want string
+	}{
+		{"weekly", []int{0, 7, 14, 21}, models.BillCycleWeekly},
+		{"monthly with uneven months", []int{0, 31, 59, 90}, models.BillCycleMonthly},
+		{"one skipped month in five gaps", []int{0, 30, 60, 121, 151, 181}, models.BillCycleMonthly},
+		{"two skipped months in four gaps", []int{0, 30, 91, 152, 182}, ""},
+		{"quarterly", []int{0, 90, 181, 273}, models.BillCycleQuarterly},
+		{"yearly", []int{0, 365}, models.BillCycleYearly},
+		{"fortnightly fits no cycle", []int{0, 14, 28, 42}, ""},
+		{"single charge", []int{0}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charges := make([]billCharge, len(tt.days))
+			for i, day := range tt.days {
+				charges[i] = billCharge{1, "netflix", 10, day}
+			}
+			if got := cycleOf(billCharges(charges...)); got != tt.want {
+				t.Errorf("cycleOf(%v) = %q, want %q", tt.days, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSubscriptions(t *testing.T) {
+	netflix := []billCharge{{5, "NETFLIX.COM 01/26", 15.99, 4}, {5, "NETFLIX.COM 02/26", 15.99, 35}, {5, "NETFLIX.COM 03/26", 15.99, 63}}
+	gym := []billCharge{{7, "Gym #0012", 10, 39}, {7, "GYM-0013!", 10, 46}, {7, "gym 14", 10, 53}, {7, "Gym 15", 10, 60}}
+	five := uint64(5)
+	six := uint64(6)
+
+	type found struct {
+		key         string
+		cycle       string
+		occurrences int
+	}
+	tests := []struct {
+		name    string
+		charges []billCharge
+		bills   []models.Bill
+		skip    map[string]bool
+		want    []found
+	}{
+		{"monthly charges", netflix, nil, nil, []found{{"5:netflix com", models.BillCycleMonthly, 3}}},
+		{"digits and punctuation are ignored", gym, nil, nil, []found{{"7:gym", models.BillCycleWeekly, 4}}},
+		{"soonest due first", append(append([]billCharge{}, netflix...), gym...), nil, nil, []found{
+			{"7:gym", models.BillCycleWeekly, 4},
+			{"5:netflix com", models.BillCycleMonthly, 3},
+		}},
+		{"categories are kept apart", []billCharge{
+			{5, "Netflix", 15.99, 4}, {6, "Netflix", 15.99, 35}, {5, "Netflix", 15.99, 63},
+		}, nil, nil, nil},
+		{"irregular gaps", []billCharge{
+			{3, "Coffee", 4, 0}, {3, "Coffee", 4, 1}, {3, "Coffee", 4, 4}, {3, "Coffee", 4, 14},
+		}, nil, nil, nil},
+		{"one-off purchase at the same payee is left out", []billCharge{
+			{4, "Amazon", 14.99, 0}, {4, "Amazon", 14.99, 31}, {4, "Amazon", 120, 40}, {4, "Amazon", 14.99, 59},
+		}, nil, nil, []found{{"4:amazon", models.BillCycleMonthly, 3}}},
+		{"yearly needs only two charges", []billCharge{
+			{8, "Domain renewal", 12, 0}, {8, "Domain renewal", 12, 365},
+		}, nil, nil, []found{{"8:domain renewal", models.BillCycleYearly, 2}}},
+		{"two monthly charges are not enough", netflix[:2], nil, nil, nil},
+		{"description without letters", []billCharge{
+			{5, "0001", 10, 0}, {5, "0002", 10, 31}, {5, "0003", 10, 59},
+		}, nil, nil, nil},
+		{"tracked by a bill", netflix, []models.Bill{{MatchDescription: "netflix com", CategoryID: &five}}, nil, nil},
+		{"tracked by a bill in any category", netflix, []models.Bill{{MatchDescription: "netflix com"}}, nil, nil},
+		{"bill in another category", netflix, []models.Bill{{MatchDescription: "netflix com", CategoryID: &six}}, nil,
+			[]found{{"5:netflix com", models.BillCycleMonthly, 3}}},
+		{"dismissed", netflix, nil, map[string]bool{"5:netflix com": true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []found
+			for _, s := range findSubscriptions(billCharges(tt.charges...), tt.bills, tt.skip) {
+				got = append(got, found{s.Key, s.Cycle, s.Occurrences})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findSubscriptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSubscriptionsFromRecurring(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		wantCycle string
+	}{
+		{"monthly", "monthly", models.BillCycleMonthly},
+		{"yearly", "yearly", models.BillCycleYearly},
+		{"daily is ignored", "daily", ""},
+		{"no pattern", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions := billCharges(billCharge{5, "Insurance", 80, 20})
+			transactions[0].IsRecurring = true
+			transactions[0].RecurringPattern = tt.pattern
+
+			found := findSubscriptions(transactions, nil, nil)
+			if tt.wantCycle == "" {
+				if len(found) != 0 {
+					t.Fatalf("findSubscriptions() = %+v, want none", found)
+				}
+				return
+			}
+			if len(found) != 1 || found[0].Cycle != tt.wantCycle || !found[0].FromRecurring || found[0].Occurrences != 1 {
+				t.Fatalf("findSubscriptions() = %+v, want one %s subscription from the recurring charge", found, tt.wantCycle)
+			}
+		})
+	}
+}
+
+func TestDetectSubscriptionAmounts(t *testing.T) {
+	tests := []struct {
+		name        string
+		amounts     []float64
+		wantAmount  float64
+		wantAverage float64
+		wantChange  *models.PriceChange // ChangedAt is not compared
+	}{
+		{"steady price", []float64{10, 10, 10}, 10, 10, nil},
+		{"price increase", []float64{10, 10, 12}, 12, 10.67, &models.PriceChange{PreviousAmount: 10, CurrentAmount: 12, Percent: 20}},
+		{"increase from the previous charge only", []float64{9, 10, 11}, 11, 10, &models.PriceChange{PreviousAmount: 10, CurrentAmount: 11, Percent: 10}},
+		{"rounding noise is not an increase", []float64{10, 10, 10.05}, 10.05, 10.02, nil},
+		{"just over one percent", []float64{10, 10, 10.2}, 10.2, 10.07, &models.PriceChange{PreviousAmount: 10, CurrentAmount: 10.2, Percent: 2}},
+		{"price drop", []float64{12, 12, 10}, 10, 11.33, nil},
+		{"outlier is not an increase", []float64{10, 10, 10, 30}, 10, 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charges := make([]billCharge, len(tt.amounts))
+			for i, amount := range tt.amounts {
+				charges[i] = billCharge{5, "Streaming", amount, 31 * i}
+			}
+			transactions := billCharges(charges...)
+
+			got, ok := detectSubscription("5:streaming", "streaming", transactions)
+			if !ok {
+				t.Fatal("detectSubscription() found nothing")
+			}
+			if got.Amount != tt.wantAmount || got.AverageAmount != tt.wantAverage {
+				t.Errorf("amount = %v, average %v, want %v, average %v", got.Amount, got.AverageAmount, tt.wantAmount, tt.wantAverage)
+			}
+			if tt.wantChange == nil {
+				if got.PriceIncrease != nil {
+					t.Errorf("PriceIncrease = %+v, want nil", *got.PriceIncrease)
+				}
+				return
+			}
+			if got.PriceIncrease == nil {
+				t.Fatalf("PriceIncrease = nil, want %+v", *tt.wantChange)
+			}
+			change := *got.PriceIncrease
+			if !change.ChangedAt.Equal(got.LastChargedAt) {
+				t.Errorf("ChangedAt = %v, want the last charge %v", change.ChangedAt, got.LastChargedAt)
+			}
+			change.ChangedAt = time.Time{}
+			if change != *tt.wantChange {
+				t.Errorf("PriceIncrease = %+v, want %+v", change, *tt.wantChange)
+			}
+		})
+	}
+}
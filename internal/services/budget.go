@@ -25,18 +25,33 @@ func NewBudgetService(cfg *config.Config) *BudgetService {
 	}
 }
 
-// CreateBudget creates a new budget
-func (s *BudgetService) CreateBudget(userID uint64, req *models.BudgetCreateRequest) (*models.Budget, error) {
+// CreateBudget creates a new budget in the ledger
+func (s *BudgetService) CreateBudget(ledger Ledger, req *models.BudgetCreateRequest) (*models.Budget, error) {
 	// Validate basic date range
 	if req.StartDate.After(req.EndDate) {
 		return nil, fmt.Errorf("start_date must be before or equal to end_date")
 	}
+	rolloverMode, err := normalizeRolloverMode(req.RolloverMode)
+	if err != nil {
+		return nil, err
+	}
+	mode := models.BudgetModeLimit
+	if req.Mode == models.BudgetModeEnvelope {
+		// Envelopes hold assigned money month to month instead of renewing a limit
+		if req.CategoryID == nil {
+			return nil, ErrEnvelopeCategoryRequired
+		}
+		mode = models.BudgetModeEnvelope
+		req.Period = "monthly"
+		req.AutoRenew = false
+		rolloverMode = models.BudgetRolloverNone
+	}
 
 	// Prevent multiple active budgets for same category & overlapping time
 	if req.CategoryID != nil {
 		var count int64
-		if err := s.db.Model(&models.Budget{}).
-			Where("user_id = ? AND is_active = ? AND category_id = ?", userID, true, *req.CategoryID).
+		if err := s.db.Model(&models.Budget{}).Scopes(ledger.Scope("")).
+			Where("is_active = ? AND category_id = ?", true, *req.CategoryID).
 			Where("NOT (end_date < ? OR start_date > ?)", req.StartDate, req.EndDate).
 			Count(&count).Error; err != nil {
 			return nil, fmt.Errorf("failed to check existing budgets: %w", err)
@@ -47,7 +62,8 @@ func (s *BudgetService) CreateBudget(userID uint64, req *models.BudgetCreateRequ
 	}
 
 	budget := &models.Budget{
-		UserID:         userID,
+		UserID:         ledger.UserID,
+		HouseholdID:    ledger.HouseholdID,
 		CategoryID:     req.CategoryID,
 		Name:           req.Name,
 		Amount:         req.Amount,
@@ -56,6 +72,9 @@ func (s *BudgetService) CreateBudget(userID uint64, req *models.BudgetCreateRequ
 		EndDate:        req.EndDate,
 		IsActive:       true,
 		AlertThreshold: req.AlertThreshold,
+		AutoRenew:      req.AutoRenew,
+		RolloverMode:   rolloverMode,
+		Mode:           mode,
 	}
 
 	if err := s.db.Create(budget).Error; err != nil {
@@ -68,10 +87,12 @@ func (s *BudgetService) CreateBudget(userID uint64, req *models.BudgetCreateRequ
 	return budget, nil
 }
 
-// GetBudgets retrieves user's budgets
-func (s *BudgetService) GetBudgets(userID uint64) ([]models.Budget, error) {
+// GetBudgets retrieves the ledger's budgets
+func (s *BudgetService) GetBudgets(ledger Ledger) ([]models.Budget, error) {
+	s.renewLedgerBudgets(ledger)
+
 	var budgets []models.Budget
-	if err := s.db.Where("user_id = ?", userID).Preload("Category").Order("created_at DESC").Find(&budgets).Error; err != nil {
+	if err := s.db.Scopes(ledger.Scope("")).Preload("Category").Order("created_at DESC").Find(&budgets).Error; err != nil {
 		return nil, fmt.Errorf("failed to get budgets: %w", err)
 	}
 
@@ -84,9 +105,9 @@ func (s *BudgetService) GetBudgets(userID uint64) ([]models.Budget, error) {
 }
 
 // UpdateBudget updates an existing budget
-func (s *BudgetService) UpdateBudget(userID uint64, budgetID uint64, req *models.BudgetUpdateRequest) (*models.Budget, error) {
+func (s *BudgetService) UpdateBudget(ledger Ledger, budgetID uint64, req *models.BudgetUpdateRequest) (*models.Budget, error) {
 	var budget models.Budget
-	if err := s.db.Where("id = ? AND user_id = ?", budgetID, userID).Preload("Category").First(&budget).Error; err != nil {
+	if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", budgetID).Preload("Category").First(&budget).Error; err != nil {
 		return nil, fmt.Errorf("budget not found: %w", err)
 	}
 
@@ -94,12 +115,16 @@ func (s *BudgetService) UpdateBudget(userID uint64, budgetID uint64, req *models
 	if req.StartDate.After(req.EndDate) {
 		return nil, fmt.Errorf("start_date must be before or equal to end_date")
 	}
+	rolloverMode, err := normalizeRolloverMode(req.RolloverMode)
+	if err != nil {
+		return nil, err
+	}
 
 	// Prevent overlapping active budgets for same category (excluding current budget)
 	if req.CategoryID != nil && req.IsActive {
 		var count int64
-		if err := s.db.Model(&models.Budget{}).
-			Where("user_id = ? AND is_active = ? AND category_id = ? AND id <> ?", userID, true, *req.CategoryID, budgetID).
+		if err := s.db.Model(&models.Budget{}).Scopes(ledger.Scope("")).
+			Where("is_active = ? AND category_id = ? AND id <> ?", true, *req.CategoryID, budgetID).
 			Where("NOT (end_date < ? OR start_date > ?)", req.StartDate, req.EndDate).
 			Count(&count).Error; err != nil {
 			return nil, fmt.Errorf("failed to check existing budgets: %w", err)
@@ -109,6 +134,15 @@ func (s *BudgetService) UpdateBudget(userID uint64, budgetID uint64, req *models
 		}
 	}
 
+	if budget.Mode == models.BudgetModeEnvelope {
+		if req.CategoryID == nil {
+			return nil, ErrEnvelopeCategoryRequired
+		}
+		req.Period = "monthly"
+		req.AutoRenew = false
+		rolloverMode = models.BudgetRolloverNone
+	}
+
 	// Update fields
 	budget.CategoryID = req.CategoryID
 	budget.Name = req.Name
@@ -118,6 +152,11 @@ func (s *BudgetService) UpdateBudget(userID uint64, budgetID uint64, req *models
 	budget.EndDate = req.EndDate
 	budget.IsActive = req.IsActive
 	budget.AlertThreshold = req.AlertThreshold
+	budget.AutoRenew = req.AutoRenew
+	budget.RolloverMode = rolloverMode
+	if rolloverMode == models.BudgetRolloverNone {
+		budget.RolloverAmount = 0
+	}
 
 	if err := s.db.Save(&budget).Error; err != nil {
 		return nil, fmt.Errorf("failed to update budget: %w", err)
@@ -130,52 +169,105 @@ func (s *BudgetService) UpdateBudget(userID uint64, budgetID uint64, req *models
 }
 
 // DeleteBudget deletes a budget
-func (s *BudgetService) DeleteBudget(userID uint64, budgetID uint64) error {
-	result := s.db.Where("id = ? AND user_id = ?", budgetID, userID).Delete(&models.Budget{})
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete budget: %w", result.Error)
+func (s *BudgetService) DeleteBudget(ledger Ledger, budgetID uint64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Scopes(ledger.Scope("")).Where("id = ?", budgetID).Delete(&models.Budget{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete budget: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("budget not found")
+		}
+		if err := tx.Where("budget_id = ?", budgetID).Delete(&models.BudgetPeriod{}).Error; err != nil {
+			return err
+		}
+		// Money left in a deleted envelope goes back to "to be assigned"
+		return tx.Where("budget_id = ?", budgetID).Delete(&models.EnvelopeAllocation{}).Error
+	})
+}
+
+// GetBudget retrieves a single budget with its current metrics
+func (s *BudgetService) GetBudget(ledger Ledger, budgetID uint64) (*models.Budget, error) {
+	var budget models.Budget
+	if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", budgetID).Preload("Category").First(&budget).Error; err != nil {
+		return nil, fmt.Errorf("budget not found: %w", err)
 	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("budget not found")
+	s.calculateBudgetMetrics(&budget)
+	return &budget, nil
+}
+
+// SnoozeAlerts suppresses threshold, exceeded and pacing alerts for a budget for the given duration
+func (s *BudgetService) SnoozeAlerts(ledger Ledger, budgetID uint64, duration time.Duration) (*models.Budget, error) {
+	budget, err := s.GetBudget(ledger, budgetID)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	until := time.Now().Add(duration)
+	if err := s.db.Model(&models.Budget{}).Where("id = ?", budget.ID).Update("alerts_snoozed_until", until).Error; err != nil {
+		return nil, fmt.Errorf("failed to snooze budget alerts: %w", err)
+	}
+	budget.AlertsSnoozedUntil = &until
+
+	return budget, nil
 }
 
 // calculateBudgetMetrics calculates spent amount, remaining amount, and usage percentage
 func (s *BudgetService) calculateBudgetMetrics(budget *models.Budget) {
+	if budget.Mode == models.BudgetModeEnvelope {
+		s.calculateEnvelopeMetrics(budget)
+		return
+	}
+
 	// Get spent amount for this budget period
 	var spentAmount float64
 	query := s.db.Model(&models.Transaction{}).
-		Where("user_id = ? AND transaction_type = ? AND transaction_date BETWEEN ? AND ?",
-			budget.UserID, "expense", budget.StartDate, budget.EndDate)
+		Scopes(rowLedger(budget.UserID, budget.HouseholdID).Scope("")).
+		Where("transaction_type = ? AND transaction_date BETWEEN ? AND ?",
+			"expense", budget.StartDate, budget.EndDate)
 
 	if budget.CategoryID != nil {
-		query = query.Where("category_id = ?", *budget.CategoryID)
+		// A budget on a parent category covers its subcategories as well
+		categoryIDs, err := categoryWithDescendants(s.db, rowLedger(budget.UserID, budget.HouseholdID), *budget.CategoryID)
+		if err != nil {
+			log.Printf("Failed to load subcategories of budget %d: %v", budget.ID, err)
+			categoryIDs = []uint64{*budget.CategoryID}
+		}
+		query = query.Where("category_id IN ?", categoryIDs)
 	}
 
 	query.Select("COALESCE(SUM(amount), 0)").Scan(&spentAmount)
 
-	// Calculate metrics
+	// Calculate metrics against the amount plus anything rolled over
+	limit := budget.Amount + budget.RolloverAmount
 	budget.SpentAmount = spentAmount
-	budget.RemainingAmount = budget.Amount - spentAmount
-	if budget.Amount > 0 {
-		budget.UsagePercentage = (spentAmount / budget.Amount) * 100
+	budget.RemainingAmount = limit - spentAmount
+	if limit > 0 {
+		budget.UsagePercentage = (spentAmount / limit) * 100
+	} else if budget.RolloverAmount != 0 {
+		// A carried-over overspend used up the whole budget
+		budget.UsagePercentage = 100
 	} else {
 		budget.UsagePercentage = 0
 	}
 }
 
 // CheckBudgetNotifications checks and triggers budget notifications
-// categoryID is optional: if provided, only checks budgets for that category or general budgets (category_id = nil)
+// categoryID is optional: if provided, only checks budgets for that category, its parent categories or general budgets (category_id = nil)
 // if categoryID is nil, checks all budgets (for scheduled checks)
-func (s *BudgetService) CheckBudgetNotifications(userID uint64, categoryID *uint64) error {
+// Household budgets alert every member of the household.
+func (s *BudgetService) CheckBudgetNotifications(ledger Ledger, categoryID *uint64) error {
 	dispatcher := NewNotificationDispatcher(s.config)
 
 	// Chỉ kiểm tra các budget đang hoạt động
-	query := s.db.Where("user_id = ? AND is_active = ?", userID, true)
+	query := s.db.Scopes(ledger.Scope("")).Where("is_active = ?", true)
 
 	if categoryID != nil {
-		query = query.Where("(category_id = ? OR category_id IS NULL)", *categoryID)
+		categoryIDs, err := categoryWithAncestors(s.db, ledger, *categoryID)
+		if err != nil {
+			return err
+		}
+		query = query.Where("(category_id IN ? OR category_id IS NULL)", categoryIDs)
 	}
 
 	var budgets []models.Budget
@@ -184,6 +276,7 @@ func (s *BudgetService) CheckBudgetNotifications(userID uint64, categoryID *uint
 	}
 
 	now := time.Now()
+	recipients := ledgerMemberIDs(s.db, ledger)
 
 	for i := range budgets {
 		// Bỏ qua ngân sách không nằm trong khoảng thời gian hiện tại
@@ -197,15 +290,17 @@ func (s *BudgetService) CheckBudgetNotifications(userID uint64, categoryID *uint
 		// Check if budget needs notification
 		if budgets[i].UsagePercentage >= budgets[i].AlertThreshold {
 			// Check if budget is exceeded
-			if budgets[i].UsagePercentage >= 100 {
-				// Budget exceeded
-				if err := dispatcher.TriggerBudgetExceededAlert(userID, &budgets[i]); err != nil {
-					log.Printf("Failed to trigger budget exceeded alert: %v", err)
-				}
-			} else {
-				// Budget threshold reached
-				if err := dispatcher.TriggerBudgetThresholdAlert(userID, &budgets[i]); err != nil {
-					log.Printf("Failed to trigger budget threshold alert: %v", err)
+			for _, userID := range recipients {
+				if budgets[i].UsagePercentage >= 100 {
+					// Budget exceeded
+					if err := dispatcher.TriggerBudgetExceededAlert(userID, &budgets[i]); err != nil {
+						log.Printf("Failed to trigger budget exceeded alert: %v", err)
+					}
+				} else {
+					// Budget threshold reached
+					if err := dispatcher.TriggerBudgetThresholdAlert(userID, &budgets[i]); err != nil {
+						log.Printf("Failed to trigger budget threshold alert: %v", err)
+					}
 				}
 			}
 		}
@@ -215,10 +310,12 @@ func (s *BudgetService) CheckBudgetNotifications(userID uint64, categoryID *uint
 }
 
 // GetBudgetInsights computes safe-to-spend and pacing information for active budgets in current period
-func (s *BudgetService) GetBudgetInsights(userID uint64) (*models.BudgetInsights, error) {
+func (s *BudgetService) GetBudgetInsights(ledger Ledger) (*models.BudgetInsights, error) {
+	s.renewLedgerBudgets(ledger)
+
 	// Load active budgets
 	var budgets []models.Budget
-	if err := s.db.Where("user_id = ? AND is_active = ?", userID, true).Find(&budgets).Error; err != nil {
+	if err := s.db.Scopes(ledger.Scope("")).Where("is_active = ?", true).Find(&budgets).Error; err != nil {
 		return nil, fmt.Errorf("failed to load budgets: %w", err)
 	}
 
@@ -232,7 +329,7 @@ func (s *BudgetService) GetBudgetInsights(userID uint64) (*models.BudgetInsights
 	var totalRemaining float64
 	var daysLeft int
 	insights := &models.BudgetInsights{
-		UserID: userID,
+		UserID: ledger.UserID,
 		Period: period,
 		AsOf:   now,
 	}
@@ -243,30 +340,15 @@ func (s *BudgetService) GetBudgetInsights(userID uint64) (*models.BudgetInsights
 		if now.Before(budgets[i].StartDate) || now.After(budgets[i].EndDate) {
 			continue
 		}
+		_, end := budgetWindow(&budgets[i], now)
 		// days left including today
-		dl := int(math.Max(1, math.Ceil(budgets[i].EndDate.Sub(now).Hours()/24)))
+		dl := int(math.Max(1, math.Ceil(end.Sub(now).Hours()/24)))
 		if daysLeft == 0 || dl < daysLeft {
 			daysLeft = dl
 		}
 		totalRemaining += math.Max(0, budgets[i].RemainingAmount)
 
-		// compute pacing
-		totalDays := int(math.Max(1, math.Round(budgets[i].EndDate.Sub(budgets[i].StartDate).Hours()/24)))
-		elapsedDays := int(math.Max(1, math.Round(now.Sub(budgets[i].StartDate).Hours()/24)))
-		allowedPace := 100.0 * float64(elapsedDays) / float64(totalDays)
-		actualPace := budgets[i].UsagePercentage
-		bp := models.BudgetPace{
-			BudgetID:        budgets[i].ID,
-			Name:            budgets[i].Name,
-			CategoryID:      budgets[i].CategoryID,
-			Amount:          budgets[i].Amount,
-			SpentAmount:     budgets[i].SpentAmount,
-			RemainingAmount: budgets[i].RemainingAmount,
-			UsagePercentage: budgets[i].UsagePercentage,
-			AllowedPacePct:  math.Min(100, math.Max(0, allowedPace)),
-			ActualPacePct:   math.Min(100, math.Max(0, actualPace)),
-			IsOverPace:      actualPace > allowedPace*1.2, // 120% of allowed pace considered risky
-		}
+		bp := budgetPace(&budgets[i], now)
 		insights.Budgets = append(insights.Budgets, bp)
 		if bp.IsOverPace {
 			insights.RiskBudgetIDs = append(insights.RiskBudgetIDs, bp.BudgetID)
@@ -295,102 +377,56 @@ func (s *BudgetService) GetBudgetInsights(userID uint64) (*models.BudgetInsights
 		insights.ProjectedEndUsagePct = weightedUsage / sumAmount
 	}
 
+	adherence, err := s.budgetAdherence(ledger, budgetAdherenceMonths)
+	if err != nil {
+		return nil, err
+	}
+	insights.Adherence = adherence
+
 	return insights, nil
 }
 
-// SuggestBudgets proposes category budgets based on recent spending or 50/30/20
-func (s *BudgetService) SuggestBudgets(userID uint64) (*models.AutoBudgetSuggestResponse, error) {
-	now := time.Now()
-	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 0, now.Location())
-
-	// fetch profile for income
-	var profile models.UserProfile
-	_ = s.db.Where("user_id = ?", userID).First(&profile).Error
-
-	// aggregate last 3 months expenses by category
-	threeMonthsAgo := startOfMonth.AddDate(0, -3, 0)
-	type row struct {
-		CategoryID uint64
-		Name       string
-		Amount     float64
-	}
-	var rows []row
-	s.db.Table("transactions t").
-		Select("t.category_id as category_id, c.name as name, COALESCE(SUM(t.amount),0) as amount").
-		Joins("JOIN categories c ON c.id = t.category_id").
-		Where("t.user_id = ? AND t.transaction_type = 'expense' AND t.transaction_date BETWEEN ? AND ?", userID, threeMonthsAgo, endOfMonth).
-		Group("t.category_id, c.name").
-		Scan(&rows)
-
-	suggestions := []models.AutoBudgetSuggestion{}
-	var total float64
-	if len(rows) > 0 {
-		for _, r := range rows {
-			medianApprox := r.Amount / 3.0
-			suggested := medianApprox * 0.9 // safety margin
-			cid := r.CategoryID
-			suggestions = append(suggestions, models.AutoBudgetSuggestion{
-				CategoryID:   &cid,
-				Name:         r.Name,
-				SuggestedAmt: math.Max(0, math.Round(suggested*100)/100),
-			})
-			total += suggested
-		}
-	} else {
-		// fallback 50/30/20 if no history
-		income := profile.MonthlyIncome
-		needs := income * 0.5
-		wants := income * 0.3
-		savings := income * 0.2
-
-		// map needs: food 40%, transport 20%, bills 30%, healthcare 10%
-		// Try to find system categories by name_en; fallback to nil
-		type catRow struct {
-			ID     uint64
-			Name   string
-			NameEn string
-		}
-		var cats []catRow
-		s.db.Raw("SELECT id, name, name_en FROM categories WHERE is_system = TRUE").Scan(&cats)
-		findCat := func(nameEn string) *uint64 {
-			for _, c := range cats {
-				if c.NameEn == nameEn {
-					id := c.ID
-					return &id
-				}
-			}
-			return nil
-		}
-		add := func(name string, amt float64, cid *uint64) {
-			suggestions = append(suggestions, models.AutoBudgetSuggestion{CategoryID: cid, Name: name, SuggestedAmt: math.Round(amt*100) / 100})
-			total += amt
-		}
-		add("Food & Dining", needs*0.4, findCat("Food & Dining"))
-		add("Transportation", needs*0.2, findCat("Transportation"))
-		add("Bills", needs*0.3, nil)
-		add("Healthcare", needs*0.1, findCat("Healthcare"))
-		add("Entertainment", wants*0.4, findCat("Entertainment"))
-		add("Shopping", wants*0.4, findCat("Shopping"))
-		add("Other", wants*0.2, findCat("Other"))
-		add("Savings", savings, findCat("Savings"))
-	}
-
-	resp := &models.AutoBudgetSuggestResponse{
-		UserID:         userID,
-		MonthlyIncome:  profile.MonthlyIncome,
-		Period:         "monthly",
-		StartDate:      startOfMonth,
-		EndDate:        endOfMonth,
-		Suggestions:    suggestions,
-		TotalSuggested: math.Round(total*100) / 100,
-		Notes:          []string{"Suggestions based on recent spending or 50/30/20 fallback", "Safety margin applied where applicable"},
-	}
-	return resp, nil
+// budgetWindow returns the period a budget's metrics cover: its own dates, or
+// the current month for an envelope
+func budgetWindow(budget *models.Budget, now time.Time) (time.Time, time.Time) {
+	if budget.Mode == models.BudgetModeEnvelope {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, -1)
+	}
+	return budget.StartDate, budget.EndDate
+}
+
+// budgetPeriodDays returns the length of the budget's window and how much of
+// it has passed, both at least one day
+func budgetPeriodDays(budget *models.Budget, now time.Time) (totalDays, elapsedDays int) {
+	start, end := budgetWindow(budget, now)
+	totalDays = int(math.Max(1, math.Round(end.Sub(start).Hours()/24)))
+	elapsedDays = int(math.Max(1, math.Round(now.Sub(start).Hours()/24)))
+	return totalDays, elapsedDays
+}
+
+// budgetPace compares how much of a budget is used with how much of its
+// window has passed. Metrics must already be calculated.
+func budgetPace(budget *models.Budget, now time.Time) models.BudgetPace {
+	totalDays, elapsedDays := budgetPeriodDays(budget, now)
+	allowedPace := 100.0 * float64(elapsedDays) / float64(totalDays)
+	actualPace := budget.UsagePercentage
+	return models.BudgetPace{
+		BudgetID:        budget.ID,
+		Name:            budget.Name,
+		CategoryID:      budget.CategoryID,
+		Amount:          budget.SpentAmount + budget.RemainingAmount, // amount plus rollover, or what an envelope held
+		SpentAmount:     budget.SpentAmount,
+		RemainingAmount: budget.RemainingAmount,
+		UsagePercentage: budget.UsagePercentage,
+		AllowedPacePct:  math.Min(100, math.Max(0, allowedPace)),
+		ActualPacePct:   math.Min(100, math.Max(0, actualPace)),
+		IsOverPace:      actualPace > allowedPace*1.2, // 120% of allowed pace considered risky
+	}
 }
 
 // CreateBudgetsFromSuggestions creates budgets from suggestions payload
-func (s *BudgetService) CreateBudgetsFromSuggestions(userID uint64, req *models.AutoBudgetCreateRequest) ([]models.Budget, error) {
+func (s *BudgetService) CreateBudgetsFromSuggestions(ledger Ledger, req *models.AutoBudgetCreateRequest) ([]models.Budget, error) {
 	if req == nil || len(req.Budgets) == 0 {
 		return nil, fmt.Errorf("no budgets provided")
 	}
@@ -413,8 +449,8 @@ func (s *BudgetService) CreateBudgetsFromSuggestions(userID uint64, req *models.
 			// Nếu có category thì tránh tạo budget trùng khoảng thời gian với budget đang active
 			if b.CategoryID != nil {
 				var count int64
-				if err := tx.Model(&models.Budget{}).
-					Where("user_id = ? AND is_active = ? AND category_id = ?", userID, true, *b.CategoryID).
+				if err := tx.Model(&models.Budget{}).Scopes(ledger.Scope("")).
+					Where("is_active = ? AND category_id = ?", true, *b.CategoryID).
 					Where("NOT (end_date < ? OR start_date > ?)", req.StartDate, req.EndDate).
 					Count(&count).Error; err != nil {
 					return fmt.Errorf("failed to check existing budgets: %w", err)
@@ -430,7 +466,8 @@ func (s *BudgetService) CreateBudgetsFromSuggestions(userID uint64, req *models.
 				name = "Budget"
 			}
 			budget := models.Budget{
-				UserID:         userID,
+				UserID:         ledger.UserID,
+				HouseholdID:    ledger.HouseholdID,
 				CategoryID:     b.CategoryID,
 				Name:           name,
 				Amount:         b.SuggestedAmt,
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+
+	// errBudgetRenewed rolls back a renewal that lost a race with another one
+	errBudgetRenewed = errors.New("budget was already renewed")
+)
+
+// budgetAdherenceMonths is how many months of closed periods GetBudgetInsights reports
+const budgetAdherenceMonths = 6
+
+// RenewDueBudgets moves every auto-renewing budget whose period has ended into
+// its current period, archiving each closed period
+func (s *BudgetService) RenewDueBudgets() error {
+	return s.renewBudgets()
+}
+
+// renewLedgerBudgets renews the ledger's due budgets so reads never show an
+// expired period while the daily job has not run yet
+func (s *BudgetService) renewLedgerBudgets(ledger Ledger) {
+	if err := s.renewBudgets(ledger.Scope("")); err != nil {
+		log.Printf("Failed to renew budgets for user %d: %v", ledger.UserID, err)
+	}
+}
+
+func (s *BudgetService) renewBudgets(scopes ...func(*gorm.DB) *gorm.DB) error {
+	now := time.Now()
+
+	var budgets []models.Budget
+	if err := s.db.Scopes(scopes...).Where("is_active = ? AND auto_renew = ? AND end_date < ?", true, true, now).
+		Find(&budgets).Error; err != nil {
+		return fmt.Errorf("failed to load budgets to renew: %w", err)
+	}
+
+	for i := range budgets {
+		if err := s.renewBudget(&budgets[i], now); err != nil && !errors.Is(err, errBudgetRenewed) {
+			log.Printf("Failed to renew budget %d: %v", budgets[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// renewBudget closes every period of the budget that ended before now,
+// carrying the balance forward according to its rollover mode
+func (s *BudgetService) renewBudget(budget *models.Budget, now time.Time) error {
+	if !budgetPeriodEnded(budget.EndDate, now) {
+		return nil
+	}
+
+	endDate := budget.EndDate
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for budgetPeriodEnded(budget.EndDate, now) {
+			s.calculateBudgetMetrics(budget)
+
+			carry := budgetCarry(budget.RolloverMode, budget.RemainingAmount)
+
+			period := &models.BudgetPeriod{
+				BudgetID:       budget.ID,
+				StartDate:      budget.StartDate,
+				EndDate:        budget.EndDate,
+				Amount:         budget.Amount,
+				RolloverAmount: budget.RolloverAmount,
+				SpentAmount:    roundAmount(budget.SpentAmount),
+				CarriedOver:    carry,
+			}
+			if err := tx.Create(period).Error; err != nil {
+				return fmt.Errorf("failed to archive budget period: %w", err)
+			}
+
+			budget.StartDate, budget.EndDate = nextBudgetPeriod(budget.Period, budget.EndDate)
+			budget.RolloverAmount = carry
+		}
+
+		result := tx.Model(&models.Budget{}).Where("id = ? AND end_date = ?", budget.ID, endDate).Updates(map[string]interface{}{
+			"start_date":      budget.StartDate,
+			"end_date":        budget.EndDate,
+			"rollover_amount": budget.RolloverAmount,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to renew budget: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errBudgetRenewed
+		}
+		return nil
+	})
+}
+
+// GetBudgetHistory lists the closed periods of a budget, newest first
+func (s *BudgetService) GetBudgetHistory(ledger Ledger, budgetID uint64) ([]models.BudgetPeriod, error) {
+	var count int64
+	if err := s.db.Model(&models.Budget{}).Scopes(ledger.Scope("")).Where("id = ?", budgetID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to load budget: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrBudgetNotFound
+	}
+
+	var periods []models.BudgetPeriod
+	if err := s.db.Where("budget_id = ?", budgetID).Order("start_date DESC").Find(&periods).Error; err != nil {
+		return nil, fmt.Errorf("failed to load budget history: %w", err)
+	}
+	return periods, nil
+}
+
+// budgetAdherence groups the ledger's closed budget periods by the month they
+// started in, for the most recent months
+func (s *BudgetService) budgetAdherence(ledger Ledger, months int) ([]models.BudgetAdherence, error) {
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -months, 0)
+
+	var periods []models.BudgetPeriod
+	if err := s.db.Table("budget_periods").
+		Joins("JOIN budgets ON budgets.id = budget_periods.budget_id").
+		Scopes(ledger.Scope("budgets")).
+		Where("budget_periods.start_date >= ?", since).
+		Select("budget_periods.*").
+		Find(&periods).Error; err != nil {
+		return nil, fmt.Errorf("failed to load budget history: %w", err)
+	}
+
+	byMonth := map[string]*models.BudgetAdherence{}
+	for _, p := range periods {
+		month := p.StartDate.Format("2006-01")
+		a, ok := byMonth[month]
+		if !ok {
+			a = &models.BudgetAdherence{Month: month}
+			byMonth[month] = a
+		}
+		limit := p.Amount + p.RolloverAmount
+		a.TotalLimit += limit
+		a.TotalSpent += p.SpentAmount
+		a.Budgets++
+		if p.SpentAmount <= limit {
+			a.WithinLimit++
+		}
+	}
+
+	adherence := make([]models.BudgetAdherence, 0, len(byMonth))
+	for _, a := range byMonth {
+		a.TotalLimit = roundAmount(a.TotalLimit)
+		a.TotalSpent = roundAmount(a.TotalSpent)
+		a.AdherenceRate = roundAmount(100 * float64(a.WithinLimit) / float64(a.Budgets))
+		adherence = append(adherence, *a)
+	}
+	sort.Slice(adherence, func(i, j int) bool { return adherence[i].Month > adherence[j].Month })
+	return adherence, nil
+}
+
+// budgetCarry is what a closing period passes to the next: nothing, only an
+// unspent remainder, or the full remainder including an overspend, which
+// reduces the next period
+func budgetCarry(mode string, remaining float64) float64 {
+	switch mode {
+	case models.BudgetRolloverUnspent:
+		if remaining > 0 {
+			return roundAmount(remaining)
+		}
+	case models.BudgetRolloverFull:
+		return roundAmount(remaining)
+	}
+	return 0
+}
+
+// budgetPeriodEnded reports whether the day after endDate has begun. End dates
+// may be stored as midnight of the last day, so the time of day is ignored.
+func budgetPeriodEnded(endDate, now time.Time) bool {
+	y, m, d := endDate.Date()
+	return !now.Before(time.Date(y, m, d+1, 0, 0, 0, 0, endDate.Location()))
+}
+
+// nextBudgetPeriod returns the period following one that ended on endDate. The
+// new period starts the next day and ends the day before the same point one
+// period later, keeping endDate's time of day; a monthly budget ending on
+// 31 January therefore runs 1–28 February next.
+func nextBudgetPeriod(period string, endDate time.Time) (time.Time, time.Time) {
+	y, m, d := endDate.Date()
+	loc := endDate.Location()
+	start := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+
+	sy, sm, sd := start.Date()
+	var next time.Time
+	switch period {
+	case "weekly":
+		next = start.AddDate(0, 0, 7)
+	case "yearly":
+		next = time.Date(sy+1, sm, clampDay(sy+1, sm, sd), 0, 0, 0, 0, loc)
+	default:
+		ny, nm := sy, sm+1
+		if nm > 12 {
+			ny, nm = ny+1, 1
+		}
+		next = time.Date(ny, nm, clampDay(ny, nm, sd), 0, 0, 0, 0, loc)
+	}
+
+	clock := endDate.Sub(time.Date(y, m, d, 0, 0, 0, 0, loc))
+	ny, nm, nd := next.Date()
+	end := time.Date(ny, nm, nd-1, 0, 0, 0, 0, loc).Add(clock)
+	return start, end
+}
+
+// clampDay limits day to the length of the month
+func clampDay(year int, month time.Month, day int) int {
+	if last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day(); day > last {
+		return last
+	}
+	return day
+}
+
+// normalizeRolloverMode defaults an empty rollover mode to none
+func normalizeRolloverMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return models.BudgetRolloverNone, nil
+	case models.BudgetRolloverNone, models.BudgetRolloverUnspent, models.BudgetRolloverFull:
+		return mode, nil
+	}
+	return "", fmt.Errorf("rollover_mode must be one of none, unspent, full")
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"tabimoney/internal/models"
+)
+
+func TestBudgetPeriodEnded(t *testing.T) {
+	endOfDay := time.Date(2024, time.January, 31, 23, 59, 59, 0, time.UTC)
+	midnight := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		endDate time.Time
+		now     time.Time
+		want    bool
+	}{
+		{"during the last day", endOfDay, time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC), false},
+		{"last day stored as midnight", midnight, time.Date(2024, time.January, 31, 23, 0, 0, 0, time.UTC), false},
+		{"next day begins", endOfDay, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), true},
+		{"next day begins, midnight end", midnight, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), true},
+		{"weeks later", midnight, time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), true},
+		{"before the period ends", endOfDay, time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := budgetPeriodEnded(tt.endDate, tt.now); got != tt.want {
+				t.Errorf("budgetPeriodEnded(%v, %v) = %v, want %v", tt.endDate, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextBudgetPeriod(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	endOfDay := 23*time.Hour + 59*time.Minute + 59*time.Second
+
+	tests := []struct {
+		name      string
+		period    string
+		endDate   time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"monthly", "monthly", date(2024, time.March, 31), date(2024, time.April, 1), date(2024, time.April, 30)},
+		{"monthly after 31 January", "monthly", date(2024, time.January, 31), date(2024, time.February, 1), date(2024, time.February, 29)},
+		{"monthly in a common year", "monthly", date(2023, time.January, 31), date(2023, time.February, 1), date(2023, time.February, 28)},
+		{"monthly from the 31st", "monthly", date(2023, time.January, 30), date(2023, time.January, 31), date(2023, time.February, 27)},
+		{"monthly across the year", "monthly", date(2023, time.December, 14), date(2023, time.December, 15), date(2024, time.January, 14)},
+		{"weekly", "weekly", date(2024, time.February, 25), date(2024, time.February, 26), date(2024, time.March, 3)},
+		{"yearly", "yearly", date(2023, time.December, 31), date(2024, time.January, 1), date(2024, time.December, 31)},
+		{"yearly from 29 February", "yearly", date(2024, time.February, 28), date(2024, time.February, 29), date(2025, time.February, 27)},
+		{"keeps the time of day", "monthly", date(2024, time.January, 31).Add(endOfDay), date(2024, time.February, 1), date(2024, time.February, 29).Add(endOfDay)},
+		{"unknown period is monthly", "", date(2024, time.April, 30), date(2024, time.May, 1), date(2024, time.May, 31)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := nextBudgetPeriod(tt.period, tt.endDate)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("nextBudgetPeriod(%q, %v) = %v – %v, want %v – %v",
+					tt.period, tt.endDate, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestNextBudgetPeriodCatchUp(t *testing.T) {
+	// A monthly budget last renewed for January and processed in mid-April
+	// closes January, February and March, as renewBudget's loop does
+	end := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.April, 15, 9, 0, 0, 0, time.UTC)
+
+	var closed []time.Time
+	for budgetPeriodEnded(end, now) {
+		closed = append(closed, end)
+		_, end = nextBudgetPeriod("monthly", end)
+	}
+
+	want := []time.Time{
+		time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC),
+	}
+	if len(closed) != len(want) {
+		t.Fatalf("closed %d periods (%v), want %d", len(closed), closed, len(want))
+	}
+	for i := range want {
+		if !closed[i].Equal(want[i]) {
+			t.Errorf("period %d ended %v, want %v", i, closed[i], want[i])
+		}
+	}
+	if wantEnd := time.Date(2024, time.April, 30, 0, 0, 0, 0, time.UTC); !end.Equal(wantEnd) {
+		t.Errorf("current period ends %v, want %v", end, wantEnd)
+	}
+}
+
+func TestBudgetCarry(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		remaining float64
+		want      float64
+	}{
+		{"none keeps nothing", models.BudgetRolloverNone, 120, 0},
+		{"unspent carries what is left", models.BudgetRolloverUnspent, 120.456, 120.46},
+		{"unspent ignores overspending", models.BudgetRolloverUnspent, -80, 0},
+		{"full carries what is left", models.BudgetRolloverFull, 120, 120},
+		{"full carries overspending as a reduction", models.BudgetRolloverFull, -80, -80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := budgetCarry(tt.mode, tt.remaining); got != tt.want {
+				t.Errorf("budgetCarry(%q, %v) = %v, want %v", tt.mode, tt.remaining, got, tt.want)
+			}
+		})
+	}
+}
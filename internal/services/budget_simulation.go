@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"tabimoney/internal/models"
+)
+
+var (
+	ErrSimulationCategoryNotFound = errors.New("simulated category not found")
+	ErrSimulationGoalNotFound     = errors.New("simulated goal not found or already achieved")
+)
+
+// simulationHistoryMonths is how many months of history the simulator
+// averages income and spending over
+const simulationHistoryMonths = 3
+
+// SimulateBudgets projects budgets, savings rate and goal completion under
+// hypothetical changes. It only reads; due budgets are not renewed first.
+func (s *BudgetService) SimulateBudgets(ledger Ledger, req *models.BudgetSimulationRequest) (*models.BudgetSimulationResponse, error) {
+	now := time.Now()
+
+	tree, err := loadCategoryTree(s.db, ledger.CategoryScope())
+	if err != nil {
+		return nil, err
+	}
+	// factors scales future spending per category; a change on a parent
+	// applies to its subcategories too
+	factors := map[uint64]float64{}
+	for _, change := range req.CategoryChanges {
+		if _, ok := tree.parent[change.CategoryID]; !ok {
+			return nil, ErrSimulationCategoryNotFound
+		}
+		for _, id := range tree.descendants(change.CategoryID) {
+			factors[id] = 1 + change.PercentChange/100
+		}
+	}
+	for _, extra := range req.ExtraExpenses {
+		if extra.CategoryID != nil {
+			if _, ok := tree.parent[*extra.CategoryID]; !ok {
+				return nil, ErrSimulationCategoryNotFound
+			}
+		}
+	}
+	factor := func(categoryID uint64) float64 {
+		if f, ok := factors[categoryID]; ok {
+			return f
+		}
+		return 1
+	}
+
+	response := &models.BudgetSimulationResponse{
+		Budgets:     []models.SimulatedBudget{},
+		Goals:       []models.SimulatedGoal{},
+		GeneratedAt: now,
+	}
+
+	var budgets []models.Budget
+	if err := s.db.Scopes(ledger.Scope("")).Where("is_active = ?", true).Find(&budgets).Error; err != nil {
+		return nil, fmt.Errorf("failed to load budgets: %w", err)
+	}
+	for i := range budgets {
+		if now.Before(budgets[i].StartDate) || now.After(budgets[i].EndDate) {
+			continue
+		}
+		s.calculateBudgetMetrics(&budgets[i])
+		simulated, err := s.simulateBudget(&budgets[i], now, tree, factor, req.ExtraExpenses)
+		if err != nil {
+			return nil, err
+		}
+		response.Budgets = append(response.Budgets, simulated)
+	}
+
+	if err := s.simulateCashflow(ledger, now, factor, req, response); err != nil {
+		return nil, err
+	}
+	if err := s.simulateGoals(ledger, req.GoalContributions, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// simulateBudget extrapolates the budget's spending so far to the end of its
+// window. Changes only scale the part still to be spent, while an extra
+// expense counts in full for the period.
+func (s *BudgetService) simulateBudget(budget *models.Budget, now time.Time, tree *categoryTree, factor func(uint64) float64, extras []models.SimulatedCashflow) (models.SimulatedBudget, error) {
+	start, end := budgetWindow(budget, now)
+	query := s.db.Model(&models.Transaction{}).
+		Scopes(rowLedger(budget.UserID, budget.HouseholdID).Scope("")).
+		Where("transaction_type = ? AND transaction_date BETWEEN ? AND ?", "expense", start, end)
+	inBudget := func(uint64) bool { return true }
+	if budget.CategoryID != nil {
+		categoryIDs := tree.descendants(*budget.CategoryID)
+		query = query.Where("category_id IN ?", categoryIDs)
+		covered := make(map[uint64]bool, len(categoryIDs))
+		for _, id := range categoryIDs {
+			covered[id] = true
+		}
+		inBudget = func(id uint64) bool { return covered[id] }
+	}
+
+	var rows []categorySpend
+	if err := query.Select("category_id, COALESCE(SUM(amount), 0) AS amount").Group("category_id").Scan(&rows).Error; err != nil {
+		return models.SimulatedBudget{}, fmt.Errorf("failed to load budget spending: %w", err)
+	}
+	return projectBudget(budget, now, rows, inBudget, factor, extras), nil
+}
+
+// categorySpend is a category's spending within a window
+type categorySpend struct {
+	CategoryID uint64
+	Amount     float64
+}
+
+// projectBudget extrapolates the spending rows to the end of the budget's
+// window, before and after the simulated changes. Metrics must already be
+// calculated.
+func projectBudget(budget *models.Budget, now time.Time, rows []categorySpend, inBudget func(uint64) bool, factor func(uint64) float64, extras []models.SimulatedCashflow) models.SimulatedBudget {
+	totalDays, elapsedDays := budgetPeriodDays(budget, now)
+	remaining := float64(totalDays)/float64(elapsedDays) - 1
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	var baseline, projected float64
+	for _, row := range rows {
+		baseline += row.Amount * (1 + remaining)
+		projected += row.Amount * (1 + remaining*factor(row.CategoryID))
+	}
+	for _, extra := range extras {
+		// Uncategorized extras only count towards budgets without a category
+		if (extra.CategoryID == nil && budget.CategoryID == nil) || (extra.CategoryID != nil && inBudget(*extra.CategoryID)) {
+			projected += extra.MonthlyAmount * float64(totalDays) / 30
+		}
+	}
+
+	simulated := models.SimulatedBudget{
+		BudgetPace:             budgetPace(budget, now),
+		BaselineProjectedSpent: roundAmount(baseline),
+		ProjectedSpent:         roundAmount(projected),
+	}
+	if limit := simulated.Amount; limit > 0 {
+		simulated.BaselineProjectedPct = roundAmount(baseline / limit * 100)
+		simulated.ProjectedPct = roundAmount(projected / limit * 100)
+	}
+	simulated.WillExceed = projected > simulated.Amount
+	return simulated
+}
+
+// simulateCashflow averages monthly income and spending over recent months
+// and applies the changes to them
+func (s *BudgetService) simulateCashflow(ledger Ledger, now time.Time, factor func(uint64) float64, req *models.BudgetSimulationRequest, response *models.BudgetSimulationResponse) error {
+	since := now.AddDate(0, -simulationHistoryMonths, 0)
+
+	var rows []cashflowSpend
+	if err := s.db.Model(&models.Transaction{}).Scopes(ledger.Scope("")).
+		Where("transaction_type IN ? AND transaction_date >= ?", []string{"income", "expense"}, since).
+		Select("transaction_type, category_id, COALESCE(SUM(amount), 0) AS amount").
+		Group("transaction_type, category_id").
+		Scan(&rows).Error; err != nil {
+		return fmt.Errorf("failed to load cashflow: %w", err)
+	}
+
+	response.Baseline, response.Simulated = projectCashflow(rows, factor, req)
+	return nil
+}
+
+// cashflowSpend is a category's income or spending over the history window
+type cashflowSpend struct {
+	TransactionType string
+	CategoryID      uint64
+	Amount          float64
+}
+
+// projectCashflow turns the history rows into monthly averages, before and
+// after the simulated changes
+func projectCashflow(rows []cashflowSpend, factor func(uint64) float64, req *models.BudgetSimulationRequest) (baseline, simulated models.SimulatedCashflowSummary) {
+	for _, row := range rows {
+		monthly := row.Amount / simulationHistoryMonths
+		if row.TransactionType == "income" {
+			baseline.MonthlyIncome += monthly
+			simulated.MonthlyIncome += monthly
+			continue
+		}
+		baseline.MonthlyExpense += monthly
+		simulated.MonthlyExpense += monthly * factor(row.CategoryID)
+	}
+	for _, extra := range req.ExtraExpenses {
+		simulated.MonthlyExpense += extra.MonthlyAmount
+	}
+	for _, extra := range req.ExtraIncome {
+		simulated.MonthlyIncome += extra.MonthlyAmount
+	}
+	for _, contribution := range req.GoalContributions {
+		simulated.GoalContributions += contribution.MonthlyAmount
+	}
+	return summarizeCashflow(baseline), summarizeCashflow(simulated)
+}
+
+func summarizeCashflow(summary models.SimulatedCashflowSummary) models.SimulatedCashflowSummary {
+	summary.MonthlySavings = summary.MonthlyIncome - summary.MonthlyExpense
+	if summary.MonthlyIncome > 0 {
+		summary.SavingsRate = roundAmount(summary.MonthlySavings / summary.MonthlyIncome * 100)
+	}
+	summary.SurplusAfterGoals = roundAmount(summary.MonthlySavings - summary.GoalContributions)
+	summary.MonthlyIncome = roundAmount(summary.MonthlyIncome)
+	summary.MonthlyExpense = roundAmount(summary.MonthlyExpense)
+	summary.MonthlySavings = roundAmount(summary.MonthlySavings)
+	summary.GoalContributions = roundAmount(summary.GoalContributions)
+	return summary
+}
+
+// simulateGoals projects each open goal's completion with the AI service's
+// estimate, adding any simulated contribution on top of it
+func (s *BudgetService) simulateGoals(ledger Ledger, contributions []models.SimulatedGoalContribution, response *models.BudgetSimulationResponse) error {
+	var goals []models.FinancialGoal
+	if err := s.db.Scopes(ledger.Scope("")).Where("is_achieved = ?", false).Order("target_date ASC").Find(&goals).Error; err != nil {
+		return fmt.Errorf("failed to load goals: %w", err)
+	}
+
+	open := make(map[uint64]bool, len(goals))
+	for _, goal := range goals {
+		open[goal.ID] = true
+	}
+	extra := map[uint64]float64{}
+	for _, contribution := range contributions {
+		if !open[contribution.GoalID] {
+			return ErrSimulationGoalNotFound
+		}
+		extra[contribution.GoalID] += contribution.MonthlyAmount
+	}
+
+	ai := NewAIService(s.config)
+	for _, goal := range goals {
+		progress := ai.calculateGoalProgress(goal, nil)
+		baseline := estimatedGoalContribution(goal)
+		monthly := baseline + extra[goal.ID]
+
+		simulated := models.SimulatedGoal{
+			GoalID:                  goal.ID,
+			Title:                   goal.Title,
+			TargetAmount:            goal.TargetAmount,
+			CurrentAmount:           goal.CurrentAmount,
+			TargetDate:              goal.TargetDate,
+			BaselineContribution:    roundAmount(baseline),
+			MonthlyContribution:     roundAmount(monthly),
+			BaselineCompletionDate:  ai.projectGoalCompletion(goal, progress, baseline),
+			ProjectedCompletionDate: ai.projectGoalCompletion(goal, progress, monthly),
+		}
+		if goal.TargetDate != nil && simulated.ProjectedCompletionDate != nil {
+			meets := !simulated.ProjectedCompletionDate.After(*goal.TargetDate)
+			simulated.MeetsTargetDate = &meets
+		}
+		response.Goals = append(response.Goals, simulated)
+	}
+	return nil
+}
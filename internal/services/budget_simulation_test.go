@@ -0,0 +1,207 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"tabimoney/internal/models"
+)
+
+func TestBudgetPeriodDays(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		mode        string
+		now         time.Time
+		wantTotal   int
+		wantElapsed int
+	}{
+		{"mid period", models.BudgetModeLimit, start.AddDate(0, 0, 10), 30, 10},
+		{"first day counts as one", models.BudgetModeLimit, start.Add(2 * time.Hour), 30, 1},
+		{"before the window", models.BudgetModeLimit, start.AddDate(0, 0, -5), 30, 1},
+		{"past the window", models.BudgetModeLimit, end.AddDate(0, 0, 5), 30, 35},
+		{"envelope uses the calendar month", models.BudgetModeEnvelope, time.Date(2026, 2, 8, 0, 0, 0, 0, time.UTC), 27, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &models.Budget{Mode: tt.mode, StartDate: start, EndDate: end}
+			total, elapsed := budgetPeriodDays(budget, tt.now)
+			if total != tt.wantTotal || elapsed != tt.wantElapsed {
+				t.Errorf("budgetPeriodDays() = %d, %d, want %d, %d", total, elapsed, tt.wantTotal, tt.wantElapsed)
+			}
+		})
+	}
+}
+
+func TestProjectBudget(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	day10 := start.AddDate(0, 0, 10) // a third of the window, so spending so far triples
+	food, dining, travel := uint64(1), uint64(2), uint64(3)
+	covered := map[uint64]bool{food: true, dining: true}
+
+	// 300 spent of 1000 by day 10
+	rows := []categorySpend{{CategoryID: food, Amount: 200}, {CategoryID: dining, Amount: 100}}
+
+	tests := []struct {
+		name          string
+		categoryID    *uint64
+		now           time.Time
+		spent         float64
+		factors       map[uint64]float64
+		extras        []models.SimulatedCashflow
+		wantBaseline  float64
+		wantProjected float64
+		wantPct       float64
+		wantExceed    bool
+		wantOverPace  bool
+	}{
+		{"no changes", &food, day10, 300, nil, nil, 900, 900, 90, false, false},
+		{"cut only scales what is left", &food, day10, 300, map[uint64]float64{food: 0.5}, nil, 900, 700, 70, false, false},
+		{"reaching the limit is not exceeding it", &food, day10, 300, map[uint64]float64{dining: 1.5}, nil, 900, 1000, 100, false, false},
+		{"increase pushes over the limit", &food, day10, 300, map[uint64]float64{food: 1.5}, nil, 900, 1100, 110, true, false},
+		{"change on another category", &food, day10, 300, map[uint64]float64{travel: 2}, nil, 900, 900, 90, false, false},
+		{"extra in a covered category counts for the period", &food, day10, 300, nil,
+			[]models.SimulatedCashflow{{CategoryID: &dining, MonthlyAmount: 150}}, 900, 1050, 105, true, false},
+		{"extra in another category", &food, day10, 300, nil,
+			[]models.SimulatedCashflow{{CategoryID: &travel, MonthlyAmount: 150}}, 900, 900, 90, false, false},
+		{"uncategorized extra skips categorized budgets", &food, day10, 300, nil,
+			[]models.SimulatedCashflow{{MonthlyAmount: 150}}, 900, 900, 90, false, false},
+		{"uncategorized extra counts for overall budgets", nil, day10, 300, nil,
+			[]models.SimulatedCashflow{{MonthlyAmount: 150}}, 900, 1050, 105, true, false},
+		{"categorized extra counts for overall budgets", nil, day10, 300, nil,
+			[]models.SimulatedCashflow{{CategoryID: &travel, MonthlyAmount: 60}}, 900, 960, 96, false, false},
+		{"ended window is not extrapolated", &food, end.AddDate(0, 0, 5), 300, map[uint64]float64{food: 2}, nil, 300, 300, 30, false, false},
+		{"spending ahead of pace", &food, day10, 500, nil, nil, 900, 900, 90, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &models.Budget{
+				Mode:            models.BudgetModeLimit,
+				CategoryID:      tt.categoryID,
+				StartDate:       start,
+				EndDate:         end,
+				SpentAmount:     tt.spent,
+				RemainingAmount: 1000 - tt.spent,
+				UsagePercentage: tt.spent / 10,
+			}
+			inBudget := func(uint64) bool { return true }
+			if tt.categoryID != nil {
+				inBudget = func(id uint64) bool { return covered[id] }
+			}
+			factor := func(id uint64) float64 {
+				if f, ok := tt.factors[id]; ok {
+					return f
+				}
+				return 1
+			}
+
+			got := projectBudget(budget, tt.now, rows, inBudget, factor, tt.extras)
+			if got.BaselineProjectedSpent != tt.wantBaseline || got.ProjectedSpent != tt.wantProjected {
+				t.Errorf("projected = %v (baseline %v), want %v (baseline %v)",
+					got.ProjectedSpent, got.BaselineProjectedSpent, tt.wantProjected, tt.wantBaseline)
+			}
+			if got.ProjectedPct != tt.wantPct || got.WillExceed != tt.wantExceed {
+				t.Errorf("pct = %v, exceed %v, want %v, exceed %v", got.ProjectedPct, got.WillExceed, tt.wantPct, tt.wantExceed)
+			}
+			if got.IsOverPace != tt.wantOverPace {
+				t.Errorf("IsOverPace = %v (allowed %v, actual %v), want %v",
+					got.IsOverPace, got.AllowedPacePct, got.ActualPacePct, tt.wantOverPace)
+			}
+		})
+	}
+}
+
+func TestProjectBudgetWithoutLimit(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	budget := &models.Budget{Mode: models.BudgetModeEnvelope, StartDate: start, EndDate: start.AddDate(0, 1, -1)}
+	rows := []categorySpend{{CategoryID: 1, Amount: 50}}
+	one := func(uint64) float64 { return 1 }
+
+	got := projectBudget(budget, start.AddDate(0, 0, 10), rows, func(uint64) bool { return true }, one, nil)
+	if got.ProjectedPct != 0 || got.BaselineProjectedPct != 0 {
+		t.Errorf("pct = %v / %v, want 0 without a limit", got.ProjectedPct, got.BaselineProjectedPct)
+	}
+	if !got.WillExceed {
+		t.Error("WillExceed = false, want true when spending an unfunded envelope")
+	}
+}
+
+func TestProjectCashflow(t *testing.T) {
+	food, rent := uint64(1), uint64(2)
+	// three months of history
+	rows := []cashflowSpend{
+		{TransactionType: "income", Amount: 9000},
+		{TransactionType: "expense", CategoryID: food, Amount: 1500},
+		{TransactionType: "expense", CategoryID: rent, Amount: 600},
+	}
+
+	tests := []struct {
+		name          string
+		rows          []cashflowSpend
+		factors       map[uint64]float64
+		req           models.BudgetSimulationRequest
+		wantBaseline  models.SimulatedCashflowSummary
+		wantSimulated models.SimulatedCashflowSummary
+	}{
+		{
+			name: "no changes",
+			rows: rows,
+			wantBaseline: models.SimulatedCashflowSummary{
+				MonthlyIncome: 3000, MonthlyExpense: 700, MonthlySavings: 2300, SavingsRate: 76.67, SurplusAfterGoals: 2300,
+			},
+			wantSimulated: models.SimulatedCashflowSummary{
+				MonthlyIncome: 3000, MonthlyExpense: 700, MonthlySavings: 2300, SavingsRate: 76.67, SurplusAfterGoals: 2300,
+			},
+		},
+		{
+			name:    "changes, extras and goal contributions",
+			rows:    rows,
+			factors: map[uint64]float64{food: 0.8},
+			req: models.BudgetSimulationRequest{
+				ExtraExpenses:     []models.SimulatedCashflow{{MonthlyAmount: 100}},
+				ExtraIncome:       []models.SimulatedCashflow{{MonthlyAmount: 250}},
+				GoalContributions: []models.SimulatedGoalContribution{{GoalID: 1, MonthlyAmount: 300}},
+			},
+			wantBaseline: models.SimulatedCashflowSummary{
+				MonthlyIncome: 3000, MonthlyExpense: 700, MonthlySavings: 2300, SavingsRate: 76.67, SurplusAfterGoals: 2300,
+			},
+			wantSimulated: models.SimulatedCashflowSummary{
+				MonthlyIncome: 3250, MonthlyExpense: 700, MonthlySavings: 2550, SavingsRate: 78.46,
+				GoalContributions: 300, SurplusAfterGoals: 2250,
+			},
+		},
+		{
+			name: "no income leaves the rate at zero",
+			rows: []cashflowSpend{{TransactionType: "expense", CategoryID: food, Amount: 100}},
+			req: models.BudgetSimulationRequest{
+				GoalContributions: []models.SimulatedGoalContribution{{GoalID: 1, MonthlyAmount: 50}},
+			},
+			wantBaseline: models.SimulatedCashflowSummary{
+				MonthlyExpense: 33.33, MonthlySavings: -33.33, SurplusAfterGoals: -33.33,
+			},
+			wantSimulated: models.SimulatedCashflowSummary{
+				MonthlyExpense: 33.33, MonthlySavings: -33.33, GoalContributions: 50, SurplusAfterGoals: -83.33,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factor := func(id uint64) float64 {
+				if f, ok := tt.factors[id]; ok {
+					return f
+				}
+				return 1
+			}
+			baseline, simulated := projectCashflow(tt.rows, factor, &tt.req)
+			if baseline != tt.wantBaseline {
+				t.Errorf("baseline = %+v, want %+v", baseline, tt.wantBaseline)
+			}
+			if simulated != tt.wantSimulated {
+				t.Errorf("simulated = %+v, want %+v", simulated, tt.wantSimulated)
+			}
+		})
+	}
+}
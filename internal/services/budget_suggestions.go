@@ -0,0 +1,410 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"tabimoney/internal/models"
+)
+
+const (
+	// maxSuggestionMonths caps the history window a request may ask for
+	maxSuggestionMonths = 24
+
+	// seasonalFactor marks a category as seasonal when it spent this many times
+	// its usual amount in the same month last year
+	seasonalFactor = 1.5
+)
+
+// fallbackShare is one line of the 50/30/20 fallback: a share of the needs or
+// wants part of income for a system category
+type fallbackShare struct {
+	group  string // needs or wants
+	nameEn string
+	share  float64
+}
+
+var fallbackShares = []fallbackShare{
+	{"needs", "Food & Dining", 0.5},
+	{"needs", "Transportation", 0.25},
+	{"needs", "Healthcare", 0.15},
+	{"needs", "Education", 0.1},
+	{"wants", "Entertainment", 0.4},
+	{"wants", "Shopping", 0.4},
+	{"wants", "Other", 0.2},
+}
+
+// SuggestBudgets proposes category budgets for the current month from the
+// chosen percentile of each category's monthly spending, raised for seasonal
+// categories and scaled to leave room for goal contributions. Without history
+// it falls back to 50/30/20. Household suggestions use the combined income of
+// all members. months and percentile default to the configured values when 0.
+func (s *BudgetService) SuggestBudgets(ledger Ledger, months, percentile int) (*models.AutoBudgetSuggestResponse, error) {
+	if months <= 0 {
+		months = s.config.Budget.SuggestionMonths
+	}
+	if months <= 0 {
+		months = 6
+	}
+	if months > maxSuggestionMonths {
+		months = maxSuggestionMonths
+	}
+	if percentile <= 0 || percentile > 100 {
+		percentile = s.config.Budget.SuggestionPercentile
+	}
+	if percentile <= 0 || percentile > 100 {
+		percentile = 50
+	}
+
+	now := time.Now()
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 23, 59, 59, 0, now.Location())
+
+	// fetch profile for income
+	var profile models.UserProfile
+	s.db.Model(&models.UserProfile{}).
+		Select("COALESCE(SUM(monthly_income),0) as monthly_income").
+		Where("user_id IN ?", ledgerMemberIDs(s.db, ledger)).
+		Scan(&profile)
+
+	history, err := s.spendingHistory(ledger, startOfMonth, months)
+	if err != nil {
+		return nil, err
+	}
+
+	income := profile.MonthlyIncome
+	var notes []string
+	if income <= 0 && len(history.months) > 0 {
+		income = roundAmount(percentileOf(history.income, 50))
+		notes = append(notes, fmt.Sprintf("No monthly income in the profile; using the median income of %.0f recorded over the last %d months", income, len(history.months)))
+	}
+
+	reserve, goalNotes, err := s.goalReserve(ledger, now)
+	if err != nil {
+		return nil, err
+	}
+	notes = append(notes, goalNotes...)
+
+	var suggestions []models.AutoBudgetSuggestion
+	if len(history.categories) > 0 {
+		suggestions = s.historySuggestions(history, percentile, startOfMonth)
+		notes = append(notes, fmt.Sprintf("Suggestions use the %s of monthly spending over the last %d months", percentileLabel(percentile), len(history.months)))
+	} else {
+		suggestions = s.fallbackSuggestions(income, reserve)
+		notes = append(notes, "No spending history yet; suggestions follow the 50/30/20 rule")
+	}
+
+	var total float64
+	for _, suggestion := range suggestions {
+		total += suggestion.SuggestedAmt
+	}
+	// Leave room for goal contributions; the fallback already did
+	if available := income - reserve; len(history.categories) > 0 && reserve > 0 && income > 0 && total > available {
+		if available <= 0 {
+			notes = append(notes, fmt.Sprintf("Active goals need %.0f a month, which is more than the monthly income", reserve))
+		} else {
+			scale := available / total
+			total = 0
+			for i := range suggestions {
+				suggestions[i].SuggestedAmt = roundAmount(suggestions[i].SuggestedAmt * scale)
+				suggestions[i].Explanation += fmt.Sprintf("; scaled to %.0f%% to leave %.0f a month for goals", scale*100, reserve)
+				total += suggestions[i].SuggestedAmt
+			}
+		}
+	}
+
+	if suggestions == nil {
+		suggestions = []models.AutoBudgetSuggestion{}
+	}
+	resp := &models.AutoBudgetSuggestResponse{
+		UserID:         ledger.UserID,
+		MonthlyIncome:  income,
+		Period:         "monthly",
+		StartDate:      startOfMonth,
+		EndDate:        endOfMonth,
+		Suggestions:    suggestions,
+		TotalSuggested: roundAmount(total),
+		GoalReserve:    roundAmount(reserve),
+		HistoryMonths:  len(history.months),
+		Percentile:     percentile,
+		Notes:          notes,
+	}
+	return resp, nil
+}
+
+// spendingHistory is the ledger's monthly spending per category and monthly
+// income over the months that have data
+type spendingHistory struct {
+	months     []time.Time // first day of each month with data, oldest first
+	income     []float64   // per month, aligned with months
+	categories []categoryHistory
+	lastYear   map[uint64]float64 // spending in the current month one year earlier
+}
+
+type categoryHistory struct {
+	id      uint64
+	name    string
+	amounts []float64 // per month, aligned with months; 0 when nothing was spent
+}
+
+// spendingHistory loads the full months before current. Months before the
+// ledger's first transaction have no data and are left out, rather than
+// counted as months without spending.
+func (s *BudgetService) spendingHistory(ledger Ledger, current time.Time, months int) (*spendingHistory, error) {
+	history := &spendingHistory{lastYear: map[uint64]float64{}}
+
+	var first []models.Transaction
+	if err := s.db.Scopes(ledger.Scope("")).Select("transaction_date").Order("transaction_date ASC").Limit(1).Find(&first).Error; err != nil {
+		return nil, fmt.Errorf("failed to load transactions: %w", err)
+	}
+	if len(first) == 0 {
+		return history, nil
+	}
+	firstMonth := time.Date(first[0].TransactionDate.Year(), first[0].TransactionDate.Month(), 1, 0, 0, 0, 0, current.Location())
+
+	index := map[string]int{}
+	for m := current.AddDate(0, -months, 0); m.Before(current); m = m.AddDate(0, 1, 0) {
+		if m.Before(firstMonth) {
+			continue
+		}
+		index[m.Format("2006-01")] = len(history.months)
+		history.months = append(history.months, m)
+	}
+	if len(history.months) == 0 {
+		return history, nil
+	}
+	history.income = make([]float64, len(history.months))
+
+	var rows []struct {
+		TransactionType string
+		CategoryID      uint64
+		Name            string
+		Year            int
+		Month           int
+		Amount          float64
+	}
+	scope, args := ledger.condition("t")
+	if err := s.db.Table("transactions t").
+		Select("t.transaction_type, t.category_id, c.name, YEAR(t.transaction_date) AS year, MONTH(t.transaction_date) AS month, COALESCE(SUM(t.amount), 0) AS amount").
+		Joins("JOIN categories c ON c.id = t.category_id").
+		Where(scope, args...).
+		Where("t.transaction_type IN ? AND t.transaction_date >= ? AND t.transaction_date < ?", []string{"income", "expense"}, history.months[0], current).
+		Group("t.transaction_type, t.category_id, c.name, YEAR(t.transaction_date), MONTH(t.transaction_date)").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load spending history: %w", err)
+	}
+
+	byCategory := map[uint64]int{}
+	for _, row := range rows {
+		i, ok := index[fmt.Sprintf("%04d-%02d", row.Year, row.Month)]
+		if !ok {
+			continue
+		}
+		if row.TransactionType == "income" {
+			history.income[i] += row.Amount
+			continue
+		}
+		c, ok := byCategory[row.CategoryID]
+		if !ok {
+			c = len(history.categories)
+			byCategory[row.CategoryID] = c
+			history.categories = append(history.categories, categoryHistory{
+				id:      row.CategoryID,
+				name:    row.Name,
+				amounts: make([]float64, len(history.months)),
+			})
+		}
+		history.categories[c].amounts[i] += row.Amount
+	}
+
+	// Same month last year, to spot seasonal costs such as Tết or school fees.
+	// A category only used then still gets a suggestion.
+	lastYear := current.AddDate(-1, 0, 0)
+	if !lastYear.Before(firstMonth) {
+		var seasonal []struct {
+			CategoryID uint64
+			Name       string
+			Amount     float64
+		}
+		if err := s.db.Table("transactions t").
+			Select("t.category_id, c.name, COALESCE(SUM(t.amount), 0) AS amount").
+			Joins("JOIN categories c ON c.id = t.category_id").
+			Where(scope, args...).
+			Where("t.transaction_type = ? AND t.transaction_date >= ? AND t.transaction_date < ?", "expense", lastYear, lastYear.AddDate(0, 1, 0)).
+			Group("t.category_id, c.name").
+			Scan(&seasonal).Error; err != nil {
+			return nil, fmt.Errorf("failed to load spending history: %w", err)
+		}
+		for _, row := range seasonal {
+			history.lastYear[row.CategoryID] = row.Amount
+			if _, ok := byCategory[row.CategoryID]; !ok {
+				byCategory[row.CategoryID] = len(history.categories)
+				history.categories = append(history.categories, categoryHistory{
+					id:      row.CategoryID,
+					name:    row.Name,
+					amounts: make([]float64, len(history.months)),
+				})
+			}
+		}
+	}
+
+	return history, nil
+}
+
+// historySuggestions suggests the percentile of each category's monthly
+// spending, or last year's amount for the month when that was much higher
+func (s *BudgetService) historySuggestions(history *spendingHistory, percentile int, current time.Time) []models.AutoBudgetSuggestion {
+	var suggestions []models.AutoBudgetSuggestion
+	for _, category := range history.categories {
+		amount := percentileOf(category.amounts, percentile)
+
+		empty := 0
+		for _, a := range category.amounts {
+			if a == 0 {
+				empty++
+			}
+		}
+		suggestion := models.AutoBudgetSuggestion{
+			Name:    category.name,
+			Basis:   "percentile",
+			History: category.amounts,
+			Explanation: fmt.Sprintf("Based on the %s of monthly spending over the last %d months",
+				percentileLabel(percentile), len(category.amounts)),
+		}
+		if empty > 0 {
+			suggestion.Explanation += fmt.Sprintf(", %d of them without spending", empty)
+		}
+		if last := history.lastYear[category.id]; last > 0 && last > amount*seasonalFactor {
+			suggestion.Basis = "seasonal"
+			suggestion.Explanation = fmt.Sprintf("Seasonal: %s last year cost %.0f, well above the usual %.0f",
+				current.AddDate(-1, 0, 0).Format("01/2006"), last, amount)
+			amount = last
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		id := category.id
+		suggestion.CategoryID = &id
+		suggestion.SuggestedAmt = roundAmount(amount)
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].SuggestedAmt > suggestions[j].SuggestedAmt })
+	return suggestions
+}
+
+// fallbackSuggestions splits income 50/30/20 over the system categories,
+// saving at least what active goals need
+func (s *BudgetService) fallbackSuggestions(income, reserve float64) []models.AutoBudgetSuggestion {
+	if income <= 0 {
+		return nil
+	}
+	savings := math.Max(income*0.2, math.Min(reserve, income))
+	groups := map[string]float64{
+		"needs": (income - savings) * 0.5 / 0.8,
+		"wants": (income - savings) * 0.3 / 0.8,
+	}
+
+	var categories []models.Category
+	s.db.Where("is_system = ?", true).Find(&categories)
+	find := func(nameEn string) *models.Category {
+		for i := range categories {
+			if categories[i].NameEn == nameEn {
+				return &categories[i]
+			}
+		}
+		return nil
+	}
+
+	var suggestions []models.AutoBudgetSuggestion
+	for _, line := range fallbackShares {
+		category := find(line.nameEn)
+		if category == nil {
+			continue
+		}
+		id := category.ID
+		suggestions = append(suggestions, models.AutoBudgetSuggestion{
+			CategoryID:   &id,
+			Name:         category.Name,
+			SuggestedAmt: roundAmount(groups[line.group] * line.share),
+			Basis:        "50_30_20",
+			Explanation:  fmt.Sprintf("%.0f%% of the %s share of income (50/30/20 rule)", line.share*100, line.group),
+		})
+	}
+	if category := find("Savings"); category != nil {
+		id := category.ID
+		explanation := "20% of income (50/30/20 rule)"
+		if savings > income*0.2 {
+			explanation = "Raised above 20% of income to cover active goal contributions"
+		}
+		suggestions = append(suggestions, models.AutoBudgetSuggestion{
+			CategoryID:   &id,
+			Name:         category.Name,
+			SuggestedAmt: roundAmount(savings),
+			Basis:        "50_30_20",
+			Explanation:  explanation,
+		})
+	}
+	return suggestions
+}
+
+// goalReserve is the monthly amount active goals need: what is left spread
+// over the months to the target date, or the usual estimate without one
+func (s *BudgetService) goalReserve(ledger Ledger, now time.Time) (float64, []string, error) {
+	var goals []models.FinancialGoal
+	if err := s.db.Scopes(ledger.Scope("")).Where("is_achieved = ?", false).Find(&goals).Error; err != nil {
+		return 0, nil, fmt.Errorf("failed to load goals: %w", err)
+	}
+
+	var reserve float64
+	var notes []string
+	for _, goal := range goals {
+		remaining := goal.TargetAmount - goal.CurrentAmount
+		if remaining <= 0 {
+			continue
+		}
+		monthly := math.Min(remaining, estimatedGoalContribution(goal))
+		if goal.TargetDate != nil && goal.TargetDate.After(now) {
+			monthsLeft := math.Max(1, math.Ceil(goal.TargetDate.Sub(now).Hours()/24/30))
+			monthly = remaining / monthsLeft
+		}
+		reserve += monthly
+		notes = append(notes, fmt.Sprintf("Reserved %.0f a month for goal \"%s\"", monthly, goal.Title))
+	}
+	return reserve, notes, nil
+}
+
+// percentileOf interpolates the p-th percentile of values
+func percentileOf(values []float64, p int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := float64(p) / 100 * float64(len(sorted)-1)
+	lo, hi := int(math.Floor(rank)), int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// percentileLabel names a percentile, e.g. "median" or "75th percentile"
+func percentileLabel(p int) string {
+	if p == 50 {
+		return "median"
+	}
+	suffix := "th"
+	if p%100 < 11 || p%100 > 13 {
+		switch p % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s percentile", p, suffix)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxCategoryDepth is the number of levels a category tree may have, so a
+// top-level category can have children and grandchildren but no deeper
+const maxCategoryDepth = 3
+
+var (
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("a category cannot be placed under itself or one of its subcategories")
+	ErrCategoryTooDeep        = fmt.Errorf("categories can be nested at most %d levels deep", maxCategoryDepth)
+)
+
+// categoryTree is the parent/child structure of the categories visible to a ledger
+type categoryTree struct {
+	parent   map[uint64]*uint64
+	children map[uint64][]uint64
+	names    map[uint64]string
+	order    []uint64
+}
+
+// categoryNode is one category's place in a categoryTree
+type categoryNode struct {
+	ID       uint64
+	ParentID *uint64
+	Name     string
+}
+
+// loadCategoryTree loads the categories matched by scope, children in display order
+func loadCategoryTree(db *gorm.DB, scope func(*gorm.DB) *gorm.DB) (*categoryTree, error) {
+	var rows []categoryNode
+	if err := db.Model(&models.Category{}).Scopes(scope).Select("id, parent_id, name").
+		Order("sort_order ASC, name ASC").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	return newCategoryTree(rows), nil
+}
+
+// newCategoryTree builds the tree of categories given in display order
+func newCategoryTree(rows []categoryNode) *categoryTree {
+	tree := &categoryTree{
+		parent:   make(map[uint64]*uint64, len(rows)),
+		children: make(map[uint64][]uint64),
+		names:    make(map[uint64]string, len(rows)),
+	}
+	for _, row := range rows {
+		tree.parent[row.ID] = row.ParentID
+		tree.names[row.ID] = row.Name
+		tree.order = append(tree.order, row.ID)
+	}
+	for _, row := range rows {
+		// A parent outside the scope makes the category a root here
+		if row.ParentID != nil {
+			if _, ok := tree.parent[*row.ParentID]; ok {
+				tree.children[*row.ParentID] = append(tree.children[*row.ParentID], row.ID)
+			}
+		}
+	}
+	return tree
+}
+
+// roots returns the top-level categories, in display order
+func (t *categoryTree) roots() []uint64 {
+	var roots []uint64
+	for _, id := range t.order {
+		if parent := t.parent[id]; parent == nil {
+			roots = append(roots, id)
+		} else if _, ok := t.parent[*parent]; !ok {
+			roots = append(roots, id)
+		}
+	}
+	return roots
+}
+
+// descendants returns id followed by every category below it
+func (t *categoryTree) descendants(id uint64) []uint64 {
+	ids := []uint64{id}
+	seen := map[uint64]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range t.children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+// ancestors returns id followed by its parent, grandparent and so on
+func (t *categoryTree) ancestors(id uint64) []uint64 {
+	ids := []uint64{id}
+	seen := map[uint64]bool{id: true}
+	for parent := t.parent[id]; parent != nil && !seen[*parent]; parent = t.parent[*parent] {
+		if _, ok := t.parent[*parent]; !ok {
+			break
+		}
+		seen[*parent] = true
+		ids = append(ids, *parent)
+	}
+	return ids
+}
+
+// height is the number of levels in the subtree rooted at id, itself included
+func (t *categoryTree) height(id uint64) int {
+	return t.subtreeHeight(id, map[uint64]bool{})
+}
+
+// subtreeHeight skips categories it has already visited, so a parent cycle
+// left in the data cannot recurse forever
+func (t *categoryTree) subtreeHeight(id uint64, seen map[uint64]bool) int {
+	seen[id] = true
+	best := 0
+	for _, child := range t.children[id] {
+		if seen[child] {
+			continue
+		}
+		if h := t.subtreeHeight(child, seen); h > best {
+			best = h
+		}
+	}
+	return best + 1
+}
+
+// categoryWithDescendants returns the category and all of its subcategories
+// visible to the ledger, so spending rolls up to a parent
+func categoryWithDescendants(db *gorm.DB, ledger Ledger, categoryID uint64) ([]uint64, error) {
+	tree, err := loadCategoryTree(db, ledger.CategoryScope())
+	if err != nil {
+		return nil, err
+	}
+	return tree.descendants(categoryID), nil
+}
+
+// categoryWithAncestors returns the category and every category above it
+// visible to the ledger, i.e. the categories whose budgets its spending counts towards
+func categoryWithAncestors(db *gorm.DB, ledger Ledger, categoryID uint64) ([]uint64, error) {
+	tree, err := loadCategoryTree(db, ledger.CategoryScope())
+	if err != nil {
+		return nil, err
+	}
+	return tree.ancestors(categoryID), nil
+}
+
+// ValidateCategoryParent checks that categoryID (0 for a new category) may be
+// placed under parentID: the parent must be in scope, must not be the category
+// or one of its subcategories, and the tree must stay within maxCategoryDepth
+func ValidateCategoryParent(db *gorm.DB, scope func(*gorm.DB) *gorm.DB, categoryID uint64, parentID *uint64) error {
+	if parentID == nil {
+		return nil
+	}
+
+	tree, err := loadCategoryTree(db, scope)
+	if err != nil {
+		return err
+	}
+	return tree.validateParent(categoryID, *parentID)
+}
+
+// validateParent is ValidateCategoryParent on a loaded tree
+func (t *categoryTree) validateParent(categoryID, parentID uint64) error {
+	if _, ok := t.parent[parentID]; !ok {
+		return ErrParentCategoryNotFound
+	}
+
+	height := 1
+	if categoryID != 0 {
+		for _, id := range t.descendants(categoryID) {
+			if id == parentID {
+				return ErrCategoryCycle
+			}
+		}
+		height = t.height(categoryID)
+	}
+
+	if len(t.ancestors(parentID))+height > maxCategoryDepth {
+		return ErrCategoryTooDeep
+	}
+	return nil
+}
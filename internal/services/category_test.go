@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateCategoryParent(t *testing.T) {
+	parent := func(id uint64) *uint64 { return &id }
+	tree := newCategoryTree([]categoryNode{
+		{ID: 1, Name: "Food"},
+		{ID: 2, ParentID: parent(1), Name: "Dining out"},
+		{ID: 3, ParentID: parent(2), Name: "Coffee"},
+		{ID: 4, ParentID: parent(1), Name: "Groceries"},
+		{ID: 5, Name: "Transport"},
+		{ID: 6, ParentID: parent(5), Name: "Fuel"},
+		{ID: 7, ParentID: parent(99), Name: "Parent out of scope"},
+		// A cycle left in the data by an older version
+		{ID: 8, ParentID: parent(9), Name: "Loop A"},
+		{ID: 9, ParentID: parent(8), Name: "Loop B"},
+	})
+
+	tests := []struct {
+		name       string
+		categoryID uint64 // 0 for a new category
+		parentID   uint64
+		wantErr    error
+	}{
+		{"new under a root", 0, 1, nil},
+		{"new on the third level", 0, 2, nil},
+		{"new on a fourth level", 0, 3, ErrCategoryTooDeep},
+		{"parent not in scope", 0, 99, ErrParentCategoryNotFound},
+		{"move a subtree under another root", 2, 5, nil},
+		{"move a subtree one level too deep", 2, 6, ErrCategoryTooDeep},
+		{"move a leaf to the third level", 4, 2, nil},
+		{"move a root with children under a child", 5, 4, ErrCategoryTooDeep},
+		{"under itself", 1, 1, ErrCategoryCycle},
+		{"under its grandchild", 1, 3, ErrCategoryCycle},
+		{"under its child", 2, 3, ErrCategoryCycle},
+		{"category whose parent is out of scope", 7, 1, nil},
+		{"new under a category in a cycle", 0, 8, nil},
+		{"close the cycle again", 9, 8, ErrCategoryCycle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tree.validateParent(tt.categoryID, tt.parentID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validateParent(%d, %d) = %v, want %v", tt.categoryID, tt.parentID, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCategoryTreeTraversal(t *testing.T) {
+	parent := func(id uint64) *uint64 { return &id }
+	tree := newCategoryTree([]categoryNode{
+		{ID: 1, Name: "Food"},
+		{ID: 2, ParentID: parent(1), Name: "Dining out"},
+		{ID: 3, ParentID: parent(2), Name: "Coffee"},
+		{ID: 4, ParentID: parent(1), Name: "Groceries"},
+		{ID: 7, ParentID: parent(99), Name: "Parent out of scope"},
+	})
+
+	tests := []struct {
+		name string
+		got  []uint64
+		want []uint64
+	}{
+		{"roots", tree.roots(), []uint64{1, 7}},
+		{"descendants", tree.descendants(1), []uint64{1, 2, 4, 3}},
+		{"descendants of a leaf", tree.descendants(3), []uint64{3}},
+		{"ancestors", tree.ancestors(3), []uint64{3, 2, 1}},
+		{"ancestors stop at the scope", tree.ancestors(7), []uint64{7}},
+	}
+	for _, tt := range tests {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if tt.got[i] != tt.want[i] {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+				break
+			}
+		}
+	}
+	if h := tree.height(1); h != 3 {
+		t.Errorf("height(1) = %d, want 3", h)
+	}
+}
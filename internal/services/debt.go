@@ -0,0 +1,678 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDebtNotFound           = errors.New("debt not found")
+	ErrDebtPaymentNotFound    = errors.New("debt payment not found")
+	ErrDebtPaidOff            = errors.New("debt is already paid off")
+	ErrDebtCategoryNotFound   = errors.New("repayment category not found")
+	ErrDebtCategoryLinked     = errors.New("another open debt already uses this repayment category")
+	ErrAutomaticDebtPayment   = errors.New("this payment follows its transaction; edit the transaction instead")
+	ErrDebtPaymentTooLow      = errors.New("monthly payment does not cover the interest, so the debt would never be paid off")
+	ErrPayoffBudgetTooLow     = errors.New("monthly budget is less than the sum of minimum payments")
+	ErrNoDebtsToPay           = errors.New("no open debts to pay off")
+	ErrInvalidDebtPaymentDate = errors.New("payment date cannot be before the debt started")
+	ErrDebtPaymentTooHigh     = errors.New("payment is more than the remaining balance")
+)
+
+// maxPayoffMonths bounds amortization and payoff simulations to 50 years
+const maxPayoffMonths = 600
+
+type DebtService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+func NewDebtService(cfg *config.Config) *DebtService {
+	return &DebtService{
+		db:     database.GetDB(),
+		config: cfg,
+	}
+}
+
+// ListDebts lists the ledger's debts, open ones first
+func (s *DebtService) ListDebts(ledger Ledger) ([]models.Debt, error) {
+	debts := []models.Debt{}
+	if err := s.db.Scopes(ledger.Scope("")).Order("paid_off_at IS NOT NULL, apr DESC, id ASC").Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load debts: %w", err)
+	}
+	now := time.Now()
+	for i := range debts {
+		setNextDueDate(&debts[i], now)
+	}
+	return debts, nil
+}
+
+// GetDebt returns one debt of the ledger
+func (s *DebtService) GetDebt(ledger Ledger, debtID uint64) (*models.Debt, error) {
+	var debt models.Debt
+	if err := s.findDebt(s.db, ledger, debtID, &debt); err != nil {
+		return nil, err
+	}
+	setNextDueDate(&debt, time.Now())
+	return &debt, nil
+}
+
+// CreateDebt adds a debt to the ledger
+func (s *DebtService) CreateDebt(ledger Ledger, req *models.DebtRequest) (*models.Debt, error) {
+	if err := s.checkDebtCategory(ledger, 0, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	debt := &models.Debt{
+		UserID:      ledger.UserID,
+		HouseholdID: ledger.HouseholdID,
+		Balance:     req.Principal,
+	}
+	if req.Balance != nil {
+		debt.Balance = *req.Balance
+	}
+	applyDebtRequest(debt, req)
+	updateDebtPaidOff(debt)
+
+	if err := s.db.Create(debt).Error; err != nil {
+		return nil, fmt.Errorf("failed to create debt: %w", err)
+	}
+
+	invalidateDashboards(s.db, ledger, map[string]interface{}{"debt_id": debt.ID, "action": "created"})
+	setNextDueDate(debt, time.Now())
+	return debt, nil
+}
+
+// UpdateDebt updates a debt's terms. Its balance only changes when given,
+// for example to match a new statement.
+func (s *DebtService) UpdateDebt(ledger Ledger, debtID uint64, req *models.DebtRequest) (*models.Debt, error) {
+	var debt models.Debt
+	if err := s.findDebt(s.db, ledger, debtID, &debt); err != nil {
+		return nil, err
+	}
+	if err := s.checkDebtCategory(ledger, debt.ID, req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if req.Balance != nil {
+		debt.Balance = *req.Balance
+	}
+	applyDebtRequest(&debt, req)
+	updateDebtPaidOff(&debt)
+
+	if err := s.db.Save(&debt).Error; err != nil {
+		return nil, fmt.Errorf("failed to update debt: %w", err)
+	}
+
+	invalidateDashboards(s.db, ledger, map[string]interface{}{"debt_id": debt.ID, "action": "updated"})
+	setNextDueDate(&debt, time.Now())
+	return &debt, nil
+}
+
+// DeleteDebt deletes a debt and its payments. Linked transactions stay.
+func (s *DebtService) DeleteDebt(ledger Ledger, debtID uint64) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Scopes(ledger.Scope("")).Where("id = ?", debtID).Delete(&models.Debt{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete debt: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrDebtNotFound
+		}
+		return tx.Where("debt_id = ?", debtID).Delete(&models.DebtPayment{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateDashboards(s.db, ledger, map[string]interface{}{"debt_id": debtID, "action": "deleted"})
+	return nil
+}
+
+// ListPayments lists a debt's payments, newest first
+func (s *DebtService) ListPayments(ledger Ledger, debtID uint64) ([]models.DebtPayment, error) {
+	var debt models.Debt
+	if err := s.findDebt(s.db, ledger, debtID, &debt); err != nil {
+		return nil, err
+	}
+
+	payments := []models.DebtPayment{}
+	if err := s.db.Where("debt_id = ?", debtID).Order("paid_at DESC, id DESC").Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load debt payments: %w", err)
+	}
+	return payments, nil
+}
+
+// AddPayment records a repayment and takes it off the balance. A payment
+// above the balance is rejected, since deleting it later would put back more
+// than was owed.
+func (s *DebtService) AddPayment(ledger Ledger, debtID uint64, req *models.DebtPaymentRequest) (*models.Debt, error) {
+	var debt models.Debt
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.findDebt(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ledger, debtID, &debt); err != nil {
+			return err
+		}
+		if debt.PaidOffAt != nil {
+			return ErrDebtPaidOff
+		}
+		paidAt := contributionDate(req.Date)
+		if paidAt.Before(debt.StartDate) {
+			return ErrInvalidDebtPaymentDate
+		}
+		if roundAmount(req.Amount) > debt.Balance {
+			return ErrDebtPaymentTooHigh
+		}
+		if req.TransactionID != nil {
+			if err := s.checkPaymentTransaction(tx, ledger, *req.TransactionID); err != nil {
+				return err
+			}
+		}
+
+		payment := &models.DebtPayment{
+			DebtID:        debt.ID,
+			UserID:        ledger.UserID,
+			HouseholdID:   debt.HouseholdID,
+			Amount:        roundAmount(req.Amount),
+			PaidAt:        paidAt,
+			Note:          truncateString(req.Note, 500),
+			TransactionID: req.TransactionID,
+		}
+		if err := tx.Create(payment).Error; err != nil {
+			return fmt.Errorf("failed to add debt payment: %w", err)
+		}
+		return applyDebtPayment(tx, &debt, payment.Amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateDashboards(s.db, ledger, map[string]interface{}{"debt_id": debt.ID, "action": "payment"})
+	setNextDueDate(&debt, time.Now())
+	return &debt, nil
+}
+
+// DeletePayment removes a manual payment and puts it back on the balance
+func (s *DebtService) DeletePayment(ledger Ledger, debtID, paymentID uint64) (*models.Debt, error) {
+	var debt models.Debt
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.findDebt(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ledger, debtID, &debt); err != nil {
+			return err
+		}
+
+		var payment models.DebtPayment
+		if err := tx.Where("id = ? AND debt_id = ?", paymentID, debtID).First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDebtPaymentNotFound
+			}
+			return fmt.Errorf("failed to load debt payment: %w", err)
+		}
+		if payment.IsAutomatic {
+			return ErrAutomaticDebtPayment
+		}
+		if err := tx.Delete(&payment).Error; err != nil {
+			return fmt.Errorf("failed to delete debt payment: %w", err)
+		}
+		return applyDebtPayment(tx, &debt, -payment.Amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateDashboards(s.db, ledger, map[string]interface{}{"debt_id": debt.ID, "action": "payment"})
+	setNextDueDate(&debt, time.Now())
+	return &debt, nil
+}
+
+// SyncTransactionPayment keeps a transaction's automatic repayment in line
+// with it: an expense or transfer in an open debt's repayment category pays
+// that debt, and stops doing so once it no longer qualifies
+func (s *DebtService) SyncTransactionPayment(transaction *models.Transaction) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.DebtPayment
+		err := tx.Where("transaction_id = ?", transaction.ID).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to load debt payment: %w", err)
+		}
+		found := err == nil
+		if found && !existing.IsAutomatic {
+			// Linked by hand; the user chose the amount
+			return nil
+		}
+
+		if found {
+			var debt models.Debt
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&debt, existing.DebtID).Error; err != nil {
+				return fmt.Errorf("failed to load debt: %w", err)
+			}
+			if debt.CategoryID != nil && *debt.CategoryID == transaction.CategoryID && transaction.TransactionType != "income" {
+				amount := capDebtPayment(transaction.Amount, debt.Balance+existing.Amount)
+				delta := amount - existing.Amount
+				if err := tx.Model(&existing).Updates(map[string]interface{}{
+					"amount":  amount,
+					"paid_at": transaction.TransactionDate,
+					"note":    truncateString(transaction.Description, 500),
+				}).Error; err != nil {
+					return fmt.Errorf("failed to update debt payment: %w", err)
+				}
+				return applyDebtPayment(tx, &debt, delta)
+			}
+
+			if err := tx.Delete(&existing).Error; err != nil {
+				return fmt.Errorf("failed to delete debt payment: %w", err)
+			}
+			if err := applyDebtPayment(tx, &debt, -existing.Amount); err != nil {
+				return err
+			}
+		}
+
+		if transaction.TransactionType == "income" {
+			return nil
+		}
+		var debts []models.Debt
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(rowLedger(transaction.UserID, transaction.HouseholdID).Scope("")).
+			Where("category_id = ? AND paid_off_at IS NULL", transaction.CategoryID).
+			Order("id ASC").Limit(1).Find(&debts).Error; err != nil {
+			return fmt.Errorf("failed to load debt: %w", err)
+		}
+		if len(debts) == 0 {
+			return nil
+		}
+
+		id := transaction.ID
+		payment := &models.DebtPayment{
+			DebtID:        debts[0].ID,
+			UserID:        transaction.UserID,
+			HouseholdID:   debts[0].HouseholdID,
+			Amount:        capDebtPayment(transaction.Amount, debts[0].Balance),
+			PaidAt:        transaction.TransactionDate,
+			Note:          truncateString(transaction.Description, 500),
+			TransactionID: &id,
+			IsAutomatic:   true,
+		}
+		if err := tx.Create(payment).Error; err != nil {
+			return fmt.Errorf("failed to add debt payment: %w", err)
+		}
+		return applyDebtPayment(tx, &debts[0], payment.Amount)
+	})
+}
+
+// RemoveTransactionPayments puts a deleted transaction's repayments back on
+// their debts
+func (s *DebtService) RemoveTransactionPayments(transactionID uint64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var payments []models.DebtPayment
+		if err := tx.Where("transaction_id = ?", transactionID).Find(&payments).Error; err != nil {
+			return fmt.Errorf("failed to load debt payments: %w", err)
+		}
+		for i := range payments {
+			var debt models.Debt
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&debt, payments[i].DebtID).Error; err != nil {
+				return fmt.Errorf("failed to load debt: %w", err)
+			}
+			if err := tx.Delete(&payments[i]).Error; err != nil {
+				return fmt.Errorf("failed to delete debt payment: %w", err)
+			}
+			if err := applyDebtPayment(tx, &debt, -payments[i].Amount); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetAmortizationSchedule pays the debt's balance off with a fixed monthly
+// payment, the minimum payment unless one is given
+func (s *DebtService) GetAmortizationSchedule(ledger Ledger, debtID uint64, payment float64) (*models.AmortizationSchedule, error) {
+	var debt models.Debt
+	if err := s.findDebt(s.db, ledger, debtID, &debt); err != nil {
+		return nil, err
+	}
+	if payment <= 0 {
+		payment = debt.MinimumPayment
+	}
+	return amortize(&debt, payment, time.Now())
+}
+
+// ComparePayoffStrategies pays all open debts off with a monthly budget,
+// the sum of minimum payments unless one is given, once by avalanche and
+// once by snowball
+func (s *DebtService) ComparePayoffStrategies(ledger Ledger, budget float64) (*models.DebtPayoffComparison, error) {
+	var debts []models.Debt
+	if err := s.db.Scopes(ledger.Scope("")).Where("paid_off_at IS NULL AND balance > 0").Order("id ASC").Find(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load debts: %w", err)
+	}
+	if len(debts) == 0 {
+		return nil, ErrNoDebtsToPay
+	}
+
+	comparison := &models.DebtPayoffComparison{}
+	for _, debt := range debts {
+		comparison.MinimumTotal += math.Min(debt.MinimumPayment, debt.Balance)
+		comparison.TotalBalance += debt.Balance
+	}
+	comparison.MinimumTotal = roundAmount(comparison.MinimumTotal)
+	comparison.TotalBalance = roundAmount(comparison.TotalBalance)
+	if budget <= 0 {
+		budget = comparison.MinimumTotal
+	}
+	if budget < comparison.MinimumTotal {
+		return nil, ErrPayoffBudgetTooLow
+	}
+	comparison.MonthlyBudget = roundAmount(budget)
+
+	now := time.Now()
+	avalanche, err := simulatePayoff(debts, budget, models.DebtStrategyAvalanche, now)
+	if err != nil {
+		return nil, err
+	}
+	snowball, err := simulatePayoff(debts, budget, models.DebtStrategySnowball, now)
+	if err != nil {
+		return nil, err
+	}
+	comparison.Avalanche = *avalanche
+	comparison.Snowball = *snowball
+	comparison.InterestSaved = roundAmount(snowball.TotalInterest - avalanche.TotalInterest)
+
+	// Snowball clears the first debt sooner, so it wins when interest is equal
+	comparison.Recommendation = models.DebtStrategySnowball
+	if comparison.InterestSaved > 0 {
+		comparison.Recommendation = models.DebtStrategyAvalanche
+	}
+	return comparison, nil
+}
+
+// findDebt loads a debt of the ledger
+func (s *DebtService) findDebt(db *gorm.DB, ledger Ledger, debtID uint64, debt *models.Debt) error {
+	if err := db.Scopes(ledger.Scope("")).Where("id = ?", debtID).First(debt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDebtNotFound
+		}
+		return fmt.Errorf("failed to load debt: %w", err)
+	}
+	return nil
+}
+
+// checkDebtCategory ensures a repayment category is visible to the ledger and
+// not used by another open debt, so each transaction pays one debt
+func (s *DebtService) checkDebtCategory(ledger Ledger, debtID uint64, categoryID *uint64) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Category{}).Scopes(ledger.CategoryScope()).Where("id = ?", *categoryID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load category: %w", err)
+	}
+	if count == 0 {
+		return ErrDebtCategoryNotFound
+	}
+	if err := s.db.Model(&models.Debt{}).Scopes(ledger.Scope("")).
+		Where("category_id = ? AND paid_off_at IS NULL AND id <> ?", *categoryID, debtID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check debts: %w", err)
+	}
+	if count > 0 {
+		return ErrDebtCategoryLinked
+	}
+	return nil
+}
+
+// checkPaymentTransaction ensures a transaction is in the ledger and does not
+// already pay a debt
+func (s *DebtService) checkPaymentTransaction(tx *gorm.DB, ledger Ledger, transactionID uint64) error {
+	var count int64
+	if err := tx.Model(&models.Transaction{}).Scopes(ledger.Scope("")).Where("id = ?", transactionID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if count == 0 {
+		return ErrLinkedTransactionNotFound
+	}
+	if err := tx.Model(&models.DebtPayment{}).Where("transaction_id = ?", transactionID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check debt payments: %w", err)
+	}
+	if count > 0 {
+		return ErrTransactionAlreadyLinked
+	}
+	return nil
+}
+
+func applyDebtRequest(debt *models.Debt, req *models.DebtRequest) {
+	y, m, d := req.StartDate.Date()
+	debt.Name = req.Name
+	debt.Kind = req.Kind
+	debt.Lender = req.Lender
+	debt.Principal = roundAmount(req.Principal)
+	debt.Balance = roundAmount(debt.Balance)
+	debt.APR = req.APR
+	debt.MinimumPayment = roundAmount(req.MinimumPayment)
+	debt.DueDay = req.DueDay
+	debt.StartDate = time.Date(y, m, d, 0, 0, 0, 0, req.StartDate.Location())
+	debt.CategoryID = req.CategoryID
+}
+
+// capDebtPayment limits an automatic payment to what is still owed, so that
+// taking it back later restores the balance it paid off and no more
+func capDebtPayment(amount, balance float64) float64 {
+	return math.Min(roundAmount(amount), roundAmount(balance))
+}
+
+// applyDebtPayment takes a payment of amount off the balance, or puts it back
+// when negative, and updates the paid off state
+func applyDebtPayment(tx *gorm.DB, debt *models.Debt, amount float64) error {
+	debt.Balance = math.Max(0, roundAmount(debt.Balance-amount))
+	updateDebtPaidOff(debt)
+	if err := tx.Model(debt).Updates(map[string]interface{}{
+		"balance":     debt.Balance,
+		"paid_off_at": debt.PaidOffAt,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update debt: %w", err)
+	}
+	return nil
+}
+
+// updateDebtPaidOff marks a debt paid off once nothing is owed, and open again
+// when a balance comes back
+func updateDebtPaidOff(debt *models.Debt) {
+	if debt.Balance <= 0 {
+		if debt.PaidOffAt == nil {
+			now := time.Now()
+			debt.PaidOffAt = &now
+		}
+	} else {
+		debt.PaidOffAt = nil
+	}
+}
+
+// setNextDueDate fills in the next payment date of an open debt
+func setNextDueDate(debt *models.Debt, now time.Time) {
+	debt.NextDueDate = nil
+	if debt.PaidOffAt == nil {
+		due := nextDebtDueDate(debt, now)
+		debt.NextDueDate = &due
+	}
+}
+
+// nextDebtDueDate returns the first due date from today on, and not before
+// the debt started
+func nextDebtDueDate(debt *models.Debt, now time.Time) time.Time {
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if debt.StartDate.After(from) {
+		from = debt.StartDate
+	}
+	due := debtDueDate(from.Year(), from.Month(), debt.DueDay, from.Location())
+	if due.Before(from) {
+		due = debtDueDate(from.Year(), from.Month()+1, debt.DueDay, from.Location())
+	}
+	return due
+}
+
+// debtDueDate returns the due day in a month, or the month's last day when
+// it is shorter
+func debtDueDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	return time.Date(first.Year(), first.Month(), clampDay(first.Year(), first.Month(), day), 0, 0, 0, 0, loc)
+}
+
+// debtMonthlyInterest is a month's interest on balance at an annual rate
+func debtMonthlyInterest(balance, apr float64) float64 {
+	return roundAmount(balance * apr / 100 / 12)
+}
+
+// amortize lays out the payments that clear the debt's balance
+func amortize(debt *models.Debt, payment float64, now time.Time) (*models.AmortizationSchedule, error) {
+	schedule := &models.AmortizationSchedule{
+		DebtID:         debt.ID,
+		Balance:        debt.Balance,
+		APR:            debt.APR,
+		MonthlyPayment: roundAmount(payment),
+		Rows:           []models.AmortizationRow{},
+	}
+	if debt.Balance <= 0 {
+		return schedule, nil
+	}
+	if payment <= debtMonthlyInterest(debt.Balance, debt.APR) {
+		return nil, ErrDebtPaymentTooLow
+	}
+
+	first := nextDebtDueDate(debt, now)
+	balance := debt.Balance
+	for month := 1; balance > 0; month++ {
+		if month > maxPayoffMonths {
+			return nil, ErrDebtPaymentTooLow
+		}
+		interest := debtMonthlyInterest(balance, debt.APR)
+		paid := math.Min(payment, roundAmount(balance+interest))
+		balance = roundAmount(balance + interest - paid)
+
+		row := models.AmortizationRow{
+			Month:     month,
+			DueDate:   debtDueDate(first.Year(), first.Month()+time.Month(month-1), debt.DueDay, first.Location()),
+			Payment:   roundAmount(paid),
+			Interest:  interest,
+			Principal: roundAmount(paid - interest),
+			Balance:   balance,
+		}
+		schedule.Rows = append(schedule.Rows, row)
+		schedule.TotalInterest += interest
+		schedule.TotalPaid += paid
+		schedule.PayoffDate = row.DueDate
+	}
+	schedule.Months = len(schedule.Rows)
+	schedule.TotalInterest = roundAmount(schedule.TotalInterest)
+	schedule.TotalPaid = roundAmount(schedule.TotalPaid)
+	return schedule, nil
+}
+
+// simulatePayoff pays debts month by month with a fixed budget. Every debt
+// gets its minimum payment; what is left, including the minimums of debts
+// already cleared, goes to the strategy's next target.
+func simulatePayoff(debts []models.Debt, budget float64, strategy string, now time.Time) (*models.DebtPayoffPlan, error) {
+	type state struct {
+		debt     *models.Debt
+		first    time.Time // first due date
+		balance  float64
+		interest float64
+		order    *models.DebtPayoffOrder
+	}
+	states := make([]*state, len(debts))
+	for i := range debts {
+		states[i] = &state{debt: &debts[i], first: nextDebtDueDate(&debts[i], now), balance: debts[i].Balance}
+	}
+
+	plan := &models.DebtPayoffPlan{Strategy: strategy, Order: []models.DebtPayoffOrder{}}
+	open := len(states)
+	for month := 1; open > 0; month++ {
+		if month > maxPayoffMonths {
+			return nil, ErrDebtPaymentTooLow
+		}
+
+		left := budget
+		for _, st := range states {
+			if st.balance <= 0 {
+				continue
+			}
+			interest := debtMonthlyInterest(st.balance, st.debt.APR)
+			st.balance = roundAmount(st.balance + interest)
+			st.interest += interest
+			plan.TotalInterest += interest
+
+			paid := math.Min(st.debt.MinimumPayment, st.balance)
+			st.balance = roundAmount(st.balance - paid)
+			left -= paid
+		}
+
+		targets := make([]*state, 0, len(states))
+		for _, st := range states {
+			if st.balance > 0 {
+				targets = append(targets, st)
+			}
+		}
+		sort.SliceStable(targets, func(i, j int) bool {
+			a, b := targets[i], targets[j]
+			if strategy == models.DebtStrategyAvalanche && a.debt.APR != b.debt.APR {
+				return a.debt.APR > b.debt.APR
+			}
+			if a.balance != b.balance {
+				return a.balance < b.balance
+			}
+			return a.debt.APR > b.debt.APR
+		})
+		for _, st := range targets {
+			if left <= 0 {
+				break
+			}
+			paid := math.Min(left, st.balance)
+			st.balance = roundAmount(st.balance - paid)
+			left -= paid
+		}
+		plan.TotalPaid += budget - math.Max(0, left)
+
+		for _, st := range states {
+			if st.balance <= 0 && st.order == nil {
+				st.order = &models.DebtPayoffOrder{
+					DebtID:        st.debt.ID,
+					Name:          st.debt.Name,
+					Months:        month,
+					PayoffDate:    debtDueDate(st.first.Year(), st.first.Month()+time.Month(month-1), st.debt.DueDay, st.first.Location()),
+					TotalInterest: roundAmount(st.interest),
+				}
+				plan.Order = append(plan.Order, *st.order)
+				open--
+			}
+		}
+		plan.Months = month
+	}
+
+	plan.TotalInterest = roundAmount(plan.TotalInterest)
+	plan.TotalPaid = roundAmount(plan.TotalPaid)
+	for _, order := range plan.Order {
+		if order.PayoffDate.After(plan.PayoffDate) {
+			plan.PayoffDate = order.PayoffDate
+		}
+	}
+	return plan, nil
+}
+
+// monthlyDebtPayments is what the ledger's open debts ask for each month
+func monthlyDebtPayments(db *gorm.DB, ledger Ledger) float64 {
+	var debts []models.Debt
+	if err := db.Scopes(ledger.Scope("")).Where("paid_off_at IS NULL AND balance > 0").Find(&debts).Error; err != nil {
+		return 0
+	}
+	total := 0.0
+	for _, debt := range debts {
+		total += math.Min(debt.MinimumPayment, debt.Balance)
+	}
+	return total
+}
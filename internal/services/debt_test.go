@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tabimoney/internal/models"
+)
+
+func TestCapDebtPayment(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  float64
+		balance float64
+		want    float64
+	}{
+		{"below balance", 40, 100, 40},
+		{"equal to balance", 100, 100, 100},
+		{"above balance", 150, 100, 100},
+		{"rounded to cents", 33.333, 100, 33.33},
+		{"paid off debt", 50, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := capDebtPayment(tt.amount, tt.balance); got != tt.want {
+				t.Errorf("capDebtPayment(%v, %v) = %v, want %v", tt.amount, tt.balance, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmortize(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name         string
+		debt         models.Debt
+		payment      float64
+		wantErr      error
+		wantMonths   int
+		wantInterest float64
+		wantPaid     float64
+		wantDueDates []time.Time
+		wantLastRow  models.AmortizationRow
+		checkLastRow bool
+	}{
+		{
+			name:         "no interest",
+			debt:         models.Debt{Balance: 300, DueDay: 15, StartDate: start},
+			payment:      100,
+			wantMonths:   3,
+			wantPaid:     300,
+			wantDueDates: []time.Time{date(time.January, 15), date(time.February, 15), date(time.March, 15)},
+		},
+		{
+			name:         "interest on the remaining balance",
+			debt:         models.Debt{Balance: 1000, APR: 12, DueDay: 15, StartDate: start},
+			payment:      500,
+			wantMonths:   3,
+			wantInterest: 15.25,
+			wantPaid:     1015.25,
+			checkLastRow: true,
+			wantLastRow: models.AmortizationRow{
+				Month: 3, DueDate: date(time.March, 15), Payment: 15.25, Interest: 0.15, Principal: 15.10, Balance: 0,
+			},
+		},
+		{
+			name:         "due day past the month end",
+			debt:         models.Debt{Balance: 300, DueDay: 31, StartDate: start},
+			payment:      100,
+			wantMonths:   3,
+			wantPaid:     300,
+			wantDueDates: []time.Time{date(time.January, 31), date(time.February, 29), date(time.March, 31)},
+		},
+		{
+			name:         "due day already passed this month",
+			debt:         models.Debt{Balance: 100, DueDay: 5, StartDate: start},
+			payment:      100,
+			wantMonths:   1,
+			wantPaid:     100,
+			wantDueDates: []time.Time{date(time.February, 5)},
+		},
+		{
+			name:    "payment equal to the interest",
+			debt:    models.Debt{Balance: 1000, APR: 12, DueDay: 15, StartDate: start},
+			payment: 10,
+			wantErr: ErrDebtPaymentTooLow,
+		},
+		{
+			name:    "payment too low to finish within the limit",
+			debt:    models.Debt{Balance: 100000, APR: 12, DueDay: 15, StartDate: start},
+			payment: 1000.01,
+			wantErr: ErrDebtPaymentTooLow,
+		},
+		{
+			name:    "paid off",
+			debt:    models.Debt{Balance: 0, DueDay: 15, StartDate: start},
+			payment: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := amortize(&tt.debt, tt.payment, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("amortize() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if schedule.Months != tt.wantMonths || schedule.TotalInterest != tt.wantInterest || schedule.TotalPaid != tt.wantPaid {
+				t.Errorf("amortize() = %d months, interest %v, paid %v, want %d, %v, %v",
+					schedule.Months, schedule.TotalInterest, schedule.TotalPaid, tt.wantMonths, tt.wantInterest, tt.wantPaid)
+			}
+			if len(schedule.Rows) != tt.wantMonths {
+				t.Fatalf("got %d rows, want %d", len(schedule.Rows), tt.wantMonths)
+			}
+			for i, due := range tt.wantDueDates {
+				if !schedule.Rows[i].DueDate.Equal(due) {
+					t.Errorf("row %d due %v, want %v", i+1, schedule.Rows[i].DueDate, due)
+				}
+			}
+			if tt.wantMonths > 0 && !schedule.PayoffDate.Equal(schedule.Rows[tt.wantMonths-1].DueDate) {
+				t.Errorf("payoff date %v is not the last due date", schedule.PayoffDate)
+			}
+			if tt.checkLastRow {
+				if got := schedule.Rows[tt.wantMonths-1]; got != tt.wantLastRow {
+					t.Errorf("last row = %+v, want %+v", got, tt.wantLastRow)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulatePayoff(t *testing.T) {
+	now := time.Date(2024, time.January, 10, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	date := func(y int, m time.Month) time.Time { return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC) }
+
+	card := models.Debt{ID: 1, Name: "Card", Balance: 1000, APR: 24, MinimumPayment: 50, DueDay: 1, StartDate: start}
+	loan := models.Debt{ID: 2, Name: "Loan", Balance: 300, APR: 0, MinimumPayment: 50, DueDay: 1, StartDate: start}
+	car := models.Debt{ID: 3, Name: "Car", Balance: 500, APR: 0, MinimumPayment: 50, DueDay: 1, StartDate: start}
+
+	type payoff struct {
+		id     uint64
+		months int
+		date   time.Time
+	}
+	tests := []struct {
+		name     string
+		debts    []models.Debt
+		budget   float64
+		strategy string
+		want     []payoff
+		wantErr  error
+	}{
+		{
+			name:     "avalanche pays the highest rate first",
+			debts:    []models.Debt{loan, card},
+			budget:   300,
+			strategy: models.DebtStrategyAvalanche,
+			want:     []payoff{{2, 5, date(2024, time.June)}, {1, 5, date(2024, time.June)}},
+		},
+		{
+			name:     "snowball pays the smallest balance first",
+			debts:    []models.Debt{card, loan},
+			budget:   300,
+			strategy: models.DebtStrategySnowball,
+			want:     []payoff{{2, 2, date(2024, time.March)}, {1, 5, date(2024, time.June)}},
+		},
+		{
+			name:     "avalanche breaks rate ties by balance",
+			debts:    []models.Debt{car, loan},
+			budget:   200,
+			strategy: models.DebtStrategyAvalanche,
+			want:     []payoff{{2, 2, date(2024, time.March)}, {3, 4, date(2024, time.May)}},
+		},
+		{
+			name:     "minimums never cover the interest",
+			debts:    []models.Debt{{ID: 4, Balance: 10000, APR: 24, MinimumPayment: 100, DueDay: 1, StartDate: start}},
+			budget:   100,
+			strategy: models.DebtStrategyAvalanche,
+			wantErr:  ErrDebtPaymentTooLow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := simulatePayoff(tt.debts, tt.budget, tt.strategy, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("simulatePayoff() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(plan.Order) != len(tt.want) {
+				t.Fatalf("got %d debts in the order, want %d", len(plan.Order), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := plan.Order[i]
+				if got.DebtID != want.id || got.Months != want.months || !got.PayoffDate.Equal(want.date) {
+					t.Errorf("order[%d] = debt %d after %d months on %v, want debt %d after %d months on %v",
+						i, got.DebtID, got.Months, got.PayoffDate, want.id, want.months, want.date)
+				}
+			}
+			last := tt.want[len(tt.want)-1]
+			if plan.Months != last.months || !plan.PayoffDate.Equal(last.date) {
+				t.Errorf("plan ends after %d months on %v, want %d on %v", plan.Months, plan.PayoffDate, last.months, last.date)
+			}
+
+			balance := 0.0
+			for _, debt := range tt.debts {
+				balance += debt.Balance
+			}
+			if got, want := plan.TotalPaid, roundAmount(balance+plan.TotalInterest); got != want {
+				t.Errorf("total paid %v, want balance plus interest %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPayoffStrategiesCompare(t *testing.T) {
+	// Avalanche never pays more interest than snowball for the same budget
+	now := time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	debts := []models.Debt{
+		{ID: 1, Balance: 1000, APR: 24, MinimumPayment: 50, DueDay: 1, StartDate: start},
+		{ID: 2, Balance: 300, APR: 0, MinimumPayment: 50, DueDay: 1, StartDate: start},
+	}
+	avalanche, err := simulatePayoff(debts, 300, models.DebtStrategyAvalanche, now)
+	if err != nil {
+		t.Fatalf("avalanche: %v", err)
+	}
+	snowball, err := simulatePayoff(debts, 300, models.DebtStrategySnowball, now)
+	if err != nil {
+		t.Fatalf("snowball: %v", err)
+	}
+	if avalanche.TotalInterest >= snowball.TotalInterest {
+		t.Errorf("avalanche interest %v, snowball %v; want avalanche lower", avalanche.TotalInterest, snowball.TotalInterest)
+	}
+}
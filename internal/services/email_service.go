@@ -9,7 +9,9 @@ import (
 	"net/smtp"
 	"os"
 	"strconv"
+	"time"
 
+	"tabimoney/internal/i18n"
 	"tabimoney/internal/models"
 )
 
@@ -28,6 +30,7 @@ type EmailTemplate struct {
 }
 
 type EmailData struct {
+	Lang             string
 	UserName         string
 	Title            string
 	Message          string
@@ -40,6 +43,35 @@ type EmailData struct {
 	Progress         float64
 	Date             string
 	Time             string
+
+	// Localized layout strings
+	Header    string
+	Greeting  string
+	TimeLabel string
+	TimeValue string
+	Footer    string
+	Style     EmailStyle
+
+	// Optional call-to-action button (account emails)
+	ActionURL   string
+	ActionLabel string
+}
+
+// EmailStyle holds the colors used by the shared email layout
+type EmailStyle struct {
+	HeaderFrom    string
+	HeaderTo      string
+	BoxBackground string
+	BoxBorder     string
+}
+
+var emailStyles = map[string]EmailStyle{
+	"warning_urgent": {HeaderFrom: "#ff4444", HeaderTo: "#cc0000", BoxBackground: "#fff3cd", BoxBorder: "#ffeaa7"},
+	"warning":        {HeaderFrom: "#ffc107", HeaderTo: "#ff8f00", BoxBackground: "#fff3cd", BoxBorder: "#ffeaa7"},
+	"error":          {HeaderFrom: "#dc3545", HeaderTo: "#c82333", BoxBackground: "#f8d7da", BoxBorder: "#f5c6cb"},
+	"success":        {HeaderFrom: "#28a745", HeaderTo: "#20c997", BoxBackground: "#d4edda", BoxBorder: "#c3e6cb"},
+	"reminder":       {HeaderFrom: "#17a2b8", HeaderTo: "#138496", BoxBackground: "#d1ecf1", BoxBorder: "#bee5eb"},
+	"info":           {HeaderFrom: "#6f42c1", HeaderTo: "#5a32a3", BoxBackground: "#e2e3e5", BoxBorder: "#d6d8db"},
 }
 
 func NewEmailService() *EmailService {
@@ -65,11 +97,18 @@ func (s *EmailService) SendNotificationEmail(user *models.User, notification *mo
 		return nil
 	}
 
+	loc := localizerForUser(user)
+	variant := notificationVariant(notification.NotificationType, notification.Priority)
+
 	// Get email template based on notification type
-	template := s.getEmailTemplate(notification.NotificationType, notification.Priority)
+	template, err := s.getEmailTemplate(loc, variant)
+	if err != nil {
+		return fmt.Errorf("failed to load email template: %w", err)
+	}
 
 	// Prepare email data
 	emailData := data
+	emailData.Lang = loc.Lang
 	emailData.UserName = user.FirstName
 	if emailData.UserName == "" {
 		emailData.UserName = user.Username
@@ -78,8 +117,14 @@ func (s *EmailService) SendNotificationEmail(user *models.User, notification *mo
 	emailData.Message = notification.Message
 	emailData.Priority = notification.Priority
 	emailData.NotificationType = notification.NotificationType
-	emailData.Date = notification.CreatedAt.Format("02/01/2006")
-	emailData.Time = notification.CreatedAt.Format("15:04")
+	emailData.Date = loc.Date(notification.CreatedAt)
+	emailData.Time = loc.Time(notification.CreatedAt)
+	emailData.Header = loc.T("email.header."+variant, nil)
+	emailData.Greeting = loc.T("email.greeting", map[string]interface{}{"name": emailData.UserName})
+	emailData.TimeLabel = loc.T("email.time_label", nil)
+	emailData.TimeValue = loc.T("email.time_value", map[string]interface{}{"date": emailData.Date, "time": emailData.Time})
+	emailData.Footer = loc.T("email.footer", nil)
+	emailData.Style = emailStyles[variant]
 
 	// Render email content
 	subject, body, err := s.renderEmailTemplate(template, emailData)
@@ -91,41 +136,61 @@ func (s *EmailService) SendNotificationEmail(user *models.User, notification *mo
 	return s.sendEmail(user.Email, subject, body)
 }
 
-// getEmailTemplate returns appropriate template based on notification type and priority
-func (s *EmailService) getEmailTemplate(notificationType, priority string) EmailTemplate {
-	switch notificationType {
-	case "warning":
-		if priority == "urgent" || priority == "high" {
-			return EmailTemplate{
-				Subject: "🚨 Cảnh báo khẩn cấp từ TabiMoney",
-				Body:    s.getUrgentWarningTemplate(),
-			}
-		}
-		return EmailTemplate{
-			Subject: "⚠️ Cảnh báo từ TabiMoney",
-			Body:    s.getWarningTemplate(),
-		}
-	case "error":
-		return EmailTemplate{
-			Subject: "❌ Thông báo lỗi từ TabiMoney",
-			Body:    s.getErrorTemplate(),
-		}
-	case "success":
-		return EmailTemplate{
-			Subject: "✅ Thành công từ TabiMoney",
-			Body:    s.getSuccessTemplate(),
-		}
-	case "reminder":
-		return EmailTemplate{
-			Subject: "🔔 Nhắc nhở từ TabiMoney",
-			Body:    s.getReminderTemplate(),
-		}
-	default:
-		return EmailTemplate{
-			Subject: "📊 Thông báo từ TabiMoney",
-			Body:    s.getInfoTemplate(),
-		}
+// SendAccountEmail sends an account email (verify_email, reset_password) with an action link
+func (s *EmailService) SendAccountEmail(user *models.User, kind, actionURL string, expiresAt time.Time) error {
+	if s.smtpHost == "" || s.smtpUsername == "" {
+		log.Printf("Email service not configured, skipping %s email for user %d", kind, user.ID)
+		return nil
+	}
+
+	loc := localizerForUser(user)
+	prefix := "email.account." + kind
+
+	body, err := i18n.Template("email.html")
+	if err != nil {
+		return fmt.Errorf("failed to load email template: %w", err)
+	}
+	emailTemplate := EmailTemplate{Subject: loc.T(prefix+".subject", nil), Body: body}
+
+	emailData := EmailData{
+		Lang:        loc.Lang,
+		UserName:    user.FirstName,
+		Title:       loc.T(prefix+".title", nil),
+		Message:     loc.T(prefix+".message", nil),
+		Header:      loc.T(prefix+".header", nil),
+		TimeLabel:   loc.T("email.account.expires_label", nil),
+		TimeValue:   loc.DateTime(expiresAt),
+		Footer:      loc.T("email.footer", nil),
+		Style:       emailStyles["info"],
+		ActionURL:   actionURL,
+		ActionLabel: loc.T(prefix+".action", nil),
+	}
+	if loc.Has(prefix + ".time_label") {
+		emailData.TimeLabel = loc.T(prefix+".time_label", nil)
+	}
+	if emailData.UserName == "" {
+		emailData.UserName = user.Username
+	}
+	emailData.Greeting = loc.T("email.greeting", map[string]interface{}{"name": emailData.UserName})
+
+	subject, html, err := s.renderEmailTemplate(emailTemplate, emailData)
+	if err != nil {
+		return fmt.Errorf("failed to render email template: %w", err)
 	}
+
+	return s.sendEmail(user.Email, subject, html)
+}
+
+// getEmailTemplate returns the localized subject and shared layout for a notification variant
+func (s *EmailService) getEmailTemplate(loc *i18n.Localizer, variant string) (EmailTemplate, error) {
+	body, err := i18n.Template("email.html")
+	if err != nil {
+		return EmailTemplate{}, err
+	}
+	return EmailTemplate{
+		Subject: loc.T("email.subject."+variant, nil),
+		Body:    body,
+	}, nil
 }
 
 // renderEmailTemplate renders email template with data
@@ -221,227 +286,3 @@ func (s *EmailService) sendEmail(to, subject, body string) error {
 	log.Printf("Email sent successfully to %s", to)
 	return nil
 }
-
-// Email Templates
-
-func (s *EmailService) getUrgentWarningTemplate() string {
-	return `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background-color: #f5f5f5; }
-        .container { max-width: 600px; margin: 0 auto; background-color: white; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #ff4444, #cc0000); color: white; padding: 20px; text-align: center; }
-        .content { padding: 30px; }
-        .alert-box { background-color: #fff3cd; border: 1px solid #ffeaa7; border-radius: 6px; padding: 15px; margin: 20px 0; }
-        .button { display: inline-block; background-color: #dc3545; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 10px 0; }
-        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #6c757d; font-size: 14px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🚨 Cảnh báo khẩn cấp</h1>
-        </div>
-        <div class="content">
-            <h2>Xin chào {{.UserName}}!</h2>
-            <div class="alert-box">
-                <h3>{{.Title}}</h3>
-                <p>{{.Message}}</p>
-            </div>
-            <p><strong>Thời gian:</strong> {{.Date}} lúc {{.Time}}</p>
-        </div>
-        <div class="footer">
-            <p>Đây là email tự động từ TabiMoney. Vui lòng không trả lời email này.</p>
-        </div>
-    </div>
-</body>
-</html>`
-}
-
-func (s *EmailService) getWarningTemplate() string {
-	return `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background-color: #f5f5f5; }
-        .container { max-width: 600px; margin: 0 auto; background-color: white; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #ffc107, #ff8f00); color: white; padding: 20px; text-align: center; }
-        .content { padding: 30px; }
-        .warning-box { background-color: #fff3cd; border: 1px solid #ffeaa7; border-radius: 6px; padding: 15px; margin: 20px 0; }
-        .button { display: inline-block; background-color: #ffc107; color: #212529; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 10px 0; }
-        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #6c757d; font-size: 14px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>⚠️ Cảnh báo</h1>
-        </div>
-        <div class="content">
-            <h2>Xin chào {{.UserName}}!</h2>
-            <div class="warning-box">
-                <h3>{{.Title}}</h3>
-                <p>{{.Message}}</p>
-            </div>
-            <p><strong>Thời gian:</strong> {{.Date}} lúc {{.Time}}</p>
-        </div>
-        <div class="footer">
-            <p>Đây là email tự động từ TabiMoney. Vui lòng không trả lời email này.</p>
-        </div>
-    </div>
-</body>
-</html>`
-}
-
-func (s *EmailService) getErrorTemplate() string {
-	return `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background-color: #f5f5f5; }
-        .container { max-width: 600px; margin: 0 auto; background-color: white; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #dc3545, #c82333); color: white; padding: 20px; text-align: center; }
-        .content { padding: 30px; }
-        .error-box { background-color: #f8d7da; border: 1px solid #f5c6cb; border-radius: 6px; padding: 15px; margin: 20px 0; }
-        .button { display: inline-block; background-color: #dc3545; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 10px 0; }
-        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #6c757d; font-size: 14px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>❌ Thông báo lỗi</h1>
-        </div>
-        <div class="content">
-            <h2>Xin chào {{.UserName}}!</h2>
-            <div class="error-box">
-                <h3>{{.Title}}</h3>
-                <p>{{.Message}}</p>
-            </div>
-            <p><strong>Thời gian:</strong> {{.Date}} lúc {{.Time}}</p>
-        </div>
-        <div class="footer">
-            <p>Đây là email tự động từ TabiMoney. Vui lòng không trả lời email này.</p>
-        </div>
-    </div>
-</body>
-</html>`
-}
-
-func (s *EmailService) getSuccessTemplate() string {
-	return `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background-color: #f5f5f5; }
-        .container { max-width: 600px; margin: 0 auto; background-color: white; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #28a745, #20c997); color: white; padding: 20px; text-align: center; }
-        .content { padding: 30px; }
-        .success-box { background-color: #d4edda; border: 1px solid #c3e6cb; border-radius: 6px; padding: 15px; margin: 20px 0; }
-        .button { display: inline-block; background-color: #28a745; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 10px 0; }
-        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #6c757d; font-size: 14px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>✅ Thành công</h1>
-        </div>
-        <div class="content">
-            <h2>Xin chào {{.UserName}}!</h2>
-            <div class="success-box">
-                <h3>{{.Title}}</h3>
-                <p>{{.Message}}</p>
-            </div>
-            <p><strong>Thời gian:</strong> {{.Date}} lúc {{.Time}}</p>
-        </div>
-        <div class="footer">
-            <p>Đây là email tự động từ TabiMoney. Vui lòng không trả lời email này.</p>
-        </div>
-    </div>
-</body>
-</html>`
-}
-
-func (s *EmailService) getReminderTemplate() string {
-	return `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background-color: #f5f5f5; }
-        .container { max-width: 600px; margin: 0 auto; background-color: white; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #17a2b8, #138496); color: white; padding: 20px; text-align: center; }
-        .content { padding: 30px; }
-        .reminder-box { background-color: #d1ecf1; border: 1px solid #bee5eb; border-radius: 6px; padding: 15px; margin: 20px 0; }
-        .button { display: inline-block; background-color: #17a2b8; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 10px 0; }
-        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #6c757d; font-size: 14px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🔔 Nhắc nhở</h1>
-        </div>
-        <div class="content">
-            <h2>Xin chào {{.UserName}}!</h2>
-            <div class="reminder-box">
-                <h3>{{.Title}}</h3>
-                <p>{{.Message}}</p>
-            </div>
-            <p><strong>Thời gian:</strong> {{.Date}} lúc {{.Time}}</p>
-        </div>
-        <div class="footer">
-            <p>Đây là email tự động từ TabiMoney. Vui lòng không trả lời email này.</p>
-        </div>
-    </div>
-</body>
-</html>`
-}
-
-func (s *EmailService) getInfoTemplate() string {
-	return `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; margin: 0; padding: 20px; background-color: #f5f5f5; }
-        .container { max-width: 600px; margin: 0 auto; background-color: white; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 10px rgba(0,0,0,0.1); }
-        .header { background: linear-gradient(135deg, #6f42c1, #5a32a3); color: white; padding: 20px; text-align: center; }
-        .content { padding: 30px; }
-        .info-box { background-color: #e2e3e5; border: 1px solid #d6d8db; border-radius: 6px; padding: 15px; margin: 20px 0; }
-        .button { display: inline-block; background-color: #6f42c1; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin: 10px 0; }
-        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #6c757d; font-size: 14px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>📊 Thông báo</h1>
-        </div>
-        <div class="content">
-            <h2>Xin chào {{.UserName}}!</h2>
-            <div class="info-box">
-                <h3>{{.Title}}</h3>
-                <p>{{.Message}}</p>
-            </div>
-            <p><strong>Thời gian:</strong> {{.Date}} lúc {{.Time}}</p>
-        </div>
-        <div class="footer">
-            <p>Đây là email tự động từ TabiMoney. Vui lòng không trả lời email này.</p>
-        </div>
-    </div>
-</body>
-</html>`
-}
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEnvelopeNotFound          = errors.New("envelope not found")
+	ErrEnvelopeCategoryRequired  = errors.New("an envelope needs a category")
+	ErrInvalidEnvelopeMonth      = errors.New("month must be in YYYY-MM format")
+	ErrNotEnoughToAssign         = errors.New("not enough money left to assign")
+	ErrEnvelopeInsufficientFunds = errors.New("the envelope does not have that much available")
+	ErrCoverOverspentFirst       = errors.New("cover overspent envelopes before assigning more money")
+	ErrEnvelopeNotOverspent      = errors.New("the envelope is not overspent")
+	ErrCoverExceedsOverspend     = errors.New("amount is more than the envelope is overspent")
+)
+
+// envelopeBalance is an envelope's money in one month
+type envelopeBalance struct {
+	assigned  float64 // assigned this month
+	activity  float64 // spent this month
+	available float64 // everything assigned up to this month minus everything spent
+}
+
+// envelopeIncome is the ledger's income in a month and since its envelopes started
+type envelopeIncome struct {
+	ThisMonth float64
+	Total     float64
+}
+
+// envelopeMonth parses a YYYY-MM month, defaulting to the current one, and
+// returns its first day and the first day of the month after
+func envelopeMonth(month string) (string, time.Time, time.Time, error) {
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, ErrInvalidEnvelopeMonth
+	}
+	return month, start, start.AddDate(0, 1, 0), nil
+}
+
+// envelopeBalance sums an envelope's allocations and the spending in its
+// category tree since the envelope started, up to the end of month
+func (s *BudgetService) envelopeBalance(db *gorm.DB, budget *models.Budget, month string, start, next time.Time) (envelopeBalance, error) {
+	var allocated struct {
+		Assigned float64
+		Total    float64
+	}
+	if err := db.Model(&models.EnvelopeAllocation{}).
+		Where("budget_id = ? AND month <= ?", budget.ID, month).
+		Select("COALESCE(SUM(CASE WHEN month = ? THEN amount ELSE 0 END), 0) AS assigned, COALESCE(SUM(amount), 0) AS total", month).
+		Scan(&allocated).Error; err != nil {
+		return envelopeBalance{}, fmt.Errorf("failed to load envelope allocations: %w", err)
+	}
+
+	var categoryIDs []uint64
+	if budget.CategoryID != nil {
+		ids, err := categoryWithDescendants(db, rowLedger(budget.UserID, budget.HouseholdID), *budget.CategoryID)
+		if err != nil {
+			return envelopeBalance{}, err
+		}
+		categoryIDs = ids
+	}
+
+	var spent struct {
+		Activity float64
+		Total    float64
+	}
+	if err := db.Model(&models.Transaction{}).
+		Scopes(rowLedger(budget.UserID, budget.HouseholdID).Scope("")).
+		Where("transaction_type = ? AND category_id IN ? AND transaction_date >= ? AND transaction_date < ?",
+			"expense", categoryIDs, budget.StartDate, next).
+		Select("COALESCE(SUM(CASE WHEN transaction_date >= ? THEN amount ELSE 0 END), 0) AS activity, COALESCE(SUM(amount), 0) AS total", start).
+		Scan(&spent).Error; err != nil {
+		return envelopeBalance{}, fmt.Errorf("failed to load envelope spending: %w", err)
+	}
+
+	return envelopeBalance{
+		assigned:  roundAmount(allocated.Assigned),
+		activity:  roundAmount(spent.Activity),
+		available: roundAmount(allocated.Total - spent.Total),
+	}, nil
+}
+
+// calculateEnvelopeMetrics fills the calculated budget fields for the current
+// month: spent is this month's activity and the limit is what the envelope
+// held before it
+func (s *BudgetService) calculateEnvelopeMetrics(budget *models.Budget) {
+	month, start, next, _ := envelopeMonth("")
+	balance, err := s.envelopeBalance(s.db, budget, month, start, next)
+	if err != nil {
+		log.Printf("Failed to calculate envelope %d: %v", budget.ID, err)
+		return
+	}
+
+	limit := balance.available + balance.activity
+	budget.SpentAmount = balance.activity
+	budget.RemainingAmount = balance.available
+	switch {
+	case limit > 0:
+		budget.UsagePercentage = (balance.activity / limit) * 100
+	case balance.activity > 0:
+		budget.UsagePercentage = 100
+	default:
+		budget.UsagePercentage = 0
+	}
+}
+
+// GetEnvelopeMonth returns the assignment screen for a month (YYYY-MM, empty
+// for the current month)
+func (s *BudgetService) GetEnvelopeMonth(ledger Ledger, month string) (*models.EnvelopeMonthResponse, error) {
+	return s.envelopeMonthState(s.db, ledger, month)
+}
+
+// envelopeMonthState computes every active envelope and "to be assigned" for a
+// month. Income counts from the month the ledger's first envelope started.
+func (s *BudgetService) envelopeMonthState(db *gorm.DB, ledger Ledger, month string) (*models.EnvelopeMonthResponse, error) {
+	month, start, next, err := envelopeMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	book := rowLedger(ledger.UserID, ledger.HouseholdID)
+
+	var envelopes []models.Budget
+	if err := db.Scopes(book.Scope("")).Where("mode = ? AND is_active = ?", models.BudgetModeEnvelope, true).
+		Order("name ASC").Find(&envelopes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load envelopes: %w", err)
+	}
+
+	balances := make([]envelopeBalance, len(envelopes))
+	for i := range envelopes {
+		if balances[i], err = s.envelopeBalance(db, &envelopes[i], month, start, next); err != nil {
+			return nil, err
+		}
+	}
+
+	var earliest []models.Budget
+	if err := db.Scopes(book.Scope("")).Where("mode = ?", models.BudgetModeEnvelope).
+		Order("start_date ASC").Limit(1).Find(&earliest).Error; err != nil {
+		return nil, fmt.Errorf("failed to load envelopes: %w", err)
+	}
+	since := start
+	if len(earliest) > 0 {
+		since = envelopeIncomeStart(start, earliest[0].StartDate)
+	}
+
+	var income envelopeIncome
+	if err := db.Model(&models.Transaction{}).Scopes(book.Scope("")).
+		Where("transaction_type = ? AND transaction_date >= ? AND transaction_date < ?", "income", since, next).
+		Select("COALESCE(SUM(CASE WHEN transaction_date >= ? THEN amount ELSE 0 END), 0) AS this_month, COALESCE(SUM(amount), 0) AS total", start).
+		Scan(&income).Error; err != nil {
+		return nil, fmt.Errorf("failed to load income: %w", err)
+	}
+
+	var allocated float64
+	if err := db.Model(&models.EnvelopeAllocation{}).Scopes(book.Scope("")).Where("month <= ?", month).
+		Select("COALESCE(SUM(amount), 0)").Scan(&allocated).Error; err != nil {
+		return nil, fmt.Errorf("failed to load envelope allocations: %w", err)
+	}
+
+	return buildEnvelopeMonth(month, envelopes, balances, income, allocated), nil
+}
+
+// envelopeIncomeStart is the first day from which income is available to
+// envelopes: the month the first envelope started, or the month shown when
+// that is earlier
+func envelopeIncomeStart(monthStart, firstEnvelopeStart time.Time) time.Time {
+	first := time.Date(firstEnvelopeStart.Year(), firstEnvelopeStart.Month(), 1, 0, 0, 0, 0, monthStart.Location())
+	if first.Before(monthStart) {
+		return first
+	}
+	return monthStart
+}
+
+// buildEnvelopeMonth totals a month's envelopes. "To be assigned" is all
+// income up to the month less everything allocated up to it, so money
+// assigned in one month is not offered again in the next.
+func buildEnvelopeMonth(month string, envelopes []models.Budget, balances []envelopeBalance, income envelopeIncome, allocated float64) *models.EnvelopeMonthResponse {
+	response := &models.EnvelopeMonthResponse{Month: month, Envelopes: []models.EnvelopeSummary{}}
+	for i, balance := range balances {
+		response.Envelopes = append(response.Envelopes, models.EnvelopeSummary{
+			BudgetID:   envelopes[i].ID,
+			Name:       envelopes[i].Name,
+			CategoryID: envelopes[i].CategoryID,
+			Target:     envelopes[i].Amount,
+			Assigned:   balance.assigned,
+			Activity:   balance.activity,
+			Available:  balance.available,
+			Overspent:  balance.available < 0,
+		})
+		response.Assigned += balance.assigned
+		response.Activity += balance.activity
+		response.Available += balance.available
+		if balance.available < 0 {
+			response.Overspent -= balance.available
+		}
+	}
+
+	response.Income = roundAmount(income.ThisMonth)
+	response.ToBeAssigned = roundAmount(income.Total - allocated)
+	response.Assigned = roundAmount(response.Assigned)
+	response.Activity = roundAmount(response.Activity)
+	response.Available = roundAmount(response.Available)
+	response.Overspent = roundAmount(response.Overspent)
+	return response
+}
+
+// ListEnvelopeAllocations lists the allocation ledger for a month, newest first
+func (s *BudgetService) ListEnvelopeAllocations(ledger Ledger, month string) ([]models.EnvelopeAllocation, error) {
+	month, _, _, err := envelopeMonth(month)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations := []models.EnvelopeAllocation{}
+	if err := s.db.Scopes(rowLedger(ledger.UserID, ledger.HouseholdID).Scope("")).Where("month = ?", month).
+		Order("created_at DESC, id DESC").Find(&allocations).Error; err != nil {
+		return nil, fmt.Errorf("failed to load envelope allocations: %w", err)
+	}
+	return allocations, nil
+}
+
+// AssignToEnvelope gives money from "to be assigned" to an envelope, or returns
+// it when the amount is negative
+func (s *BudgetService) AssignToEnvelope(ledger Ledger, req *models.EnvelopeAssignRequest) (*models.EnvelopeMonthResponse, error) {
+	amount := roundAmount(req.Amount)
+	return s.allocateEnvelopes(ledger, req.Month, func(tx *gorm.DB, state *models.EnvelopeMonthResponse) error {
+		if err := checkEnvelopeAssign(state, req.BudgetID, amount); err != nil {
+			return err
+		}
+		return createAllocations(tx, newEnvelopeAllocation(ledger, state.Month, req.BudgetID, amount, models.EnvelopeAllocationAssign, nil, req.Note))
+	})
+}
+
+// checkEnvelopeAssign checks that amount can be assigned to an envelope from
+// "to be assigned", or returned to it when negative. While envelopes are
+// overspent, new money may only go to them.
+func checkEnvelopeAssign(state *models.EnvelopeMonthResponse, budgetID uint64, amount float64) error {
+	envelope := findEnvelope(state, budgetID)
+	if envelope == nil {
+		return ErrEnvelopeNotFound
+	}
+	if amount > 0 {
+		if amount > state.ToBeAssigned {
+			return ErrNotEnoughToAssign
+		}
+		if state.Overspent > 0 && !envelope.Overspent {
+			return ErrCoverOverspentFirst
+		}
+	} else if -amount > envelope.Available {
+		return ErrEnvelopeInsufficientFunds
+	}
+	return nil
+}
+
+// MoveBetweenEnvelopes moves available money from one envelope to another
+func (s *BudgetService) MoveBetweenEnvelopes(ledger Ledger, req *models.EnvelopeMoveRequest) (*models.EnvelopeMonthResponse, error) {
+	amount := roundAmount(req.Amount)
+	return s.allocateEnvelopes(ledger, req.Month, func(tx *gorm.DB, state *models.EnvelopeMonthResponse) error {
+		from, to := findEnvelope(state, req.FromBudgetID), findEnvelope(state, req.ToBudgetID)
+		if from == nil || to == nil {
+			return ErrEnvelopeNotFound
+		}
+		if amount > from.Available {
+			return ErrEnvelopeInsufficientFunds
+		}
+		if state.Overspent > 0 && !to.Overspent {
+			return ErrCoverOverspentFirst
+		}
+		fromID, toID := from.BudgetID, to.BudgetID
+		return createAllocations(tx,
+			newEnvelopeAllocation(ledger, state.Month, fromID, -amount, models.EnvelopeAllocationMove, &toID, req.Note),
+			newEnvelopeAllocation(ledger, state.Month, toID, amount, models.EnvelopeAllocationMove, &fromID, req.Note),
+		)
+	})
+}
+
+// CoverOverspending covers an overspent envelope from another envelope or from
+// "to be assigned"
+func (s *BudgetService) CoverOverspending(ledger Ledger, req *models.EnvelopeCoverRequest) (*models.EnvelopeMonthResponse, error) {
+	return s.allocateEnvelopes(ledger, req.Month, func(tx *gorm.DB, state *models.EnvelopeMonthResponse) error {
+		target := findEnvelope(state, req.BudgetID)
+		if target == nil {
+			return ErrEnvelopeNotFound
+		}
+		if !target.Overspent {
+			return ErrEnvelopeNotOverspent
+		}
+
+		overspend := -target.Available
+		amount := roundAmount(req.Amount)
+		if amount == 0 {
+			amount = overspend
+		}
+		if amount > overspend {
+			return ErrCoverExceedsOverspend
+		}
+
+		targetID := target.BudgetID
+		if req.FromBudgetID == nil {
+			if amount > state.ToBeAssigned {
+				return ErrNotEnoughToAssign
+			}
+			return createAllocations(tx, newEnvelopeAllocation(ledger, state.Month, targetID, amount, models.EnvelopeAllocationCover, nil, req.Note))
+		}
+
+		from := findEnvelope(state, *req.FromBudgetID)
+		if from == nil || from.BudgetID == targetID {
+			return ErrEnvelopeNotFound
+		}
+		if amount > from.Available {
+			return ErrEnvelopeInsufficientFunds
+		}
+		fromID := from.BudgetID
+		return createAllocations(tx,
+			newEnvelopeAllocation(ledger, state.Month, fromID, -amount, models.EnvelopeAllocationCover, &targetID, req.Note),
+			newEnvelopeAllocation(ledger, state.Month, targetID, amount, models.EnvelopeAllocationCover, &fromID, req.Note),
+		)
+	})
+}
+
+// allocateEnvelopes runs an allocation against the month's state with the
+// ledger's envelopes locked, so concurrent allocations cannot assign the same
+// money twice, and returns the updated month
+func (s *BudgetService) allocateEnvelopes(ledger Ledger, month string, allocate func(tx *gorm.DB, state *models.EnvelopeMonthResponse) error) (*models.EnvelopeMonthResponse, error) {
+	if _, _, _, err := envelopeMonth(month); err != nil {
+		return nil, err
+	}
+
+	var state *models.EnvelopeMonthResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var locked []models.Budget
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(rowLedger(ledger.UserID, ledger.HouseholdID).Scope("")).
+			Where("mode = ?", models.BudgetModeEnvelope).
+			Select("id").Find(&locked).Error; err != nil {
+			return fmt.Errorf("failed to lock envelopes: %w", err)
+		}
+
+		current, err := s.envelopeMonthState(tx, ledger, month)
+		if err != nil {
+			return err
+		}
+		if err := allocate(tx, current); err != nil {
+			return err
+		}
+		state, err = s.envelopeMonthState(tx, ledger, current.Month)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// newEnvelopeAllocation builds an allocation entry made by the acting user
+func newEnvelopeAllocation(ledger Ledger, month string, budgetID uint64, amount float64, kind string, counterpartID *uint64, note string) *models.EnvelopeAllocation {
+	return &models.EnvelopeAllocation{
+		UserID:        ledger.UserID,
+		HouseholdID:   ledger.HouseholdID,
+		BudgetID:      budgetID,
+		Month:         month,
+		Amount:        amount,
+		Kind:          kind,
+		CounterpartID: counterpartID,
+		Note:          truncateString(note, 255),
+	}
+}
+
+func createAllocations(tx *gorm.DB, allocations ...*models.EnvelopeAllocation) error {
+	for _, allocation := range allocations {
+		if err := tx.Create(allocation).Error; err != nil {
+			return fmt.Errorf("failed to record envelope allocation: %w", err)
+		}
+	}
+	return nil
+}
+
+func findEnvelope(state *models.EnvelopeMonthResponse, budgetID uint64) *models.EnvelopeSummary {
+	for i := range state.Envelopes {
+		if state.Envelopes[i].BudgetID == budgetID {
+			return &state.Envelopes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tabimoney/internal/models"
+)
+
+func TestBuildEnvelopeMonth(t *testing.T) {
+	envelopes := []models.Budget{
+		{ID: 1, Name: "Groceries", Amount: 3000000},
+		{ID: 2, Name: "Rent", Amount: 5000000},
+		{ID: 3, Name: "Fun", Amount: 1000000},
+	}
+	tests := []struct {
+		name             string
+		balances         []envelopeBalance
+		income           envelopeIncome
+		allocated        float64
+		wantToBeAssigned float64
+		wantAvailable    float64
+		wantOverspent    float64
+		wantOverspentIDs []uint64
+	}{
+		{
+			name: "first month",
+			balances: []envelopeBalance{
+				{assigned: 3000000, activity: 1200000, available: 1800000},
+				{assigned: 5000000, activity: 5000000, available: 0},
+				{assigned: 1000000, activity: 0, available: 1000000},
+			},
+			income:           envelopeIncome{ThisMonth: 12000000, Total: 12000000},
+			allocated:        9000000,
+			wantToBeAssigned: 3000000,
+			wantAvailable:    2800000,
+		},
+		{
+			name: "later month counts earlier income and allocations",
+			balances: []envelopeBalance{
+				{assigned: 1000000, activity: 500000, available: 2300000},
+				{assigned: 5000000, activity: 5000000, available: 0},
+				{assigned: 0, activity: 0, available: 1000000},
+			},
+			income:           envelopeIncome{ThisMonth: 8000000, Total: 20000000},
+			allocated:        15000000,
+			wantToBeAssigned: 5000000,
+			wantAvailable:    3300000,
+		},
+		{
+			name: "overspent envelopes",
+			balances: []envelopeBalance{
+				{assigned: 3000000, activity: 3450000.5, available: -450000.5},
+				{assigned: 5000000, activity: 5000000, available: 0},
+				{assigned: 1000000, activity: 1200000, available: -200000},
+			},
+			income:           envelopeIncome{ThisMonth: 9000000, Total: 9000000},
+			allocated:        9000000,
+			wantToBeAssigned: 0,
+			wantAvailable:    -650000.5,
+			wantOverspent:    650000.5,
+			wantOverspentIDs: []uint64{1, 3},
+		},
+		{
+			name: "more assigned than received",
+			balances: []envelopeBalance{
+				{assigned: 3000000, available: 3000000},
+				{assigned: 5000000, available: 5000000},
+				{},
+			},
+			income:           envelopeIncome{ThisMonth: 6000000, Total: 6000000},
+			allocated:        8000000,
+			wantToBeAssigned: -2000000,
+			wantAvailable:    8000000,
+		},
+		{
+			name: "money returned to be assigned",
+			balances: []envelopeBalance{
+				{assigned: -500000, available: 500000},
+				{},
+				{},
+			},
+			income:           envelopeIncome{Total: 10000000},
+			allocated:        999999.999,
+			wantToBeAssigned: 9000000,
+			wantAvailable:    500000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildEnvelopeMonth("2024-03", envelopes, tt.balances, tt.income, tt.allocated)
+			if got.ToBeAssigned != tt.wantToBeAssigned || got.Available != tt.wantAvailable || got.Overspent != tt.wantOverspent {
+				t.Errorf("to be assigned %v, available %v, overspent %v; want %v, %v, %v",
+					got.ToBeAssigned, got.Available, got.Overspent, tt.wantToBeAssigned, tt.wantAvailable, tt.wantOverspent)
+			}
+			if got.Income != roundAmount(tt.income.ThisMonth) {
+				t.Errorf("income %v, want %v", got.Income, tt.income.ThisMonth)
+			}
+			if len(got.Envelopes) != len(envelopes) {
+				t.Fatalf("got %d envelopes, want %d", len(got.Envelopes), len(envelopes))
+			}
+			var overspent []uint64
+			for _, e := range got.Envelopes {
+				if e.Overspent {
+					overspent = append(overspent, e.BudgetID)
+				}
+			}
+			if len(overspent) != len(tt.wantOverspentIDs) {
+				t.Fatalf("overspent envelopes %v, want %v", overspent, tt.wantOverspentIDs)
+			}
+			for i := range overspent {
+				if overspent[i] != tt.wantOverspentIDs[i] {
+					t.Errorf("overspent envelopes %v, want %v", overspent, tt.wantOverspentIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvelopeIncomeStart(t *testing.T) {
+	march := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		firstStart time.Time
+		want       time.Time
+	}{
+		{"first envelope started earlier", time.Date(2023, time.November, 20, 0, 0, 0, 0, time.UTC), time.Date(2023, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"first envelope started this month", time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), march},
+		{"first envelope starts later", time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC), march},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := envelopeIncomeStart(march, tt.firstStart); !got.Equal(tt.want) {
+				t.Errorf("envelopeIncomeStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckEnvelopeAssign(t *testing.T) {
+	state := func(toBeAssigned float64, envelopes ...models.EnvelopeSummary) *models.EnvelopeMonthResponse {
+		s := &models.EnvelopeMonthResponse{ToBeAssigned: toBeAssigned, Envelopes: envelopes}
+		for _, e := range envelopes {
+			if e.Overspent {
+				s.Overspent -= e.Available
+			}
+		}
+		return s
+	}
+	groceries := models.EnvelopeSummary{BudgetID: 1, Available: 400000}
+	rent := models.EnvelopeSummary{BudgetID: 2, Available: 0}
+	overspent := models.EnvelopeSummary{BudgetID: 3, Available: -150000, Overspent: true}
+
+	tests := []struct {
+		name     string
+		state    *models.EnvelopeMonthResponse
+		budgetID uint64
+		amount   float64
+		wantErr  error
+	}{
+		{"assign what is left", state(1000000, groceries, rent), 2, 1000000, nil},
+		{"assign more than is left", state(1000000, groceries, rent), 2, 1000000.01, ErrNotEnoughToAssign},
+		{"nothing left to assign", state(0, groceries, rent), 1, 1, ErrNotEnoughToAssign},
+		{"return to be assigned", state(0, groceries, rent), 1, -400000, nil},
+		{"return more than available", state(0, groceries, rent), 1, -400000.01, ErrEnvelopeInsufficientFunds},
+		{"assign elsewhere while overspent", state(500000, groceries, overspent), 1, 100000, ErrCoverOverspentFirst},
+		{"assign to the overspent envelope", state(500000, groceries, overspent), 3, 150000, nil},
+		{"return money while another is overspent", state(500000, groceries, overspent), 1, -100000, nil},
+		{"unknown envelope", state(500000, groceries), 9, 100, ErrEnvelopeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkEnvelopeAssign(tt.state, tt.budgetID, tt.amount); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkEnvelopeAssign() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -24,10 +25,15 @@ func NewGoalService(cfg *config.Config) *GoalService {
 	}
 }
 
-// CreateGoal creates a new financial goal
-func (s *GoalService) CreateGoal(userID uint64, req *models.FinancialGoalCreateRequest) (*models.FinancialGoal, error) {
+// CreateGoal creates a new financial goal in the ledger
+func (s *GoalService) CreateGoal(ledger Ledger, req *models.FinancialGoalCreateRequest) (*models.FinancialGoal, error) {
+	if err := s.checkLinkedCategory(ledger, 0, req.LinkedCategoryID); err != nil {
+		return nil, err
+	}
+
 	goal := &models.FinancialGoal{
-		UserID:       userID,
+		UserID:       ledger.UserID,
+		HouseholdID:  ledger.HouseholdID,
 		Title:        req.Title,
 		Description:  req.Description,
 		TargetAmount: req.TargetAmount,
@@ -36,6 +42,7 @@ func (s *GoalService) CreateGoal(userID uint64, req *models.FinancialGoalCreateR
 		GoalType:     req.GoalType,
 		Priority:     req.Priority,
 		IsAchieved:   false,
+		LinkedCategoryID: req.LinkedCategoryID,
 	}
 
 	if err := s.db.Create(goal).Error; err != nil {
@@ -45,10 +52,10 @@ func (s *GoalService) CreateGoal(userID uint64, req *models.FinancialGoalCreateR
 	return goal, nil
 }
 
-// GetGoals retrieves user's financial goals
-func (s *GoalService) GetGoals(userID uint64) ([]models.FinancialGoal, error) {
+// GetGoals retrieves the ledger's financial goals
+func (s *GoalService) GetGoals(ledger Ledger) ([]models.FinancialGoal, error) {
 	var goals []models.FinancialGoal
-	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&goals).Error; err != nil {
+	if err := s.db.Scopes(ledger.Scope("")).Order("created_at DESC").Find(&goals).Error; err != nil {
 		return nil, fmt.Errorf("failed to get goals: %w", err)
 	}
 
@@ -65,10 +72,13 @@ func (s *GoalService) GetGoals(userID uint64) ([]models.FinancialGoal, error) {
 }
 
 // UpdateGoal updates an existing goal
-func (s *GoalService) UpdateGoal(userID uint64, goalID uint64, req *models.FinancialGoalUpdateRequest) (*models.FinancialGoal, error) {
+func (s *GoalService) UpdateGoal(ledger Ledger, goalID uint64, req *models.FinancialGoalUpdateRequest) (*models.FinancialGoal, error) {
 	var goal models.FinancialGoal
-	if err := s.db.Where("id = ? AND user_id = ?", goalID, userID).First(&goal).Error; err != nil {
-		return nil, fmt.Errorf("goal not found: %w", err)
+	if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", goalID).First(&goal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGoalNotFound
+		}
+		return nil, fmt.Errorf("failed to load goal: %w", err)
 	}
 
 	// Basic validation to keep domain state consistent
@@ -78,6 +88,9 @@ func (s *GoalService) UpdateGoal(userID uint64, goalID uint64, req *models.Finan
 	if req.CurrentAmount < 0 {
 		return nil, fmt.Errorf("current_amount cannot be negative")
 	}
+	if err := s.checkLinkedCategory(ledger, goal.ID, req.LinkedCategoryID); err != nil {
+		return nil, err
+	}
 
 	// Update fields
 	goal.Title = req.Title
@@ -87,21 +100,10 @@ func (s *GoalService) UpdateGoal(userID uint64, goalID uint64, req *models.Finan
 	goal.TargetDate = req.TargetDate
 	goal.GoalType = req.GoalType
 	goal.Priority = req.Priority
+	goal.LinkedCategoryID = req.LinkedCategoryID
 
 	// Recalculate achievement state based on new numbers
-	if goal.CurrentAmount >= goal.TargetAmount {
-		if !goal.IsAchieved {
-			goal.IsAchieved = true
-			now := time.Now()
-			goal.AchievedAt = &now
-		}
-	} else {
-		// If user chỉnh lại target hoặc current xuống thấp hơn, coi như goal chưa đạt nữa
-		if goal.IsAchieved {
-			goal.IsAchieved = false
-			goal.AchievedAt = nil
-		}
-	}
+	updateGoalAchievement(&goal)
 
 	if err := s.db.Save(&goal).Error; err != nil {
 		return nil, fmt.Errorf("failed to update goal: %w", err)
@@ -117,60 +119,38 @@ func (s *GoalService) UpdateGoal(userID uint64, goalID uint64, req *models.Finan
 	return &goal, nil
 }
 
-// DeleteGoal deletes a goal
-func (s *GoalService) DeleteGoal(userID uint64, goalID uint64) error {
-	result := s.db.Where("id = ? AND user_id = ?", goalID, userID).Delete(&models.FinancialGoal{})
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete goal: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("goal not found")
-	}
-	return nil
-}
-
-// AddContribution adds money to a goal
-func (s *GoalService) AddContribution(userID uint64, goalID uint64, amount float64, note string) (*models.FinancialGoal, error) {
+// GetGoal retrieves a single goal with its progress
+func (s *GoalService) GetGoal(ledger Ledger, goalID uint64) (*models.FinancialGoal, error) {
 	var goal models.FinancialGoal
-	if err := s.db.Where("id = ? AND user_id = ?", goalID, userID).First(&goal).Error; err != nil {
+	if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", goalID).First(&goal).Error; err != nil {
 		return nil, fmt.Errorf("goal not found: %w", err)
 	}
-
-	if amount <= 0 {
-		return nil, fmt.Errorf("contribution amount must be greater than 0")
-	}
-
-	// Không cho góp thêm vào mục tiêu đã đạt để tránh trạng thái/notification khó hiểu
-	if goal.IsAchieved {
-		return nil, fmt.Errorf("goal is already achieved; cannot add more contributions")
-	}
-
-	goal.CurrentAmount += amount
-
-	// Check if goal is achieved
-	if goal.CurrentAmount >= goal.TargetAmount && !goal.IsAchieved {
-		goal.IsAchieved = true
-		now := time.Now()
-		goal.AchievedAt = &now
-	}
-
-	if err := s.db.Save(&goal).Error; err != nil {
-		return nil, fmt.Errorf("failed to add contribution: %w", err)
-	}
-
-	// Calculate progress
-	if goal.TargetAmount <= 0 {
-		goal.Progress = 0
-	} else {
+	if goal.TargetAmount > 0 {
 		goal.Progress = (goal.CurrentAmount / goal.TargetAmount) * 100
 	}
-
-	// Trigger notifications
-	s.checkGoalNotifications(userID, &goal)
-
 	return &goal, nil
 }
 
+// DeleteGoal deletes a goal with its contributions and savings plan
+func (s *GoalService) DeleteGoal(ledger Ledger, goalID uint64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Scopes(ledger.Scope("")).Where("id = ?", goalID).Delete(&models.FinancialGoal{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete goal: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrGoalNotFound
+		}
+		for _, model := range []interface{}{&models.GoalContribution{}, &models.GoalSavingsPlan{}, &models.GoalSavingsPlanRun{}} {
+			if err := tx.Where("goal_id = ?", goalID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// Holdings saved towards the goal stay in the portfolio
+		return tx.Model(&models.InvestmentHolding{}).Where("goal_id = ?", goalID).Update("goal_id", nil).Error
+	})
+}
+
 // checkGoalNotifications checks and triggers goal notifications
 func (s *GoalService) checkGoalNotifications(userID uint64, goal *models.FinancialGoal) {
 	dispatcher := NewNotificationDispatcher(s.config)
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrGoalNotFound              = errors.New("goal not found")
+	ErrGoalAchieved              = errors.New("goal is already achieved; cannot add more contributions")
+	ErrInvalidContribution       = errors.New("contribution amount must be greater than 0")
+	ErrGoalContributionNotFound  = errors.New("goal contribution not found")
+	ErrAutomaticContribution     = errors.New("this contribution follows its transaction; edit the transaction or unlink the goal's category instead")
+	ErrLinkedTransactionNotFound = errors.New("linked transaction not found")
+	ErrTransactionAlreadyLinked  = errors.New("transaction already contributes to a goal")
+	ErrGoalCategoryNotFound      = errors.New("linked category not found")
+	ErrGoalCategoryLinked        = errors.New("another active goal is already linked to this category")
+)
+
+// AddContribution records money put towards a goal
+func (s *GoalService) AddContribution(ledger Ledger, goalID uint64, req *models.GoalContributionRequest) (*models.FinancialGoal, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidContribution
+	}
+
+	var goal models.FinancialGoal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockGoal(tx, ledger, goalID, &goal); err != nil {
+			return err
+		}
+		// Không cho góp thêm vào mục tiêu đã đạt để tránh trạng thái/notification khó hiểu
+		if goal.IsAchieved {
+			return ErrGoalAchieved
+		}
+		if req.TransactionID != nil {
+			if err := s.checkLinkedTransaction(tx, ledger, *req.TransactionID, 0); err != nil {
+				return err
+			}
+		}
+
+		contribution := &models.GoalContribution{
+			GoalID:        goal.ID,
+			UserID:        ledger.UserID,
+			HouseholdID:   goal.HouseholdID,
+			Amount:        roundAmount(req.Amount),
+			ContributedAt: contributionDate(req.Date),
+			Note:          truncateString(req.Note, 500),
+			TransactionID: req.TransactionID,
+		}
+		if err := tx.Create(contribution).Error; err != nil {
+			return fmt.Errorf("failed to add contribution: %w", err)
+		}
+		return applyContribution(tx, &goal, contribution.Amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyGoalMembers(ledger, &goal)
+	return &goal, nil
+}
+
+// ListContributions lists a goal's contributions, newest first
+func (s *GoalService) ListContributions(ledger Ledger, goalID uint64) ([]models.GoalContribution, error) {
+	var count int64
+	if err := s.db.Model(&models.FinancialGoal{}).Scopes(ledger.Scope("")).Where("id = ?", goalID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to load goal: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrGoalNotFound
+	}
+
+	contributions := []models.GoalContribution{}
+	if err := s.db.Where("goal_id = ?", goalID).Order("contributed_at DESC, id DESC").Find(&contributions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load contributions: %w", err)
+	}
+	return contributions, nil
+}
+
+// UpdateContribution edits a manual contribution and adjusts the goal by the difference
+func (s *GoalService) UpdateContribution(ledger Ledger, goalID, contributionID uint64, req *models.GoalContributionRequest) (*models.FinancialGoal, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidContribution
+	}
+
+	var goal models.FinancialGoal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		contribution, err := s.lockContribution(tx, ledger, goalID, contributionID, &goal)
+		if err != nil {
+			return err
+		}
+		if req.TransactionID != nil {
+			if err := s.checkLinkedTransaction(tx, ledger, *req.TransactionID, contribution.ID); err != nil {
+				return err
+			}
+		}
+
+		delta := roundAmount(req.Amount) - contribution.Amount
+		contribution.Amount = roundAmount(req.Amount)
+		if req.Date != nil {
+			contribution.ContributedAt = *req.Date
+		}
+		contribution.Note = truncateString(req.Note, 500)
+		contribution.TransactionID = req.TransactionID
+		if err := tx.Save(contribution).Error; err != nil {
+			return fmt.Errorf("failed to update contribution: %w", err)
+		}
+		return applyContribution(tx, &goal, delta)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyGoalMembers(ledger, &goal)
+	return &goal, nil
+}
+
+// DeleteContribution removes a manual contribution and takes it off the goal
+func (s *GoalService) DeleteContribution(ledger Ledger, goalID, contributionID uint64) (*models.FinancialGoal, error) {
+	var goal models.FinancialGoal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		contribution, err := s.lockContribution(tx, ledger, goalID, contributionID, &goal)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(contribution).Error; err != nil {
+			return fmt.Errorf("failed to delete contribution: %w", err)
+		}
+		return applyContribution(tx, &goal, -contribution.Amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// SyncTransactionContribution keeps a transaction's automatic contribution in
+// line with it: a non-income transaction in a category linked to an open goal
+// contributes its amount, and stops contributing once it no longer qualifies.
+// Linking a category does not pick up earlier transactions.
+func (s *GoalService) SyncTransactionContribution(ledger Ledger, transaction *models.Transaction) error {
+	var changed *models.FinancialGoal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.GoalContribution
+		err := tx.Where("transaction_id = ?", transaction.ID).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to load contribution: %w", err)
+		}
+		found := err == nil
+		if found && !existing.IsAutomatic {
+			// Linked by hand; the user chose the amount
+			return nil
+		}
+
+		if found {
+			var goal models.FinancialGoal
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&goal, existing.GoalID).Error; err != nil {
+				return fmt.Errorf("failed to load goal: %w", err)
+			}
+			if goal.LinkedCategoryID != nil && *goal.LinkedCategoryID == transaction.CategoryID && transaction.TransactionType != "income" {
+				delta := roundAmount(transaction.Amount) - existing.Amount
+				if err := tx.Model(&existing).Updates(map[string]interface{}{
+					"amount":         roundAmount(transaction.Amount),
+					"contributed_at": transaction.TransactionDate,
+					"note":           truncateString(transaction.Description, 500),
+				}).Error; err != nil {
+					return fmt.Errorf("failed to update contribution: %w", err)
+				}
+				changed = &goal
+				return applyContribution(tx, &goal, delta)
+			}
+
+			if err := tx.Delete(&existing).Error; err != nil {
+				return fmt.Errorf("failed to delete contribution: %w", err)
+			}
+			if err := applyContribution(tx, &goal, -existing.Amount); err != nil {
+				return err
+			}
+		}
+
+		if transaction.TransactionType == "income" {
+			return nil
+		}
+		var goals []models.FinancialGoal
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(rowLedger(transaction.UserID, transaction.HouseholdID).Scope("")).
+			Where("linked_category_id = ? AND is_achieved = ?", transaction.CategoryID, false).
+			Order("id ASC").Limit(1).Find(&goals).Error; err != nil {
+			return fmt.Errorf("failed to load linked goal: %w", err)
+		}
+		if len(goals) == 0 {
+			return nil
+		}
+
+		id := transaction.ID
+		contribution := &models.GoalContribution{
+			GoalID:        goals[0].ID,
+			UserID:        transaction.UserID,
+			HouseholdID:   goals[0].HouseholdID,
+			Amount:        roundAmount(transaction.Amount),
+			ContributedAt: transaction.TransactionDate,
+			Note:          truncateString(transaction.Description, 500),
+			TransactionID: &id,
+			IsAutomatic:   true,
+		}
+		if err := tx.Create(contribution).Error; err != nil {
+			return fmt.Errorf("failed to add contribution: %w", err)
+		}
+		changed = &goals[0]
+		return applyContribution(tx, &goals[0], contribution.Amount)
+	})
+	if err != nil {
+		return err
+	}
+
+	if changed != nil {
+		s.notifyGoalMembers(ledger, changed)
+	}
+	return nil
+}
+
+// RemoveTransactionContributions takes a deleted transaction's contributions
+// off their goals
+func (s *GoalService) RemoveTransactionContributions(transactionID uint64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var contributions []models.GoalContribution
+		if err := tx.Where("transaction_id = ?", transactionID).Find(&contributions).Error; err != nil {
+			return fmt.Errorf("failed to load contributions: %w", err)
+		}
+		for i := range contributions {
+			var goal models.FinancialGoal
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&goal, contributions[i].GoalID).Error; err != nil {
+				return fmt.Errorf("failed to load goal: %w", err)
+			}
+			if err := tx.Delete(&contributions[i]).Error; err != nil {
+				return fmt.Errorf("failed to delete contribution: %w", err)
+			}
+			if err := applyContribution(tx, &goal, -contributions[i].Amount); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockGoal loads a ledger goal for update
+func (s *GoalService) lockGoal(tx *gorm.DB, ledger Ledger, goalID uint64, goal *models.FinancialGoal) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(ledger.Scope("")).Where("id = ?", goalID).First(goal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGoalNotFound
+		}
+		return fmt.Errorf("failed to load goal: %w", err)
+	}
+	return nil
+}
+
+// lockContribution loads a manual contribution of a ledger goal, locking the goal
+func (s *GoalService) lockContribution(tx *gorm.DB, ledger Ledger, goalID, contributionID uint64, goal *models.FinancialGoal) (*models.GoalContribution, error) {
+	if err := s.lockGoal(tx, ledger, goalID, goal); err != nil {
+		return nil, err
+	}
+
+	var contribution models.GoalContribution
+	if err := tx.Where("id = ? AND goal_id = ?", contributionID, goalID).First(&contribution).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGoalContributionNotFound
+		}
+		return nil, fmt.Errorf("failed to load contribution: %w", err)
+	}
+	if contribution.IsAutomatic {
+		return nil, ErrAutomaticContribution
+	}
+	return &contribution, nil
+}
+
+// checkLinkedTransaction ensures a transaction is in the ledger and not
+// already counted by another contribution than exceptID
+func (s *GoalService) checkLinkedTransaction(tx *gorm.DB, ledger Ledger, transactionID, exceptID uint64) error {
+	var count int64
+	if err := tx.Model(&models.Transaction{}).Scopes(ledger.Scope("")).Where("id = ?", transactionID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load transaction: %w", err)
+	}
+	if count == 0 {
+		return ErrLinkedTransactionNotFound
+	}
+	if err := tx.Model(&models.GoalContribution{}).Where("transaction_id = ? AND id <> ?", transactionID, exceptID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check contributions: %w", err)
+	}
+	if count > 0 {
+		return ErrTransactionAlreadyLinked
+	}
+	return nil
+}
+
+// checkLinkedCategory ensures a goal's linked category is visible to the ledger
+// and not linked to another open goal, so each transaction has one goal to go to
+func (s *GoalService) checkLinkedCategory(ledger Ledger, goalID uint64, categoryID *uint64) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Category{}).Scopes(ledger.CategoryScope()).Where("id = ?", *categoryID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load category: %w", err)
+	}
+	if count == 0 {
+		return ErrGoalCategoryNotFound
+	}
+	if err := s.db.Model(&models.FinancialGoal{}).Scopes(ledger.Scope("")).
+		Where("linked_category_id = ? AND is_achieved = ? AND id <> ?", *categoryID, false, goalID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check linked goals: %w", err)
+	}
+	if count > 0 {
+		return ErrGoalCategoryLinked
+	}
+	return nil
+}
+
+// applyContribution moves a goal's current amount by delta and updates its
+// achievement state
+func applyContribution(tx *gorm.DB, goal *models.FinancialGoal, delta float64) error {
+	goal.CurrentAmount = math.Max(0, roundAmount(goal.CurrentAmount+delta))
+	updateGoalAchievement(goal)
+	if err := tx.Model(goal).Updates(map[string]interface{}{
+		"current_amount": goal.CurrentAmount,
+		"is_achieved":    goal.IsAchieved,
+		"achieved_at":    goal.AchievedAt,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+	if goal.TargetAmount > 0 {
+		goal.Progress = (goal.CurrentAmount / goal.TargetAmount) * 100
+	}
+	return nil
+}
+
+// updateGoalAchievement marks a goal achieved once it reaches its target, and
+// not achieved again when it falls below
+func updateGoalAchievement(goal *models.FinancialGoal) {
+	if goal.CurrentAmount >= goal.TargetAmount {
+		if !goal.IsAchieved {
+			goal.IsAchieved = true
+			now := time.Now()
+			goal.AchievedAt = &now
+		}
+	} else if goal.IsAchieved {
+		// If user chỉnh lại target hoặc current xuống thấp hơn, coi như goal chưa đạt nữa
+		goal.IsAchieved = false
+		goal.AchievedAt = nil
+	}
+}
+
+// notifyGoalMembers sends goal notifications to everyone sharing the goal
+func (s *GoalService) notifyGoalMembers(ledger Ledger, goal *models.FinancialGoal) {
+	for _, userID := range ledgerMemberIDs(s.db, ledger) {
+		s.checkGoalNotifications(userID, goal)
+	}
+}
+
+// contributionDate is the given date, or now
+func contributionDate(date *time.Time) time.Time {
+	if date == nil || date.IsZero() {
+		return time.Now()
+	}
+	return *date
+}
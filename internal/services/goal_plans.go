@@ -0,0 +1,377 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSavingsPlanNotFound         = errors.New("savings plan not found")
+	ErrSavingsPlanCategoryNotFound = errors.New("savings plan category not found")
+)
+
+// maxProjectedRuns bounds how far ahead a plan's completion date is projected
+const maxProjectedRuns = 1200
+
+// GetSavingsPlan returns a goal's savings plan with its projection
+func (s *GoalService) GetSavingsPlan(ledger Ledger, goalID uint64) (*models.GoalSavingsPlan, error) {
+	var goal models.FinancialGoal
+	if err := s.findGoal(ledger, goalID, &goal); err != nil {
+		return nil, err
+	}
+
+	var plan models.GoalSavingsPlan
+	if err := s.db.Where("goal_id = ?", goalID).First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSavingsPlanNotFound
+		}
+		return nil, fmt.Errorf("failed to load savings plan: %w", err)
+	}
+	plan.Projection = savingsPlanProjection(&plan, &goal)
+	return &plan, nil
+}
+
+// SaveSavingsPlan creates or replaces a goal's savings plan. The first run is
+// the first scheduled date from today on; past dates are not caught up.
+func (s *GoalService) SaveSavingsPlan(ledger Ledger, goalID uint64, req *models.GoalSavingsPlanRequest) (*models.GoalSavingsPlan, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidContribution
+	}
+
+	var goal models.FinancialGoal
+	if err := s.findGoal(ledger, goalID, &goal); err != nil {
+		return nil, err
+	}
+	if goal.IsAchieved {
+		return nil, ErrGoalAchieved
+	}
+	if req.CreateTransaction {
+		if req.CategoryID == nil {
+			return nil, ErrSavingsPlanCategoryNotFound
+		}
+		var count int64
+		if err := s.db.Model(&models.Category{}).Scopes(ledger.CategoryScope()).Where("id = ?", *req.CategoryID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to load category: %w", err)
+		}
+		if count == 0 {
+			return nil, ErrSavingsPlanCategoryNotFound
+		}
+	}
+
+	var plan models.GoalSavingsPlan
+	err := s.db.Where("goal_id = ?", goalID).First(&plan).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load savings plan: %w", err)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		plan = models.GoalSavingsPlan{
+			GoalID:      goal.ID,
+			UserID:      ledger.UserID,
+			HouseholdID: goal.HouseholdID,
+		}
+	}
+
+	y, m, d := req.StartDate.Date()
+	plan.StartDate = time.Date(y, m, d, 0, 0, 0, 0, req.StartDate.Location())
+	plan.Amount = roundAmount(req.Amount)
+	plan.Frequency = req.Frequency
+	plan.IsActive = req.IsActive == nil || *req.IsActive
+	plan.CreateTransaction = req.CreateTransaction
+	plan.CategoryID = nil
+	if req.CreateTransaction {
+		plan.CategoryID = req.CategoryID
+	}
+
+	// Never schedule a date that has already run, so replacing a plan on its
+	// run day does not contribute twice
+	now := time.Now()
+	notBefore := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, plan.StartDate.Location())
+	if plan.LastRunAt != nil && !plan.LastRunAt.Before(notBefore) {
+		notBefore = plan.LastRunAt.AddDate(0, 0, 1)
+	}
+	plan.NextRunAt = firstSavingsPlanRun(plan.StartDate, plan.Frequency, notBefore)
+
+	if err := s.db.Save(&plan).Error; err != nil {
+		return nil, fmt.Errorf("failed to save savings plan: %w", err)
+	}
+	plan.Projection = savingsPlanProjection(&plan, &goal)
+	return &plan, nil
+}
+
+// DeleteSavingsPlan stops and removes a goal's savings plan with its run
+// history. Contributions it already made stay.
+func (s *GoalService) DeleteSavingsPlan(ledger Ledger, goalID uint64) error {
+	var goal models.FinancialGoal
+	if err := s.findGoal(ledger, goalID, &goal); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("goal_id = ?", goalID).Delete(&models.GoalSavingsPlan{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete savings plan: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrSavingsPlanNotFound
+		}
+		return tx.Where("goal_id = ?", goalID).Delete(&models.GoalSavingsPlanRun{}).Error
+	})
+}
+
+// ListSavingsPlanRuns lists the runs of a goal's savings plan, newest first
+func (s *GoalService) ListSavingsPlanRuns(ledger Ledger, goalID uint64) ([]models.GoalSavingsPlanRun, error) {
+	var goal models.FinancialGoal
+	if err := s.findGoal(ledger, goalID, &goal); err != nil {
+		return nil, err
+	}
+
+	runs := []models.GoalSavingsPlanRun{}
+	if err := s.db.Where("goal_id = ?", goalID).Order("scheduled_for DESC, id DESC").Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load savings plan runs: %w", err)
+	}
+	return runs, nil
+}
+
+// RunDueSavingsPlans runs every active savings plan whose next run has come,
+// catching up on runs missed while the scheduler was down
+func (s *GoalService) RunDueSavingsPlans() error {
+	now := time.Now()
+
+	var planIDs []uint64
+	if err := s.db.Model(&models.GoalSavingsPlan{}).Where("is_active = ? AND next_run_at <= ?", true, now).
+		Pluck("id", &planIDs).Error; err != nil {
+		return fmt.Errorf("failed to load due savings plans: %w", err)
+	}
+
+	for _, id := range planIDs {
+		for {
+			ran, err := s.runSavingsPlan(id, now)
+			if err != nil {
+				log.Printf("Failed to run savings plan %d: %v", id, err)
+				break
+			}
+			if !ran {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// runSavingsPlan performs the plan's next run if it is due and reports
+// whether it did. A run that cannot contribute is still recorded, as skipped
+// or failed, and the plan moves on to its next date.
+func (s *GoalService) runSavingsPlan(planID uint64, now time.Time) (bool, error) {
+	var (
+		plan        models.GoalSavingsPlan
+		goal        models.FinancialGoal
+		run         models.GoalSavingsPlanRun
+		ledger      Ledger
+		due         bool
+		contributed bool
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&plan, planID).Error; err != nil {
+			return fmt.Errorf("failed to load savings plan: %w", err)
+		}
+		if !plan.IsActive || plan.NextRunAt.After(now) {
+			return nil
+		}
+		due = true
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&goal, plan.GoalID).Error; err != nil {
+			return fmt.Errorf("failed to load goal: %w", err)
+		}
+		ledger = rowLedger(goal.UserID, goal.HouseholdID)
+
+		run = models.GoalSavingsPlanRun{
+			PlanID:       plan.ID,
+			GoalID:       plan.GoalID,
+			ScheduledFor: plan.NextRunAt,
+			Amount:       plan.Amount,
+		}
+		if goal.IsAchieved {
+			// Nothing left to save for; stop the plan instead of skipping forever
+			run.Status = models.SavingsPlanRunSkipped
+			run.Reason = "goal already achieved"
+			plan.IsActive = false
+		} else if err := tx.Transaction(func(tx *gorm.DB) error {
+			return s.contributeSavingsPlan(tx, ledger, &plan, &goal, &run)
+		}); err != nil {
+			run.Status = models.SavingsPlanRunFailed
+			run.Reason = truncateString(err.Error(), 500)
+			run.ContributionID, run.TransactionID = nil, nil
+		} else {
+			run.Status = models.SavingsPlanRunCompleted
+			contributed = true
+		}
+
+		if err := tx.Create(&run).Error; err != nil {
+			return fmt.Errorf("failed to record savings plan run: %w", err)
+		}
+		scheduled := plan.NextRunAt
+		plan.LastRunAt = &scheduled
+		plan.NextRunAt = firstSavingsPlanRun(plan.StartDate, plan.Frequency, scheduled.AddDate(0, 0, 1))
+		return tx.Model(&plan).Updates(map[string]interface{}{
+			"last_run_at": plan.LastRunAt,
+			"next_run_at": plan.NextRunAt,
+			"is_active":   plan.IsActive,
+		}).Error
+	})
+	if err != nil || !due {
+		return false, err
+	}
+
+	if contributed {
+		s.notifyGoalMembers(ledger, &goal)
+		action := map[string]interface{}{"goal_id": goal.ID, "action": "savings_plan_run"}
+		if run.TransactionID != nil {
+			action["transaction_id"] = *run.TransactionID
+		}
+		invalidateDashboards(s.db, ledger, action)
+	}
+	return true, nil
+}
+
+// contributeSavingsPlan adds a run's contribution to the goal, with its
+// transfer transaction when the plan records one
+func (s *GoalService) contributeSavingsPlan(tx *gorm.DB, ledger Ledger, plan *models.GoalSavingsPlan, goal *models.FinancialGoal, run *models.GoalSavingsPlanRun) error {
+	contribution := &models.GoalContribution{
+		GoalID:        goal.ID,
+		UserID:        plan.UserID,
+		HouseholdID:   goal.HouseholdID,
+		Amount:        plan.Amount,
+		ContributedAt: run.ScheduledFor,
+		Note:          "savings plan",
+	}
+
+	if plan.CreateTransaction {
+		var count int64
+		if plan.CategoryID != nil {
+			if err := tx.Model(&models.Category{}).Scopes(ledger.CategoryScope()).Where("id = ?", *plan.CategoryID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to load category: %w", err)
+			}
+		}
+		if count == 0 {
+			return ErrSavingsPlanCategoryNotFound
+		}
+
+		metadata, _ := json.Marshal(map[string]interface{}{"goal_id": goal.ID, "savings_plan_id": plan.ID})
+		transaction := &models.Transaction{
+			UserID:          plan.UserID,
+			HouseholdID:     goal.HouseholdID,
+			CategoryID:      *plan.CategoryID,
+			Amount:          plan.Amount,
+			Description:     "Savings plan: " + goal.Title,
+			TransactionType: "transfer",
+			TransactionDate: run.ScheduledFor,
+			Tags:            "[]",
+			Metadata:        string(metadata),
+		}
+		if err := tx.Create(transaction).Error; err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		contribution.TransactionID = &transaction.ID
+		run.TransactionID = &transaction.ID
+	}
+
+	if err := tx.Create(contribution).Error; err != nil {
+		return fmt.Errorf("failed to add contribution: %w", err)
+	}
+	run.ContributionID = &contribution.ID
+	return applyContribution(tx, goal, contribution.Amount)
+}
+
+// findGoal loads a goal of the ledger
+func (s *GoalService) findGoal(ledger Ledger, goalID uint64, goal *models.FinancialGoal) error {
+	if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", goalID).First(goal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGoalNotFound
+		}
+		return fmt.Errorf("failed to load goal: %w", err)
+	}
+	return nil
+}
+
+// savingsPlanProjection works out when the plan reaches the goal's target and
+// whether that is in time for its target date
+func savingsPlanProjection(plan *models.GoalSavingsPlan, goal *models.FinancialGoal) *models.GoalSavingsPlanProjection {
+	remaining := math.Max(0, roundAmount(goal.TargetAmount-goal.CurrentAmount))
+	projection := &models.GoalSavingsPlanProjection{RemainingAmount: remaining}
+	if remaining == 0 {
+		if goal.TargetDate != nil {
+			meets := true
+			projection.MeetsTargetDate = &meets
+		}
+		return projection
+	}
+	if !plan.IsActive {
+		return projection
+	}
+
+	next := savingsPlanRunIndex(plan.StartDate, plan.Frequency, plan.NextRunAt)
+	if needed := int(math.Ceil(remaining / plan.Amount)); needed <= maxProjectedRuns {
+		completion := savingsPlanRun(plan.StartDate, plan.Frequency, next+needed-1)
+		projection.ProjectedCompletionDate = &completion
+	}
+
+	if goal.TargetDate != nil {
+		// Runs on the target date itself still count
+		y, m, d := goal.TargetDate.Date()
+		deadline := time.Date(y, m, d+1, 0, 0, 0, 0, goal.TargetDate.Location())
+		runs := 0
+		for runs <= maxProjectedRuns && savingsPlanRun(plan.StartDate, plan.Frequency, next+runs).Before(deadline) {
+			runs++
+		}
+		projection.RunsBeforeTargetDate = &runs
+
+		meets := projection.ProjectedCompletionDate != nil && projection.ProjectedCompletionDate.Before(deadline)
+		projection.MeetsTargetDate = &meets
+		if runs > 0 {
+			// Round up to the cent so the last run does not fall short
+			required := math.Ceil(remaining/float64(runs)*100) / 100
+			projection.RequiredAmount = &required
+		}
+	}
+	return projection
+}
+
+// savingsPlanRun returns the n-th scheduled date of a plan, counting from 0.
+// Monthly and yearly plans keep the start date's day, falling back to the
+// month's last day when it is shorter.
+func savingsPlanRun(start time.Time, frequency string, n int) time.Time {
+	y, m, d := start.Date()
+	loc := start.Location()
+	switch frequency {
+	case models.SavingsPlanWeekly:
+		return time.Date(y, m, d+7*n, 0, 0, 0, 0, loc)
+	case models.SavingsPlanYearly:
+		return time.Date(y+n, m, clampDay(y+n, m, d), 0, 0, 0, 0, loc)
+	default:
+		first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, loc)
+		return time.Date(first.Year(), first.Month(), clampDay(first.Year(), first.Month(), d), 0, 0, 0, 0, loc)
+	}
+}
+
+// savingsPlanRunIndex returns the index of the first scheduled date not
+// before date
+func savingsPlanRunIndex(start time.Time, frequency string, date time.Time) int {
+	n := 0
+	for savingsPlanRun(start, frequency, n).Before(date) {
+		n++
+	}
+	return n
+}
+
+// firstSavingsPlanRun returns the first scheduled date not before notBefore
+func firstSavingsPlanRun(start time.Time, frequency string, notBefore time.Time) time.Time {
+	return savingsPlanRun(start, frequency, savingsPlanRunIndex(start, frequency, notBefore))
+}
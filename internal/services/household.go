@@ -0,0 +1,440 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrHouseholdNotFound       = errors.New("household not found")
+	ErrHouseholdForbidden      = errors.New("only the household owner can do this")
+	ErrHouseholdFull           = errors.New("household has reached its member limit")
+	ErrAlreadyMember           = errors.New("user is already a member of this household")
+	ErrInvitationInvalid       = errors.New("invitation is invalid or has expired")
+	ErrOwnerCannotLeave        = errors.New("transfer ownership before leaving the household")
+	ErrHouseholdMemberNotFound = errors.New("household member not found")
+)
+
+type HouseholdService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+func NewHouseholdService(cfg *config.Config) *HouseholdService {
+	return &HouseholdService{
+		db:     database.GetDB(),
+		config: cfg,
+	}
+}
+
+// ResolveLedger returns the household ledger for a member, carrying their role.
+// Non-members get ErrHouseholdNotFound so household IDs cannot be probed.
+func (s *HouseholdService) ResolveLedger(userID, householdID uint64) (Ledger, error) {
+	var member models.HouseholdMember
+	if err := s.db.Where("household_id = ? AND user_id = ?", householdID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Ledger{}, ErrHouseholdNotFound
+		}
+		return Ledger{}, fmt.Errorf("failed to load household membership: %w", err)
+	}
+	return Ledger{UserID: userID, HouseholdID: &member.HouseholdID, Role: member.Role}, nil
+}
+
+// CreateHousehold creates a household with the caller as its owner
+func (s *HouseholdService) CreateHousehold(userID uint64, req *models.HouseholdCreateRequest) (*models.HouseholdResponse, error) {
+	household := &models.Household{Name: strings.TrimSpace(req.Name), OwnerID: userID}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(household).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.HouseholdMember{
+			HouseholdID: household.ID,
+			UserID:      userID,
+			Role:        models.HouseholdRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create household: %w", err)
+	}
+	return s.GetHousehold(userID, household.ID)
+}
+
+// ListHouseholds returns the households the user belongs to
+func (s *HouseholdService) ListHouseholds(userID uint64) ([]models.HouseholdResponse, error) {
+	var ids []uint64
+	if err := s.db.Model(&models.HouseholdMember{}).Where("user_id = ?", userID).Pluck("household_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to list households: %w", err)
+	}
+
+	responses := make([]models.HouseholdResponse, 0, len(ids))
+	for _, id := range ids {
+		household, err := s.GetHousehold(userID, id)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *household)
+	}
+	return responses, nil
+}
+
+// GetHousehold returns a household and its members
+func (s *HouseholdService) GetHousehold(userID, householdID uint64) (*models.HouseholdResponse, error) {
+	ledger, err := s.ResolveLedger(userID, householdID)
+	if err != nil {
+		return nil, err
+	}
+
+	var household models.Household
+	if err := s.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Members.User").First(&household, householdID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load household: %w", err)
+	}
+
+	resp := &models.HouseholdResponse{
+		ID:        household.ID,
+		Name:      household.Name,
+		OwnerID:   household.OwnerID,
+		Role:      ledger.Role,
+		Members:   make([]models.HouseholdMemberResponse, 0, len(household.Members)),
+		CreatedAt: household.CreatedAt,
+	}
+	for _, m := range household.Members {
+		member := models.HouseholdMemberResponse{UserID: m.UserID, Role: m.Role, JoinedAt: m.CreatedAt}
+		if m.User != nil {
+			member.Username = m.User.Username
+			member.FirstName = m.User.FirstName
+			member.LastName = m.User.LastName
+			member.Email = m.User.Email
+		}
+		resp.Members = append(resp.Members, member)
+	}
+	return resp, nil
+}
+
+// UpdateHousehold renames a household
+func (s *HouseholdService) UpdateHousehold(userID, householdID uint64, req *models.HouseholdUpdateRequest) (*models.HouseholdResponse, error) {
+	if _, err := s.requireOwner(userID, householdID); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.Household{}).Where("id = ?", householdID).Update("name", strings.TrimSpace(req.Name)).Error; err != nil {
+		return nil, fmt.Errorf("failed to update household: %w", err)
+	}
+	return s.GetHousehold(userID, householdID)
+}
+
+// DeleteHousehold deletes a household together with everything in its ledger
+func (s *HouseholdService) DeleteHousehold(userID, householdID uint64) error {
+	ledger, err := s.requireOwner(userID, householdID)
+	if err != nil {
+		return err
+	}
+	// Collected up front, as the membership rows go with the household
+	memberIDs := ledgerMemberIDs(s.db, ledger)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("budget_id IN (?)", tx.Model(&models.Budget{}).Select("id").Where("household_id = ?", householdID)).
+			Delete(&models.BudgetPeriod{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("plan_id IN (?)", tx.Model(&models.GoalSavingsPlan{}).Select("id").Where("household_id = ?", householdID)).
+			Delete(&models.GoalSavingsPlanRun{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{
+			&models.EnvelopeAllocation{},
+			&models.GoalContribution{},
+			&models.GoalSavingsPlan{},
+			&models.DebtPayment{},
+			&models.Debt{},
+			&models.Bill{},
+			&models.DismissedSubscription{},
+			&models.InvestmentTrade{},
+			&models.InvestmentHolding{},
+			&models.Transaction{},
+			&models.Budget{},
+			&models.FinancialGoal{},
+			&models.Category{},
+			&models.HouseholdInvitation{},
+			&models.HouseholdMember{},
+		} {
+			if err := tx.Where("household_id = ?", householdID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Household{}, householdID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete household: %w", err)
+	}
+
+	invalidateMemberDashboards(ledger, memberIDs, map[string]interface{}{"household_id": householdID, "action": "household_deleted"})
+	return nil
+}
+
+// InviteMember creates an invitation and emails its link. The token is also
+// returned so the owner can share it some other way.
+func (s *HouseholdService) InviteMember(userID, householdID uint64, req *models.HouseholdInviteRequest) (*models.HouseholdInviteResponse, error) {
+	if _, err := s.requireOwner(userID, householdID); err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	var members int64
+	if err := s.db.Model(&models.HouseholdMember{}).Where("household_id = ?", householdID).Count(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to count members: %w", err)
+	}
+	if s.config.Household.MaxMembers > 0 && members >= int64(s.config.Household.MaxMembers) {
+		return nil, ErrHouseholdFull
+	}
+
+	var invitee models.User
+	inviteeFound := s.db.Preload("Profile").Where("email = ?", email).First(&invitee).Error == nil
+	if inviteeFound {
+		var count int64
+		s.db.Model(&models.HouseholdMember{}).Where("household_id = ? AND user_id = ?", householdID, invitee.ID).Count(&count)
+		if count > 0 {
+			return nil, ErrAlreadyMember
+		}
+	}
+
+	token, tokenHash, err := newAccountToken()
+	if err != nil {
+		return nil, err
+	}
+	invitation := &models.HouseholdInvitation{
+		HouseholdID: householdID,
+		Email:       email,
+		Role:        req.Role,
+		TokenHash:   tokenHash,
+		InvitedBy:   userID,
+		ExpiresAt:   time.Now().Add(s.config.GetHouseholdInvitationExpiration()),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// A new invitation replaces any pending one for the same address
+		if err := tx.Where("household_id = ? AND email = ? AND accepted_at IS NULL", householdID, email).
+			Delete(&models.HouseholdInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(invitation).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	recipient := &invitee
+	if !inviteeFound {
+		recipient = &models.User{Email: email, Username: email}
+	}
+	link := strings.TrimRight(s.config.Server.FrontendURL, "/") + "/households/accept?token=" + token
+	go func() {
+		if err := NewEmailService().SendAccountEmail(recipient, "household_invite", link, invitation.ExpiresAt); err != nil {
+			log.Printf("Failed to send household invitation email for household %d: %v", householdID, err)
+		}
+	}()
+
+	return &models.HouseholdInviteResponse{
+		HouseholdInvitationResponse: invitationToResponse(invitation),
+		Token:                       token,
+	}, nil
+}
+
+// ListInvitations returns the household's pending invitations
+func (s *HouseholdService) ListInvitations(userID, householdID uint64) ([]models.HouseholdInvitationResponse, error) {
+	if _, err := s.requireOwner(userID, householdID); err != nil {
+		return nil, err
+	}
+
+	var invitations []models.HouseholdInvitation
+	if err := s.db.Where("household_id = ? AND accepted_at IS NULL AND expires_at > ?", householdID, time.Now()).
+		Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	responses := make([]models.HouseholdInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		responses = append(responses, invitationToResponse(&invitations[i]))
+	}
+	return responses, nil
+}
+
+// RevokeInvitation cancels a pending invitation
+func (s *HouseholdService) RevokeInvitation(userID, householdID, invitationID uint64) error {
+	if _, err := s.requireOwner(userID, householdID); err != nil {
+		return err
+	}
+	result := s.db.Where("id = ? AND household_id = ? AND accepted_at IS NULL", invitationID, householdID).
+		Delete(&models.HouseholdInvitation{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationInvalid
+	}
+	return nil
+}
+
+// AcceptInvitation adds the caller to the invited household. The invitation
+// must have been sent to the caller's email address.
+func (s *HouseholdService) AcceptInvitation(userID uint64, token string) (*models.HouseholdResponse, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	var invitation models.HouseholdInvitation
+	if err := s.db.Where("token_hash = ? AND accepted_at IS NULL AND expires_at > ?", hashToken(token), time.Now()).
+		First(&invitation).Error; err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, ErrInvitationInvalid
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.HouseholdMember{}).Where("household_id = ? AND user_id = ?", invitation.HouseholdID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAlreadyMember
+		}
+		if s.config.Household.MaxMembers > 0 {
+			if err := tx.Model(&models.HouseholdMember{}).Where("household_id = ?", invitation.HouseholdID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count >= int64(s.config.Household.MaxMembers) {
+				return ErrHouseholdFull
+			}
+		}
+
+		result := tx.Model(&models.HouseholdInvitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationInvalid
+		}
+		return tx.Create(&models.HouseholdMember{
+			HouseholdID: invitation.HouseholdID,
+			UserID:      userID,
+			Role:        invitation.Role,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrAlreadyMember) || errors.Is(err, ErrHouseholdFull) || errors.Is(err, ErrInvitationInvalid) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return s.GetHousehold(userID, invitation.HouseholdID)
+}
+
+// UpdateMemberRole changes a member's role. Making another member the owner
+// transfers ownership; the previous owner stays on as an editor.
+func (s *HouseholdService) UpdateMemberRole(userID, householdID, memberID uint64, req *models.HouseholdMemberRoleRequest) (*models.HouseholdResponse, error) {
+	if _, err := s.requireOwner(userID, householdID); err != nil {
+		return nil, err
+	}
+	if memberID == userID {
+		return nil, ErrOwnerCannotLeave
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.HouseholdMember{}).
+			Where("household_id = ? AND user_id = ?", householdID, memberID).
+			Update("role", req.Role)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			tx.Model(&models.HouseholdMember{}).Where("household_id = ? AND user_id = ?", householdID, memberID).Count(&count)
+			if count == 0 {
+				return ErrHouseholdMemberNotFound
+			}
+		}
+		if req.Role != models.HouseholdRoleOwner {
+			return nil
+		}
+
+		if err := tx.Model(&models.HouseholdMember{}).
+			Where("household_id = ? AND user_id = ?", householdID, userID).
+			Update("role", models.HouseholdRoleEditor).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Household{}).Where("id = ?", householdID).Update("owner_id", memberID).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrHouseholdMemberNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update member role: %w", err)
+	}
+
+	return s.GetHousehold(userID, householdID)
+}
+
+// RemoveMember removes a member from the household. Owners can remove anyone
+// else; any other member can only remove themselves (leave). The rows a
+// member added stay in the household ledger.
+func (s *HouseholdService) RemoveMember(userID, householdID, memberID uint64) error {
+	ledger, err := s.ResolveLedger(userID, householdID)
+	if err != nil {
+		return err
+	}
+	isOwner := ledger.Role == models.HouseholdRoleOwner
+	if memberID == userID && isOwner {
+		return ErrOwnerCannotLeave
+	}
+	if memberID != userID && !isOwner {
+		return ErrHouseholdForbidden
+	}
+
+	result := s.db.Where("household_id = ? AND user_id = ?", householdID, memberID).Delete(&models.HouseholdMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove member: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrHouseholdMemberNotFound
+	}
+	return nil
+}
+
+func (s *HouseholdService) requireOwner(userID, householdID uint64) (Ledger, error) {
+	ledger, err := s.ResolveLedger(userID, householdID)
+	if err != nil {
+		return Ledger{}, err
+	}
+	if ledger.Role != models.HouseholdRoleOwner {
+		return Ledger{}, ErrHouseholdForbidden
+	}
+	return ledger, nil
+}
+
+func invitationToResponse(inv *models.HouseholdInvitation) models.HouseholdInvitationResponse {
+	return models.HouseholdInvitationResponse{
+		ID:          inv.ID,
+		HouseholdID: inv.HouseholdID,
+		Email:       inv.Email,
+		Role:        inv.Role,
+		InvitedBy:   inv.InvitedBy,
+		ExpiresAt:   inv.ExpiresAt,
+		CreatedAt:   inv.CreatedAt,
+	}
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrHoldingNotFound        = errors.New("investment holding not found")
+	ErrHoldingExists          = errors.New("the ledger already has a holding with this symbol")
+	ErrTradeNotFound          = errors.New("investment trade not found")
+	ErrInvalidTrade           = errors.New("buys and sales need a quantity and price; dividends need an amount above the fee")
+	ErrInsufficientQuantity   = errors.New("cannot sell more than the quantity held at that date")
+	ErrInvestmentGoalNotFound = errors.New("investment goal not found")
+)
+
+// quantityEpsilon absorbs rounding when a sale closes out a position
+const quantityEpsilon = 1e-8
+
+type InvestmentService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+func NewInvestmentService(cfg *config.Config) *InvestmentService {
+	return &InvestmentService{
+		db:     database.GetDB(),
+		config: cfg,
+	}
+}
+
+// ListHoldings lists the ledger's holdings valued at the latest prices,
+// optionally only those saved towards one goal
+func (s *InvestmentService) ListHoldings(ledger Ledger, goalID *uint64) ([]models.InvestmentHolding, error) {
+	query := s.db.Scopes(ledger.Scope(""))
+	if goalID != nil {
+		query = query.Where("goal_id = ?", *goalID)
+	}
+	holdings := []models.InvestmentHolding{}
+	if err := query.Order("symbol ASC, id ASC").Find(&holdings).Error; err != nil {
+		return nil, fmt.Errorf("failed to load holdings: %w", err)
+	}
+	if err := s.priceHoldings(holdings); err != nil {
+		return nil, err
+	}
+	return holdings, nil
+}
+
+// GetHolding returns one holding of the ledger
+func (s *InvestmentService) GetHolding(ledger Ledger, holdingID uint64) (*models.InvestmentHolding, error) {
+	var holding models.InvestmentHolding
+	if err := s.findHolding(s.db, ledger, holdingID, &holding); err != nil {
+		return nil, err
+	}
+	return s.pricedHolding(holding)
+}
+
+// CreateHolding adds a holding; its position comes from the trades added to it
+func (s *InvestmentService) CreateHolding(ledger Ledger, req *models.InvestmentHoldingRequest) (*models.InvestmentHolding, error) {
+	symbol := normalizeSymbol(req.Symbol)
+	if err := s.checkHolding(ledger, 0, symbol, req.GoalID); err != nil {
+		return nil, err
+	}
+
+	holding := &models.InvestmentHolding{
+		UserID:      ledger.UserID,
+		HouseholdID: ledger.HouseholdID,
+		GoalID:      req.GoalID,
+		Symbol:      symbol,
+		Name:        req.Name,
+		AssetClass:  req.AssetClass,
+	}
+	if err := s.db.Create(holding).Error; err != nil {
+		return nil, fmt.Errorf("failed to create holding: %w", err)
+	}
+	return s.pricedHolding(*holding)
+}
+
+// UpdateHolding updates a holding's symbol, name, asset class or goal
+func (s *InvestmentService) UpdateHolding(ledger Ledger, holdingID uint64, req *models.InvestmentHoldingRequest) (*models.InvestmentHolding, error) {
+	var holding models.InvestmentHolding
+	if err := s.findHolding(s.db, ledger, holdingID, &holding); err != nil {
+		return nil, err
+	}
+	symbol := normalizeSymbol(req.Symbol)
+	if err := s.checkHolding(ledger, holding.ID, symbol, req.GoalID); err != nil {
+		return nil, err
+	}
+
+	holding.Symbol = symbol
+	holding.Name = req.Name
+	holding.AssetClass = req.AssetClass
+	holding.GoalID = req.GoalID
+	if err := s.db.Save(&holding).Error; err != nil {
+		return nil, fmt.Errorf("failed to update holding: %w", err)
+	}
+	return s.pricedHolding(holding)
+}
+
+// DeleteHolding deletes a holding and its trades
+func (s *InvestmentService) DeleteHolding(ledger Ledger, holdingID uint64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Scopes(ledger.Scope("")).Where("id = ?", holdingID).Delete(&models.InvestmentHolding{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete holding: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrHoldingNotFound
+		}
+		return tx.Where("holding_id = ?", holdingID).Delete(&models.InvestmentTrade{}).Error
+	})
+}
+
+// ListTrades lists a holding's trades, newest first
+func (s *InvestmentService) ListTrades(ledger Ledger, holdingID uint64) ([]models.InvestmentTrade, error) {
+	var holding models.InvestmentHolding
+	if err := s.findHolding(s.db, ledger, holdingID, &holding); err != nil {
+		return nil, err
+	}
+
+	trades := []models.InvestmentTrade{}
+	if err := s.db.Where("holding_id = ?", holdingID).Order("traded_at DESC, id DESC").Find(&trades).Error; err != nil {
+		return nil, fmt.Errorf("failed to load trades: %w", err)
+	}
+	return trades, nil
+}
+
+// AddTrade records a buy, sale or dividend and updates the holding's position
+func (s *InvestmentService) AddTrade(ledger Ledger, holdingID uint64, req *models.InvestmentTradeRequest) (*models.InvestmentHolding, error) {
+	trade := &models.InvestmentTrade{
+		UserID:   ledger.UserID,
+		Type:     req.Type,
+		Fee:      roundAmount(req.Fee),
+		TradedAt: contributionDate(req.Date),
+		Note:     truncateString(req.Note, 500),
+	}
+	switch req.Type {
+	case models.InvestmentTradeBuy, models.InvestmentTradeSell:
+		if req.Quantity <= 0 || req.Price <= 0 {
+			return nil, ErrInvalidTrade
+		}
+		trade.Quantity = req.Quantity
+		trade.Price = req.Price
+		trade.Amount = roundAmount(req.Quantity*req.Price + req.Fee)
+		if req.Type == models.InvestmentTradeSell {
+			trade.Amount = roundAmount(req.Quantity*req.Price - req.Fee)
+		}
+	default:
+		trade.Amount = roundAmount(req.Amount - req.Fee)
+	}
+	if trade.Amount <= 0 {
+		return nil, ErrInvalidTrade
+	}
+
+	var holding models.InvestmentHolding
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.findHolding(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ledger, holdingID, &holding); err != nil {
+			return err
+		}
+		trade.HoldingID = holding.ID
+		trade.HouseholdID = holding.HouseholdID
+		if err := tx.Create(trade).Error; err != nil {
+			return fmt.Errorf("failed to add trade: %w", err)
+		}
+		return replayHolding(tx, &holding)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.pricedHolding(holding)
+}
+
+// DeleteTrade removes a trade and updates the holding's position. Removing a
+// buy that later sales depend on is refused.
+func (s *InvestmentService) DeleteTrade(ledger Ledger, holdingID, tradeID uint64) (*models.InvestmentHolding, error) {
+	var holding models.InvestmentHolding
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.findHolding(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ledger, holdingID, &holding); err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND holding_id = ?", tradeID, holdingID).Delete(&models.InvestmentTrade{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete trade: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrTradeNotFound
+		}
+		return replayHolding(tx, &holding)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.pricedHolding(holding)
+}
+
+// GetPortfolio values the ledger's holdings and breaks the market value down
+// by asset class and goal. Holdings without a price count at cost.
+func (s *InvestmentService) GetPortfolio(ledger Ledger) (*models.PortfolioSummary, error) {
+	holdings, err := s.ListHoldings(ledger, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.PortfolioSummary{
+		Holdings:        holdings,
+		ByAssetClass:    []models.PortfolioAllocation{},
+		ByGoal:          []models.PortfolioAllocation{},
+		UnpricedSymbols: []string{},
+	}
+	byClass := map[string]float64{}
+	byGoal := map[uint64]float64{}
+	for _, h := range holdings {
+		summary.MarketValue += h.MarketValue
+		summary.CostBasis += h.CostBasis
+		summary.UnrealizedPL += h.UnrealizedPL
+		summary.RealizedPL += h.RealizedPL
+		summary.Dividends += h.Dividends
+		if h.Quantity <= 0 {
+			continue
+		}
+		byClass[h.AssetClass] += h.MarketValue
+		if h.GoalID != nil {
+			byGoal[*h.GoalID] += h.MarketValue
+		}
+		if h.Price == nil {
+			summary.UnpricedSymbols = append(summary.UnpricedSymbols, h.Symbol)
+		}
+	}
+	summary.MarketValue = roundAmount(summary.MarketValue)
+	summary.CostBasis = roundAmount(summary.CostBasis)
+	summary.UnrealizedPL = roundAmount(summary.UnrealizedPL)
+	summary.RealizedPL = roundAmount(summary.RealizedPL)
+	summary.Dividends = roundAmount(summary.Dividends)
+	summary.TotalReturn = roundAmount(summary.UnrealizedPL + summary.RealizedPL + summary.Dividends)
+	if summary.CostBasis > 0 {
+		summary.UnrealizedPLPercent = roundAmount(summary.UnrealizedPL / summary.CostBasis * 100)
+	}
+
+	for class, value := range byClass {
+		summary.ByAssetClass = append(summary.ByAssetClass, portfolioAllocation(class, nil, value, summary.MarketValue))
+	}
+	if len(byGoal) > 0 {
+		var goals []models.FinancialGoal
+		if err := s.db.Scopes(ledger.Scope("")).Where("id IN ?", mapKeys(byGoal)).Find(&goals).Error; err != nil {
+			return nil, fmt.Errorf("failed to load goals: %w", err)
+		}
+		for _, goal := range goals {
+			goalID := goal.ID
+			summary.ByGoal = append(summary.ByGoal, portfolioAllocation(goal.Title, &goalID, byGoal[goal.ID], summary.MarketValue))
+		}
+	}
+	for _, allocations := range [][]models.PortfolioAllocation{summary.ByAssetClass, summary.ByGoal} {
+		sort.Slice(allocations, func(i, j int) bool { return allocations[i].MarketValue > allocations[j].MarketValue })
+	}
+
+	return summary, nil
+}
+
+// portfolioValue returns the market value of a ledger's holdings
+func (s *InvestmentService) portfolioValue(ledger Ledger) (float64, error) {
+	holdings, err := s.ListHoldings(ledger, nil)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, h := range holdings {
+		total += h.MarketValue
+	}
+	return roundAmount(total), nil
+}
+
+func (s *InvestmentService) findHolding(db *gorm.DB, ledger Ledger, holdingID uint64, holding *models.InvestmentHolding) error {
+	if err := db.Scopes(ledger.Scope("")).Where("id = ?", holdingID).First(holding).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrHoldingNotFound
+		}
+		return fmt.Errorf("failed to load holding: %w", err)
+	}
+	return nil
+}
+
+// checkHolding ensures the symbol is not held twice in the ledger and the
+// goal is one of the ledger's investment goals
+func (s *InvestmentService) checkHolding(ledger Ledger, holdingID uint64, symbol string, goalID *uint64) error {
+	var count int64
+	if err := s.db.Model(&models.InvestmentHolding{}).Scopes(ledger.Scope("")).
+		Where("symbol = ? AND id <> ?", symbol, holdingID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check holdings: %w", err)
+	}
+	if count > 0 {
+		return ErrHoldingExists
+	}
+
+	if goalID == nil {
+		return nil
+	}
+	if err := s.db.Model(&models.FinancialGoal{}).Scopes(ledger.Scope("")).
+		Where("id = ? AND goal_type = ?", *goalID, "investment").Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to load goal: %w", err)
+	}
+	if count == 0 {
+		return ErrInvestmentGoalNotFound
+	}
+	return nil
+}
+
+// priceHoldings values holdings at the latest price of their symbols
+func (s *InvestmentService) priceHoldings(holdings []models.InvestmentHolding) error {
+	if len(holdings) == 0 {
+		return nil
+	}
+	symbols := make([]string, 0, len(holdings))
+	for _, h := range holdings {
+		symbols = append(symbols, h.Symbol)
+	}
+	var prices []models.AssetPrice
+	if err := s.db.Where("symbol IN ?", symbols).Find(&prices).Error; err != nil {
+		return fmt.Errorf("failed to load prices: %w", err)
+	}
+	bySymbol := make(map[string]*models.AssetPrice, len(prices))
+	for i := range prices {
+		bySymbol[prices[i].Symbol] = &prices[i]
+	}
+	for i := range holdings {
+		decorateHolding(&holdings[i], bySymbol[holdings[i].Symbol])
+	}
+	return nil
+}
+
+// pricedHolding returns a copy of one holding valued at its latest price
+func (s *InvestmentService) pricedHolding(holding models.InvestmentHolding) (*models.InvestmentHolding, error) {
+	holdings := []models.InvestmentHolding{holding}
+	if err := s.priceHoldings(holdings); err != nil {
+		return nil, err
+	}
+	return &holdings[0], nil
+}
+
+// replayHolding recomputes a holding's position from all its trades in date
+// order, using average cost, and stores each sale's realized profit
+func replayHolding(tx *gorm.DB, holding *models.InvestmentHolding) error {
+	var trades []models.InvestmentTrade
+	if err := tx.Where("holding_id = ?", holding.ID).Order("traded_at ASC, id ASC").Find(&trades).Error; err != nil {
+		return fmt.Errorf("failed to load trades: %w", err)
+	}
+
+	changed, err := replayTrades(holding, trades)
+	if err != nil {
+		return err
+	}
+	for _, trade := range changed {
+		if err := tx.Model(trade).Update("realized_pl", trade.RealizedPL).Error; err != nil {
+			return fmt.Errorf("failed to update trade: %w", err)
+		}
+	}
+	if err := tx.Model(holding).Updates(map[string]interface{}{
+		"quantity":    holding.Quantity,
+		"cost_basis":  holding.CostBasis,
+		"realized_pl": holding.RealizedPL,
+		"dividends":   holding.Dividends,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update holding: %w", err)
+	}
+	return nil
+}
+
+// replayTrades sets the holding's position from its trades, oldest first. A
+// sale takes out its share of the average cost; the sales whose realized
+// profit changed are returned.
+func replayTrades(holding *models.InvestmentHolding, trades []models.InvestmentTrade) ([]*models.InvestmentTrade, error) {
+	var changed []*models.InvestmentTrade
+	quantity, cost, realized, dividends := 0.0, 0.0, 0.0, 0.0
+	for i := range trades {
+		trade := &trades[i]
+		switch trade.Type {
+		case models.InvestmentTradeBuy:
+			quantity += trade.Quantity
+			cost += trade.Amount
+		case models.InvestmentTradeSell:
+			if trade.Quantity > quantity+quantityEpsilon {
+				return nil, ErrInsufficientQuantity
+			}
+			soldCost := cost
+			if trade.Quantity < quantity {
+				soldCost = cost * trade.Quantity / quantity
+			}
+			quantity -= trade.Quantity
+			cost -= soldCost
+			if quantity < quantityEpsilon {
+				quantity, cost = 0, 0
+			}
+
+			profit := roundAmount(trade.Amount - soldCost)
+			realized += profit
+			if profit != trade.RealizedPL {
+				trade.RealizedPL = profit
+				changed = append(changed, trade)
+			}
+		case models.InvestmentTradeDividend:
+			dividends += trade.Amount
+		}
+	}
+
+	holding.Quantity = quantity
+	holding.CostBasis = roundAmount(cost)
+	holding.RealizedPL = roundAmount(realized)
+	holding.Dividends = roundAmount(dividends)
+	return changed, nil
+}
+
+// decorateHolding fills in a holding's value at price, or at cost without one
+func decorateHolding(holding *models.InvestmentHolding, price *models.AssetPrice) {
+	holding.AverageCost = 0
+	if holding.Quantity > 0 {
+		holding.AverageCost = roundAmount(holding.CostBasis / holding.Quantity)
+	}
+
+	holding.Price, holding.PriceAsOf = nil, nil
+	holding.MarketValue = holding.CostBasis
+	if price != nil {
+		value, asOf := price.Price, price.AsOf
+		holding.Price, holding.PriceAsOf = &value, &asOf
+		holding.MarketValue = roundAmount(holding.Quantity * value)
+	}
+	holding.UnrealizedPL = roundAmount(holding.MarketValue - holding.CostBasis)
+	holding.UnrealizedPLPercent = 0
+	if holding.CostBasis > 0 {
+		holding.UnrealizedPLPercent = roundAmount(holding.UnrealizedPL / holding.CostBasis * 100)
+	}
+}
+
+func portfolioAllocation(key string, goalID *uint64, value, total float64) models.PortfolioAllocation {
+	allocation := models.PortfolioAllocation{Key: key, GoalID: goalID, MarketValue: roundAmount(value)}
+	if total > 0 {
+		allocation.Percent = roundAmount(value / total * 100)
+	}
+	return allocation
+}
+
+func mapKeys(m map[uint64]float64) []uint64 {
+	keys := make([]uint64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
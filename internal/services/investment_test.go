@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"tabimoney/internal/models"
+)
+
+func buyTrade(quantity, amount float64) models.InvestmentTrade {
+	return models.InvestmentTrade{Type: models.InvestmentTradeBuy, Quantity: quantity, Amount: amount}
+}
+
+func sellTrade(quantity, amount float64) models.InvestmentTrade {
+	return models.InvestmentTrade{Type: models.InvestmentTradeSell, Quantity: quantity, Amount: amount}
+}
+
+func dividendTrade(amount float64) models.InvestmentTrade {
+	return models.InvestmentTrade{Type: models.InvestmentTradeDividend, Amount: amount}
+}
+
+func TestReplayTrades(t *testing.T) {
+	tests := []struct {
+		name          string
+		trades        []models.InvestmentTrade
+		wantQuantity  float64
+		wantCost      float64
+		wantRealized  float64
+		wantDividends float64
+		wantProfits   []float64 // realized profit of each sale, in order
+	}{
+		{"buys average their cost", []models.InvestmentTrade{
+			buyTrade(10, 1000), buyTrade(10, 1200),
+		}, 20, 2200, 0, 0, nil},
+		{"sale takes its share of the average cost", []models.InvestmentTrade{
+			buyTrade(10, 1000), buyTrade(10, 1200), sellTrade(5, 800),
+		}, 15, 1650, 250, 0, []float64{250}},
+		{"sale at a loss", []models.InvestmentTrade{
+			buyTrade(10, 1000), buyTrade(10, 1200), sellTrade(5, 400),
+		}, 15, 1650, -150, 0, []float64{-150}},
+		{"selling everything closes the position", []models.InvestmentTrade{
+			buyTrade(3, 100), sellTrade(3, 130),
+		}, 0, 0, 30, 0, []float64{30}},
+		{"quantity rounding still closes the position", []models.InvestmentTrade{
+			buyTrade(0.1, 10), buyTrade(0.2, 20), sellTrade(0.3, 36),
+		}, 0, 0, 6, 0, []float64{6}},
+		{"buying again after closing starts a new average", []models.InvestmentTrade{
+			buyTrade(2, 100), sellTrade(2, 120), buyTrade(1, 80), sellTrade(1, 70),
+		}, 0, 0, 10, 0, []float64{20, -10}},
+		{"partial sales round each profit", []models.InvestmentTrade{
+			buyTrade(3, 100), sellTrade(1, 40), sellTrade(1, 40),
+		}, 1, 33.33, 13.34, 0, []float64{6.67, 6.67}},
+		{"dividends leave the cost alone", []models.InvestmentTrade{
+			buyTrade(10, 500), dividendTrade(25), sellTrade(5, 300), dividendTrade(12.5),
+		}, 5, 250, 50, 37.5, []float64{50}},
+		{"no trades", nil, 0, 0, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holding := &models.InvestmentHolding{Quantity: 99, CostBasis: 99, RealizedPL: 99, Dividends: 99}
+			if _, err := replayTrades(holding, tt.trades); err != nil {
+				t.Fatalf("replayTrades() error = %v", err)
+			}
+			if holding.Quantity != tt.wantQuantity || holding.CostBasis != tt.wantCost {
+				t.Errorf("position = %v for %v, want %v for %v", holding.Quantity, holding.CostBasis, tt.wantQuantity, tt.wantCost)
+			}
+			if holding.RealizedPL != tt.wantRealized || holding.Dividends != tt.wantDividends {
+				t.Errorf("realized = %v, dividends %v, want %v, dividends %v", holding.RealizedPL, holding.Dividends, tt.wantRealized, tt.wantDividends)
+			}
+			var profits []float64
+			for _, trade := range tt.trades {
+				if trade.Type == models.InvestmentTradeSell {
+					profits = append(profits, trade.RealizedPL)
+				}
+			}
+			if !reflect.DeepEqual(profits, tt.wantProfits) {
+				t.Errorf("sale profits = %v, want %v", profits, tt.wantProfits)
+			}
+		})
+	}
+}
+
+func TestReplayTradesInsufficientQuantity(t *testing.T) {
+	tests := []struct {
+		name   string
+		trades []models.InvestmentTrade
+	}{
+		{"selling more than held", []models.InvestmentTrade{buyTrade(2, 100), sellTrade(3, 150)}},
+		{"sale dated before the buy", []models.InvestmentTrade{sellTrade(1, 50), buyTrade(1, 40)}},
+		{"selling again after closing", []models.InvestmentTrade{buyTrade(1, 40), sellTrade(1, 50), sellTrade(1, 50)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holding := &models.InvestmentHolding{}
+			if _, err := replayTrades(holding, tt.trades); !errors.Is(err, ErrInsufficientQuantity) {
+				t.Errorf("replayTrades() error = %v, want ErrInsufficientQuantity", err)
+			}
+		})
+	}
+}
+
+func TestReplayTradesReportsChangedSales(t *testing.T) {
+	trades := []models.InvestmentTrade{
+		{ID: 1, Type: models.InvestmentTradeBuy, Quantity: 4, Amount: 400},
+		{ID: 2, Type: models.InvestmentTradeSell, Quantity: 1, Amount: 150, RealizedPL: 50}, // already stored
+		{ID: 3, Type: models.InvestmentTradeSell, Quantity: 1, Amount: 90, RealizedPL: 0},   // stale after an edit
+	}
+	changed, err := replayTrades(&models.InvestmentHolding{}, trades)
+	if err != nil {
+		t.Fatalf("replayTrades() error = %v", err)
+	}
+	if len(changed) != 1 || changed[0].ID != 3 || changed[0].RealizedPL != -10 {
+		t.Errorf("changed = %+v, want only trade 3 with -10", changed)
+	}
+}
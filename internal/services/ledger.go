@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrLedgerReadOnly is returned when a household viewer tries to change ledger data
+var ErrLedgerReadOnly = errors.New("your household role does not allow changes")
+
+// Ledger identifies the books a request works on: the caller's personal ledger
+// or a household they belong to. Transactions, budgets, goals and categories
+// are always scoped through a Ledger rather than by filtering on user_id.
+type Ledger struct {
+	UserID      uint64  // acting user, recorded as the author of new rows
+	HouseholdID *uint64 // nil for the personal ledger
+	Role        string  // caller's household role; empty for the personal ledger
+	MemberID    *uint64 // optional filter: only rows added by this member
+}
+
+// PersonalLedger returns the user's own ledger
+func PersonalLedger(userID uint64) Ledger {
+	return Ledger{UserID: userID}
+}
+
+// rowLedger returns the ledger a stored row belongs to
+func rowLedger(userID uint64, householdID *uint64) Ledger {
+	return Ledger{UserID: userID, HouseholdID: householdID}
+}
+
+// IsHousehold reports whether this is a shared household ledger
+func (l Ledger) IsHousehold() bool {
+	return l.HouseholdID != nil
+}
+
+// CanWrite reports whether the caller may change data in this ledger
+func (l Ledger) CanWrite() bool {
+	return !l.IsHousehold() || l.Role == models.HouseholdRoleOwner || l.Role == models.HouseholdRoleEditor
+}
+
+// ForMember narrows a household ledger to rows added by one member. It has no
+// effect on the personal ledger, where every row is the user's own.
+func (l Ledger) ForMember(memberID uint64) Ledger {
+	if l.IsHousehold() {
+		l.MemberID = &memberID
+	}
+	return l
+}
+
+// Scope restricts a query to the ledger's rows. alias qualifies the columns
+// when the query joins other tables.
+func (l Ledger) Scope(alias string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		query, args := l.condition(alias)
+		return db.Where(query, args...)
+	}
+}
+
+// CategoryScope restricts a category query to system categories plus the
+// ledger's own categories.
+func (l Ledger) CategoryScope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		query, args := l.condition("")
+		return db.Where("(is_system = ? OR ("+query+"))", append([]interface{}{true}, args...)...)
+	}
+}
+
+func (l Ledger) condition(alias string) (string, []interface{}) {
+	col := func(name string) string {
+		if alias == "" {
+			return name
+		}
+		return alias + "." + name
+	}
+	if l.IsHousehold() {
+		if l.MemberID != nil {
+			return col("household_id") + " = ? AND " + col("user_id") + " = ?", []interface{}{*l.HouseholdID, *l.MemberID}
+		}
+		return col("household_id") + " = ?", []interface{}{*l.HouseholdID}
+	}
+	return col("user_id") + " = ? AND " + col("household_id") + " IS NULL", []interface{}{l.UserID}
+}
+
+// dashboardCacheKey returns the cache key for the ledger's dashboard in a period
+func (l Ledger) dashboardCacheKey(period string) string {
+	if !l.IsHousehold() {
+		return fmt.Sprintf("dashboard:%d:%s", l.UserID, period)
+	}
+	if l.MemberID != nil {
+		return fmt.Sprintf("dashboard:household:%d:member:%d:%s", *l.HouseholdID, *l.MemberID, period)
+	}
+	return fmt.Sprintf("dashboard:household:%d:%s", *l.HouseholdID, period)
+}
+
+// ledgerMemberIDs returns the users who can see the ledger
+func ledgerMemberIDs(db *gorm.DB, l Ledger) []uint64 {
+	if !l.IsHousehold() {
+		return []uint64{l.UserID}
+	}
+	var ids []uint64
+	if err := db.Model(&models.HouseholdMember{}).Where("household_id = ?", *l.HouseholdID).Pluck("user_id", &ids).Error; err != nil {
+		return []uint64{l.UserID}
+	}
+	return ids
+}
+
+// invalidateDashboards clears the ledger's cached dashboards and tells every
+// member's connected clients to refresh
+func invalidateDashboards(db *gorm.DB, l Ledger, data map[string]interface{}) {
+	invalidateMemberDashboards(l, ledgerMemberIDs(db, l), data)
+}
+
+// invalidateMemberDashboards is invalidateDashboards for a member list that
+// was read earlier, e.g. before the memberships were deleted
+func invalidateMemberDashboards(l Ledger, memberIDs []uint64, data map[string]interface{}) {
+	ctx := context.Background()
+	if l.IsHousehold() {
+		database.DeleteHouseholdDashboardCache(ctx, *l.HouseholdID)
+	} else {
+		database.DeleteDashboardCache(ctx, l.UserID)
+	}
+	for _, id := range memberIDs {
+		PublishUserEvent(id, "dashboard_invalidated", data)
+	}
+}
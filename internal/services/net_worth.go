@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"tabimoney/internal/models"
+)
+
+// GetNetWorth adds up the ledger's cash from recorded income and expenses and
+// its portfolio at market value, less its outstanding debts. Cash moves with
+// every trade: buys spend it, sales and dividends bring it back.
+func (s *TransactionService) GetNetWorth(ledger Ledger) (*models.NetWorth, error) {
+	var cash float64
+	if err := s.db.Model(&models.Transaction{}).Scopes(ledger.Scope("")).
+		Select("COALESCE(SUM(CASE transaction_type WHEN 'income' THEN amount WHEN 'expense' THEN -amount ELSE 0 END), 0)").
+		Scan(&cash).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum transactions: %w", err)
+	}
+
+	var tradeCash float64
+	if err := s.db.Model(&models.InvestmentTrade{}).Scopes(ledger.Scope("")).
+		Select("COALESCE(SUM(CASE type WHEN 'buy' THEN -amount ELSE amount END), 0)").
+		Scan(&tradeCash).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum trades: %w", err)
+	}
+	cash += tradeCash
+
+	var debts float64
+	if err := s.db.Model(&models.Debt{}).Scopes(ledger.Scope("")).Where("paid_off_at IS NULL").
+		Select("COALESCE(SUM(balance), 0)").Scan(&debts).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum debts: %w", err)
+	}
+
+	investments, err := NewInvestmentService(s.config).portfolioValue(ledger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NetWorth{
+		Cash:        roundAmount(cash),
+		Investments: investments,
+		Debts:       roundAmount(debts),
+		Total:       roundAmount(cash + investments - debts),
+		AsOf:        time.Now(),
+	}, nil
+}
@@ -1,11 +1,14 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
 	"tabimoney/internal/database"
+	"tabimoney/internal/i18n"
 	"tabimoney/internal/models"
 
 	"gorm.io/gorm"
@@ -15,6 +18,25 @@ type NotificationService struct {
 	db *gorm.DB
 }
 
+// UserEvent is pushed to connected clients over the notification stream
+type UserEvent struct {
+	Type string      `json:"type"` // notification, dashboard_invalidated
+	Data interface{} `json:"data"`
+	At   time.Time   `json:"at"`
+}
+
+// PublishUserEvent publishes a real-time event for the user through Redis pub/sub (best-effort)
+func PublishUserEvent(userID uint64, eventType string, data interface{}) {
+	payload, err := json.Marshal(UserEvent{Type: eventType, Data: data, At: time.Now()})
+	if err != nil {
+		log.Printf("failed to marshal user event %s: %v", eventType, err)
+		return
+	}
+	if err := database.PublishUserEvent(context.Background(), userID, payload); err != nil {
+		log.Printf("failed to publish user event %s for user %d: %v", eventType, userID, err)
+	}
+}
+
 func NewNotificationService() *NotificationService {
 	return &NotificationService{db: database.GetDB()}
 }
@@ -37,25 +59,170 @@ func (s *NotificationService) Create(userID uint64, title, message, notifType, p
 		return nil, fmt.Errorf("failed to create notification: %w", err)
 	}
 	log.Printf("notification created: id=%d user=%d title=%s", n.ID, userID, title)
+	s.invalidateUnreadCount(userID)
+	PublishUserEvent(userID, "notification", n)
 	return n, nil
 }
 
-func (s *NotificationService) List(userID uint64, onlyUnread bool) ([]models.Notification, error) {
-	var items []models.Notification
-	q := s.db.Where("user_id = ?", userID).Order("created_at DESC")
-	if onlyUnread {
+// List returns a page of the user's notifications matching the query filters
+func (s *NotificationService) List(userID uint64, req *models.NotificationQueryRequest) ([]models.Notification, int64, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+
+	q := s.db.Model(&models.Notification{}).Where("user_id = ? AND is_archived = ?", userID, req.Archived)
+	if req.OnlyUnread {
 		q = q.Where("is_read = ?", false)
 	}
-	if err := q.Find(&items).Error; err != nil {
-		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	if req.NotificationType != "" {
+		q = q.Where("notification_type = ?", req.NotificationType)
+	}
+	if req.Priority != "" {
+		q = q.Where("priority = ?", req.Priority)
+	}
+	if req.StartDate != nil {
+		q = q.Where("created_at >= ?", *req.StartDate)
+	}
+	if req.EndDate != nil {
+		q = q.Where("created_at <= ?", *req.EndDate)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	items := make([]models.Notification, 0)
+	if err := q.Order("created_at DESC").Offset((req.Page - 1) * req.Limit).Limit(req.Limit).Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return items, total, nil
+}
+
+// UnreadCount returns the number of unread, non-archived notifications (cached in Redis)
+func (s *NotificationService) UnreadCount(userID uint64) (int64, error) {
+	ctx := context.Background()
+	if count, err := database.GetUnreadCountCache(ctx, userID); err == nil {
+		return count, nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ? AND is_archived = ?", userID, false, false).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
 	}
-	return items, nil
+	_ = database.SetUnreadCountCache(ctx, userID, count, 10*time.Minute)
+	return count, nil
 }
 
 func (s *NotificationService) MarkRead(userID, id uint64) error {
-	res := s.db.Model(&models.Notification{}).Where("user_id = ? AND id = ?", userID, id).Update("is_read", true)
+	res := s.db.Model(&models.Notification{}).Where("user_id = ? AND id = ?", userID, id).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
 	if res.Error != nil {
 		return fmt.Errorf("failed to mark read: %w", res.Error)
 	}
+	s.invalidateUnreadCount(userID)
 	return nil
 }
+
+// MarkAllRead marks every unread notification of the user as read
+func (s *NotificationService) MarkAllRead(userID uint64) (int64, error) {
+	res := s.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to mark all read: %w", res.Error)
+	}
+	s.invalidateUnreadCount(userID)
+	return res.RowsAffected, nil
+}
+
+// Delete removes the given notifications of the user
+func (s *NotificationService) Delete(userID uint64, ids []uint64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("no notification ids provided")
+	}
+	res := s.db.Where("user_id = ? AND id IN ?", userID, ids).Delete(&models.Notification{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to delete notifications: %w", res.Error)
+	}
+	s.invalidateUnreadCount(userID)
+	return res.RowsAffected, nil
+}
+
+// Archive moves the given notifications out of the inbox (archived=false restores them)
+func (s *NotificationService) Archive(userID uint64, ids []uint64, archived bool) (int64, error) {
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("no notification ids provided")
+	}
+	updates := map[string]interface{}{"is_archived": archived, "archived_at": nil}
+	if archived {
+		updates["archived_at"] = time.Now()
+	}
+	res := s.db.Model(&models.Notification{}).Where("user_id = ? AND id IN ?", userID, ids).Updates(updates)
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to archive notifications: %w", res.Error)
+	}
+	s.invalidateUnreadCount(userID)
+	return res.RowsAffected, nil
+}
+
+// PurgeReadOlderThan deletes read notifications created before the cutoff for all users
+func (s *NotificationService) PurgeReadOlderThan(cutoff time.Time) (int64, error) {
+	res := s.db.Where("is_read = ? AND created_at < ?", true, cutoff).Delete(&models.Notification{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to purge notifications: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+func (s *NotificationService) invalidateUnreadCount(userID uint64) {
+	if err := database.DeleteUnreadCountCache(context.Background(), userID); err != nil {
+		log.Printf("failed to invalidate unread count cache for user %d: %v", userID, err)
+	}
+}
+
+// localizerForUser returns a localizer for the user's profile language and currency
+func localizerForUser(user *models.User) *i18n.Localizer {
+	if user == nil || user.Profile == nil {
+		return i18n.For("", "")
+	}
+	return i18n.For(user.Profile.Language, user.Profile.Currency)
+}
+
+// loadUserLocalizer loads the user's profile and returns the matching localizer
+func loadUserLocalizer(db *gorm.DB, userID uint64) *i18n.Localizer {
+	var profile models.UserProfile
+	if err := db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return i18n.For("", "")
+	}
+	return i18n.For(profile.Language, profile.Currency)
+}
+
+// notificationVariant maps type and priority to the template variant used by email and Telegram
+func notificationVariant(notificationType, priority string) string {
+	switch notificationType {
+	case "warning":
+		if priority == "urgent" || priority == "high" {
+			return "warning_urgent"
+		}
+		return "warning"
+	case "error", "success", "reminder":
+		return notificationType
+	default:
+		return "info"
+	}
+}
+
+// notificationKindScope matches notifications created by a trigger of the
+// given kind. Rows written before the kind was stored in metadata are matched
+// by the Vietnamese title they were created with instead.
+func notificationKindScope(kind, legacyTitle string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(JSON_UNQUOTE(JSON_EXTRACT(metadata, '$.kind')) = ? OR (JSON_EXTRACT(metadata, '$.kind') IS NULL AND title LIKE ?))",
+			kind, "%"+legacyTitle+"%")
+	}
+}
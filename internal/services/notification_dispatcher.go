@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"tabimoney/internal/config"
@@ -25,9 +27,12 @@ type NotificationTrigger struct {
 	UserID           uint64
 	NotificationType string
 	Priority         string
-	Title            string
-	Message          string
-	Metadata         map[string]interface{}
+	// Kind selects the catalog entries ("<kind>.title", "<kind>.message") used to
+	// render Title and Message in the user's language; Metadata is the template data.
+	Kind     string
+	Title    string
+	Message  string
+	Metadata map[string]interface{}
 }
 
 func NewNotificationDispatcher(cfg *config.Config) *NotificationDispatcher {
@@ -52,9 +57,21 @@ func (d *NotificationDispatcher) DispatchNotification(trigger NotificationTrigge
 	preferences := d.getUserNotificationPreferences(&user)
 	if !d.shouldSendNotification(preferences, trigger.NotificationType, trigger.Priority) {
 		log.Printf("Notification skipped for user %d based on preferences", trigger.UserID)
+		countDelivery("in_app", "skipped")
 		return nil
 	}
 
+	// Render localized title and message from the catalog
+	if trigger.Kind != "" {
+		if trigger.Metadata == nil {
+			trigger.Metadata = map[string]interface{}{}
+		}
+		trigger.Metadata["kind"] = trigger.Kind
+		loc := localizerForUser(&user)
+		trigger.Title = loc.T(trigger.Kind+".title", trigger.Metadata)
+		trigger.Message = loc.T(trigger.Kind+".message", trigger.Metadata)
+	}
+
 	// Create notification record
 	metadataJSON := "{}"
 	if trigger.Metadata != nil {
@@ -74,6 +91,7 @@ func (d *NotificationDispatcher) DispatchNotification(trigger NotificationTrigge
 	if err != nil {
 		return fmt.Errorf("failed to create notification: %w", err)
 	}
+	countDelivery("in_app", "created")
 
 	// Send through different channels
 	go d.sendEmailNotification(&user, notification, trigger.Metadata)
@@ -164,7 +182,10 @@ func (d *NotificationDispatcher) sendEmailNotification(user *models.User, notifi
 
 	if err := d.emailSvc.SendNotificationEmail(user, notification, emailData); err != nil {
 		log.Printf("Failed to send email notification to user %d: %v", user.ID, err)
+		countDelivery("email", "failed")
+		return
 	}
+	countDelivery("email", "sent")
 }
 
 // sendTelegramNotification sends telegram notification
@@ -176,23 +197,48 @@ func (d *NotificationDispatcher) sendTelegramNotification(userID uint64, notific
 
 	if err := d.telegramSvc.SendNotificationMessage(userID, notification, metadata); err != nil {
 		log.Printf("Failed to send telegram notification to user %d: %v", userID, err)
+		countDelivery("telegram", "failed")
+		return
+	}
+	countDelivery("telegram", "sent")
+}
+
+// deliveryChannels are the channel:outcome counters reported in the admin stats
+var deliveryChannels = []string{
+	"in_app:created", "in_app:skipped",
+	"email:sent", "email:failed",
+	"telegram:sent", "telegram:failed",
+}
+
+// countDelivery bumps today's counter for a channel outcome
+func countDelivery(channel, outcome string) {
+	if err := database.IncrementDailyCounter(context.Background(), "notifications:"+channel+":"+outcome); err != nil {
+		log.Printf("Failed to count %s %s notification: %v", channel, outcome, err)
 	}
 }
 
 // Budget Notification Triggers
 
+// budgetAlertsSnoozed reports whether the user snoozed warnings for this budget
+func budgetAlertsSnoozed(budget *models.Budget) bool {
+	return budget.AlertsSnoozedUntil != nil && time.Now().Before(*budget.AlertsSnoozedUntil)
+}
+
 // TriggerBudgetThresholdAlert triggers budget threshold alert
 func (d *NotificationDispatcher) TriggerBudgetThresholdAlert(userID uint64, budget *models.Budget) error {
+	if budgetAlertsSnoozed(budget) {
+		return nil
+	}
+
 	trigger := NotificationTrigger{
 		UserID:           userID,
 		NotificationType: "warning",
 		Priority:         "high",
-		Title:            "Ngân sách đạt ngưỡng cảnh báo",
-		Message:          fmt.Sprintf("Ngân sách '%s' đã đạt %.1f%% ngưỡng cảnh báo (%.0f%%).", budget.Name, budget.UsagePercentage, budget.AlertThreshold),
+		Kind:             "budget_threshold",
 		Metadata: map[string]interface{}{
 			"budget_id":        budget.ID,
 			"budget_name":      budget.Name,
-			"amount":           budget.Amount,
+			"amount":           budget.Amount + budget.RolloverAmount,
 			"usage_percentage": budget.UsagePercentage,
 			"remaining_amount": budget.RemainingAmount,
 			"alert_threshold":  budget.AlertThreshold,
@@ -204,18 +250,21 @@ func (d *NotificationDispatcher) TriggerBudgetThresholdAlert(userID uint64, budg
 
 // TriggerBudgetExceededAlert triggers budget exceeded alert
 func (d *NotificationDispatcher) TriggerBudgetExceededAlert(userID uint64, budget *models.Budget) error {
+	if budgetAlertsSnoozed(budget) {
+		return nil
+	}
+
 	trigger := NotificationTrigger{
 		UserID:           userID,
 		NotificationType: "warning",
 		Priority:         "urgent",
-		Title:            "Ngân sách đã vượt quá",
-		Message:          fmt.Sprintf("Ngân sách '%s' đã vượt quá %.1f%%!", budget.Name, budget.UsagePercentage),
+		Kind:             "budget_exceeded",
 		Metadata: map[string]interface{}{
 			"budget_id":        budget.ID,
 			"budget_name":      budget.Name,
-			"amount":           budget.Amount,
+			"amount":           budget.Amount + budget.RolloverAmount,
 			"usage_percentage": budget.UsagePercentage,
-			"exceeded_amount":  budget.SpentAmount - budget.Amount,
+			"exceeded_amount":  budget.SpentAmount - budget.Amount - budget.RolloverAmount,
 		},
 	}
 
@@ -224,12 +273,15 @@ func (d *NotificationDispatcher) TriggerBudgetExceededAlert(userID uint64, budge
 
 // TriggerBudgetPacingAlert warns when spending pace is too fast
 func (d *NotificationDispatcher) TriggerBudgetPacingAlert(userID uint64, budget *models.Budget, allowedPacePct, actualPacePct float64, daysLeft int) error {
+	if budgetAlertsSnoozed(budget) {
+		return nil
+	}
+
 	trigger := NotificationTrigger{
 		UserID:           userID,
 		NotificationType: "warning",
 		Priority:         "medium",
-		Title:            "Tốc độ chi vượt pace ngân sách",
-		Message:          fmt.Sprintf("Ngân sách '%s' đang chi %.1f%% so với pace cho phép (%.1f%%). Còn %d ngày trong kỳ.", budget.Name, actualPacePct, allowedPacePct, daysLeft),
+		Kind:             "budget_pacing",
 		Metadata: map[string]interface{}{
 			"budget_id":        budget.ID,
 			"budget_name":      budget.Name,
@@ -247,12 +299,11 @@ func (d *NotificationDispatcher) TriggerBudgetAchievementAlert(userID uint64, bu
 		UserID:           userID,
 		NotificationType: "success",
 		Priority:         "medium",
-		Title:            "Hoàn thành tiết kiệm ngân sách",
-		Message:          fmt.Sprintf("Chúc mừng! Bạn đã hoàn thành tiết kiệm ngân sách '%s'.", budget.Name),
+		Kind:             "budget_achievement",
 		Metadata: map[string]interface{}{
 			"budget_id":   budget.ID,
 			"budget_name": budget.Name,
-			"amount":      budget.Amount,
+			"amount":      budget.Amount + budget.RolloverAmount,
 		},
 	}
 
@@ -267,8 +318,7 @@ func (d *NotificationDispatcher) TriggerGoalProgressAlert(userID uint64, goal *m
 		UserID:           userID,
 		NotificationType: "info",
 		Priority:         "medium",
-		Title:            fmt.Sprintf("Mục tiêu đạt %s", milestone),
-		Message:          fmt.Sprintf("Mục tiêu '%s' đã đạt %.1f%%!", goal.Title, goal.Progress),
+		Kind:             "goal_progress",
 		Metadata: map[string]interface{}{
 			"goal_id":   goal.ID,
 			"goal_name": goal.Title,
@@ -287,8 +337,7 @@ func (d *NotificationDispatcher) TriggerGoalDeadlineAlert(userID uint64, goal *m
 		UserID:           userID,
 		NotificationType: "warning",
 		Priority:         "high",
-		Title:            "Cảnh báo hạn chót mục tiêu",
-		Message:          fmt.Sprintf("Mục tiêu '%s' còn %d ngày nữa đến hạn!", goal.Title, daysLeft),
+		Kind:             "goal_deadline",
 		Metadata: map[string]interface{}{
 			"goal_id":   goal.ID,
 			"goal_name": goal.Title,
@@ -307,8 +356,7 @@ func (d *NotificationDispatcher) TriggerGoalAchievedAlert(userID uint64, goal *m
 		UserID:           userID,
 		NotificationType: "success",
 		Priority:         "high",
-		Title:            "Chúc mừng hoàn thành mục tiêu!",
-		Message:          fmt.Sprintf("Chúc mừng! Bạn đã hoàn thành mục tiêu '%s'!", goal.Title),
+		Kind:             "goal_achieved",
 		Metadata: map[string]interface{}{
 			"goal_id":   goal.ID,
 			"goal_name": goal.Title,
@@ -328,8 +376,7 @@ func (d *NotificationDispatcher) TriggerAnomalyAlert(userID uint64, anomaly *mod
 		UserID:           userID,
 		NotificationType: "warning",
 		Priority:         "high",
-		Title:            "Phát hiện giao dịch bất thường",
-		Message:          fmt.Sprintf("Giao dịch %.0f VND tại %s có vẻ bất thường (điểm số: %.2f).", anomaly.Amount, anomaly.CategoryName, anomaly.AnomalyScore),
+		Kind:             "anomaly",
 		Metadata: map[string]interface{}{
 			"transaction_id": anomaly.TransactionID,
 			"amount":         anomaly.Amount,
@@ -348,11 +395,11 @@ func (d *NotificationDispatcher) TriggerSpendingPredictionAlert(userID uint64, p
 		UserID:           userID,
 		NotificationType: "info",
 		Priority:         "medium",
-		Title:            "Dự đoán chi tiêu tháng tới",
-		Message:          fmt.Sprintf("Dự đoán chi tiêu tháng tới: %.0f VND (độ tin cậy: %.1f%%)", prediction.PredictedAmount, prediction.ConfidenceScore*100),
+		Kind:             "spending_prediction",
 		Metadata: map[string]interface{}{
 			"predicted_amount": prediction.PredictedAmount,
 			"confidence_score": prediction.ConfidenceScore,
+			"confidence_pct":   prediction.ConfidenceScore * 100,
 			"recommendations":  prediction.Recommendations,
 		},
 	}
@@ -368,8 +415,7 @@ func (d *NotificationDispatcher) TriggerLargeTransactionAlert(userID uint64, tra
 		UserID:           userID,
 		NotificationType: "warning",
 		Priority:         "medium",
-		Title:            "Giao dịch lớn được phát hiện",
-		Message:          fmt.Sprintf("Giao dịch %.0f VND tại %s vượt quá ngưỡng %.0f VND", transaction.Amount, transaction.Category.Name, threshold),
+		Kind:             "large_transaction",
 		Metadata: map[string]interface{}{
 			"transaction_id": transaction.ID,
 			"amount":         transaction.Amount,
@@ -382,16 +428,105 @@ func (d *NotificationDispatcher) TriggerLargeTransactionAlert(userID uint64, tra
 	return d.DispatchNotification(trigger)
 }
 
+// Split Notification Triggers
+
+// TriggerIOUReminder reminds a user that they owe creditor money
+func (d *NotificationDispatcher) TriggerIOUReminder(userID uint64, creditor *models.User, amount float64) error {
+	trigger := NotificationTrigger{
+		UserID:           userID,
+		NotificationType: "reminder",
+		Priority:         "medium",
+		Kind:             "iou_reminder",
+		Metadata: map[string]interface{}{
+			"creditor_id":   creditor.ID,
+			"creditor_name": userDisplayName(creditor),
+			"amount":        amount,
+		},
+	}
+
+	return d.DispatchNotification(trigger)
+}
+
+// Debt Notification Triggers
+
+// TriggerDebtDueReminder reminds a user of a debt payment coming due
+func (d *NotificationDispatcher) TriggerDebtDueReminder(userID uint64, debt *models.Debt, dueDate time.Time, daysLeft int) error {
+	priority := "medium"
+	if daysLeft <= 1 {
+		priority = "high"
+	}
+	trigger := NotificationTrigger{
+		UserID:           userID,
+		NotificationType: "reminder",
+		Priority:         priority,
+		Kind:             "debt_due",
+		Metadata: map[string]interface{}{
+			"debt_id":   debt.ID,
+			"debt_name": debt.Name,
+			"amount":    math.Min(debt.MinimumPayment, debt.Balance),
+			"balance":   debt.Balance,
+			"due_date":  dueDate,
+			"days_left": daysLeft,
+		},
+	}
+
+	return d.DispatchNotification(trigger)
+}
+
+// Bill Notification Triggers
+
+// TriggerBillDueReminder reminds a user of a bill or subscription coming due
+func (d *NotificationDispatcher) TriggerBillDueReminder(userID uint64, bill *models.Bill, daysLeft int) error {
+	priority := "medium"
+	if daysLeft <= 1 {
+		priority = "high"
+	}
+	amount := bill.Amount
+	if bill.LastChargedAmount != nil && *bill.LastChargedAmount > amount {
+		amount = *bill.LastChargedAmount
+	}
+	trigger := NotificationTrigger{
+		UserID:           userID,
+		NotificationType: "reminder",
+		Priority:         priority,
+		Kind:             "bill_due",
+		Metadata: map[string]interface{}{
+			"bill_id":   bill.ID,
+			"bill_name": bill.Name,
+			"amount":    amount,
+			"due_date":  bill.NextDueDate,
+			"days_left": daysLeft,
+		},
+	}
+
+	return d.DispatchNotification(trigger)
+}
+
 // Analytics Notification Triggers
 
+// TriggerNewDeviceLogin warns a user about a sign-in from a device they have not used before
+func (d *NotificationDispatcher) TriggerNewDeviceLogin(userID uint64, client models.ClientInfo) error {
+	trigger := NotificationTrigger{
+		UserID:           userID,
+		NotificationType: "warning",
+		Priority:         "high",
+		Kind:             "new_device_login",
+		Metadata: map[string]interface{}{
+			"user_agent": client.UserAgent,
+			"ip_address": client.IPAddress,
+		},
+	}
+
+	return d.DispatchNotification(trigger)
+}
+
 // TriggerMonthlyReportAlert triggers monthly report alert
 func (d *NotificationDispatcher) TriggerMonthlyReportAlert(userID uint64, analytics *models.DashboardAnalytics) error {
 	trigger := NotificationTrigger{
 		UserID:           userID,
 		NotificationType: "info",
 		Priority:         "low",
-		Title:            "Báo cáo tài chính hàng tháng",
-		Message:          fmt.Sprintf("Báo cáo tháng %s: Thu %.0f VND, Chi %.0f VND, Chênh lệch %.0f VND", analytics.Period, analytics.TotalIncome, analytics.TotalExpense, analytics.NetAmount),
+		Kind:             "monthly_report",
 		Metadata: map[string]interface{}{
 			"period":        analytics.Period,
 			"total_income":  analytics.TotalIncome,
@@ -431,8 +566,7 @@ func (d *NotificationDispatcher) TriggerFinancialHealthAlert(userID uint64, heal
 		UserID:           userID,
 		NotificationType: notificationType,
 		Priority:         priority,
-		Title:            fmt.Sprintf("Sức khỏe tài chính: %s", health.Level),
-		Message:          fmt.Sprintf("Sức khỏe tài chính tháng %s: %.1f/100 điểm. Tỷ lệ tiết kiệm: %.1f%%", period, health.Score, health.SavingsRate),
+		Kind:             "financial_health",
 		Metadata: map[string]interface{}{
 			"period":          period,
 			"health_score":    health.Score,
@@ -580,13 +714,13 @@ func (d *NotificationDispatcher) checkMonthlyReports() error {
 
 		err := d.db.Where("user_id = ? AND notification_type = ? AND created_at >= ?",
 			user.ID, "info", startOfMonth).
-			Where("title LIKE ?", "%Báo cáo tài chính hàng tháng%").
+			Scopes(notificationKindScope("monthly_report", "Báo cáo tài chính hàng tháng")).
 			First(&recentNotification).Error
 
 		if err == gorm.ErrRecordNotFound {
 			// Generate and send monthly report
 			ts := NewTransactionService(d.config)
-			analytics, err := ts.GetMonthlySummary(user.ID, now.Year(), int(now.Month()-1))
+			analytics, err := ts.GetMonthlySummary(PersonalLedger(user.ID), now.Year(), int(now.Month()-1))
 			if err == nil {
 				d.TriggerMonthlyReportAlert(user.ID, analytics)
 			}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"tabimoney/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval limits JWKS refetches triggered by unknown key IDs
+const jwksRefreshInterval = time.Minute
+
+var ErrOIDCProviderNotFound = errors.New("unknown login provider")
+
+var (
+	oidcRegistryOnce sync.Once
+	oidcRegistry     map[string]*OIDCProvider
+)
+
+// OIDCProvider is an OpenID Connect client for one configured provider. The
+// discovery document and signing keys are fetched lazily and cached.
+type OIDCProvider struct {
+	config     config.OIDCProviderConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// OIDCClaims are the identity claims used for sign-in and account linking
+type OIDCClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Picture       string
+}
+
+// getOIDCProvider returns a configured provider by name
+func getOIDCProvider(cfg *config.Config, name string) (*OIDCProvider, error) {
+	oidcRegistryOnce.Do(func() {
+		oidcRegistry = make(map[string]*OIDCProvider, len(cfg.OIDC.Providers))
+		for _, p := range cfg.OIDC.Providers {
+			oidcRegistry[p.Name] = &OIDCProvider{
+				config:     p,
+				httpClient: &http.Client{Timeout: 10 * time.Second},
+			}
+		}
+	})
+
+	provider, ok := oidcRegistry[strings.ToLower(name)]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+	return provider, nil
+}
+
+// AuthCodeURL builds the authorization request URL with a PKCE S256 challenge
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeVerifier, redirectURI string) (string, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return discovery.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange trades an authorization code for tokens
+func (p *OIDCProvider) Exchange(code, codeVerifier, redirectURI string) (*oidcTokenResponse, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	resp, err := p.httpClient.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tokens oidcTokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("provider did not return an ID token")
+	}
+	return &tokens, nil
+}
+
+// VerifyIDToken checks the ID token signature, issuer, audience, expiry and nonce
+func (p *OIDCProvider) VerifyIDToken(rawIDToken, nonce string) (*OIDCClaims, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid ID token claims")
+	}
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, errors.New("ID token nonce mismatch")
+	}
+
+	identity := oidcClaimsFromMap(claims)
+	if identity.Subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	return identity, nil
+}
+
+// UserInfo fetches claims from the userinfo endpoint, used when the ID token
+// does not carry the email address
+func (p *OIDCProvider) UserInfo(accessToken string) (*OIDCClaims, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	if discovery.UserinfoEndpoint == "" || accessToken == "" {
+		return nil, errors.New("userinfo endpoint not available")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, discovery.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var claims map[string]interface{}
+	if err := p.getJSON(req, &claims); err != nil {
+		return nil, fmt.Errorf("userinfo request failed: %w", err)
+	}
+	return oidcClaimsFromMap(claims), nil
+}
+
+func (p *OIDCProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.config.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery oidcDiscovery
+	if err := p.getJSON(req, &discovery); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed for %s: %w", p.config.Name, err)
+	}
+	if strings.TrimRight(discovery.Issuer, "/") != p.config.IssuerURL {
+		return nil, fmt.Errorf("OIDC discovery issuer %q does not match configured issuer %q", discovery.Issuer, p.config.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document for %s is incomplete", p.config.Name)
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// signingKey returns the provider key for a key ID, refetching the JWKS when
+// the ID is unknown (providers rotate keys)
+func (p *OIDCProvider) signingKey(kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval && p.keys != nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if p.discovery == nil {
+		return nil, errors.New("provider not discovered")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a cached key; tokens without a kid match a single-key set
+func (p *OIDCProvider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *OIDCProvider) getJSON(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func oidcClaimsFromMap(claims map[string]interface{}) *OIDCClaims {
+	identity := &OIDCClaims{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.GivenName, _ = claims["given_name"].(string)
+	identity.FamilyName, _ = claims["family_name"].(string)
+	identity.Picture, _ = claims["picture"].(string)
+
+	// Some providers send email_verified as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+	identity.Email = strings.ToLower(strings.TrimSpace(identity.Email))
+	return identity
+}
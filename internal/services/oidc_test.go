@@ -0,0 +1,337 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tabimoney/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID    = "tabimoney-test"
+	testRedirectURI = "https://app.example.com/auth/oidc/test/callback"
+	testKeyID       = "test-key"
+)
+
+// mockOIDCServer is a minimal provider serving discovery, JWKS and a token
+// endpoint that enforces the PKCE challenge of the authorization request
+type mockOIDCServer struct {
+	t      *testing.T
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	issuer string // defaults to the server URL
+
+	mu    sync.Mutex
+	codes map[string]mockAuthRequest
+}
+
+type mockAuthRequest struct {
+	challenge string
+	nonce     string
+	redirect  string
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	m := &mockOIDCServer{t: t, key: key, codes: map[string]mockAuthRequest{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/jwks", m.jwks)
+	mux.HandleFunc("/token", m.token)
+	m.srv = httptest.NewServer(mux)
+	m.issuer = m.srv.URL
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockOIDCServer) provider() *OIDCProvider {
+	return &OIDCProvider{
+		config: config.OIDCProviderConfig{
+			Name:      "test",
+			IssuerURL: m.srv.URL,
+			ClientID:  testClientID,
+			Scopes:    []string{"openid", "email"},
+		},
+		httpClient: m.srv.Client(),
+	}
+}
+
+func (m *mockOIDCServer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 m.issuer,
+		"authorization_endpoint": m.srv.URL + "/authorize",
+		"token_endpoint":         m.srv.URL + "/token",
+		"jwks_uri":               m.srv.URL + "/jwks",
+	})
+}
+
+func (m *mockOIDCServer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := m.key.PublicKey
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad form", http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	req, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !ok, r.PostForm.Get("grant_type") != "authorization_code":
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	case r.PostForm.Get("client_id") != testClientID, r.PostForm.Get("redirect_uri") != req.redirect:
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusBadRequest)
+		return
+	case base64.RawURLEncoding.EncodeToString(verifier[:]) != req.challenge:
+		http.Error(w, `{"error":"invalid_grant","error_description":"PKCE verification failed"}`, http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     m.sign(m.key, testKeyID, m.claims(req.nonce)),
+	})
+}
+
+// authorize plays the user approving the authorization request and returns
+// the code the provider would redirect back with
+func (m *mockOIDCServer) authorize(authURL string) string {
+	m.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatalf("parse auth URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		m.t.Fatalf("auth URL has no S256 challenge: %s", authURL)
+	}
+
+	code := randomTestString(m.t)
+	m.mu.Lock()
+	m.codes[code] = mockAuthRequest{
+		challenge: q.Get("code_challenge"),
+		nonce:     q.Get("nonce"),
+		redirect:  q.Get("redirect_uri"),
+	}
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockOIDCServer) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            m.issuer,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "Alice@Example.com",
+		"email_verified": true,
+	}
+}
+
+func (m *mockOIDCServer) sign(key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	m.t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		m.t.Fatalf("sign ID token: %v", err)
+	}
+	return signed
+}
+
+func randomTestString(t *testing.T) string {
+	t.Helper()
+	s, err := randomURLToken(16)
+	if err != nil {
+		t.Fatalf("random token: %v", err)
+	}
+	return s
+}
+
+func TestOIDCLoginFlow(t *testing.T) {
+	m := newMockOIDCServer(t)
+	provider := m.provider()
+
+	state, nonce, verifier := randomTestString(t), randomTestString(t), randomTestString(t)
+	authURL, err := provider.AuthCodeURL(state, nonce, verifier, testRedirectURI)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if !strings.HasPrefix(authURL, m.srv.URL+"/authorize?") {
+		t.Fatalf("auth URL %q does not use the discovered endpoint", authURL)
+	}
+	u, _ := url.Parse(authURL)
+	if got := u.Query().Get("state"); got != state {
+		t.Errorf("state = %q, want %q", got, state)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	if got := u.Query().Get("code_challenge"); got != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		t.Errorf("code_challenge = %q does not match the verifier", got)
+	}
+
+	tokens, err := provider.Exchange(m.authorize(authURL), verifier, testRedirectURI)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	claims, err := provider.VerifyIDToken(tokens.IDToken, nonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != "subject-1" || claims.Email != "alice@example.com" || !claims.EmailVerified {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestOIDCExchangeRequiresPKCEVerifier(t *testing.T) {
+	m := newMockOIDCServer(t)
+	provider := m.provider()
+
+	authURL, err := provider.AuthCodeURL("state", "nonce", "right-verifier", testRedirectURI)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code := m.authorize(authURL)
+	if _, err := provider.Exchange(code, "wrong-verifier", testRedirectURI); err == nil {
+		t.Fatal("Exchange succeeded with the wrong code verifier")
+	}
+	// The provider spends the code on the failed attempt
+	if _, err := provider.Exchange(code, "right-verifier", testRedirectURI); err == nil {
+		t.Fatal("Exchange succeeded with a spent code")
+	}
+}
+
+func TestOIDCVerifyIDToken(t *testing.T) {
+	m := newMockOIDCServer(t)
+	provider := m.provider()
+	if _, err := provider.getDiscovery(); err != nil {
+		t.Fatalf("discovery: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		nonce   string
+		wantErr bool
+	}{
+		{"valid", func() string { return m.sign(m.key, testKeyID, m.claims("n1")) }, "n1", false},
+		{"nonce mismatch", func() string { return m.sign(m.key, testKeyID, m.claims("n1")) }, "n2", true},
+		{"nonce missing", func() string {
+			c := m.claims("")
+			delete(c, "nonce")
+			return m.sign(m.key, testKeyID, c)
+		}, "", true},
+		{"wrong audience", func() string {
+			c := m.claims("n1")
+			c["aud"] = "someone-else"
+			return m.sign(m.key, testKeyID, c)
+		}, "n1", true},
+		{"wrong issuer", func() string {
+			c := m.claims("n1")
+			c["iss"] = "https://evil.example.com"
+			return m.sign(m.key, testKeyID, c)
+		}, "n1", true},
+		{"expired", func() string {
+			c := m.claims("n1")
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+			return m.sign(m.key, testKeyID, c)
+		}, "n1", true},
+		{"no expiry", func() string {
+			c := m.claims("n1")
+			delete(c, "exp")
+			return m.sign(m.key, testKeyID, c)
+		}, "n1", true},
+		{"unknown key", func() string { return m.sign(otherKey, "other-key", m.claims("n1")) }, "n1", true},
+		{"forged with known kid", func() string { return m.sign(otherKey, testKeyID, m.claims("n1")) }, "n1", true},
+		{"HMAC signed", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, m.claims("n1"))
+			token.Header["kid"] = testKeyID
+			signed, _ := token.SignedString([]byte("secret"))
+			return signed
+		}, "n1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(tt.token(), tt.nonce)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyIDToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	m := newMockOIDCServer(t)
+	m.issuer = "https://evil.example.com"
+	if _, err := m.provider().AuthCodeURL("state", "nonce", "verifier", testRedirectURI); err == nil {
+		t.Fatal("AuthCodeURL accepted a discovery document for another issuer")
+	}
+}
+
+func TestParseOIDCState(t *testing.T) {
+	valid := `{"provider":"test","nonce":"n","code_verifier":"v"}`
+	tests := []struct {
+		name     string
+		raw      string
+		provider string
+		wantErr  bool
+	}{
+		{"valid", valid, "test", false},
+		{"other provider", valid, "google", true},
+		{"no nonce", `{"provider":"test","code_verifier":"v"}`, "test", true},
+		{"no verifier", `{"provider":"test","nonce":"n"}`, "test", true},
+		{"garbage", "not json", "test", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := parseOIDCState(tt.raw, tt.provider)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOIDCState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && err != ErrOIDCStateInvalid {
+				t.Errorf("error = %v, want ErrOIDCStateInvalid", err)
+			}
+			if err == nil && (state.Nonce != "n" || state.CodeVerifier != "v") {
+				t.Errorf("state = %+v", state)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"time"
 
 	"tabimoney/internal/config"
@@ -13,6 +16,8 @@ import (
 	"gorm.io/gorm"
 )
 
+var ErrUnknownJob = errors.New("unknown job")
+
 type ScheduledNotificationService struct {
 	dispatcher *NotificationDispatcher
 	db         *gorm.DB
@@ -49,45 +54,267 @@ func (s *ScheduledNotificationService) StartScheduler(ctx context.Context) {
 	}
 }
 
+// scheduledTask is a background job the scheduler knows about. Jobs that are
+// not scheduled only run when triggered through the admin API.
+type scheduledTask struct {
+	name      string
+	scheduled bool
+	run       func() error
+}
+
+// tasks lists the scheduler's jobs in the order they run
+func (s *ScheduledNotificationService) tasks() []scheduledTask {
+	auth := NewAuthService(s.config)
+	return []scheduledTask{
+		// Start the next period of auto-renewing budgets before checking them
+		{"budget_renewal", true, NewBudgetService(s.config).RenewDueBudgets},
+		{"budget_alerts", true, s.checkBudgetAlerts},
+		{"budget_pacing_alerts", true, s.checkBudgetPacingAlerts},
+		// Make scheduled goal contributions before checking goals
+		{"goal_savings_plans", true, NewGoalService(s.config).RunDueSavingsPlans},
+		{"goal_alerts", true, s.checkGoalAlerts},
+		{"monthly_reports", true, s.checkMonthlyReports},
+		{"financial_health_alerts", true, s.checkFinancialHealthAlerts},
+		// Remind counterparties about idle IOU balances
+		{"iou_reminders", true, s.checkIOUReminders},
+		// Remind of debt payments coming due
+		{"debt_reminders", true, s.checkDebtReminders},
+		// Remind of bills and subscriptions coming due
+		{"bill_reminders", true, s.checkBillReminders},
+		// Refresh asset prices from the configured price file
+		{"investment_prices", true, NewInvestmentService(s.config).LoadPriceFile},
+		// Delete expired data exports and accounts past their deletion grace period
+		{"data_export_cleanup", true, auth.CleanupDataExports},
+		{"account_purge", true, auth.PurgeDueAccounts},
+		// Purge old read notifications
+		{"notification_purge", true, s.purgeOldNotifications},
+		// NOTE: AI batch jobs are not scheduled to avoid unnecessary
+		// load/notifications. Trigger them on demand instead.
+		{"anomaly_detection", false, s.RunAnomalyDetection},
+		{"spending_prediction", false, s.RunSpendingPrediction},
+	}
+}
+
 // runScheduledTasks runs all scheduled notification tasks
 func (s *ScheduledNotificationService) runScheduledTasks() {
 	log.Println("Running scheduled notification tasks...")
 
-	// Check budget alerts
-	if err := s.checkBudgetAlerts(); err != nil {
-		log.Printf("Failed to check budget alerts: %v", err)
+	for _, task := range s.tasks() {
+		if !task.scheduled {
+			continue
+		}
+		if err := s.runTask(task); err != nil {
+			log.Printf("Scheduled task %s failed: %v", task.name, err)
+		}
 	}
 
-	// Check budget pacing alerts
-	if err := s.checkBudgetPacingAlerts(); err != nil {
-		log.Printf("Failed to check budget pacing alerts: %v", err)
+	log.Println("Scheduled notification tasks completed")
+}
+
+// RunTask runs a single job by name, whether or not it is scheduled
+func (s *ScheduledNotificationService) RunTask(name string) error {
+	for _, task := range s.tasks() {
+		if task.name == name {
+			return s.runTask(task)
+		}
 	}
+	return ErrUnknownJob
+}
 
-	// Check goal alerts
-	if err := s.checkGoalAlerts(); err != nil {
-		log.Printf("Failed to check goal alerts: %v", err)
+// HasTask reports whether a job with this name exists
+func (s *ScheduledNotificationService) HasTask(name string) bool {
+	for _, task := range s.tasks() {
+		if task.name == name {
+			return true
+		}
 	}
+	return false
+}
 
-	// Check monthly reports
-	if err := s.checkMonthlyReports(); err != nil {
-		log.Printf("Failed to check monthly reports: %v", err)
+// TaskStatuses reports the last run of every job
+func (s *ScheduledNotificationService) TaskStatuses() []models.SchedulerTaskStatus {
+	ctx := context.Background()
+	tasks := s.tasks()
+	statuses := make([]models.SchedulerTaskStatus, 0, len(tasks))
+	for _, task := range tasks {
+		status := models.SchedulerTaskStatus{Name: task.name}
+		if raw, err := database.GetSchedulerStatus(ctx, task.name); err == nil {
+			_ = json.Unmarshal([]byte(raw), &status)
+		}
+		status.Name = task.name
+		status.Scheduled = task.scheduled
+		statuses = append(statuses, status)
 	}
+	return statuses
+}
 
-	// Check financial health alerts
-	if err := s.checkFinancialHealthAlerts(); err != nil {
-		log.Printf("Failed to check financial health alerts: %v", err)
+// runTask runs a job and records its outcome for the admin stats
+func (s *ScheduledNotificationService) runTask(task scheduledTask) error {
+	started := time.Now()
+	err := task.run()
+
+	status := models.SchedulerTaskStatus{
+		Name:       task.name,
+		Scheduled:  task.scheduled,
+		LastRunAt:  &started,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		status.Error = err.Error()
 	}
+	if data, mErr := json.Marshal(status); mErr == nil {
+		if sErr := database.SetSchedulerStatus(context.Background(), task.name, string(data)); sErr != nil {
+			log.Printf("Failed to record status of task %s: %v", task.name, sErr)
+		}
+	}
+	return err
+}
 
-	// NOTE: AI batch jobs disabled to avoid unnecessary load/notifications.
-	// If needed, trigger via API on-demand or with feature flags.
-	// if err := s.RunAnomalyDetection(); err != nil {
-	// 	log.Printf("Failed to run anomaly detection: %v", err)
-	// }
-	// if err := s.RunSpendingPrediction(); err != nil {
-	// 	log.Printf("Failed to run spending prediction: %v", err)
-	// }
+// purgeOldNotifications deletes read notifications past the retention window
+func (s *ScheduledNotificationService) purgeOldNotifications() error {
+	days := s.config.Notification.RetentionDays
+	if days <= 0 {
+		return nil
+	}
 
-	log.Println("Scheduled notification tasks completed")
+	cutoff := time.Now().AddDate(0, 0, -days)
+	deleted, err := NewNotificationService().PurgeReadOlderThan(cutoff)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Purged %d read notifications older than %d days", deleted, days)
+	}
+	return nil
+}
+
+// checkIOUReminders reminds registered users of debts that have had no
+// activity for the configured number of days, at most once per period
+func (s *ScheduledNotificationService) checkIOUReminders() error {
+	days := s.config.Notification.IOUReminderDays
+	if days <= 0 {
+		return nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	var balances []struct {
+		UserID       uint64
+		LinkedUserID uint64
+		Balance      float64
+	}
+	if err := s.db.Table("iou_entries e").
+		Select("e.user_id, c.linked_user_id, SUM(e.amount) AS balance").
+		Joins("JOIN counterparties c ON c.id = e.counterparty_id").
+		Where("c.linked_user_id IS NOT NULL").
+		Group("e.user_id, c.linked_user_id").
+		Having("SUM(e.amount) >= 0.01 AND MAX(e.created_at) < ?", cutoff).
+		Scan(&balances).Error; err != nil {
+		return err
+	}
+
+	for _, b := range balances {
+		if iouReminderSentSince(s.db, b.LinkedUserID, b.UserID, cutoff) {
+			continue
+		}
+		var creditor models.User
+		if err := s.db.First(&creditor, b.UserID).Error; err != nil {
+			continue
+		}
+		if err := s.dispatcher.TriggerIOUReminder(b.LinkedUserID, &creditor, roundAmount(b.Balance)); err != nil {
+			log.Printf("Failed to send IOU reminder to user %d: %v", b.LinkedUserID, err)
+		}
+	}
+
+	return nil
+}
+
+// checkDebtReminders reminds every member of a ledger of debt payments due
+// within the configured number of days, once per due date, unless the
+// minimum has already been paid since the previous due date
+func (s *ScheduledNotificationService) checkDebtReminders() error {
+	days := s.config.Notification.DebtReminderDays
+	if days <= 0 {
+		return nil
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var debts []models.Debt
+	if err := s.db.Where("paid_off_at IS NULL AND balance > 0").Find(&debts).Error; err != nil {
+		return err
+	}
+
+	for i := range debts {
+		debt := &debts[i]
+		due := nextDebtDueDate(debt, now)
+		daysLeft := int(due.Sub(today).Hours() / 24)
+		if daysLeft > days {
+			continue
+		}
+
+		previous := debtDueDate(due.Year(), due.Month()-1, debt.DueDay, due.Location())
+		var paid float64
+		if err := s.db.Model(&models.DebtPayment{}).Where("debt_id = ? AND paid_at > ?", debt.ID, previous).
+			Select("COALESCE(SUM(amount), 0)").Scan(&paid).Error; err != nil {
+			log.Printf("Failed to load payments of debt %d: %v", debt.ID, err)
+			continue
+		}
+		if paid >= math.Min(debt.MinimumPayment, debt.Balance) {
+			continue
+		}
+
+		for _, userID := range ledgerMemberIDs(s.db, rowLedger(debt.UserID, debt.HouseholdID)) {
+			var count int64
+			s.db.Model(&models.Notification{}).Where("user_id = ? AND notification_type = ? AND metadata LIKE ? AND metadata LIKE ?",
+				userID, "reminder", fmt.Sprintf("%%\"debt_id\":%d,%%", debt.ID), fmt.Sprintf("%%\"due_date\":\"%s%%", due.Format("2006-01-02"))).
+				Count(&count)
+			if count > 0 {
+				continue
+			}
+			if err := s.dispatcher.TriggerDebtDueReminder(userID, debt, due, daysLeft); err != nil {
+				log.Printf("Failed to send debt reminder to user %d: %v", userID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkBillReminders reminds every member of a ledger of active bills due
+// within each bill's reminder days, once per due date. Paid bills have
+// already moved on to their next due date.
+func (s *ScheduledNotificationService) checkBillReminders() error {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var bills []models.Bill
+	if err := s.db.Where("is_active = ? AND reminder_days > 0 AND next_due_date >= ?", true, today).Find(&bills).Error; err != nil {
+		return err
+	}
+
+	for i := range bills {
+		bill := &bills[i]
+		due := bill.NextDueDate
+		daysLeft := int(time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, now.Location()).Sub(today).Hours() / 24)
+		if daysLeft > bill.ReminderDays {
+			continue
+		}
+
+		for _, userID := range ledgerMemberIDs(s.db, rowLedger(bill.UserID, bill.HouseholdID)) {
+			var count int64
+			s.db.Model(&models.Notification{}).Where("user_id = ? AND notification_type = ? AND metadata LIKE ? AND metadata LIKE ?",
+				userID, "reminder", fmt.Sprintf("%%\"bill_id\":%d,%%", bill.ID), fmt.Sprintf("%%\"due_date\":\"%s%%", due.Format("2006-01-02"))).
+				Count(&count)
+			if count > 0 {
+				continue
+			}
+			if err := s.dispatcher.TriggerBillDueReminder(userID, bill, daysLeft); err != nil {
+				log.Printf("Failed to send bill reminder to user %d: %v", userID, err)
+			}
+		}
+	}
+
+	return nil
 }
 
 // checkBudgetAlerts checks for budget alerts that need to be sent
@@ -104,22 +331,25 @@ func (s *ScheduledNotificationService) checkBudgetAlerts() error {
 		bs := NewBudgetService(s.config)
 		bs.calculateBudgetMetrics(&budget)
 
-		// Check if budget needs alert
-		if budget.UsagePercentage >= budget.AlertThreshold {
+		// Check if budget needs alert; household budgets alert every member
+		if budget.UsagePercentage < budget.AlertThreshold {
+			continue
+		}
+		for _, userID := range ledgerMemberIDs(s.db, rowLedger(budget.UserID, budget.HouseholdID)) {
 			// Check if we already sent this alert recently
 			var recentNotification models.Notification
 			oneDayAgo := time.Now().Add(-24 * time.Hour)
 
 			err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
-				budget.UserID, "warning", oneDayAgo, fmt.Sprintf("%%\"budget_id\":%d%%", budget.ID)).
+				userID, "warning", oneDayAgo, fmt.Sprintf("%%\"budget_id\":%d%%", budget.ID)).
 				First(&recentNotification).Error
 
 			if err == gorm.ErrRecordNotFound {
 				// No recent alert, send one
 				if budget.UsagePercentage >= 100 {
-					s.dispatcher.TriggerBudgetExceededAlert(budget.UserID, &budget)
+					s.dispatcher.TriggerBudgetExceededAlert(userID, &budget)
 				} else {
-					s.dispatcher.TriggerBudgetThresholdAlert(budget.UserID, &budget)
+					s.dispatcher.TriggerBudgetThresholdAlert(userID, &budget)
 				}
 			}
 		}
@@ -154,16 +384,18 @@ func (s *ScheduledNotificationService) checkBudgetPacingAlerts() error {
 			continue
 		}
 
-		// dedupe: only one pacing alert per day per budget
-		var recent models.Notification
-		oneDayAgo := now.Add(-24 * time.Hour)
-		if err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
-			bb.UserID, "warning", oneDayAgo, fmt.Sprintf("%%\"budget_id\":%d%%", bb.ID)).First(&recent).Error; err == gorm.ErrRecordNotFound {
-			daysLeft := int(bb.EndDate.Sub(now).Hours() / 24)
-			if daysLeft < 0 {
-				daysLeft = 0
+		// dedupe: only one pacing alert per day per budget and member
+		for _, userID := range ledgerMemberIDs(s.db, rowLedger(bb.UserID, bb.HouseholdID)) {
+			var recent models.Notification
+			oneDayAgo := now.Add(-24 * time.Hour)
+			if err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
+				userID, "warning", oneDayAgo, fmt.Sprintf("%%\"budget_id\":%d%%", bb.ID)).First(&recent).Error; err == gorm.ErrRecordNotFound {
+				daysLeft := int(bb.EndDate.Sub(now).Hours() / 24)
+				if daysLeft < 0 {
+					daysLeft = 0
+				}
+				_ = s.dispatcher.TriggerBudgetPacingAlert(userID, &bb, allowedPace, actualPace, daysLeft)
 			}
-			_ = s.dispatcher.TriggerBudgetPacingAlert(bb.UserID, &bb, allowedPace, actualPace, daysLeft)
 		}
 	}
 	return nil
@@ -182,45 +414,53 @@ func (s *ScheduledNotificationService) checkGoalAlerts() error {
 			goal.Progress = (goal.CurrentAmount / goal.TargetAmount) * 100
 		}
 
-		// Check deadline warning (30 days before)
-		if goal.TargetDate != nil {
-			daysLeft := int(goal.TargetDate.Sub(time.Now()).Hours() / 24)
-			if daysLeft <= 30 && daysLeft > 0 {
-				// Check if we already sent this alert recently
-				var recentNotification models.Notification
-				oneWeekAgo := time.Now().Add(-7 * 24 * time.Hour)
+		// Household goals alert every member
+		for _, userID := range ledgerMemberIDs(s.db, rowLedger(goal.UserID, goal.HouseholdID)) {
+			s.checkGoalAlertsFor(userID, &goal)
+		}
+	}
 
-				err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
-					goal.UserID, "warning", oneWeekAgo, fmt.Sprintf("%%\"goal_id\":%d%%", goal.ID)).
-					First(&recentNotification).Error
+	return nil
+}
 
-				if err == gorm.ErrRecordNotFound {
-					s.dispatcher.TriggerGoalDeadlineAlert(goal.UserID, &goal, daysLeft)
-				}
+// checkGoalAlertsFor sends due deadline and milestone alerts for a goal to one user
+func (s *ScheduledNotificationService) checkGoalAlertsFor(userID uint64, goal *models.FinancialGoal) {
+	// Check deadline warning (30 days before)
+	if goal.TargetDate != nil {
+		daysLeft := int(goal.TargetDate.Sub(time.Now()).Hours() / 24)
+		if daysLeft <= 30 && daysLeft > 0 {
+			// Check if we already sent this alert recently
+			var recentNotification models.Notification
+			oneWeekAgo := time.Now().Add(-7 * 24 * time.Hour)
+
+			err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
+				userID, "warning", oneWeekAgo, fmt.Sprintf("%%\"goal_id\":%d%%", goal.ID)).
+				First(&recentNotification).Error
+
+			if err == gorm.ErrRecordNotFound {
+				s.dispatcher.TriggerGoalDeadlineAlert(userID, goal, daysLeft)
 			}
 		}
+	}
 
-		// Check progress milestones
-		milestones := []float64{25, 50, 75, 90}
-		for _, milestone := range milestones {
-			if goal.Progress >= milestone && goal.Progress < milestone+5 {
-				// Check if we already sent this milestone alert
-				var recentNotification models.Notification
-				oneWeekAgo := time.Now().Add(-7 * 24 * time.Hour)
+	// Check progress milestones
+	milestones := []float64{25, 50, 75, 90}
+	for _, milestone := range milestones {
+		if goal.Progress >= milestone && goal.Progress < milestone+5 {
+			// Check if we already sent this milestone alert
+			var recentNotification models.Notification
+			oneWeekAgo := time.Now().Add(-7 * 24 * time.Hour)
 
-				err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
-					goal.UserID, "info", oneWeekAgo, fmt.Sprintf("%%\"milestone\":\"%.0f%%\"%%", milestone)).
-					First(&recentNotification).Error
+			err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
+				userID, "info", oneWeekAgo, fmt.Sprintf("%%\"milestone\":\"%.0f%%\"%%", milestone)).
+				First(&recentNotification).Error
 
-				if err == gorm.ErrRecordNotFound {
-					s.dispatcher.TriggerGoalProgressAlert(goal.UserID, &goal, fmt.Sprintf("%.0f%%", milestone))
-				}
-				break
+			if err == gorm.ErrRecordNotFound {
+				s.dispatcher.TriggerGoalProgressAlert(userID, goal, fmt.Sprintf("%.0f%%", milestone))
 			}
+			break
 		}
 	}
-
-	return nil
 }
 
 // checkMonthlyReports checks for monthly reports that need to be sent
@@ -244,13 +484,13 @@ func (s *ScheduledNotificationService) checkMonthlyReports() error {
 
 		err := s.db.Where("user_id = ? AND notification_type = ? AND created_at >= ?",
 			user.ID, "info", startOfMonth).
-			Where("title LIKE ?", "%Báo cáo tài chính hàng tháng%").
+			Scopes(notificationKindScope("monthly_report", "Báo cáo tài chính hàng tháng")).
 			First(&recentNotification).Error
 
 		if err == gorm.ErrRecordNotFound {
 			// Generate and send monthly report
 			ts := NewTransactionService(s.config)
-			analytics, err := ts.GetMonthlySummary(user.ID, now.Year(), int(now.Month()-1))
+			analytics, err := ts.GetMonthlySummary(PersonalLedger(user.ID), now.Year(), int(now.Month()-1))
 			if err == nil {
 				s.dispatcher.TriggerMonthlyReportAlert(user.ID, analytics)
 			}
@@ -277,7 +517,7 @@ func (s *ScheduledNotificationService) checkFinancialHealthAlerts() error {
 	for _, user := range users {
 		// Generate monthly analytics
 		ts := NewTransactionService(s.config)
-		analytics, err := ts.GetMonthlySummary(user.ID, now.Year(), int(now.Month()-1))
+		analytics, err := ts.GetMonthlySummary(PersonalLedger(user.ID), now.Year(), int(now.Month()-1))
 		if err != nil {
 			continue
 		}
@@ -288,8 +528,9 @@ func (s *ScheduledNotificationService) checkFinancialHealthAlerts() error {
 			var recentNotification models.Notification
 			oneMonthAgo := time.Now().AddDate(0, -1, 0)
 
-			err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ? AND title LIKE ?",
-				user.ID, "warning", oneMonthAgo, "%Sức khỏe tài chính%").
+			err := s.db.Where("user_id = ? AND notification_type = ? AND created_at > ?",
+				user.ID, "warning", oneMonthAgo).
+				Scopes(notificationKindScope("financial_health", "Sức khỏe tài chính")).
 				First(&recentNotification).Error
 
 			if err == gorm.ErrRecordNotFound {
@@ -0,0 +1,737 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/database"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCounterpartyNotFound      = errors.New("counterparty not found")
+	ErrCounterpartyExists        = errors.New("a counterparty for this user already exists")
+	ErrCounterpartyHasBalance    = errors.New("counterparty still has an outstanding balance")
+	ErrCounterpartyNotLinked     = errors.New("counterparty has not linked their account")
+	ErrCounterpartyLinked        = errors.New("counterparty is already linked")
+	ErrCounterpartyNoEmail       = errors.New("counterparty has no email address")
+	ErrCounterpartyInvalidInvite = errors.New("invitation is invalid or has expired")
+	ErrSplitNotFound             = errors.New("split expense not found")
+	ErrSplitInvalid              = errors.New("split shares do not add up")
+	ErrNothingOwed               = errors.New("counterparty does not owe you anything")
+	ErrReminderTooSoon           = errors.New("a reminder was already sent recently")
+)
+
+// reminderCooldown is the minimum time between manual reminders to the same person
+const reminderCooldown = 24 * time.Hour
+
+// SplitService tracks expenses shared with other people and the IOU balances
+// they leave behind. Splits always live in the personal ledger.
+type SplitService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+func NewSplitService(cfg *config.Config) *SplitService {
+	return &SplitService{
+		db:     database.GetDB(),
+		config: cfg,
+	}
+}
+
+// ListCounterparties lists the user's counterparties
+func (s *SplitService) ListCounterparties(userID uint64) ([]models.Counterparty, error) {
+	var counterparties []models.Counterparty
+	if err := s.db.Where("user_id = ?", userID).Order("name ASC").Find(&counterparties).Error; err != nil {
+		return nil, fmt.Errorf("failed to list counterparties: %w", err)
+	}
+	return counterparties, nil
+}
+
+// CreateCounterparty adds a counterparty and, when an email is given, invites
+// them to link their account. Nothing about who is registered is revealed:
+// the counterparty stays unlinked until the invitation is accepted.
+func (s *SplitService) CreateCounterparty(userID uint64, req *models.CounterpartyCreateRequest) (*models.Counterparty, error) {
+	counterparty := &models.Counterparty{
+		UserID: userID,
+		Name:   strings.TrimSpace(req.Name),
+		Email:  strings.ToLower(strings.TrimSpace(req.Email)),
+	}
+	if err := s.db.Create(counterparty).Error; err != nil {
+		return nil, fmt.Errorf("failed to create counterparty: %w", err)
+	}
+
+	if counterparty.Email != "" {
+		if err := s.sendInvite(userID, counterparty); err != nil {
+			return nil, err
+		}
+	}
+	return counterparty, nil
+}
+
+// InviteCounterparty sends a new link invitation to an unlinked counterparty,
+// replacing any earlier one
+func (s *SplitService) InviteCounterparty(userID, counterpartyID uint64) (*models.Counterparty, error) {
+	counterparty, err := s.getCounterparty(s.db, userID, counterpartyID)
+	if err != nil {
+		return nil, err
+	}
+	if counterparty.LinkedUserID != nil {
+		return nil, ErrCounterpartyLinked
+	}
+	if counterparty.Email == "" {
+		return nil, ErrCounterpartyNoEmail
+	}
+	if err := s.sendInvite(userID, counterparty); err != nil {
+		return nil, err
+	}
+	return counterparty, nil
+}
+
+// AcceptCounterpartyInvite links the caller to the counterparty that invited
+// them and returns the counterparty representing the inviter in the caller's
+// books. The invitation must have been sent to the caller's email address.
+// Entries recorded before the link stay in the inviter's books only.
+func (s *SplitService) AcceptCounterpartyInvite(userID uint64, token string) (*models.Counterparty, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	var counterparty models.Counterparty
+	if err := s.db.Where("invite_token_hash = ? AND invite_expires_at > ? AND linked_user_id IS NULL", hashToken(token), time.Now()).
+		First(&counterparty).Error; err != nil {
+		return nil, ErrCounterpartyInvalidInvite
+	}
+	if !strings.EqualFold(counterparty.Email, user.Email) || counterparty.UserID == userID {
+		return nil, ErrCounterpartyInvalidInvite
+	}
+
+	var mirror *models.Counterparty
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Counterparty{}).Where("user_id = ? AND linked_user_id = ?", counterparty.UserID, userID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCounterpartyExists
+		}
+
+		result := tx.Model(&models.Counterparty{}).
+			Where("id = ? AND linked_user_id IS NULL", counterparty.ID).
+			Updates(map[string]interface{}{
+				"linked_user_id":    userID,
+				"invite_token_hash": "",
+				"invite_expires_at": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCounterpartyInvalidInvite
+		}
+
+		var err error
+		mirror, err = s.mirrorCounterparty(tx, userID, counterparty.UserID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrCounterpartyExists) || errors.Is(err, ErrCounterpartyInvalidInvite) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return mirror, nil
+}
+
+// sendInvite stores a new invitation token on the counterparty and emails
+// its link. The token is also returned on the counterparty so the user can
+// share it some other way.
+func (s *SplitService) sendInvite(userID uint64, counterparty *models.Counterparty) error {
+	token, tokenHash, err := newAccountToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.config.GetHouseholdInvitationExpiration())
+	if err := s.db.Model(counterparty).Updates(map[string]interface{}{
+		"invite_token_hash": tokenHash,
+		"invite_expires_at": expiresAt,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	counterparty.InviteExpiresAt = &expiresAt
+	counterparty.InviteToken = token
+
+	// A registered recipient only changes the language of the email
+	recipient := &models.User{Email: counterparty.Email, Username: counterparty.Email}
+	var invitee models.User
+	if s.db.Preload("Profile").Where("email = ?", counterparty.Email).First(&invitee).Error == nil {
+		recipient = &invitee
+	}
+	link := strings.TrimRight(s.config.Server.FrontendURL, "/") + "/splits/accept?token=" + token
+	go func() {
+		if err := NewEmailService().SendAccountEmail(recipient, "counterparty_invite", link, expiresAt); err != nil {
+			log.Printf("Failed to send counterparty invitation email for counterparty %d of user %d: %v", counterparty.ID, userID, err)
+		}
+	}()
+	return nil
+}
+
+// UpdateCounterparty renames a counterparty
+func (s *SplitService) UpdateCounterparty(userID, counterpartyID uint64, req *models.CounterpartyUpdateRequest) (*models.Counterparty, error) {
+	counterparty, err := s.getCounterparty(s.db, userID, counterpartyID)
+	if err != nil {
+		return nil, err
+	}
+	counterparty.Name = strings.TrimSpace(req.Name)
+	if err := s.db.Save(counterparty).Error; err != nil {
+		return nil, fmt.Errorf("failed to update counterparty: %w", err)
+	}
+	return counterparty, nil
+}
+
+// DeleteCounterparty removes a counterparty once the balance with them is settled
+func (s *SplitService) DeleteCounterparty(userID, counterpartyID uint64) error {
+	counterparty, err := s.getCounterparty(s.db, userID, counterpartyID)
+	if err != nil {
+		return err
+	}
+	balance, err := s.balanceWith(userID, counterparty.ID)
+	if err != nil {
+		return err
+	}
+	if !isZeroAmount(balance) {
+		return ErrCounterpartyHasBalance
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND counterparty_id = ?", userID, counterparty.ID).Delete(&models.IOUEntry{}).Error; err != nil {
+			return fmt.Errorf("failed to delete counterparty history: %w", err)
+		}
+		if err := tx.Delete(counterparty).Error; err != nil {
+			return fmt.Errorf("failed to delete counterparty: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListSplits lists the user's split expenses, newest first
+func (s *SplitService) ListSplits(userID uint64) ([]models.SplitExpense, error) {
+	var splits []models.SplitExpense
+	if err := s.db.Preload("Shares").Where("user_id = ?", userID).
+		Order("expense_date DESC, id DESC").Find(&splits).Error; err != nil {
+		return nil, fmt.Errorf("failed to list splits: %w", err)
+	}
+	return splits, nil
+}
+
+// GetSplit retrieves a split expense with its shares
+func (s *SplitService) GetSplit(userID, splitID uint64) (*models.SplitExpense, error) {
+	var split models.SplitExpense
+	if err := s.db.Preload("Shares").Where("id = ? AND user_id = ?", splitID, userID).First(&split).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSplitNotFound
+		}
+		return nil, fmt.Errorf("failed to get split: %w", err)
+	}
+	return &split, nil
+}
+
+// CreateSplit records a shared expense and the IOU entries it creates. When
+// the user paid, every counterparty owes the user their share. When a
+// counterparty paid, only the user's own share towards that counterparty is
+// recorded; what the other participants owe the payer is not the user's to track.
+func (s *SplitService) CreateSplit(userID uint64, req *models.SplitExpenseCreateRequest) (*models.SplitExpense, error) {
+	expenseDate, err := time.Parse("2006-01-02", req.ExpenseDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expense_date format, expected YYYY-MM-DD: %w", err)
+	}
+
+	counterparties := make(map[uint64]*models.Counterparty, len(req.Shares))
+	for _, share := range req.Shares {
+		if _, dup := counterparties[share.CounterpartyID]; dup {
+			return nil, fmt.Errorf("%w: counterparty %d appears twice", ErrSplitInvalid, share.CounterpartyID)
+		}
+		counterparty, err := s.getCounterparty(s.db, userID, share.CounterpartyID)
+		if err != nil {
+			return nil, err
+		}
+		counterparties[share.CounterpartyID] = counterparty
+	}
+
+	var payer *models.Counterparty
+	if req.PaidByCounterpartyID != nil {
+		if payer, err = s.getCounterparty(s.db, userID, *req.PaidByCounterpartyID); err != nil {
+			return nil, err
+		}
+	}
+
+	myShare, shares, err := computeShares(req)
+	if err != nil {
+		return nil, err
+	}
+	if payer != nil && isZeroAmount(myShare.Amount) {
+		return nil, fmt.Errorf("%w: you have no share in an expense someone else paid", ErrSplitInvalid)
+	}
+
+	split := &models.SplitExpense{
+		UserID:               userID,
+		Description:          strings.TrimSpace(req.Description),
+		TotalAmount:          roundAmount(req.TotalAmount),
+		SplitMethod:          req.SplitMethod,
+		PaidByCounterpartyID: req.PaidByCounterpartyID,
+		ExpenseDate:          expenseDate,
+	}
+
+	// The user's books show the full payment as an expense; settle-ups later
+	// record what comes back as income
+	var transactionID *uint64
+	if req.CategoryID != nil && payer == nil {
+		transaction, err := NewTransactionService(s.config).CreateTransaction(PersonalLedger(userID), &models.TransactionCreateRequest{
+			CategoryID:      *req.CategoryID,
+			Amount:          split.TotalAmount,
+			Description:     split.Description,
+			TransactionType: "expense",
+			TransactionDate: req.ExpenseDate,
+			Metadata:        map[string]interface{}{"source": "split"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		transactionID = &transaction.ID
+		split.TransactionID = transactionID
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(split).Error; err != nil {
+			return fmt.Errorf("failed to create split: %w", err)
+		}
+
+		rows := append([]models.SplitShare{myShare}, shares...)
+		for i := range rows {
+			rows[i].SplitExpenseID = split.ID
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to create split shares: %w", err)
+		}
+		split.Shares = rows
+
+		if payer != nil {
+			_, err := s.recordEntry(tx, userID, payer, -myShare.Amount, models.IOUEntrySplit, &split.ID, nil, split.Description)
+			return err
+		}
+		for _, share := range shares {
+			if isZeroAmount(share.Amount) {
+				continue
+			}
+			if _, err := s.recordEntry(tx, userID, counterparties[*share.CounterpartyID], share.Amount, models.IOUEntrySplit, &split.ID, nil, split.Description); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if transactionID != nil {
+			if delErr := NewTransactionService(s.config).DeleteTransaction(PersonalLedger(userID), *transactionID); delErr != nil {
+				log.Printf("Failed to roll back split transaction %d: %v", *transactionID, delErr)
+			}
+		}
+		return nil, err
+	}
+
+	return split, nil
+}
+
+// DeleteSplit removes a split expense, the IOU entries it created on both
+// sides and the expense transaction recorded for it
+func (s *SplitService) DeleteSplit(userID, splitID uint64) error {
+	split, err := s.GetSplit(userID, splitID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("split_expense_id = ?", split.ID).Delete(&models.IOUEntry{}).Error; err != nil {
+			return fmt.Errorf("failed to delete split entries: %w", err)
+		}
+		if err := tx.Where("split_expense_id = ?", split.ID).Delete(&models.SplitShare{}).Error; err != nil {
+			return fmt.Errorf("failed to delete split shares: %w", err)
+		}
+		if err := tx.Delete(split).Error; err != nil {
+			return fmt.Errorf("failed to delete split: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if split.TransactionID != nil {
+		if err := NewTransactionService(s.config).DeleteTransaction(PersonalLedger(userID), *split.TransactionID); err != nil {
+			log.Printf("Failed to delete transaction %d for split %d: %v", *split.TransactionID, split.ID, err)
+		}
+	}
+	return nil
+}
+
+// GetBalances returns the running balance with every counterparty
+func (s *SplitService) GetBalances(userID uint64) (*models.BalanceSummaryResponse, error) {
+	var rows []struct {
+		CounterpartyID uint64
+		Balance        float64
+		LastActivity   time.Time
+	}
+	if err := s.db.Model(&models.IOUEntry{}).
+		Select("counterparty_id, SUM(amount) AS balance, MAX(created_at) AS last_activity").
+		Where("user_id = ?", userID).Group("counterparty_id").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to calculate balances: %w", err)
+	}
+
+	counterparties, err := s.ListCounterparties(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.BalanceSummaryResponse{Balances: make([]models.CounterpartyBalanceResponse, 0, len(counterparties))}
+	for _, counterparty := range counterparties {
+		balance := models.CounterpartyBalanceResponse{Counterparty: counterparty}
+		for _, row := range rows {
+			if row.CounterpartyID == counterparty.ID {
+				lastActivity := row.LastActivity
+				balance.Balance = roundAmount(row.Balance)
+				balance.LastActivity = &lastActivity
+				break
+			}
+		}
+		if balance.Balance > 0 {
+			summary.OwedToYou += balance.Balance
+		} else {
+			summary.YouOwe -= balance.Balance
+		}
+		summary.Balances = append(summary.Balances, balance)
+	}
+	summary.OwedToYou = roundAmount(summary.OwedToYou)
+	summary.YouOwe = roundAmount(summary.YouOwe)
+	summary.Net = roundAmount(summary.OwedToYou - summary.YouOwe)
+
+	return summary, nil
+}
+
+// GetEntries lists the IOU history with one counterparty, newest first
+func (s *SplitService) GetEntries(userID, counterpartyID uint64) ([]models.IOUEntry, error) {
+	if _, err := s.getCounterparty(s.db, userID, counterpartyID); err != nil {
+		return nil, err
+	}
+	var entries []models.IOUEntry
+	if err := s.db.Where("user_id = ? AND counterparty_id = ?", userID, counterpartyID).
+		Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get entries: %w", err)
+	}
+	return entries, nil
+}
+
+// SettleUp records a payment between the user and a counterparty and, when
+// a category is given, the matching transaction in the personal ledger
+func (s *SplitService) SettleUp(userID uint64, req *models.SettleUpRequest) (*models.IOUEntry, error) {
+	counterparty, err := s.getCounterparty(s.db, userID, req.CounterpartyID)
+	if err != nil {
+		return nil, err
+	}
+
+	date := time.Now().Format("2006-01-02")
+	if req.Date != "" {
+		if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+			return nil, fmt.Errorf("invalid date format, expected YYYY-MM-DD: %w", err)
+		}
+		date = req.Date
+	}
+
+	amount := roundAmount(req.Amount)
+	note := strings.TrimSpace(req.Note)
+
+	// Money received reduces what the counterparty owes; money paid reduces
+	// what the user owes
+	delta, transactionType := -amount, "income"
+	if req.Direction == "paid" {
+		delta, transactionType = amount, "expense"
+	}
+	if req.AsTransfer {
+		transactionType = "transfer"
+	}
+
+	var transactionID *uint64
+	if req.CategoryID != nil {
+		description := note
+		if description == "" {
+			description = "Settle up: " + counterparty.Name
+		}
+		transaction, err := NewTransactionService(s.config).CreateTransaction(PersonalLedger(userID), &models.TransactionCreateRequest{
+			CategoryID:      *req.CategoryID,
+			Amount:          amount,
+			Description:     description,
+			TransactionType: transactionType,
+			TransactionDate: date,
+			Metadata:        map[string]interface{}{"source": "settle_up", "counterparty_id": counterparty.ID},
+		})
+		if err != nil {
+			return nil, err
+		}
+		transactionID = &transaction.ID
+	}
+
+	var entry *models.IOUEntry
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, err = s.recordEntry(tx, userID, counterparty, delta, models.IOUEntrySettlement, nil, transactionID, note)
+		return err
+	})
+	if err != nil {
+		if transactionID != nil {
+			if delErr := NewTransactionService(s.config).DeleteTransaction(PersonalLedger(userID), *transactionID); delErr != nil {
+				log.Printf("Failed to roll back settle-up transaction %d: %v", *transactionID, delErr)
+			}
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// RemindCounterparty notifies a linked counterparty that they owe the user
+func (s *SplitService) RemindCounterparty(userID, counterpartyID uint64) error {
+	counterparty, err := s.getCounterparty(s.db, userID, counterpartyID)
+	if err != nil {
+		return err
+	}
+	if counterparty.LinkedUserID == nil {
+		return ErrCounterpartyNotLinked
+	}
+	balance, err := s.balanceWith(userID, counterparty.ID)
+	if err != nil {
+		return err
+	}
+	if balance <= 0 || isZeroAmount(balance) {
+		return ErrNothingOwed
+	}
+
+	if iouReminderSentSince(s.db, *counterparty.LinkedUserID, userID, time.Now().Add(-reminderCooldown)) {
+		return ErrReminderTooSoon
+	}
+
+	var creditor models.User
+	if err := s.db.First(&creditor, userID).Error; err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	return NewNotificationDispatcher(s.config).TriggerIOUReminder(*counterparty.LinkedUserID, &creditor, roundAmount(balance))
+}
+
+// recordEntry writes an IOU entry and, for counterparties who accepted the
+// link invitation, the mirrored entry in the other user's books
+func (s *SplitService) recordEntry(tx *gorm.DB, userID uint64, counterparty *models.Counterparty, amount float64, entryType string, splitID, transactionID *uint64, note string) (*models.IOUEntry, error) {
+	entry := &models.IOUEntry{
+		UserID:         userID,
+		CounterpartyID: counterparty.ID,
+		Amount:         roundAmount(amount),
+		EntryType:      entryType,
+		SplitExpenseID: splitID,
+		TransactionID:  transactionID,
+		Note:           note,
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to record entry: %w", err)
+	}
+
+	if counterparty.LinkedUserID == nil {
+		return entry, nil
+	}
+	mirror, err := s.mirrorCounterparty(tx, *counterparty.LinkedUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	mirrored := &models.IOUEntry{
+		UserID:         *counterparty.LinkedUserID,
+		CounterpartyID: mirror.ID,
+		Amount:         -entry.Amount,
+		EntryType:      entryType,
+		SplitExpenseID: splitID,
+		Note:           note,
+	}
+	if err := tx.Create(mirrored).Error; err != nil {
+		return nil, fmt.Errorf("failed to record mirrored entry: %w", err)
+	}
+	return entry, nil
+}
+
+// mirrorCounterparty returns the counterparty representing userID in the
+// books of ownerID, creating it on first use. It is only called for users
+// who have linked with each other, so an unlinked counterparty the owner kept
+// for the same email is linked rather than duplicated.
+func (s *SplitService) mirrorCounterparty(tx *gorm.DB, ownerID, userID uint64) (*models.Counterparty, error) {
+	var counterparty models.Counterparty
+	err := tx.Where("user_id = ? AND linked_user_id = ?", ownerID, userID).First(&counterparty).Error
+	if err == nil {
+		return &counterparty, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find counterparty: %w", err)
+	}
+
+	var user models.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = tx.Where("user_id = ? AND email = ? AND linked_user_id IS NULL", ownerID, user.Email).
+		Order("id ASC").First(&counterparty).Error
+	if err == nil {
+		if err := tx.Model(&counterparty).Updates(map[string]interface{}{
+			"linked_user_id":    userID,
+			"invite_token_hash": "",
+			"invite_expires_at": nil,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to link counterparty: %w", err)
+		}
+		counterparty.LinkedUserID = &userID
+		return &counterparty, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to find counterparty: %w", err)
+	}
+
+	counterparty = models.Counterparty{
+		UserID:       ownerID,
+		LinkedUserID: &userID,
+		Name:         userDisplayName(&user),
+		Email:        user.Email,
+	}
+	if err := tx.Create(&counterparty).Error; err != nil {
+		return nil, fmt.Errorf("failed to create counterparty: %w", err)
+	}
+	return &counterparty, nil
+}
+
+func (s *SplitService) getCounterparty(db *gorm.DB, userID, counterpartyID uint64) (*models.Counterparty, error) {
+	var counterparty models.Counterparty
+	if err := db.Where("id = ? AND user_id = ?", counterpartyID, userID).First(&counterparty).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCounterpartyNotFound
+		}
+		return nil, fmt.Errorf("failed to get counterparty: %w", err)
+	}
+	return &counterparty, nil
+}
+
+func (s *SplitService) balanceWith(userID, counterpartyID uint64) (float64, error) {
+	var balance float64
+	if err := s.db.Model(&models.IOUEntry{}).Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ? AND counterparty_id = ?", userID, counterpartyID).Scan(&balance).Error; err != nil {
+		return 0, fmt.Errorf("failed to calculate balance: %w", err)
+	}
+	return balance, nil
+}
+
+// computeShares works out the user's own share and each counterparty's share
+// in whole cents. Rounding leftovers go to the first participant so the
+// shares always add up to the total.
+func computeShares(req *models.SplitExpenseCreateRequest) (models.SplitShare, []models.SplitShare, error) {
+	total := int64(math.Round(req.TotalAmount * 100))
+	cents := make([]int64, len(req.Shares)+1) // index 0 is the user
+	var percentages []float64
+
+	switch req.SplitMethod {
+	case models.SplitMethodEqual:
+		first, n := 1, int64(len(req.Shares))
+		if req.IncludeSelf {
+			first, n = 0, n+1
+		}
+		for i := first; i < len(cents); i++ {
+			cents[i] = total / n
+		}
+		cents[first] += total - (total/n)*n
+
+	case models.SplitMethodPercentage:
+		percentages = make([]float64, len(cents))
+		percentages[0] = req.MyPercentage
+		sum := req.MyPercentage
+		for i, share := range req.Shares {
+			percentages[i+1] = share.Percentage
+			sum += share.Percentage
+		}
+		if math.Abs(sum-100) > 0.01 {
+			return models.SplitShare{}, nil, fmt.Errorf("%w: percentages total %.2f, expected 100", ErrSplitInvalid, sum)
+		}
+		var allocated int64
+		for i, pct := range percentages {
+			cents[i] = int64(math.Round(float64(total) * pct / 100))
+			allocated += cents[i]
+		}
+		for i := range cents {
+			if cents[i] > 0 || i == len(cents)-1 {
+				cents[i] += total - allocated
+				break
+			}
+		}
+
+	case models.SplitMethodExact:
+		cents[0] = int64(math.Round(req.MyAmount * 100))
+		sum := cents[0]
+		for i, share := range req.Shares {
+			cents[i+1] = int64(math.Round(share.Amount * 100))
+			sum += cents[i+1]
+		}
+		if sum != total {
+			return models.SplitShare{}, nil, fmt.Errorf("%w: shares total %.2f, expected %.2f", ErrSplitInvalid, float64(sum)/100, float64(total)/100)
+		}
+	}
+
+	shareAt := func(i int) models.SplitShare {
+		share := models.SplitShare{Amount: float64(cents[i]) / 100}
+		if percentages != nil {
+			pct := percentages[i]
+			share.Percentage = &pct
+		}
+		return share
+	}
+
+	mine := shareAt(0)
+	shares := make([]models.SplitShare, len(req.Shares))
+	for i, share := range req.Shares {
+		counterpartyID := share.CounterpartyID
+		shares[i] = shareAt(i + 1)
+		shares[i].CounterpartyID = &counterpartyID
+	}
+	return mine, shares, nil
+}
+
+// iouReminderSentSince reports whether debtorID was reminded about a debt to
+// creditorID after since
+func iouReminderSentSince(db *gorm.DB, debtorID, creditorID uint64, since time.Time) bool {
+	var count int64
+	db.Model(&models.Notification{}).Where("user_id = ? AND notification_type = ? AND created_at > ? AND metadata LIKE ?",
+		debtorID, "reminder", since, fmt.Sprintf("%%\"creditor_id\":%d%%", creditorID)).Count(&count)
+	return count > 0
+}
+
+// userDisplayName returns the user's full name, or the username when no name is set
+func userDisplayName(user *models.User) string {
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+	return user.Username
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func isZeroAmount(amount float64) bool {
+	return math.Abs(amount) < 0.005
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/database"
+	"tabimoney/internal/i18n"
+	"tabimoney/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Callback actions attached to notification buttons. Telegram limits callback
+// data to 64 bytes, so actions are short codes followed by ":<id>[:<arg>]".
+const (
+	callbackSnoozeBudget       = "bs"
+	callbackBudgetDetails      = "bd"
+	callbackGoalContribute     = "gc"
+	callbackGoalDetails        = "gd"
+	callbackNotAnomaly         = "an"
+	callbackTransactionDetails = "td"
+	callbackUndoTransaction    = "tu"
+)
+
+const (
+	budgetSnoozeDuration  = 24 * time.Hour
+	goalQuickContribution = 500000.0
+)
+
+var errTelegramNotLinked = errors.New("telegram account not linked")
+
+// TelegramBotService handles updates received on the bot webhook
+type TelegramBotService struct {
+	db           *gorm.DB
+	config       *config.Config
+	telegram     *TelegramService
+	auth         *AuthService
+	transactions *TransactionService
+	budgets      *BudgetService
+	goals        *GoalService
+	households   *HouseholdService
+	ai           *AIService
+}
+
+func NewTelegramBotService(cfg *config.Config) *TelegramBotService {
+	return &TelegramBotService{
+		db:           database.GetDB(),
+		config:       cfg,
+		telegram:     NewTelegramService(),
+		auth:         NewAuthService(cfg),
+		transactions: NewTransactionService(cfg),
+		budgets:      NewBudgetService(cfg),
+		goals:        NewGoalService(cfg),
+		households:   NewHouseholdService(cfg),
+		ai:           NewAIService(cfg),
+	}
+}
+
+// HandleUpdate processes a single webhook update
+func (s *TelegramBotService) HandleUpdate(update *TelegramUpdate) error {
+	if update.CallbackQuery != nil {
+		return s.HandleCallbackQuery(update.CallbackQuery)
+	}
+	if update.Message != nil {
+		return s.handleMessage(update.Message)
+	}
+	return nil
+}
+
+// HandleCallbackQuery runs the action behind an inline button and edits the
+// original message with the result
+func (s *TelegramBotService) HandleCallbackQuery(q *TelegramCallbackQuery) error {
+	userID, err := s.webUserID(q.From.ID)
+	if err != nil {
+		loc := i18n.For(q.From.LanguageCode, "")
+		_ = s.telegram.AnswerCallbackQuery(q.ID, loc.T("telegram.callback.not_linked", nil))
+		if errors.Is(err, errTelegramNotLinked) {
+			return nil
+		}
+		return err
+	}
+
+	loc := loadUserLocalizer(s.db, userID)
+	result, err := s.runCallback(loc, userID, q.Data)
+	if err != nil {
+		log.Printf("Telegram callback %q failed for user %d: %v", q.Data, userID, err)
+		return s.telegram.AnswerCallbackQuery(q.ID, loc.T("telegram.callback.failed", nil))
+	}
+
+	if err := s.telegram.AnswerCallbackQuery(q.ID, ""); err != nil {
+		log.Printf("Failed to answer callback query: %v", err)
+	}
+	if q.Message == nil {
+		return nil
+	}
+	return s.telegram.EditMessageText(q.Message.Chat.ID, q.Message.MessageID, result)
+}
+
+// runCallback parses callback data and returns the text that replaces the
+// message. User-entered text is escaped for Markdown on the loaded records,
+// which are not saved afterwards.
+func (s *TelegramBotService) runCallback(loc *i18n.Localizer, userID uint64, data string) (string, error) {
+	parts := strings.Split(data, ":")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("invalid callback data")
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid callback id: %w", err)
+	}
+
+	switch parts[0] {
+	case callbackSnoozeBudget:
+		ledger := s.callbackLedger(userID, &models.Budget{}, id)
+		if !ledger.CanWrite() {
+			return "", ErrLedgerReadOnly
+		}
+		budget, err := s.budgets.SnoozeAlerts(ledger, id, budgetSnoozeDuration)
+		if err != nil {
+			return "", err
+		}
+		return loc.T("telegram.callback.budget_snoozed", map[string]interface{}{
+			"budget_name": escapeMarkdown(budget.Name),
+			"until":       *budget.AlertsSnoozedUntil,
+		}), nil
+
+	case callbackBudgetDetails:
+		budget, err := s.budgets.GetBudget(s.callbackLedger(userID, &models.Budget{}, id), id)
+		if err != nil {
+			return "", err
+		}
+		budget.Name = escapeMarkdown(budget.Name)
+		return loc.T("telegram.callback.budget_details", budget), nil
+
+	case callbackGoalContribute:
+		amount := goalQuickContribution
+		if len(parts) > 2 {
+			if v, err := strconv.ParseFloat(parts[2], 64); err == nil && v > 0 {
+				amount = v
+			}
+		}
+		ledger := s.callbackLedger(userID, &models.FinancialGoal{}, id)
+		if !ledger.CanWrite() {
+			return "", ErrLedgerReadOnly
+		}
+		goal, err := s.goals.AddContribution(ledger, id, &models.GoalContributionRequest{Amount: amount, Note: "telegram"})
+		if err != nil {
+			return "", err
+		}
+		goal.Title = escapeMarkdown(goal.Title)
+		return loc.T("telegram.callback.goal_contributed", map[string]interface{}{
+			"amount": amount,
+			"goal":   goal,
+		}), nil
+
+	case callbackGoalDetails:
+		goal, err := s.goals.GetGoal(s.callbackLedger(userID, &models.FinancialGoal{}, id), id)
+		if err != nil {
+			return "", err
+		}
+		goal.Title = escapeMarkdown(goal.Title)
+		return loc.T("telegram.callback.goal_details", goal), nil
+
+	case callbackNotAnomaly:
+		if err := s.ai.MarkNotAnomaly(userID, id); err != nil {
+			return "", err
+		}
+		return loc.T("telegram.callback.not_anomaly", nil), nil
+
+	case callbackTransactionDetails:
+		var tx models.Transaction
+		ledger := s.callbackLedger(userID, &models.Transaction{}, id)
+		if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", id).Preload("Category").First(&tx).Error; err != nil {
+			return "", fmt.Errorf("transaction not found: %w", err)
+		}
+		tx.Description = escapeMarkdown(tx.Description)
+		tx.Location = escapeMarkdown(tx.Location)
+		if tx.Category != nil {
+			tx.Category.Name = escapeMarkdown(tx.Category.Name)
+			tx.Category.NameEn = escapeMarkdown(tx.Category.NameEn)
+		}
+		return loc.T("telegram.callback.transaction_details", tx), nil
+
+	case callbackUndoTransaction:
+		if err := s.transactions.DeleteTransaction(s.callbackLedger(userID, &models.Transaction{}, id), id); err != nil {
+			return "", err
+		}
+		return loc.T("telegram.callback.transaction_undone", nil), nil
+	}
+
+	return "", fmt.Errorf("unknown callback action %q", parts[0])
+}
+
+// callbackLedger returns the ledger holding the budget, goal or transaction an
+// alert was about, so buttons on household alerts act on the household. Unknown rows
+// and non-members fall back to the personal ledger, where the lookup fails.
+func (s *TelegramBotService) callbackLedger(userID uint64, model interface{}, id uint64) Ledger {
+	var householdID *uint64
+	if err := s.db.Model(model).Select("household_id").Where("id = ?", id).Scan(&householdID).Error; err != nil || householdID == nil {
+		return PersonalLedger(userID)
+	}
+	ledger, err := s.households.ResolveLedger(userID, *householdID)
+	if err != nil {
+		return PersonalLedger(userID)
+	}
+	return ledger
+}
+
+// webUserID resolves the TabiMoney user linked to a Telegram user
+func (s *TelegramBotService) webUserID(telegramUserID int64) (uint64, error) {
+	var account models.TelegramAccount
+	if err := s.db.Where("telegram_user_id = ?", telegramUserID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errTelegramNotLinked
+		}
+		return 0, fmt.Errorf("failed to load telegram account: %w", err)
+	}
+	return account.WebUserID, nil
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"tabimoney/internal/i18n"
+	"tabimoney/internal/models"
+)
+
+// linkCodePattern matches codes produced by AuthService.GenerateTelegramLinkCode,
+// optionally with the LINK_ prefix used by older web clients
+var linkCodePattern = regexp.MustCompile(`^(?:LINK_)?[A-Z0-9]{8,16}$`)
+
+// amountPattern matches amounts such as 50000, 50.000, 50k, 1.5tr, 2m or +5tr
+var amountPattern = regexp.MustCompile(`(?i)^\+?\d+(?:[.,]\d+)*(?:k|nghìn|ngàn|tr|triệu|m)?$`)
+
+// handleMessage routes a text message to a bot command or the free-text expense parser
+func (s *TelegramBotService) handleMessage(msg *TelegramChatMessage) error {
+	if msg.From == nil || strings.TrimSpace(msg.Text) == "" {
+		return nil
+	}
+	text := strings.TrimSpace(msg.Text)
+	chatID := msg.Chat.ID
+
+	userID, err := s.webUserID(msg.From.ID)
+	linked := err == nil
+	if err != nil && !errors.Is(err, errTelegramNotLinked) {
+		return err
+	}
+
+	loc := i18n.For(msg.From.LanguageCode, "")
+	if linked {
+		loc = loadUserLocalizer(s.db, userID)
+	}
+
+	if strings.HasPrefix(text, "/") {
+		command, args := splitCommand(text)
+		switch command {
+		case "start", "link":
+			if args != "" {
+				return s.linkAccount(loc, chatID, msg.From.ID, args)
+			}
+			if linked {
+				return s.reply(chatID, loc.T("telegram.bot.help", nil))
+			}
+			return s.reply(chatID, loc.T("telegram.bot.link_instructions", nil))
+		case "help":
+			return s.reply(chatID, loc.T("telegram.bot.help", nil))
+		}
+
+		if !linked {
+			return s.reply(chatID, loc.T("telegram.bot.not_linked", nil))
+		}
+
+		switch command {
+		case "add":
+			return s.addTransaction(loc, chatID, userID, args)
+		case "balance":
+			return s.sendBalance(loc, chatID, userID)
+		case "budget", "budgets":
+			return s.sendBudgets(loc, chatID, userID)
+		case "goals", "goal":
+			return s.sendGoals(loc, chatID, userID)
+		case "report":
+			return s.sendReport(loc, chatID, userID, args)
+		default:
+			return s.reply(chatID, loc.T("telegram.bot.unknown_command", nil))
+		}
+	}
+
+	// Link codes are accepted as plain messages, as in the web instructions
+	if code := strings.ToUpper(text); linkCodePattern.MatchString(code) && (!linked || strings.IndexFunc(code, unicode.IsLetter) >= 0) {
+		return s.linkAccount(loc, chatID, msg.From.ID, code)
+	}
+
+	if !linked {
+		return s.reply(chatID, loc.T("telegram.bot.not_linked", nil))
+	}
+	return s.addTransaction(loc, chatID, userID, text)
+}
+
+// linkAccount connects the Telegram user to the web account that issued the code
+func (s *TelegramBotService) linkAccount(loc *i18n.Localizer, chatID, telegramUserID int64, code string) error {
+	code = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(code)), "LINK_")
+
+	webUserID, err := s.auth.ValidateTelegramLinkCode(code)
+	if err != nil {
+		return s.reply(chatID, loc.T("telegram.bot.link_invalid", nil))
+	}
+	if err := s.auth.LinkTelegramAccount(telegramUserID, webUserID); err != nil {
+		log.Printf("Failed to link telegram user %d: %v", telegramUserID, err)
+		return s.reply(chatID, loc.T("telegram.bot.error", nil))
+	}
+
+	log.Printf("Telegram user %d linked to user %d", telegramUserID, webUserID)
+	return s.reply(chatID, loadUserLocalizer(s.db, webUserID).T("telegram.bot.linked", nil))
+}
+
+// addTransaction records an expense (or income when the amount starts with "+")
+// from text such as "cà phê 45k", "45k cà phê #Ăn uống" or "+15tr lương"
+func (s *TelegramBotService) addTransaction(loc *i18n.Localizer, chatID int64, userID uint64, text string) error {
+	amount, income, description, categoryHint := parseTransactionText(text)
+	if amount <= 0 {
+		return s.reply(chatID, loc.T("telegram.bot.add_usage", nil))
+	}
+	if description == "" {
+		description = categoryHint
+	}
+
+	category, err := s.resolveCategory(userID, description, categoryHint, amount)
+	if err != nil {
+		log.Printf("Failed to resolve category for telegram transaction: %v", err)
+		return s.reply(chatID, loc.T("telegram.bot.no_category", nil))
+	}
+
+	txType := "expense"
+	if income {
+		txType = "income"
+	}
+	now := time.Now()
+	tx, err := s.transactions.CreateTransaction(PersonalLedger(userID), &models.TransactionCreateRequest{
+		CategoryID:      category.ID,
+		Amount:          amount,
+		Description:     description,
+		TransactionType: txType,
+		TransactionDate: now.Format("2006-01-02"),
+		TransactionTime: now.Format("15:04"),
+		Metadata:        map[string]interface{}{"source": "telegram"},
+	})
+	if err != nil {
+		log.Printf("Failed to create telegram transaction for user %d: %v", userID, err)
+		return s.reply(chatID, loc.T("telegram.bot.error", nil))
+	}
+
+	text = loc.T("telegram.bot.transaction_added", map[string]interface{}{
+		"income":      income,
+		"amount":      tx.Amount,
+		"category":    escapeMarkdown(categoryName(loc, category)),
+		"description": escapeMarkdown(tx.Description),
+		"date":        tx.TransactionDate,
+	})
+	keyboard := &TelegramKeyboard{InlineKeyboard: [][]TelegramInlineButton{{
+		{Text: loc.T("telegram.button.undo", nil), CallbackData: fmt.Sprintf("%s:%d", callbackUndoTransaction, tx.ID)},
+	}}}
+	return s.telegram.sendMessage(chatID, text, keyboard)
+}
+
+// resolveCategory picks the category named by an explicit #hint, otherwise the
+// best suggestion for the description
+func (s *TelegramBotService) resolveCategory(userID uint64, description, hint string, amount float64) (*models.Category, error) {
+	if hint != "" {
+		var category models.Category
+		err := s.db.Scopes(PersonalLedger(userID).CategoryScope()).Where("is_active = ?", true).
+			Where("LOWER(name) = LOWER(?) OR LOWER(name_en) = LOWER(?)", hint, hint).
+			Order("user_id DESC").First(&category).Error
+		if err == nil {
+			return &category, nil
+		}
+	}
+
+	suggestions, err := s.ai.SuggestCategory(&models.CategorySuggestionRequest{
+		UserID:      userID,
+		Description: description,
+		Amount:      amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(suggestions.Suggestions) == 0 {
+		return nil, fmt.Errorf("no category suggestion for %q", description)
+	}
+
+	var category models.Category
+	if err := s.db.Scopes(PersonalLedger(userID).CategoryScope()).Where("id = ?", suggestions.Suggestions[0].CategoryID).
+		First(&category).Error; err != nil {
+		return nil, fmt.Errorf("suggested category not found: %w", err)
+	}
+	return &category, nil
+}
+
+func (s *TelegramBotService) sendBalance(loc *i18n.Localizer, chatID int64, userID uint64) error {
+	now := time.Now()
+	summary, err := s.transactions.GetMonthlySummary(PersonalLedger(userID), now.Year(), int(now.Month()))
+	if err != nil {
+		return err
+	}
+
+	var totals struct {
+		Income  float64
+		Expense float64
+	}
+	if err := s.db.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(CASE WHEN transaction_type = 'income' THEN amount ELSE 0 END), 0) AS income, " +
+			"COALESCE(SUM(CASE WHEN transaction_type = 'expense' THEN amount ELSE 0 END), 0) AS expense").
+		Scopes(PersonalLedger(userID).Scope("")).
+		Scan(&totals).Error; err != nil {
+		return fmt.Errorf("failed to calculate balance: %w", err)
+	}
+
+	return s.reply(chatID, loc.T("telegram.bot.balance", map[string]interface{}{
+		"period":        summary.Period,
+		"total_income":  summary.TotalIncome,
+		"total_expense": summary.TotalExpense,
+		"net_amount":    summary.NetAmount,
+		"balance":       totals.Income - totals.Expense,
+	}))
+}
+
+func (s *TelegramBotService) sendBudgets(loc *i18n.Localizer, chatID int64, userID uint64) error {
+	budgets, err := s.budgets.GetBudgets(PersonalLedger(userID))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	rows := make([]map[string]interface{}, 0, len(budgets))
+	for _, b := range budgets {
+		if !b.IsActive || now.Before(b.StartDate) || now.After(b.EndDate) {
+			continue
+		}
+		icon := "✅"
+		if b.UsagePercentage >= 100 {
+			icon = "🚨"
+		} else if b.UsagePercentage >= b.AlertThreshold {
+			icon = "⚠️"
+		}
+		rows = append(rows, map[string]interface{}{
+			"icon":      icon,
+			"name":      escapeMarkdown(b.Name),
+			"spent":     b.SpentAmount,
+			"amount":    b.Amount,
+			"usage":     b.UsagePercentage,
+			"remaining": b.RemainingAmount,
+		})
+	}
+
+	if len(rows) == 0 {
+		return s.reply(chatID, loc.T("telegram.bot.budgets_empty", nil))
+	}
+	return s.reply(chatID, loc.T("telegram.bot.budgets", rows))
+}
+
+func (s *TelegramBotService) sendGoals(loc *i18n.Localizer, chatID int64, userID uint64) error {
+	goals, err := s.goals.GetGoals(PersonalLedger(userID))
+	if err != nil {
+		return err
+	}
+
+	rows := make([]map[string]interface{}, 0, len(goals))
+	for _, g := range goals {
+		if g.IsAchieved {
+			continue
+		}
+		rows = append(rows, map[string]interface{}{
+			"title":       escapeMarkdown(g.Title),
+			"progress":    g.Progress,
+			"current":     g.CurrentAmount,
+			"target":      g.TargetAmount,
+			"target_date": g.TargetDate,
+		})
+	}
+
+	if len(rows) == 0 {
+		return s.reply(chatID, loc.T("telegram.bot.goals_empty", nil))
+	}
+	return s.reply(chatID, loc.T("telegram.bot.goals", rows))
+}
+
+// sendReport sends the monthly report for the current month or the month given as YYYY-MM
+func (s *TelegramBotService) sendReport(loc *i18n.Localizer, chatID int64, userID uint64, args string) error {
+	month := time.Now()
+	if args != "" {
+		parsed, err := time.Parse("2006-01", strings.TrimSpace(args))
+		if err != nil {
+			return s.reply(chatID, loc.T("telegram.bot.report_usage", nil))
+		}
+		month = parsed
+	}
+
+	report, err := s.transactions.GetMonthlySummary(PersonalLedger(userID), month.Year(), int(month.Month()))
+	if err != nil {
+		return err
+	}
+	return s.reply(chatID, loc.T("telegram.monthly_report", monthlyReportData(report)))
+}
+
+func (s *TelegramBotService) reply(chatID int64, text string) error {
+	return s.telegram.sendMessage(chatID, text, nil)
+}
+
+// splitCommand splits "/add@TabiMoneyBot 50k coffee" into "add" and "50k coffee"
+func splitCommand(text string) (string, string) {
+	command, args, _ := strings.Cut(strings.TrimPrefix(text, "/"), " ")
+	command, _, _ = strings.Cut(command, "@")
+	return strings.ToLower(command), strings.TrimSpace(args)
+}
+
+// parseTransactionText extracts the amount, a trailing #category hint and the
+// remaining words as the description. Amounts with a unit or sign ("45k", "+5tr")
+// win over bare numbers, and the largest bare number wins over quantities
+// ("2 ly cà phê 90000").
+func parseTransactionText(text string) (amount float64, income bool, description, categoryHint string) {
+	words := strings.Fields(text)
+	for i, w := range words {
+		if strings.HasPrefix(w, "#") && len(w) > 1 {
+			// Category names may contain spaces: "#Ăn uống" takes the remaining words
+			categoryHint = strings.TrimPrefix(strings.Join(words[i:], " "), "#")
+			words = words[:i]
+			break
+		}
+	}
+
+	amountIdx, explicit := -1, false
+	for i, w := range words {
+		if !amountPattern.MatchString(w) {
+			continue
+		}
+		v, ok := parseAmount(w)
+		if !ok {
+			continue
+		}
+		hasUnit := strings.HasPrefix(w, "+") || strings.IndexFunc(w, unicode.IsLetter) >= 0
+		if amountIdx == -1 || (hasUnit && !explicit) || (hasUnit == explicit && v > amount) {
+			amountIdx, explicit, amount = i, hasUnit, v
+			income = strings.HasPrefix(w, "+")
+		}
+	}
+
+	rest := make([]string, 0, len(words))
+	for i, w := range words {
+		if i != amountIdx {
+			rest = append(rest, w)
+		}
+	}
+	return amount, income, strings.Join(rest, " "), categoryHint
+}
+
+// parseAmount converts shorthand amounts to a number. Dots and commas followed by
+// exactly three digits are thousands separators; otherwise they are decimals
+// ("1.5tr" = 1,500,000 while "50.000" = 50,000).
+func parseAmount(token string) (float64, bool) {
+	token = strings.ToLower(strings.TrimPrefix(token, "+"))
+
+	multiplier := 1.0
+	for _, suffix := range []struct {
+		text  string
+		value float64
+	}{{"nghìn", 1e3}, {"ngàn", 1e3}, {"triệu", 1e6}, {"tr", 1e6}, {"k", 1e3}, {"m", 1e6}} {
+		if strings.HasSuffix(token, suffix.text) {
+			token = strings.TrimSuffix(token, suffix.text)
+			multiplier = suffix.value
+			break
+		}
+	}
+
+	groups := strings.FieldsFunc(token, func(r rune) bool { return r == '.' || r == ',' })
+	if len(groups) == 0 {
+		return 0, false
+	}
+	number := groups[0]
+	for i, g := range groups[1:] {
+		if len(g) == 3 && (multiplier == 1 || i < len(groups)-2) {
+			number += g
+		} else {
+			number += "." + g
+		}
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v * multiplier, true
+}
+
+// categoryName returns the category name in the localizer's language
+func categoryName(loc *i18n.Localizer, c *models.Category) string {
+	if loc.Lang == "en" && c.NameEn != "" {
+		return c.NameEn
+	}
+	return c.Name
+}
+
+// escapeMarkdown escapes user-provided text for Telegram's legacy Markdown mode
+func escapeMarkdown(text string) string {
+	return strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[").Replace(text)
+}
@@ -0,0 +1,89 @@
+package services
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		token  string
+		want   float64
+		wantOK bool
+	}{
+		{"50000", 50000, true},
+		{"50.000", 50000, true},
+		{"50,000", 50000, true},
+		{"1.500.000", 1500000, true},
+		{"12.5", 12.5, true},
+		{"12,50", 12.5, true},
+		{"45k", 45000, true},
+		{"45K", 45000, true},
+		{"1.5tr", 1500000, true},
+		{"1,5tr", 1500000, true},
+		{"1.500k", 1500, true},
+		{"2m", 2000000, true},
+		{"3triệu", 3000000, true},
+		{"200nghìn", 200000, true},
+		{"200ngàn", 200000, true},
+		{"+5tr", 5000000, true},
+		{"1.234.5k", 1234500, true},
+		{"0", 0, false},
+		{"k", 0, false},
+		{"abc", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			got, ok := parseAmount(tt.token)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseAmount(%q) = %v, %v, want %v, %v", tt.token, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseTransactionText(t *testing.T) {
+	tests := []struct {
+		text            string
+		wantAmount      float64
+		wantIncome      bool
+		wantDescription string
+		wantCategory    string
+	}{
+		{"45k cà phê", 45000, false, "cà phê", ""},
+		{"cà phê 45k", 45000, false, "cà phê", ""},
+		{"ăn trưa 50.000", 50000, false, "ăn trưa", ""},
+		{"+5tr lương tháng 3", 5000000, true, "lương tháng 3", ""},
+		{"2 ly cà phê 90000", 90000, false, "2 ly cà phê", ""},
+		{"2 ly cà phê 90k", 90000, false, "2 ly cà phê", ""},
+		{"mua 3 vé 300 200k", 200000, false, "mua 3 vé 300", ""},
+		{"grab 35k #Di chuyển", 35000, false, "grab", "Di chuyển"},
+		{"50k #Ăn uống", 50000, false, "", "Ăn uống"},
+		{"phở #", 0, false, "phở #", ""},
+		{"cà phê sữa", 0, false, "cà phê sữa", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			amount, income, description, category := parseTransactionText(tt.text)
+			if amount != tt.wantAmount || income != tt.wantIncome || description != tt.wantDescription || category != tt.wantCategory {
+				t.Errorf("parseTransactionText(%q) = %v, %v, %q, %q, want %v, %v, %q, %q",
+					tt.text, amount, income, description, category,
+					tt.wantAmount, tt.wantIncome, tt.wantDescription, tt.wantCategory)
+			}
+		})
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		text, wantCommand, wantArgs string
+	}{
+		{"/add 50k coffee", "add", "50k coffee"},
+		{"/Add@TabiMoneyBot 50k coffee", "add", "50k coffee"},
+		{"/balance", "balance", ""},
+		{"/report  2024-03 ", "report", "2024-03"},
+	}
+	for _, tt := range tests {
+		command, args := splitCommand(tt.text)
+		if command != tt.wantCommand || args != tt.wantArgs {
+			t.Errorf("splitCommand(%q) = %q, %q, want %q, %q", tt.text, command, args, tt.wantCommand, tt.wantArgs)
+		}
+	}
+}
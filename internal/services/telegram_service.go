@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"tabimoney/internal/database"
+	"tabimoney/internal/i18n"
 	"tabimoney/internal/models"
 
 	"gorm.io/gorm"
@@ -37,6 +38,40 @@ type TelegramInlineButton struct {
 	URL          string `json:"url,omitempty"`
 }
 
+// TelegramUpdate is an incoming webhook update from the Bot API
+type TelegramUpdate struct {
+	UpdateID      int64                  `json:"update_id"`
+	Message       *TelegramChatMessage   `json:"message,omitempty"`
+	CallbackQuery *TelegramCallbackQuery `json:"callback_query,omitempty"`
+}
+
+type TelegramUser struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	Username     string `json:"username,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+}
+
+type TelegramChat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+type TelegramChatMessage struct {
+	MessageID int64         `json:"message_id"`
+	From      *TelegramUser `json:"from,omitempty"`
+	Chat      TelegramChat  `json:"chat"`
+	Date      int64         `json:"date"`
+	Text      string        `json:"text,omitempty"`
+}
+
+type TelegramCallbackQuery struct {
+	ID      string               `json:"id"`
+	From    TelegramUser         `json:"from"`
+	Message *TelegramChatMessage `json:"message,omitempty"`
+	Data    string               `json:"data,omitempty"`
+}
+
 type TelegramResponse struct {
 	OK          bool   `json:"ok"`
 	Description string `json:"description,omitempty"`
@@ -74,11 +109,13 @@ func (s *TelegramService) SendNotificationMessage(userID uint64, notification *m
 		return nil
 	}
 
-	// Format message based on notification type
-	message := s.formatNotificationMessage(notification, data)
+	// Format message based on notification type in the user's language
+	loc := loadUserLocalizer(s.db, userID)
+	message := s.formatNotificationMessage(loc, notification, data)
+	keyboard := s.buildNotificationKeyboard(loc, data)
 
 	// Send message
-	return s.sendMessage(chatID, message, notification)
+	return s.sendMessage(chatID, message, keyboard)
 }
 
 // getUserTelegramChatID gets user's Telegram chat ID from database
@@ -94,26 +131,11 @@ func (s *TelegramService) getUserTelegramChatID(userID uint64) (int64, error) {
 }
 
 // formatNotificationMessage formats notification message for Telegram
-func (s *TelegramService) formatNotificationMessage(notification *models.Notification, data map[string]interface{}) string {
+func (s *TelegramService) formatNotificationMessage(loc *i18n.Localizer, notification *models.Notification, data map[string]interface{}) string {
 	var message string
 
-	// Add emoji based on notification type
-	switch notification.NotificationType {
-	case "warning":
-		if notification.Priority == "urgent" || notification.Priority == "high" {
-			message += "🚨 *CẢNH BÁO KHẨN CẤP*\n\n"
-		} else {
-			message += "⚠️ *CẢNH BÁO*\n\n"
-		}
-	case "error":
-		message += "❌ *LỖI*\n\n"
-	case "success":
-		message += "✅ *THÀNH CÔNG*\n\n"
-	case "reminder":
-		message += "🔔 *NHẮC NHỞ*\n\n"
-	default:
-		message += "📊 *THÔNG BÁO*\n\n"
-	}
+	// Add header based on notification type
+	message += loc.T("telegram.header."+notificationVariant(notification.NotificationType, notification.Priority), nil) + "\n\n"
 
 	// Add title
 	message += fmt.Sprintf("*%s*\n\n", notification.Title)
@@ -123,52 +145,90 @@ func (s *TelegramService) formatNotificationMessage(notification *models.Notific
 
 	// Add specific data based on notification type
 	if amount, ok := data["amount"].(float64); ok && amount > 0 {
-		message += fmt.Sprintf("💰 Số tiền: *%.0f VND*\n", amount)
+		message += loc.T("telegram.label.amount", amount) + "\n"
 	}
 
 	if categoryName, ok := data["category_name"].(string); ok && categoryName != "" {
-		message += fmt.Sprintf("📂 Danh mục: *%s*\n", categoryName)
+		message += loc.T("telegram.label.category", categoryName) + "\n"
 	}
 
 	if budgetName, ok := data["budget_name"].(string); ok && budgetName != "" {
-		message += fmt.Sprintf("📊 Ngân sách: *%s*\n", budgetName)
+		message += loc.T("telegram.label.budget", budgetName) + "\n"
 	}
 
 	if goalName, ok := data["goal_name"].(string); ok && goalName != "" {
-		message += fmt.Sprintf("🎯 Mục tiêu: *%s*\n", goalName)
+		message += loc.T("telegram.label.goal", goalName) + "\n"
 	}
 
 	if progress, ok := data["progress"].(float64); ok && progress > 0 {
-		message += fmt.Sprintf("📈 Tiến độ: *%.1f%%*\n", progress)
+		message += loc.T("telegram.label.progress", progress) + "\n"
 	}
 
 	if usagePercentage, ok := data["usage_percentage"].(float64); ok && usagePercentage > 0 {
-		message += fmt.Sprintf("📊 Sử dụng: *%.1f%%*\n", usagePercentage)
+		message += loc.T("telegram.label.usage", usagePercentage) + "\n"
 	}
 
 	// Add timestamp
-	message += fmt.Sprintf("\n🕐 %s", notification.CreatedAt.Format("02/01/2006 15:04"))
+	message += fmt.Sprintf("\n🕐 %s", loc.DateTime(notification.CreatedAt))
 
 	return message
 }
 
-// sendMessage sends message to Telegram
-func (s *TelegramService) sendMessage(chatID int64, text string, notification *models.Notification) error {
+// sendMessage sends message to Telegram, optionally with an inline keyboard
+func (s *TelegramService) sendMessage(chatID int64, text string, keyboard *TelegramKeyboard) error {
 	// Prepare message payload
 	payload := map[string]interface{}{
 		"chat_id":    chatID,
 		"text":       text,
 		"parse_mode": "Markdown",
 	}
+	if keyboard != nil && len(keyboard.InlineKeyboard) > 0 {
+		payload["reply_markup"] = keyboard
+	}
+
+	if err := s.callAPI("sendMessage", payload); err != nil {
+		return err
+	}
+
+	log.Printf("Telegram message sent successfully to chat %d", chatID)
+	return nil
+}
+
+// EditMessageText replaces the text of a previously sent message and removes its keyboard
+func (s *TelegramService) EditMessageText(chatID, messageID int64, text string) error {
+	return s.callAPI("editMessageText", map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       text,
+		"parse_mode": "Markdown",
+	})
+}
+
+// AnswerCallbackQuery acknowledges a button press so the client stops its loading indicator
+func (s *TelegramService) AnswerCallbackQuery(callbackQueryID, text string) error {
+	payload := map[string]interface{}{
+		"callback_query_id": callbackQueryID,
+	}
+	if text != "" {
+		payload["text"] = text
+	}
+	return s.callAPI("answerCallbackQuery", payload)
+}
+
+// callAPI posts a JSON payload to a Bot API method
+func (s *TelegramService) callAPI(method string, payload map[string]interface{}) error {
+	if s.botToken == "" {
+		return fmt.Errorf("telegram bot token not configured")
+	}
 
 	// Convert to JSON
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message payload: %w", err)
+		return fmt.Errorf("failed to marshal %s payload: %w", method, err)
 	}
 
 	// Send HTTP request
-	resp, err := http.Post(s.apiURL+"/sendMessage", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := http.Post(s.apiURL+"/"+method, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to send HTTP request: %w", err)
 	}
@@ -184,25 +244,102 @@ func (s *TelegramService) sendMessage(chatID int64, text string, notification *m
 		return fmt.Errorf("telegram API error: %s", telegramResp.Description)
 	}
 
-	log.Printf("Telegram message sent successfully to chat %d", chatID)
 	return nil
 }
 
-// SendBudgetAlert sends budget alert to Telegram
-func (s *TelegramService) SendBudgetAlert(userID uint64, budget *models.Budget, alertType string) error {
-	notification := &models.Notification{
-		Title:            s.getBudgetAlertTitle(alertType),
-		Message:          s.getBudgetAlertMessage(budget, alertType),
-		NotificationType: "warning",
-		Priority:         s.getBudgetAlertPriority(alertType),
-		CreatedAt:        time.Now(),
+// buildNotificationKeyboard returns the action buttons for a notification kind, or nil
+// when the notification has nothing to act on. Callback data is "<action>:<id>[:<arg>]"
+// and is handled by TelegramBotService.HandleCallbackQuery.
+func (s *TelegramService) buildNotificationKeyboard(loc *i18n.Localizer, data map[string]interface{}) *TelegramKeyboard {
+	kind, _ := data["kind"].(string)
+	var row []TelegramInlineButton
+
+	switch kind {
+	case "budget_threshold", "budget_exceeded", "budget_pacing":
+		budgetID := metadataID(data["budget_id"])
+		if budgetID == 0 {
+			return nil
+		}
+		row = append(row,
+			TelegramInlineButton{Text: loc.T("telegram.button.snooze_budget", nil), CallbackData: fmt.Sprintf("%s:%d", callbackSnoozeBudget, budgetID)},
+			TelegramInlineButton{Text: loc.T("telegram.button.details", nil), CallbackData: fmt.Sprintf("%s:%d", callbackBudgetDetails, budgetID)},
+		)
+	case "goal_progress", "goal_deadline", "goal_behind", "goal_update":
+		goalID := metadataID(data["goal_id"])
+		if goalID == 0 {
+			return nil
+		}
+		amount := goalQuickContribution
+		row = append(row,
+			TelegramInlineButton{Text: loc.T("telegram.button.add_to_goal", amount), CallbackData: fmt.Sprintf("%s:%d:%.0f", callbackGoalContribute, goalID, amount)},
+			TelegramInlineButton{Text: loc.T("telegram.button.details", nil), CallbackData: fmt.Sprintf("%s:%d", callbackGoalDetails, goalID)},
+		)
+	case "goal_achieved":
+		if goalID := metadataID(data["goal_id"]); goalID != 0 {
+			row = append(row, TelegramInlineButton{Text: loc.T("telegram.button.details", nil), CallbackData: fmt.Sprintf("%s:%d", callbackGoalDetails, goalID)})
+		}
+	case "anomaly":
+		txID := metadataID(data["transaction_id"])
+		if txID == 0 {
+			return nil
+		}
+		row = append(row,
+			TelegramInlineButton{Text: loc.T("telegram.button.not_anomaly", nil), CallbackData: fmt.Sprintf("%s:%d", callbackNotAnomaly, txID)},
+			TelegramInlineButton{Text: loc.T("telegram.button.details", nil), CallbackData: fmt.Sprintf("%s:%d", callbackTransactionDetails, txID)},
+		)
+	case "large_transaction":
+		if txID := metadataID(data["transaction_id"]); txID != 0 {
+			row = append(row, TelegramInlineButton{Text: loc.T("telegram.button.details", nil), CallbackData: fmt.Sprintf("%s:%d", callbackTransactionDetails, txID)})
+		}
 	}
 
+	if len(row) == 0 {
+		return nil
+	}
+	return &TelegramKeyboard{InlineKeyboard: [][]TelegramInlineButton{row}}
+}
+
+// metadataID reads an ID from notification metadata, which may hold native or JSON-decoded numbers
+func metadataID(v interface{}) uint64 {
+	switch id := v.(type) {
+	case uint64:
+		return id
+	case int:
+		if id > 0 {
+			return uint64(id)
+		}
+	case int64:
+		if id > 0 {
+			return uint64(id)
+		}
+	case float64:
+		if id > 0 {
+			return uint64(id)
+		}
+	}
+	return 0
+}
+
+// SendBudgetAlert sends budget alert to Telegram
+func (s *TelegramService) SendBudgetAlert(userID uint64, budget *models.Budget, alertType string) error {
 	data := map[string]interface{}{
+		"budget_id":        budget.ID,
 		"budget_name":      budget.Name,
-		"amount":           budget.Amount,
+		"amount":           budget.Amount + budget.RolloverAmount,
 		"usage_percentage": budget.UsagePercentage,
 		"remaining_amount": budget.RemainingAmount,
+		"alert_threshold":  budget.AlertThreshold,
+	}
+
+	kind := s.getBudgetAlertKind(alertType)
+	data["kind"] = kind
+	loc := loadUserLocalizer(s.db, userID)
+	notification := &models.Notification{
+		Title:            loc.T(kind+".title", data),
+		Message:          loc.T(kind+".message", data),
+		NotificationType: "warning",
+		Priority:         s.getBudgetAlertPriority(alertType),
+		CreatedAt:        time.Now(),
 	}
 
 	return s.SendNotificationMessage(userID, notification, data)
@@ -210,66 +347,63 @@ func (s *TelegramService) SendBudgetAlert(userID uint64, budget *models.Budget,
 
 // SendGoalAlert sends goal alert to Telegram
 func (s *TelegramService) SendGoalAlert(userID uint64, goal *models.FinancialGoal, alertType string) error {
-	notification := &models.Notification{
-		Title:            s.getGoalAlertTitle(alertType),
-		Message:          s.getGoalAlertMessage(goal, alertType),
-		NotificationType: s.getGoalAlertType(alertType),
-		Priority:         s.getGoalAlertPriority(alertType),
-		CreatedAt:        time.Now(),
-	}
-
 	data := map[string]interface{}{
+		"goal_id":   goal.ID,
 		"goal_name": goal.Title,
 		"amount":    goal.TargetAmount,
 		"progress":  goal.Progress,
 	}
+	if goal.TargetDate != nil {
+		data["days_left"] = int(time.Until(*goal.TargetDate).Hours() / 24)
+	}
+
+	kind := s.getGoalAlertKind(alertType)
+	data["kind"] = kind
+	loc := loadUserLocalizer(s.db, userID)
+	notification := &models.Notification{
+		Title:            loc.T(kind+".title", data),
+		Message:          loc.T(kind+".message", data),
+		NotificationType: s.getGoalAlertType(alertType),
+		Priority:         s.getGoalAlertPriority(alertType),
+		CreatedAt:        time.Now(),
+	}
 
 	return s.SendNotificationMessage(userID, notification, data)
 }
 
 // SendAnomalyAlert sends anomaly detection alert to Telegram
 func (s *TelegramService) SendAnomalyAlert(userID uint64, anomaly *models.Anomaly) error {
+	data := map[string]interface{}{
+		"kind":           "anomaly",
+		"transaction_id": anomaly.TransactionID,
+		"amount":         anomaly.Amount,
+		"category_name":  anomaly.CategoryName,
+		"anomaly_score":  anomaly.AnomalyScore,
+	}
+
+	loc := loadUserLocalizer(s.db, userID)
 	notification := &models.Notification{
-		Title:            "Phát hiện giao dịch bất thường",
-		Message:          fmt.Sprintf("Giao dịch %.0f VND tại %s có vẻ bất thường", anomaly.Amount, anomaly.CategoryName),
+		Title:            loc.T("anomaly.title", data),
+		Message:          loc.T("anomaly.message", data),
 		NotificationType: "warning",
 		Priority:         "high",
 		CreatedAt:        time.Now(),
 	}
 
-	data := map[string]interface{}{
-		"amount":        anomaly.Amount,
-		"category_name": anomaly.CategoryName,
-		"anomaly_score": anomaly.AnomalyScore,
-	}
-
 	return s.SendNotificationMessage(userID, notification, data)
 }
 
 // Helper methods for budget alerts
-func (s *TelegramService) getBudgetAlertTitle(alertType string) string {
+func (s *TelegramService) getBudgetAlertKind(alertType string) string {
 	switch alertType {
 	case "threshold_reached":
-		return "Ngân sách đạt ngưỡng cảnh báo"
+		return "budget_threshold"
 	case "budget_exceeded":
-		return "Ngân sách đã vượt quá"
+		return "budget_exceeded"
 	case "budget_achievement":
-		return "Hoàn thành tiết kiệm ngân sách"
+		return "budget_achievement"
 	default:
-		return "Cập nhật ngân sách"
-	}
-}
-
-func (s *TelegramService) getBudgetAlertMessage(budget *models.Budget, alertType string) string {
-	switch alertType {
-	case "threshold_reached":
-		return fmt.Sprintf("Ngân sách '%s' đã đạt %.1f%% ngưỡng cảnh báo", budget.Name, budget.UsagePercentage)
-	case "budget_exceeded":
-		return fmt.Sprintf("Ngân sách '%s' đã vượt quá %.1f%%", budget.Name, budget.UsagePercentage)
-	case "budget_achievement":
-		return fmt.Sprintf("Chúc mừng! Bạn đã hoàn thành tiết kiệm ngân sách '%s'", budget.Name)
-	default:
-		return fmt.Sprintf("Cập nhật về ngân sách '%s'", budget.Name)
+		return "budget_update"
 	}
 }
 
@@ -285,33 +419,18 @@ func (s *TelegramService) getBudgetAlertPriority(alertType string) string {
 }
 
 // Helper methods for goal alerts
-func (s *TelegramService) getGoalAlertTitle(alertType string) string {
-	switch alertType {
-	case "progress_update":
-		return "Cập nhật tiến độ mục tiêu"
-	case "deadline_warning":
-		return "Cảnh báo hạn chót mục tiêu"
-	case "goal_achieved":
-		return "Chúc mừng hoàn thành mục tiêu!"
-	case "behind_schedule":
-		return "Mục tiêu chậm tiến độ"
-	default:
-		return "Cập nhật mục tiêu"
-	}
-}
-
-func (s *TelegramService) getGoalAlertMessage(goal *models.FinancialGoal, alertType string) string {
+func (s *TelegramService) getGoalAlertKind(alertType string) string {
 	switch alertType {
 	case "progress_update":
-		return fmt.Sprintf("Mục tiêu '%s' đã đạt %.1f%%", goal.Title, goal.Progress)
+		return "goal_progress"
 	case "deadline_warning":
-		return fmt.Sprintf("Mục tiêu '%s' sắp đến hạn", goal.Title)
+		return "goal_deadline"
 	case "goal_achieved":
-		return fmt.Sprintf("Chúc mừng! Bạn đã hoàn thành mục tiêu '%s'", goal.Title)
+		return "goal_achieved"
 	case "behind_schedule":
-		return fmt.Sprintf("Mục tiêu '%s' đang chậm tiến độ", goal.Title)
+		return "goal_behind"
 	default:
-		return fmt.Sprintf("Cập nhật về mục tiêu '%s'", goal.Title)
+		return "goal_update"
 	}
 }
 
@@ -337,57 +456,77 @@ func (s *TelegramService) getGoalAlertPriority(alertType string) string {
 	}
 }
 
+// reportCategory is a ranked category row for the monthly report template
+type reportCategory struct {
+	Rank         int
+	CategoryName string
+	Amount       float64
+	Percentage   float64
+}
+
 // SendMonthlyReport sends monthly financial report to Telegram
 func (s *TelegramService) SendMonthlyReport(userID uint64, report *models.DashboardAnalytics) error {
-	message := fmt.Sprintf(`📊 *BÁO CÁO THÁNG %s*
+	data := monthlyReportData(report)
 
-💰 Tổng thu nhập: *%.0f VND*
-💸 Tổng chi tiêu: *%.0f VND*
-📈 Chênh lệch: *%.0f VND*
-
-🏥 Sức khỏe tài chính: *%s* (%.1f/100)
+	loc := loadUserLocalizer(s.db, userID)
+	notification := &models.Notification{
+		Title:            loc.T("monthly_report.title", data),
+		Message:          loc.T("telegram.monthly_report", data),
+		NotificationType: "info",
+		Priority:         "low",
+		CreatedAt:        time.Now(),
+	}
 
-📂 Top danh mục chi tiêu:
-`, report.Period, report.TotalIncome, report.TotalExpense, report.NetAmount,
-		report.FinancialHealth.Level, report.FinancialHealth.Score)
+	return s.SendNotificationMessage(userID, notification, nil)
+}
 
+// monthlyReportData builds the template data for telegram.monthly_report
+func monthlyReportData(report *models.DashboardAnalytics) map[string]interface{} {
 	// Add top categories
+	categories := make([]reportCategory, 0, 5)
 	for i, category := range report.CategoryBreakdown {
 		if i >= 5 { // Limit to top 5
 			break
 		}
-		message += fmt.Sprintf("%d. %s: *%.0f VND* (%.1f%%)\n",
-			i+1, category.CategoryName, category.Amount, category.Percentage)
+		categories = append(categories, reportCategory{
+			Rank:         i + 1,
+			CategoryName: category.CategoryName,
+			Amount:       category.Amount,
+			Percentage:   category.Percentage,
+		})
+	}
+
+	return map[string]interface{}{
+		"period":        report.Period,
+		"total_income":  report.TotalIncome,
+		"total_expense": report.TotalExpense,
+		"net_amount":    report.NetAmount,
+		"health_level":  report.FinancialHealth.Level,
+		"health_score":  report.FinancialHealth.Score,
+		"categories":    categories,
+		"generated_at":  report.GeneratedAt,
 	}
-
-	message += fmt.Sprintf("\n🕐 %s", report.GeneratedAt.Format("02/01/2006 15:04"))
-
-	notification := &models.Notification{
-		Title:            "Báo cáo tài chính hàng tháng",
-		Message:          message,
-		NotificationType: "info",
-		Priority:         "low",
-		CreatedAt:        time.Now(),
-	}
-
-	return s.SendNotificationMessage(userID, notification, nil)
 }
 
 // SendLargeTransactionAlert sends alert for large transactions
 func (s *TelegramService) SendLargeTransactionAlert(userID uint64, transaction *models.Transaction, threshold float64) error {
+	data := map[string]interface{}{
+		"kind":           "large_transaction",
+		"transaction_id": transaction.ID,
+		"amount":         transaction.Amount,
+		"category_name":  transaction.Category.Name,
+		"description":    transaction.Description,
+		"threshold":      threshold,
+	}
+
+	loc := loadUserLocalizer(s.db, userID)
 	notification := &models.Notification{
-		Title:            "Giao dịch lớn được phát hiện",
-		Message:          fmt.Sprintf("Giao dịch %.0f VND tại %s vượt quá ngưỡng %.0f VND", transaction.Amount, transaction.Category.Name, threshold),
+		Title:            loc.T("large_transaction.title", data),
+		Message:          loc.T("large_transaction.message", data),
 		NotificationType: "warning",
 		Priority:         "medium",
 		CreatedAt:        time.Now(),
 	}
 
-	data := map[string]interface{}{
-		"amount":        transaction.Amount,
-		"category_name": transaction.Category.Name,
-		"description":   transaction.Description,
-	}
-
 	return s.SendNotificationMessage(userID, notification, data)
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"tabimoney/internal/config"
@@ -27,12 +28,13 @@ func NewTransactionService(cfg *config.Config) *TransactionService {
 	}
 }
 
-// CreateTransaction creates a new transaction
-func (s *TransactionService) CreateTransaction(userID uint64, req *models.TransactionCreateRequest) (*models.TransactionResponse, error) {
-	// Validate category exists and belongs to user or is system category
+// CreateTransaction creates a new transaction in the ledger, recording the acting user as its author
+func (s *TransactionService) CreateTransaction(ledger Ledger, req *models.TransactionCreateRequest) (*models.TransactionResponse, error) {
+	userID := ledger.UserID
+
+	// Validate category exists and belongs to the ledger or is system category
 	var category models.Category
-	if err := s.db.Where("id = ? AND (user_id = ? OR is_system = ?)",
-		req.CategoryID, userID, true).First(&category).Error; err != nil {
+	if err := s.db.Scopes(ledger.CategoryScope()).Where("id = ?", req.CategoryID).First(&category).Error; err != nil {
 		return nil, fmt.Errorf("category not found or not accessible: %w", err)
 	}
 
@@ -58,6 +60,7 @@ func (s *TransactionService) CreateTransaction(userID uint64, req *models.Transa
 	// Create transaction
 	transaction := &models.Transaction{
 		UserID:           userID,
+		HouseholdID:      ledger.HouseholdID,
 		CategoryID:       req.CategoryID,
 		Amount:           req.Amount,
 		Description:      req.Description,
@@ -86,7 +89,7 @@ func (s *TransactionService) CreateTransaction(userID uint64, req *models.Transa
 	bs := NewBudgetService(s.config)
 	if transaction.TransactionType == "expense" {
 		// Chỉ kiểm tra budgets cho category này hoặc general budgets
-		if err := bs.CheckBudgetNotifications(userID, &transaction.CategoryID); err != nil {
+		if err := bs.CheckBudgetNotifications(ledger, &transaction.CategoryID); err != nil {
 			log.Printf("Failed to check budget notifications: %v", err)
 		}
 	}
@@ -102,20 +105,31 @@ func (s *TransactionService) CreateTransaction(userID uint64, req *models.Transa
 		}
 	}
 
-	// Clear dashboard cache
-	ctx := context.Background()
-	database.DeleteDashboardCache(ctx, userID)
+	// Count the transaction towards a goal or debt linked to its category (best-effort)
+	if err := NewGoalService(s.config).SyncTransactionContribution(ledger, transaction); err != nil {
+		log.Printf("Failed to sync goal contribution: %v", err)
+	}
+	if err := NewDebtService(s.config).SyncTransactionPayment(transaction); err != nil {
+		log.Printf("Failed to sync debt payment: %v", err)
+	}
+	// Treat a charge matching a bill as its payment (best-effort)
+	if err := NewBillService(s.config).MatchTransaction(transaction); err != nil {
+		log.Printf("Failed to match bill payment: %v", err)
+	}
+
+	// Clear dashboard cache and tell connected clients to refresh
+	invalidateDashboards(s.db, ledger, map[string]interface{}{"transaction_id": transaction.ID, "action": "created"})
 
 	return s.transactionToResponse(transaction), nil
 }
 
-// GetTransactions retrieves transactions with filtering and pagination
-func (s *TransactionService) GetTransactions(userID uint64, req *models.TransactionQueryRequest) ([]models.TransactionResponse, int64, error) {
+// GetTransactions retrieves the ledger's transactions with filtering and pagination
+func (s *TransactionService) GetTransactions(ledger Ledger, req *models.TransactionQueryRequest) ([]models.TransactionResponse, int64, error) {
 	var transactions []models.Transaction
 	var total int64
 
 	// Build query
-	query := s.db.Where("user_id = ?", userID)
+	query := s.db.Scopes(ledger.Scope(""))
 
 	// Apply filters
 	if req.CategoryID != nil {
@@ -176,11 +190,11 @@ func (s *TransactionService) GetTransactions(userID uint64, req *models.Transact
 }
 
 // UpdateTransaction updates an existing transaction
-func (s *TransactionService) UpdateTransaction(userID, transactionID uint64, req *models.TransactionUpdateRequest) (*models.TransactionResponse, error) {
+func (s *TransactionService) UpdateTransaction(ledger Ledger, transactionID uint64, req *models.TransactionUpdateRequest) (*models.TransactionResponse, error) {
 	// Find transaction
 	var transaction models.Transaction
 
-	if err := s.db.Where("user_id = ? AND id = ?", userID, transactionID).First(&transaction).Error; err != nil {
+	if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", transactionID).First(&transaction).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("transaction not found")
 		}
@@ -192,8 +206,7 @@ func (s *TransactionService) UpdateTransaction(userID, transactionID uint64, req
 
 	// Validate category
 	var category models.Category
-	if err := s.db.Where("id = ? AND (user_id = ? OR is_system = ?)",
-		req.CategoryID, userID, true).First(&category).Error; err != nil {
+	if err := s.db.Scopes(ledger.CategoryScope()).Where("id = ?", req.CategoryID).First(&category).Error; err != nil {
 		return nil, fmt.Errorf("category not found or not accessible: %w", err)
 	}
 
@@ -241,29 +254,36 @@ func (s *TransactionService) UpdateTransaction(userID, transactionID uint64, req
 	bs := NewBudgetService(s.config)
 	if transaction.TransactionType == "expense" {
 		// Kiểm tra budgets cho category mới
-		if err := bs.CheckBudgetNotifications(userID, &transaction.CategoryID); err != nil {
+		if err := bs.CheckBudgetNotifications(ledger, &transaction.CategoryID); err != nil {
 			log.Printf("Failed to check budget notifications for new category: %v", err)
 		}
 		// Nếu category đã thay đổi, cũng kiểm tra budgets cho category cũ
 		if oldCategoryID != transaction.CategoryID && oldCategoryID != 0 {
-			if err := bs.CheckBudgetNotifications(userID, &oldCategoryID); err != nil {
+			if err := bs.CheckBudgetNotifications(ledger, &oldCategoryID); err != nil {
 				log.Printf("Failed to check budget notifications for old category: %v", err)
 			}
 		}
 	}
 
-	// Clear dashboard cache
-	ctx := context.Background()
-	database.DeleteDashboardCache(ctx, userID)
+	// Follow the new amount/category in the linked goal or debt (best-effort)
+	if err := NewGoalService(s.config).SyncTransactionContribution(ledger, &transaction); err != nil {
+		log.Printf("Failed to sync goal contribution: %v", err)
+	}
+	if err := NewDebtService(s.config).SyncTransactionPayment(&transaction); err != nil {
+		log.Printf("Failed to sync debt payment: %v", err)
+	}
+
+	// Clear dashboard cache and tell connected clients to refresh
+	invalidateDashboards(s.db, ledger, map[string]interface{}{"transaction_id": transaction.ID, "action": "updated"})
 
 	return s.transactionToResponse(&transaction), nil
 }
 
 // DeleteTransaction deletes a transaction
-func (s *TransactionService) DeleteTransaction(userID, transactionID uint64) error {
+func (s *TransactionService) DeleteTransaction(ledger Ledger, transactionID uint64) error {
 	// Find transaction
 	var transaction models.Transaction
-	if err := s.db.Where("user_id = ? AND id = ?", userID, transactionID).First(&transaction).Error; err != nil {
+	if err := s.db.Scopes(ledger.Scope("")).Where("id = ?", transactionID).First(&transaction).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("transaction not found")
 		}
@@ -281,24 +301,31 @@ func (s *TransactionService) DeleteTransaction(userID, transactionID uint64) err
 	bs := NewBudgetService(s.config)
 	if transaction.TransactionType == "expense" {
 		// Chỉ kiểm tra budgets cho category này hoặc general budgets
-		if err := bs.CheckBudgetNotifications(userID, &transaction.CategoryID); err != nil {
+		if err := bs.CheckBudgetNotifications(ledger, &transaction.CategoryID); err != nil {
 			log.Printf("Failed to check budget notifications: %v", err)
 		}
 	}
 
-	// Clear dashboard cache
-	ctx := context.Background()
-	database.DeleteDashboardCache(ctx, userID)
+	// Take the transaction's contribution off its goal and payment off its debt (best-effort)
+	if err := NewGoalService(s.config).RemoveTransactionContributions(transaction.ID); err != nil {
+		log.Printf("Failed to remove goal contribution: %v", err)
+	}
+	if err := NewDebtService(s.config).RemoveTransactionPayments(transaction.ID); err != nil {
+		log.Printf("Failed to remove debt payment: %v", err)
+	}
+
+	// Clear dashboard cache and tell connected clients to refresh
+	invalidateDashboards(s.db, ledger, map[string]interface{}{"transaction_id": transaction.ID, "action": "deleted"})
 
 	return nil
 }
 
-// GetMonthlySummary retrieves monthly financial summary
-func (s *TransactionService) GetMonthlySummary(userID uint64, year int, month int) (*models.DashboardAnalytics, error) {
+// GetMonthlySummary retrieves the ledger's monthly financial summary
+func (s *TransactionService) GetMonthlySummary(ledger Ledger, year int, month int) (*models.DashboardAnalytics, error) {
 	// Check cache first
 	ctx := context.Background()
 	period := fmt.Sprintf("%d-%02d", year, month)
-	cacheKey := fmt.Sprintf("dashboard:%d:%s", userID, period)
+	cacheKey := ledger.dashboardCacheKey(period)
 
 	if cached, err := database.GetCache(ctx, cacheKey); err == nil {
 		var analytics models.DashboardAnalytics
@@ -313,15 +340,24 @@ func (s *TransactionService) GetMonthlySummary(userID uint64, year int, month in
 
 	// Get transactions
 	var transactions []models.Transaction
-	if err := s.db.Where("user_id = ? AND transaction_date BETWEEN ? AND ?",
-		userID, startDate, endDate).
+	if err := s.db.Scopes(ledger.Scope("")).
+		Where("transaction_date BETWEEN ? AND ?", startDate, endDate).
 		Preload("Category").
 		Find(&transactions).Error; err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
 
 	// Calculate analytics
-	analytics := s.calculateMonthlyAnalytics(userID, transactions, period)
+	analytics := s.calculateMonthlyAnalytics(ledger.UserID, transactions, period)
+
+	// Debt ratio: monthly debt payments as a share of income
+	if analytics.TotalIncome > 0 {
+		health := &analytics.FinancialHealth
+		health.DebtRatio = roundAmount(monthlyDebtPayments(s.db, ledger) / analytics.TotalIncome * 100)
+		if health.DebtRatio > 36 {
+			health.Recommendations = append(health.Recommendations, "Các khoản trả nợ hàng tháng vượt quá 36% thu nhập. Hãy ưu tiên giảm nợ trước khi vay thêm")
+		}
+	}
 
 	// Cache result
 	if analyticsJSON, err := json.Marshal(analytics); err == nil {
@@ -331,11 +367,12 @@ func (s *TransactionService) GetMonthlySummary(userID uint64, year int, month in
 	return analytics, nil
 }
 
-// GetCategorySpending retrieves spending breakdown by category
-func (s *TransactionService) GetCategorySpending(userID uint64, startDate, endDate time.Time) ([]models.CategoryAnalytics, error) {
+// GetCategorySpending retrieves the ledger's spending breakdown by category
+func (s *TransactionService) GetCategorySpending(ledger Ledger, startDate, endDate time.Time) ([]models.CategoryAnalytics, error) {
 	var results []models.CategoryAnalytics
 
 	// Query category spending
+	scope, args := ledger.condition("t")
 	if err := s.db.Raw(`
 		SELECT 
 			c.id as category_id,
@@ -345,12 +382,12 @@ func (s *TransactionService) GetCategorySpending(userID uint64, startDate, endDa
 			AVG(t.amount) as average_amount
 		FROM transactions t
 		JOIN categories c ON t.category_id = c.id
-		WHERE t.user_id = ? 
+		WHERE `+scope+`
 			AND t.transaction_type = 'expense'
 			AND t.transaction_date BETWEEN ? AND ?
 		GROUP BY c.id, c.name
 		ORDER BY amount DESC
-	`, userID, startDate, endDate).Scan(&results).Error; err != nil {
+	`, append(args, startDate, endDate)...).Scan(&results).Error; err != nil {
 		return nil, fmt.Errorf("failed to get category spending: %w", err)
 	}
 
@@ -369,6 +406,79 @@ func (s *TransactionService) GetCategorySpending(userID uint64, startDate, endDa
 	return results, nil
 }
 
+// GetCategorySpendingTree returns the ledger's spending as a category tree,
+// with every parent summing up its subcategories. Categories without spending
+// anywhere below them are left out.
+func (s *TransactionService) GetCategorySpendingTree(ledger Ledger, startDate, endDate time.Time) ([]models.CategorySpendingNode, error) {
+	spending, err := s.GetCategorySpending(ledger, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := loadCategoryTree(s.db, ledger.CategoryScope())
+	if err != nil {
+		return nil, err
+	}
+
+	own := make(map[uint64]models.CategoryAnalytics, len(spending))
+	var totalAmount float64
+	for _, item := range spending {
+		own[item.CategoryID] = item
+		totalAmount += item.Amount
+	}
+
+	var build func(id uint64) (models.CategorySpendingNode, bool)
+	build = func(id uint64) (models.CategorySpendingNode, bool) {
+		item := own[id]
+		node := models.CategorySpendingNode{
+			CategoryID:            id,
+			CategoryName:          tree.names[id],
+			ParentID:              tree.parent[id],
+			Amount:                item.Amount,
+			TransactionCount:      item.TransactionCount,
+			TotalAmount:           item.Amount,
+			TotalTransactionCount: item.TransactionCount,
+		}
+		for _, childID := range tree.children[id] {
+			if child, ok := build(childID); ok {
+				node.TotalAmount += child.TotalAmount
+				node.TotalTransactionCount += child.TotalTransactionCount
+				node.Children = append(node.Children, child)
+			}
+		}
+		if totalAmount > 0 {
+			node.Percentage = (node.TotalAmount / totalAmount) * 100
+		}
+		sort.SliceStable(node.Children, func(i, j int) bool {
+			return node.Children[i].TotalAmount > node.Children[j].TotalAmount
+		})
+		return node, node.TotalTransactionCount > 0
+	}
+
+	var nodes []models.CategorySpendingNode
+	for _, id := range tree.roots() {
+		if node, ok := build(id); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	// Spending in categories the ledger can no longer see is listed at the top level
+	for _, item := range spending {
+		if _, ok := tree.parent[item.CategoryID]; !ok {
+			nodes = append(nodes, models.CategorySpendingNode{
+				CategoryID:            item.CategoryID,
+				CategoryName:          item.CategoryName,
+				Amount:                item.Amount,
+				TransactionCount:      item.TransactionCount,
+				TotalAmount:           item.Amount,
+				TotalTransactionCount: item.TransactionCount,
+				Percentage:            item.Percentage,
+			})
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].TotalAmount > nodes[j].TotalAmount })
+
+	return nodes, nil
+}
+
 // Helper methods
 
 // getLargeTransactionThreshold gets the large transaction threshold for a user
@@ -388,6 +498,7 @@ func (s *TransactionService) transactionToResponse(t *models.Transaction) *model
 	response := &models.TransactionResponse{
 		ID:                    t.ID,
 		UserID:                t.UserID,
+		HouseholdID:           t.HouseholdID,
 		CategoryID:            t.CategoryID,
 		Amount:                t.Amount,
 		Description:           t.Description,
@@ -421,6 +532,7 @@ func (s *TransactionService) categoryToResponse(c *models.Category) *models.Cate
 	return &models.CategoryResponse{
 		ID:          c.ID,
 		UserID:      c.UserID,
+		HouseholdID: c.HouseholdID,
 		Name:        c.Name,
 		NameEn:      c.NameEn,
 		Description: c.Description,
@@ -519,7 +631,7 @@ func (s *TransactionService) calculateMonthlyAnalytics(userID uint64, transactio
 		Level:           s.getFinancialHealthLevel(savingsRate),
 		IncomeRatio:     totalIncome,
 		SavingsRate:     savingsRate,
-		DebtRatio:       0, // Simplified - would need debt data
+		DebtRatio:       0, // filled in by GetMonthlySummary from the ledger's debts
 		Recommendations: s.generateFinancialRecommendations(savingsRate, totalIncome, totalExpense),
 	}
 
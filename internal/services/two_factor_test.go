@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tabimoney/internal/config"
+	"tabimoney/internal/models"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA1 truncated to six digits
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	s := &AuthService{config: &config.Config{JWT: config.JWTConfig{Secret: "test-secret"}}}
+	key := []byte("12345678901234567890")
+	encrypted, err := s.encryptTOTPSecret(totpEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("encryptTOTPSecret: %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	current := now.Unix() / totpPeriod
+	code := func(step int64) string { return totpCode(key, step) }
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64 // 0 when the code must be rejected
+	}{
+		{"current step", code(current), 0, current},
+		{"previous step within skew", code(current - 1), 0, current - 1},
+		{"next step within skew", code(current + 1), 0, current + 1},
+		{"two steps behind", code(current - 2), 0, 0},
+		{"two steps ahead", code(current + 2), 0, 0},
+		{"replayed code", code(current), current, 0},
+		{"step before the last accepted one", code(current - 1), current, 0},
+		{"newer code after an accepted one", code(current + 1), current, current + 1},
+		{"current code after a skewed one", code(current), current - 1, current},
+		{"wrong code", "000000", 0, 0},
+		{"too short", "12345", 0, 0},
+		{"not numeric", "12a456", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{TwoFactorSecret: encrypted, TwoFactorLastStep: tt.lastStep}
+			step, err := s.verifyTOTPAt(user, tt.code, now)
+			if tt.wantStep == 0 {
+				if !errors.Is(err, ErrInvalidTwoFactorCode) {
+					t.Fatalf("verifyTOTPAt() = %d, %v, want ErrInvalidTwoFactorCode", step, err)
+				}
+				return
+			}
+			if err != nil || step != tt.wantStep {
+				t.Fatalf("verifyTOTPAt() = %d, %v, want step %d", step, err, tt.wantStep)
+			}
+		})
+	}
+}